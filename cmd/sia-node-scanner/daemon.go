@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// runDaemon scans the network every interval until stop is closed.
+func (ns *nodeScanner) runDaemon(interval time.Duration, stop <-chan struct{}) {
+	for {
+		start := time.Now()
+		ns.scan()
+		log.Printf("Scan took %v, next scan in %v\n", time.Since(start), time.Until(start.Add(interval)))
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Until(start.Add(interval))):
+		}
+	}
+}
+
+// nodeInfo is a node returned by the HTTP API.
+type nodeInfo struct {
+	Address modules.NetAddress
+	nodeStats
+}
+
+// statsInfo is returned by the /stats endpoint of the HTTP API.
+type statsInfo struct {
+	StartTime time.Time
+	Nodes     int
+	Hosts     int
+
+	// LastScan is the last completed scan.
+	LastScan scanSummary
+}
+
+// httpHandler returns the handler of the HTTP API.
+func (ns *nodeScanner) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nodes", ns.nodesHandler)
+	mux.HandleFunc("/hosts", ns.hostsHandler)
+	mux.HandleFunc("/stats", ns.statsHandler)
+	return mux
+}
+
+// writeJSON writes obj to w as JSON.
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		log.Println("Error writing HTTP response: ", err)
+	}
+}
+
+// filters are the query parameters shared by /nodes and /hosts.
+type filters struct {
+	// minUptime is the minimum percentage of the recorded scans a node must
+	// have been up for.
+	minUptime float64
+
+	// since is the maximum amount of time since the last successful
+	// connection.
+	since time.Duration
+
+	// minVersion is the minimum version a node must have reported.
+	minVersion string
+
+	// online selects nodes that were up during the last scan.
+	online bool
+}
+
+// parseFilters parses the filters from the query of req.
+func parseFilters(req *http.Request) (f filters, err error) {
+	q := req.URL.Query()
+	if s := q.Get("minuptime"); s != "" {
+		f.minUptime, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			return filters{}, errors.AddContext(err, "unable to parse minuptime")
+		}
+	}
+	if s := q.Get("since"); s != "" {
+		f.since, err = time.ParseDuration(s)
+		if err != nil {
+			return filters{}, errors.AddContext(err, "unable to parse since")
+		}
+	}
+	if s := q.Get("minversion"); s != "" {
+		if !build.IsVersion(s) {
+			return filters{}, errors.New("invalid minversion")
+		}
+		f.minVersion = s
+	}
+	if s := q.Get("online"); s != "" {
+		f.online, err = strconv.ParseBool(s)
+		if err != nil {
+			return filters{}, errors.AddContext(err, "unable to parse online")
+		}
+	}
+	return f, nil
+}
+
+// match returns whether a node or host with the given stats passes the
+// filters.
+func (f filters) match(lastSuccess time.Time, version string, history []uptimeRecord) bool {
+	if f.since != 0 && time.Since(lastSuccess) > f.since {
+		return false
+	}
+	if f.minVersion != "" && (version == "" || build.VersionCmp(version, f.minVersion) < 0) {
+		return false
+	}
+	if f.online && (len(history) == 0 || !history[len(history)-1].Up) {
+		return false
+	}
+	if f.minUptime != 0 && historyUptime(history) < f.minUptime {
+		return false
+	}
+	return true
+}
+
+// historyUptime returns the percentage of the records in history that were
+// up.
+func historyUptime(history []uptimeRecord) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+	var up int
+	for _, r := range history {
+		if r.Up {
+			up++
+		}
+	}
+	return 100 * float64(up) / float64(len(history))
+}
+
+// nodesHandler handles the /nodes endpoint, which returns the nodes matching
+// the filters of the request, sorted by address.
+func (ns *nodeScanner) nodesHandler(w http.ResponseWriter, req *http.Request) {
+	f, err := parseFilters(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ns.mu.Lock()
+	nodes := make([]nodeInfo, 0, len(ns.data.NodeStats))
+	for addr, stats := range ns.data.NodeStats {
+		if f.match(stats.LastSuccessfulConnectionTime, stats.Version, stats.History) {
+			stats.History = append([]uptimeRecord(nil), stats.History...)
+			nodes = append(nodes, nodeInfo{Address: addr, nodeStats: stats})
+		}
+	}
+	ns.mu.Unlock()
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Address < nodes[j].Address
+	})
+	writeJSON(w, nodes)
+}
+
+// hostsHandler handles the /hosts endpoint, which returns the probed hosts
+// matching the filters of the request, sorted by address.
+func (ns *nodeScanner) hostsHandler(w http.ResponseWriter, req *http.Request) {
+	f, err := parseFilters(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var acceptingContracts bool
+	if s := req.URL.Query().Get("acceptingcontracts"); s != "" {
+		acceptingContracts, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "unable to parse acceptingcontracts: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ns.mu.Lock()
+	hosts := make([]hostStats, 0, len(ns.data.Hosts))
+	for _, stats := range ns.data.Hosts {
+		if acceptingContracts && !stats.Settings.AcceptingContracts {
+			continue
+		}
+		if f.match(stats.LastSuccessfulProbeTime, stats.Settings.Version, stats.History) {
+			stats.History = append([]uptimeRecord(nil), stats.History...)
+			hosts = append(hosts, stats)
+		}
+	}
+	ns.mu.Unlock()
+
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].NetAddress < hosts[j].NetAddress
+	})
+	writeJSON(w, hosts)
+}
+
+// statsHandler handles the /stats endpoint.
+func (ns *nodeScanner) statsHandler(w http.ResponseWriter, req *http.Request) {
+	ns.mu.Lock()
+	si := statsInfo{
+		StartTime: ns.data.StartTime,
+		Nodes:     len(ns.data.NodeStats),
+		Hosts:     len(ns.data.Hosts),
+		LastScan:  ns.lastScan,
+	}
+	ns.mu.Unlock()
+	writeJSON(w, si)
+}
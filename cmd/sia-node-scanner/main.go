@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -40,6 +43,10 @@ const (
 	// timeBetweenRequests is the amount of time a worker will wait between RPCs
 	// to avoid spamming its peers.
 	timeBetweenRequests = 50 * time.Millisecond
+
+	// maxNodeHistory is the number of scan results kept for each node. With
+	// hourly scans this covers the 30 days before a node is pruned.
+	maxNodeHistory = 24 * 30
 )
 
 var persistMetadata = siaPersist.Metadata{
@@ -81,6 +88,21 @@ type nodeScanner struct {
 	data persistData
 	// persistFile stores persistData using siaPersist.
 	persistFile string
+	// dir is the directory the scanner stores its files in.
+	dir string
+
+	// hostProber is set if the scanner also probes the hosts announced on the
+	// network.
+	hostProber *hostProber
+
+	// scanStart is the start time of the current scan and lastScan holds the
+	// summary of the last completed scan.
+	scanStart time.Time
+	lastScan  scanSummary
+
+	// mu protects data, stats and lastScan, which are read by the HTTP API
+	// while a scan is running.
+	mu sync.Mutex
 }
 
 type persistData struct {
@@ -89,6 +111,10 @@ type persistData struct {
 
 	// Keep connection time and uptime stats for each node.
 	NodeStats map[modules.NetAddress]nodeStats
+
+	// Hosts holds the results of probing the hosts announced on the network,
+	// keyed by the string of their public key.
+	Hosts map[string]hostStats
 }
 
 type nodeStats struct {
@@ -110,6 +136,27 @@ type nodeStats struct {
 	// UptimePercentage is TotalUptime divided by time since
 	// FirstConnectionTime.
 	UptimePercentage float64
+
+	// Version is the version the node reported during the last successful
+	// connection.
+	Version string
+
+	// History holds the results of the most recent scans of this node, oldest
+	// first.
+	History []uptimeRecord
+}
+
+// uptimeRecord is the result of scanning a node or probing a host once.
+type uptimeRecord struct {
+	Timestamp time.Time
+	Up        bool
+}
+
+// scanSummary describes a completed scan.
+type scanSummary struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Stats     scannerStats
 }
 
 // workAssignment tells a worker which node it should scan,
@@ -126,6 +173,7 @@ type workAssignment struct {
 type nodeScanResult struct {
 	Addr      modules.NetAddress
 	Timestamp time.Time
+	Version   string
 	Err       error
 	nodes     map[modules.NetAddress]struct{}
 }
@@ -147,20 +195,49 @@ type scannerStats struct {
 
 func main() {
 	dirPtr := flag.String("dir", "", "Directory where the node scanner will store its results")
+	intervalPtr := flag.Duration("interval", 0, "Time between the starts of two scans. If set, the scanner keeps running and re-scans the network instead of exiting after one scan")
+	httpPtr := flag.String("http", "", "Address to serve the scan results on as JSON, e.g. localhost:9990. Requires -interval")
+	hostsAPIPtr := flag.String("hosts-api", "", "Address of a siad API, e.g. localhost:9980, whose hostdb is used to find announced hosts to probe")
 	flag.Parse()
 
+	if *intervalPtr < 0 {
+		log.Fatal("The scan interval can't be negative")
+	}
+	if *httpPtr != "" && *intervalPtr == 0 {
+		log.Fatal("The HTTP API is only available in daemon mode, use -interval to set the time between scans")
+	}
+
 	// Create a new nodeScanner and create new files and a gateway.
 	ns := newNodeScanner(*dirPtr)
 
-	// Inialize work queues and work/result channels.
-	ns.initialize()
+	// Probe the announced hosts after every scan if a siad API is given.
+	if *hostsAPIPtr != "" {
+		if err := ns.enableHostProbes(*hostsAPIPtr); err != nil {
+			log.Fatal("Error setting up host probes: ", err)
+		}
+	}
 
-	// Start all workers and the main scan loop.
-	ns.startScan()
+	// Without an interval, scan once and exit.
+	if *intervalPtr == 0 {
+		ns.scan()
+		return
+	}
+
+	if *httpPtr != "" {
+		l, err := net.Listen("tcp", *httpPtr)
+		if err != nil {
+			log.Fatal("Error listening for HTTP requests: ", err)
+		}
+		log.Println("Serving results at: ", l.Addr())
+		go func() {
+			log.Fatal(http.Serve(l, ns.httpHandler()))
+		}()
+	}
+	ns.runDaemon(*intervalPtr, nil)
 }
 
-// newNodeScanner creates a nodeScanner, creates the directories and files it
-// uses while scanning, and initializes a gateway used for the scan.
+// newNodeScanner creates a nodeScanner, creates the directories it uses while
+// scanning, and initializes a gateway used for the scan.
 func newNodeScanner(scannerDirPrefix string) (ns *nodeScanner) {
 	ns = new(nodeScanner)
 	ns.stats = scannerStats{}
@@ -181,15 +258,7 @@ func newNodeScanner(scannerDirPrefix string) (ns *nodeScanner) {
 		}
 	}
 	log.Printf("Logging data in:  %s\n", scannerDirPath)
-
-	// Create the file for this scan.
-	startTime := time.Now().Format("01-02:15:04")
-	scanLogName := scannerDirPath + "/scan-" + startTime + ".json"
-	scanLog, err := os.Create(scanLogName)
-	if err != nil {
-		log.Fatal("Error creating scan file: ", err)
-	}
-	ns.scanLog = scanLog
+	ns.dir = scannerDirPath
 
 	// Create dummy gateway at localhost. It is used only to connect/disconnect
 	// from nodes and to use the ShareNodes RPC with other nodes for the purposes
@@ -209,10 +278,36 @@ func newNodeScanner(scannerDirPrefix string) (ns *nodeScanner) {
 	return
 }
 
+// scan runs a full scan of the network, followed by a probe of the announced
+// hosts if host probes are enabled.
+func (ns *nodeScanner) scan() {
+	ns.initialize()
+	ns.startScan()
+	if ns.hostProber != nil {
+		ns.probeHosts()
+	}
+}
+
 // initialize uses the persisted set of nodes (if it exists) or the set
 // of bootstrap peers to initialize the nodeScanner data structures used to give
 // out worker assignments and to receive results.
 func (ns *nodeScanner) initialize() {
+	// Create the file for this scan.
+	ns.scanStart = time.Now()
+	scanLogName := filepath.Join(ns.dir, "scan-"+ns.scanStart.Format("01-02:15:04")+".json")
+	scanLog, err := os.Create(scanLogName)
+	if err != nil {
+		log.Fatal("Error creating scan file: ", err)
+	}
+	ns.scanLog = scanLog
+
+	// Reset the counters of the previous scan.
+	ns.mu.Lock()
+	ns.stats = scannerStats{}
+	ns.mu.Unlock()
+	ns.totalWorkAssignments = 0
+	ns.totalResults = 0
+
 	// If the persisted set is empty, start with bootstrap nodes in queue.
 	// Otherwise start off with the persisted node set in the queue.
 	if len(ns.data.NodeStats) == 0 {
@@ -224,6 +319,7 @@ func (ns *nodeScanner) initialize() {
 		prunedPersistedData := persistData{
 			StartTime: ns.data.StartTime,
 			NodeStats: make(map[modules.NetAddress]nodeStats),
+			Hosts:     ns.data.Hosts,
 		}
 
 		now := time.Now()
@@ -235,7 +331,9 @@ func (ns *nodeScanner) initialize() {
 				ns.queue = append(ns.queue, node)
 			}
 		}
+		ns.mu.Lock()
 		ns.data = prunedPersistedData
+		ns.mu.Unlock()
 		log.Printf("Starting crawl with %d persisted peers\n", len(ns.data.NodeStats))
 	}
 
@@ -277,6 +375,8 @@ func (ns *nodeScanner) startScan() {
 	// Persist the node set periodically.
 	printTicker := time.NewTicker(10 * time.Second)
 	persistTicker := time.NewTicker(10 * time.Second)
+	defer printTicker.Stop()
+	defer persistTicker.Stop()
 
 	for {
 		select {
@@ -348,6 +448,17 @@ func (ns *nodeScanner) close() {
 	json.NewEncoder(ns.scanLog).Encode(ns.stats)
 	ns.scanLog.Close()
 
+	// Stop the workers of this scan.
+	close(ns.workCh)
+
+	ns.mu.Lock()
+	ns.lastScan = scanSummary{
+		StartTime: ns.scanStart,
+		EndTime:   time.Now(),
+		Stats:     ns.stats,
+	}
+	ns.mu.Unlock()
+
 	// Save the persistData.
 	ns.persistData()
 }
@@ -361,6 +472,9 @@ func (ns *nodeScanner) logWorkerResult(res nodeScanResult) {
 		log.Println("Error writing nodeScanResult to file! - ", err)
 	}
 
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
 	if res.Err == nil {
 		ns.stats.SuccessfulConnections++
 		return
@@ -385,6 +499,8 @@ func (ns *nodeScanner) logWorkerResult(res nodeScanResult) {
 }
 
 func (ns *nodeScanner) getStatsStr() string {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	s := fmt.Sprintf("Seen: %d,  Queued: %d, In WorkCh: %d, In ResultCh: %d\n", len(ns.seen), len(ns.queue), len(ns.workCh), len(ns.resultCh))
 	s += fmt.Sprintf("Number assigned: %d, Number of results: %d\n", ns.totalWorkAssignments, ns.totalResults)
 	s += fmt.Sprintf("Successful Connections: %d, Failed: %d\n\t(Unacceptable version: %d, Unreachable: %d, No Route: %d, Refused: %d, Timed Out: %d, Already Connected: %d)\n\n", ns.stats.SuccessfulConnections, ns.stats.FailedConnections, ns.stats.UnacceptableVersionFailures, ns.stats.NetworkIsUnreachableFailures, ns.stats.NoRouteToHostFailures, ns.stats.ConnectionRefusedFailures, ns.stats.ConnectionTimedOutFailures, ns.stats.AlreadyConnectedFailures)
@@ -409,7 +525,13 @@ func startWorker(g *gateway.Gateway, workCh <-chan workAssignment, resultCh chan
 			continue
 		}
 
-		resultCh <- sendShareNodesRequests(g, work)
+		res := sendShareNodesRequests(g, work)
+		for _, p := range g.Peers() {
+			if p.NetAddress == work.node {
+				res.Version = p.Version
+			}
+		}
+		resultCh <- res
 		g.Disconnect(work.node)
 	}
 }
@@ -445,6 +567,8 @@ func sendShareNodesRequests(g *gateway.Gateway, work workAssignment) nodeScanRes
 }
 
 func (ns *nodeScanner) updateNodeStats(res nodeScanResult) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	stats, ok := ns.data.NodeStats[res.Addr]
 
 	// If the scan failed, and we have never persisted the node, ignore it.
@@ -458,6 +582,8 @@ func (ns *nodeScanner) updateNodeStats(res nodeScanResult) {
 			RecentUptime:                 1,
 			TotalUptime:                  1,
 			UptimePercentage:             100.0,
+			Version:                      res.Version,
+			History:                      []uptimeRecord{{Timestamp: res.Timestamp, Up: true}},
 		}
 		ns.data.NodeStats[res.Addr] = stats
 		return
@@ -471,7 +597,14 @@ func (ns *nodeScanner) updateNodeStats(res nodeScanResult) {
 		stats.LastSuccessfulConnectionTime = res.Timestamp
 		stats.RecentUptime += timeElapsed
 		stats.TotalUptime += timeElapsed
+		if res.Version != "" {
+			stats.Version = res.Version
+		}
 	}
+	stats.History = appendHistory(stats.History, uptimeRecord{
+		Timestamp: res.Timestamp,
+		Up:        res.Err == nil,
+	})
 	// Subtract 1 from TotalUptime because we give everyone an extra second to
 	// start. This makes sure the uptime rate isn't higher than 1.
 	stats.UptimePercentage = 100.0 * float64(stats.TotalUptime-1) / float64(res.Timestamp.Sub(stats.FirstConnectionTime))
@@ -479,11 +612,22 @@ func (ns *nodeScanner) updateNodeStats(res nodeScanResult) {
 	ns.data.NodeStats[res.Addr] = stats
 }
 
+// appendHistory appends a record to history, dropping the oldest records if
+// there are more than maxNodeHistory.
+func appendHistory(history []uptimeRecord, r uptimeRecord) []uptimeRecord {
+	history = append(history, r)
+	if len(history) > maxNodeHistory {
+		history = append([]uptimeRecord(nil), history[len(history)-maxNodeHistory:]...)
+	}
+	return history
+}
+
 func (ns *nodeScanner) setupPersistFile(fileName string) error {
 	ns.persistFile = fileName
 	ns.data = persistData{
 		StartTime: time.Now(),
 		NodeStats: make(map[modules.NetAddress]nodeStats),
+		Hosts:     make(map[string]hostStats),
 	}
 
 	// Try loading the persist file.
@@ -493,10 +637,16 @@ func (ns *nodeScanner) setupPersistFile(fileName string) error {
 		// It will be created when saved for the first time.
 		return nil
 	}
+	// Sets persisted before hosts were probed don't have a host map.
+	if ns.data.Hosts == nil {
+		ns.data.Hosts = make(map[string]hostStats)
+	}
 
 	return err
 }
 
 func (ns *nodeScanner) persistData() error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	return siaPersist.SaveJSON(persistMetadata, ns.data, ns.persistFile)
 }
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api/client"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// hostProbeTimeout is the amount of time a host has to accept a
+	// connection.
+	hostProbeTimeout = time.Minute
+
+	// hostProbeDeadline is the amount of time a host has to complete a probe.
+	hostProbeDeadline = 2 * time.Minute
+
+	// maxSettingsLen is the maximum size of the settings a host may send.
+	maxSettingsLen = 10e3
+)
+
+// hostProber probes the hosts that a siad node learned about from host
+// announcements.
+type hostProber struct {
+	// client is used to get the announced hosts from siad's hostdb.
+	client *client.Client
+
+	// mux is used to fetch the price tables of hosts.
+	mux    *siamux.SiaMux
+	muxLog *os.File
+}
+
+// hostStats holds the results of probing a host.
+type hostStats struct {
+	PublicKey  types.SiaPublicKey
+	NetAddress modules.NetAddress
+
+	// Timestamps of the last probe and of the last successful probe.
+	LastProbeTime           time.Time
+	LastSuccessfulProbeTime time.Time
+
+	// LastError is the error of the last probe, if it failed.
+	LastError string
+
+	// Settings and PriceTable are the ones returned by the last successful
+	// probe.
+	Settings   modules.HostExternalSettings
+	PriceTable modules.RPCPriceTable
+
+	// History holds the results of the most recent probes of this host,
+	// oldest first.
+	History []uptimeRecord
+}
+
+// enableHostProbes makes the scanner probe the hosts that the siad node with
+// the API at apiAddr knows about after every scan.
+func (ns *nodeScanner) enableHostProbes(apiAddr string) error {
+	dir, err := filepath.Abs(ns.dir)
+	if err != nil {
+		return err
+	}
+	mux, muxLog, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, "localhost:0", "localhost:0")
+	if err != nil {
+		return errors.AddContext(err, "unable to create siamux")
+	}
+	ns.hostProber = &hostProber{
+		client: client.New(client.Options{
+			Address:   apiAddr,
+			UserAgent: "Sia-Agent",
+		}),
+		mux:    mux,
+		muxLog: muxLog,
+	}
+	return nil
+}
+
+// close shuts down the siamux of the prober.
+func (hp *hostProber) close() error {
+	return errors.Compose(hp.mux.Close(), hp.muxLog.Close())
+}
+
+// probeHosts probes all hosts known to the siad node and persists the
+// results.
+func (ns *nodeScanner) probeHosts() {
+	hdag, err := ns.hostProber.client.HostDbAllGet()
+	if err != nil {
+		log.Println("Error fetching hosts to probe: ", err)
+		return
+	}
+	log.Printf("Probing %d hosts\n", len(hdag.Hosts))
+
+	entries := make(chan modules.HostDBEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < maxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				settings, pt, err := ns.hostProber.probe(entry)
+				ns.updateHostStats(entry, time.Now(), settings, pt, err)
+			}
+		}()
+	}
+	for _, host := range hdag.Hosts {
+		entries <- host.HostDBEntry
+	}
+	close(entries)
+	wg.Wait()
+
+	if err := ns.persistData(); err != nil {
+		log.Println("Error persisting host probes: ", err)
+	}
+}
+
+// updateHostStats records the result of probing a host.
+func (ns *nodeScanner) updateHostStats(entry modules.HostDBEntry, timestamp time.Time, settings modules.HostExternalSettings, pt modules.RPCPriceTable, err error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	// Like nodes, hosts are only recorded once they were reachable.
+	key := entry.PublicKey.String()
+	stats, ok := ns.data.Hosts[key]
+	if !ok && err != nil {
+		return
+	} else if !ok {
+		stats.PublicKey = entry.PublicKey
+	}
+	stats.NetAddress = entry.NetAddress
+	stats.LastProbeTime = timestamp
+	stats.LastError = ""
+	if err != nil {
+		stats.LastError = err.Error()
+	} else {
+		stats.LastSuccessfulProbeTime = timestamp
+		stats.Settings = settings
+		stats.PriceTable = pt
+	}
+	stats.History = appendHistory(stats.History, uptimeRecord{
+		Timestamp: timestamp,
+		Up:        err == nil,
+	})
+
+	// Forget hosts that haven't been reachable for as long as nodes are kept.
+	if timestamp.Sub(stats.LastSuccessfulProbeTime) > pruneAge {
+		delete(ns.data.Hosts, key)
+		return
+	}
+	ns.data.Hosts[key] = stats
+}
+
+// probe requests the settings of a host using RHP2 and its price table using
+// RHP3 on the addresses it announced.
+func (hp *hostProber) probe(entry modules.HostDBEntry) (settings modules.HostExternalSettings, pt modules.RPCPriceTable, err error) {
+	dialer := &net.Dialer{Timeout: hostProbeTimeout}
+	conn, err := modules.GlobalProxy.Dial(dialer, entry.NetAddress)
+	if err != nil {
+		return settings, pt, errors.AddContext(err, "could not connect to host")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(hostProbeDeadline))
+
+	s, _, err := modules.NewRenterSession(conn, entry.PublicKey)
+	if err != nil {
+		return settings, pt, errors.AddContext(err, "could not open RHP2 session")
+	}
+	defer s.WriteRequest(modules.RPCLoopExit, nil)
+	if err := s.WriteRequest(modules.RPCLoopSettings, nil); err != nil {
+		return settings, pt, errors.AddContext(err, "could not request settings")
+	}
+	var resp modules.LoopSettingsResponse
+	if err := s.ReadResponse(&resp, maxSettingsLen); err != nil {
+		return settings, pt, errors.AddContext(err, "could not read settings")
+	}
+	if err := json.Unmarshal(resp.Settings, &settings); err != nil {
+		return settings, pt, errors.AddContext(err, "could not unmarshal settings")
+	}
+
+	pt, err = hp.fetchPriceTable(settings.SiaMuxAddress(), entry.PublicKey)
+	if err != nil {
+		return settings, pt, errors.AddContext(err, "could not fetch price table")
+	}
+	return settings, pt, nil
+}
+
+// fetchPriceTable requests the price table of the host with the given siamux
+// address.
+func (hp *hostProber) fetchPriceTable(siamuxAddr string, hpk types.SiaPublicKey) (pt modules.RPCPriceTable, err error) {
+	siamuxAddr, err = modules.GlobalProxy.TunnelAddress(siamuxAddr)
+	if err != nil {
+		return pt, err
+	}
+	stream, err := hp.mux.NewEphemeralStream(modules.HostSiaMuxSubscriberName, siamuxAddr, hostProbeTimeout, modules.SiaPKToMuxPK(hpk))
+	if err != nil {
+		return pt, err
+	}
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+	if err := stream.SetDeadline(time.Now().Add(hostProbeDeadline)); err != nil {
+		return pt, err
+	}
+
+	if err := modules.RPCWrite(stream, modules.RPCUpdatePriceTable); err != nil {
+		return pt, err
+	}
+	var update modules.RPCUpdatePriceTableResponse
+	if err := modules.RPCRead(stream, &update); err != nil {
+		return pt, err
+	}
+	err = json.Unmarshal(update.PriceTableJSON, &pt)
+	return pt, err
+}
@@ -1,16 +1,22 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/gateway"
 	"go.sia.tech/siad/persist"
 	siaPersist "go.sia.tech/siad/persist"
+	"go.sia.tech/siad/siatest"
 )
 
 const numTestingGateways = 3
@@ -253,3 +259,283 @@ func TestRestartScanner(t *testing.T) {
 		}
 	}
 }
+
+// TestNodeHistory checks that the results of scans are recorded in the
+// history of a node and that the history is bounded.
+func TestNodeHistory(t *testing.T) {
+	t.Parallel()
+
+	ns := &nodeScanner{
+		data: persistData{
+			NodeStats: make(map[modules.NetAddress]nodeStats),
+		},
+	}
+	addr := modules.NetAddress("1.2.3.4:9981")
+	start := time.Now().Add(-time.Hour)
+
+	// Failed scans of unknown nodes aren't recorded.
+	ns.updateNodeStats(nodeScanResult{Addr: addr, Timestamp: start, Err: errors.New("failed")})
+	if _, ok := ns.data.NodeStats[addr]; ok {
+		t.Fatal("unreachable node shouldn't be recorded")
+	}
+
+	for i := 0; i < maxNodeHistory+10; i++ {
+		res := nodeScanResult{
+			Addr:      addr,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Version:   "1.5.4",
+		}
+		if i%2 == 1 {
+			res.Err = errors.New("failed")
+			res.Version = ""
+		}
+		ns.updateNodeStats(res)
+	}
+	stats := ns.data.NodeStats[addr]
+	if len(stats.History) != maxNodeHistory {
+		t.Fatal("wrong history length", len(stats.History))
+	}
+	last := stats.History[len(stats.History)-1]
+	if !last.Timestamp.Equal(start.Add(time.Duration(maxNodeHistory+9)*time.Second)) || last.Up {
+		t.Fatal("wrong last record", last)
+	}
+	if !stats.History[0].Timestamp.Equal(start.Add(10 * time.Second)) {
+		t.Fatal("oldest records should have been dropped", stats.History[0])
+	}
+	if stats.Version != "1.5.4" {
+		t.Fatal("version of the last successful scan should be kept", stats.Version)
+	}
+	if uptime := historyUptime(stats.History); uptime != 50 {
+		t.Fatal("wrong uptime", uptime)
+	}
+}
+
+// TestHTTPAPI checks that the HTTP API returns the nodes and hosts matching
+// the filters of a request.
+func TestHTTPAPI(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	up := []uptimeRecord{{Timestamp: now, Up: true}}
+	down := []uptimeRecord{{Timestamp: now.Add(-time.Hour), Up: true}, {Timestamp: now, Up: false}}
+	ns := &nodeScanner{
+		data: persistData{
+			StartTime: now,
+			NodeStats: map[modules.NetAddress]nodeStats{
+				"1.1.1.1:9981": {LastSuccessfulConnectionTime: now, Version: "1.5.4", History: up},
+				"2.2.2.2:9981": {LastSuccessfulConnectionTime: now.Add(-time.Hour), Version: "1.5.0", History: down},
+				"3.3.3.3:9981": {LastSuccessfulConnectionTime: now.Add(-48 * time.Hour), History: down},
+			},
+			Hosts: map[string]hostStats{
+				"a": {NetAddress: "1.1.1.1:9982", LastSuccessfulProbeTime: now, History: up, Settings: modules.HostExternalSettings{AcceptingContracts: true, Version: "1.5.4"}},
+				"b": {NetAddress: "2.2.2.2:9982", LastSuccessfulProbeTime: now, History: up},
+			},
+		},
+	}
+	srv := httptest.NewServer(ns.httpHandler())
+	defer srv.Close()
+
+	get := func(path string, obj interface{}) int {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		query string
+		addrs []modules.NetAddress
+	}{
+		{"", []modules.NetAddress{"1.1.1.1:9981", "2.2.2.2:9981", "3.3.3.3:9981"}},
+		{"?online=true", []modules.NetAddress{"1.1.1.1:9981"}},
+		{"?since=24h", []modules.NetAddress{"1.1.1.1:9981", "2.2.2.2:9981"}},
+		{"?minversion=1.5.1", []modules.NetAddress{"1.1.1.1:9981"}},
+		{"?minuptime=50", []modules.NetAddress{"1.1.1.1:9981", "2.2.2.2:9981", "3.3.3.3:9981"}},
+		{"?minuptime=60&since=24h", []modules.NetAddress{"1.1.1.1:9981"}},
+	}
+	for _, test := range tests {
+		var nodes []nodeInfo
+		if code := get("/nodes"+test.query, &nodes); code != http.StatusOK {
+			t.Fatal("unexpected status", test.query, code)
+		}
+		if len(nodes) != len(test.addrs) {
+			t.Fatal("wrong number of nodes", test.query, nodes)
+		}
+		for i := range nodes {
+			if nodes[i].Address != test.addrs[i] {
+				t.Fatal("wrong nodes", test.query, nodes)
+			}
+		}
+	}
+	for _, query := range []string{"?online=maybe", "?since=1", "?minuptime=x", "?minversion=v1"} {
+		if code := get("/nodes"+query, nil); code != http.StatusBadRequest {
+			t.Fatal("invalid filter should be rejected", query, code)
+		}
+	}
+
+	var hosts []hostStats
+	if code := get("/hosts?acceptingcontracts=true", &hosts); code != http.StatusOK {
+		t.Fatal("unexpected status", code)
+	}
+	if len(hosts) != 1 || hosts[0].NetAddress != "1.1.1.1:9982" {
+		t.Fatal("wrong hosts", hosts)
+	}
+
+	var si statsInfo
+	if code := get("/stats", &si); code != http.StatusOK {
+		t.Fatal("unexpected status", code)
+	}
+	if si.Nodes != 3 || si.Hosts != 2 {
+		t.Fatal("wrong stats", si)
+	}
+}
+
+// TestRescan checks that the scanner can scan the network repeatedly and
+// keeps the history of the nodes between scans.
+func TestRescan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("SiaNodeScanner-TestRescan")
+	err := os.Mkdir(testDir, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal("Error creating testing directory: ", err)
+	}
+	g, err := gateway.New("localhost:0", true, build.TempDir("SiaNodeScanner-TestRescanGateway"))
+	if err != nil {
+		t.Fatal("Error making new gateway: ", err)
+	}
+	ns := newNodeScanner(testDir)
+	defer func() {
+		if err := ns.gateway.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Start from a persisted set containing the testing gateway.
+	recentPast := time.Now().Add(-10 * time.Minute)
+	ns.data.NodeStats[g.Address()] = nodeStats{
+		FirstConnectionTime:          recentPast,
+		LastSuccessfulConnectionTime: recentPast,
+		RecentUptime:                 1,
+		TotalUptime:                  1,
+		UptimePercentage:             100.0,
+	}
+
+	// Scan twice, the second time with the gateway offline.
+	ns.scan()
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ns.scan()
+
+	stats := ns.data.NodeStats[g.Address()]
+	if len(stats.History) != 2 || !stats.History[0].Up || stats.History[1].Up {
+		t.Fatal("wrong history", stats.History)
+	}
+	if stats.Version != gateway.ProtocolVersion {
+		t.Fatal("version wasn't recorded", stats.Version)
+	}
+	if ns.lastScan.EndTime.Before(ns.lastScan.StartTime) || ns.lastScan.Stats.FailedConnections != 1 {
+		t.Fatal("wrong summary of the last scan", ns.lastScan)
+	}
+
+	// The history should have been persisted.
+	var data persistData
+	err = siaPersist.LoadJSON(persistMetadata, &data, ns.persistFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.NodeStats[g.Address()].History) != 2 {
+		t.Fatal("history wasn't persisted")
+	}
+}
+
+// TestProbeHosts checks that the scanner probes the hosts announced on the
+// network.
+func TestProbeHosts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("SiaNodeScanner-TestProbeHosts")
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(filepath.Join(testDir, "group"), groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ns := newNodeScanner(testDir)
+	defer func() {
+		if err := ns.gateway.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := ns.enableHostProbes(tg.Renters()[0].Client.Address); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ns.hostProber.close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Probe the hosts and check their settings and price tables.
+	ns.probeHosts()
+	if len(ns.data.Hosts) != len(tg.Hosts()) {
+		t.Fatalf("expected %v hosts, got %v", len(tg.Hosts()), len(ns.data.Hosts))
+	}
+	for _, host := range tg.Hosts() {
+		hpk, err := host.HostPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats, ok := ns.data.Hosts[hpk.String()]
+		if !ok {
+			t.Fatal("host wasn't probed")
+		}
+		if stats.LastError != "" || len(stats.History) != 1 || !stats.History[0].Up {
+			t.Fatal("probe should have succeeded", stats.LastError, stats.History)
+		}
+		if !stats.Settings.AcceptingContracts || stats.PriceTable.Validity == 0 {
+			t.Fatal("settings or price table are missing", stats.Settings, stats.PriceTable)
+		}
+	}
+
+	// Take a host offline and probe again.
+	offline := tg.Hosts()[0]
+	hpk, err := offline.HostPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.RemoveNode(offline); err != nil {
+		t.Fatal(err)
+	}
+	ns.probeHosts()
+	stats := ns.data.Hosts[hpk.String()]
+	if stats.LastError == "" || len(stats.History) != 2 || stats.History[1].Up {
+		t.Fatal("probe should have failed", stats.LastError, stats.History)
+	}
+	if stats.PriceTable.Validity == 0 {
+		t.Fatal("price table of the last successful probe should be kept")
+	}
+}
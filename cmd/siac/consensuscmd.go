@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/node/api"
 )
 
@@ -17,6 +20,16 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusCheckpointCmd = &cobra.Command{
+		Use:   "checkpoint [path]",
+		Short: "Export a checkpoint of the consensus set",
+		Long: `Export a checkpoint of the current block to the provided path. A new node
+can be bootstrapped from the checkpoint with the --consensus-checkpoint and
+--consensus-checkpoint-hash flags of siad instead of validating the blockchain
+from the genesis block.`,
+		Run: wrap(consensuscheckpointcmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
@@ -60,3 +73,26 @@ Progress (estimated): %.1f%%
 		fmt.Println("Genesis Timestamp:", time.Unix(int64(cg.GenesisTimestamp), 0))
 	}
 }
+
+// consensuscheckpointcmd is the handler for the command `siac consensus
+// checkpoint [path]`. Exports a checkpoint of the consensus set.
+func consensuscheckpointcmd(path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		die("Could not create checkpoint file:", err)
+	}
+	h := crypto.NewHash()
+	err = httpClient.ConsensusCheckpointGet(io.MultiWriter(f, h))
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		die("Could not export checkpoint:", err)
+	}
+	if err := f.Close(); err != nil {
+		die("Could not write checkpoint file:", err)
+	}
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	fmt.Printf("Exported checkpoint to %v\n", path)
+	fmt.Printf("Checkpoint hash: %v\n", hash)
+}
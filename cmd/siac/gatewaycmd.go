@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -98,8 +100,10 @@ For example: siac gateway blocklist set 123.123.123.123 111.222.111.222 mysiahos
 	gatewayListCmd = &cobra.Command{
 		Use:   "list",
 		Short: "View a list of peers",
-		Long:  "View the current peer list.",
-		Run:   wrap(gatewaylistcmd),
+		Long: `View the current peer list. With -v, the traffic, connection age,
+handshake latency, RPC counts and last relayed block of every peer are shown,
+along with the recently disconnected peers.`,
+		Run: wrap(gatewaylistcmd),
 	}
 
 	gatewayRatelimitCmd = &cobra.Command{
@@ -182,6 +186,24 @@ func gatewayblocklistcmd() {
 	for _, ip := range gbg.Blocklist {
 		fmt.Println(ip)
 	}
+
+	info, err := httpClient.GatewayGet()
+	if err != nil {
+		die("Could not get gateway bans", err)
+	}
+	if len(info.Bans) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(len(info.Bans), "ip addresses currently banned for misbehaving")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Address\tReason\tExpiry")
+	for _, ban := range info.Bans {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", ban.Host, ban.Reason, ban.Expiry.Format(time.RFC822))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
 }
 
 // gatewayblocklistappendcmd is the handler for the command
@@ -250,21 +272,89 @@ func gatewaylistcmd() {
 	if err != nil {
 		die("Could not get peer list:", err)
 	}
+	if verbose {
+		if len(info.Peers) == 0 {
+			fmt.Println("No peers to show.")
+		} else {
+			fmt.Println(len(info.Peers), "active peers:")
+			printPeerStats(info.Peers, false)
+		}
+		if len(info.PeerHistory) > 0 {
+			fmt.Println()
+			fmt.Println(len(info.PeerHistory), "recently disconnected peers:")
+			printPeerStats(info.PeerHistory, true)
+		}
+		return
+	}
 	if len(info.Peers) == 0 {
 		fmt.Println("No peers to show.")
 		return
 	}
 	fmt.Println(len(info.Peers), "active peers:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Version\tOutbound\tAddress")
+	fmt.Fprintln(w, "Version\tOutbound\tScore\tAddress")
 	for _, peer := range info.Peers {
-		fmt.Fprintf(w, "%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.Score, peer.NetAddress)
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
 }
 
+// printPeerStats prints a table of peers and their stats, followed by the RPC
+// counts and last relayed block of each peer. If disconnected is true, the
+// time of disconnect is shown instead of the connection age.
+func printPeerStats(peers []modules.Peer, disconnected bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if disconnected {
+		fmt.Fprintln(w, "Version\tOutbound\tScore\tSent\tReceived\tHandshake\tDisconnected\tAddress")
+	} else {
+		fmt.Fprintln(w, "Version\tOutbound\tScore\tSent\tReceived\tHandshake\tConnected\tAddress")
+	}
+	for _, peer := range peers {
+		s := peer.Stats
+		age := time.Since(s.ConnectedSince)
+		if disconnected {
+			age = time.Since(s.DisconnectedAt)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v ago\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.Score,
+			modules.FilesizeUnits(s.BytesSent), modules.FilesizeUnits(s.BytesReceived),
+			s.HandshakeLatency.Round(time.Millisecond), age.Round(time.Second), peer.NetAddress)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+	for _, peer := range peers {
+		s := peer.Stats
+		fmt.Println()
+		fmt.Println(peer.NetAddress)
+		if s.LastBlockRelayedTime.IsZero() {
+			fmt.Println("  Last Block Relayed: none")
+		} else {
+			fmt.Printf("  Last Block Relayed: %v (%v ago)\n", s.LastBlockRelayed, time.Since(s.LastBlockRelayedTime).Round(time.Second))
+		}
+		fmt.Println("  RPCs Called:       ", rpcCounts(s.RPCsCalled))
+		fmt.Println("  RPCs Handled:      ", rpcCounts(s.RPCsHandled))
+	}
+}
+
+// rpcCounts formats RPC counts as a sorted list of name=count pairs.
+func rpcCounts(counts map[string]uint64) string {
+	if len(counts) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%v=%v", name, counts[name])
+	}
+	return strings.Join(pairs, " ")
+}
+
 // gatewayratelimitcmd is the handler for the command `siac gateway ratelimit`.
 // sets the maximum upload & download bandwidth the gateway module is permitted
 // to use.
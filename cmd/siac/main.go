@@ -40,14 +40,47 @@ var (
 	renterAllContracts        bool   // Show all active and expired contracts
 	renterBubbleAll           bool   // Bubble the entire directory tree
 	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
+	renterDownloadArchive     string // Downloads folders as an archive of this format.
 	renterDownloadAsync       bool   // Downloads files asynchronously
+	renterDownloadPriority    string // Priority class of downloads.
 	renterDownloadRecursive   bool   // Downloads folders recursively.
 	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
+	renterFindCreatedAfter    string // Only find files created after this date.
+	renterFindCreatedBefore   string // Only find files created before this date.
+	renterFindMaxHealth       string // Only find files with at most this health.
+	renterFindMaxSize         string // Only find files with at most this size.
+	renterFindMinHealth       string // Only find files with at least this health.
+	renterFindMinSize         string // Only find files with at least this size.
+	renterFindModifiedAfter   string // Only find files modified after this date.
+	renterFindModifiedBefore  string // Only find files modified before this date.
+	renterFindTags            string // Only find files with these comma separated tags.
 	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
 	renterListRecursive       bool   // List files of folder recursively.
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
 	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
+	renterScheduleDays        string // Weekdays on which a bandwidth window starts.
+	renterScheduleDownload    string // Max download speed of a bandwidth window.
+	renterScheduleName        string // Name of a bandwidth window.
+	renterSchedulePause       bool   // Pause repairs during a bandwidth window.
+	renterScheduleRepair      string // Max repair speed of a bandwidth window.
+	renterScheduleUpload      string // Max upload speed of a bandwidth window.
 	renterShowHistory         bool   // Show download history in addition to download queue.
+	renterSyncDelete          bool   // Delete files that were removed from the other side of a sync.
+	renterSyncDirection       string // The direction of a sync.
+	renterSyncDryRun          bool   // Only report what a sync would do.
+	renterSyncExclude         string // Comma separated patterns of files excluded from a sync.
+	renterSyncInclude         string // Comma separated patterns of files included in a sync.
+	renterSyncWatch           string // Interval at which a sync is repeated.
+	renterUploadChecksum      string // Checksum algorithm used for uploads.
+	renterUploadCompression   string // Compression codec used for uploads.
+	renterUploadDedup         bool   // Deduplicate the chunks of uploads.
+	renterUploadTags          string // Comma separated tags of uploads.
+	renterVersioningKeepDays  uint64 // Number of days prior versions of files are kept.
+	renterVersioningKeepVers  uint64 // Number of prior versions of files that are kept.
+
+	// Renter Metadata Flags
+	renterFindMeta   []string // Only find files with this key=value metadata.
+	renterUploadMeta []string // The key=value metadata of uploads.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -78,12 +111,14 @@ var (
 	dictionaryLanguage string // dictionary for seed utils
 
 	// Wallet Flags
+	walletBumpFee        string // fee per byte the bumped transaction set should pay
 	initForce            bool   // destroy and re-encrypt the wallet on init if it already exists
 	initPassword         bool   // supply a custom password when creating a wallet
 	walletRawTxn         bool   // Encode/decode transactions in base64-encoded binary.
 	walletStartHeight    uint64 // Start height for transaction search.
 	walletEndHeight      uint64 // End height for transaction search.
 	walletTxnFeeIncluded bool   // include the fee in the balance being sent
+	walletTxnFeeTarget   uint64 // number of blocks within which the sent transaction should confirm
 	insecureInput        bool   // Insecure password/seed input. Disables the shoulder-surfing and Mac secure input feature.
 )
 
@@ -290,6 +325,7 @@ func initCmds() *cobra.Command {
 
 	// create command tree (alphabetized by root command)
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusCheckpointCmd)
 	root.AddCommand(jsonCmd)
 
 	root.AddCommand(gatewayCmd)
@@ -308,15 +344,16 @@ func initCmds() *cobra.Command {
 	hostdbCmd.Flags().IntVarP(&hostdbNumHosts, "numhosts", "n", 0, "Number of hosts to display from the hostdb")
 
 	root.AddCommand(minerCmd)
-	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
+	minerCmd.AddCommand(minerStartCmd, minerStopCmd, minerStratumCmd)
 
 	root.AddCommand(renterCmd)
 	renterCmd.AddCommand(renterAllowanceCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
 		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
-		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
+		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd, renterFilesVerifyCmd,
+		renterFindCmd, renterFuseCmd, renterLostCmd, renterMetadataCmd, renterPricesCmd, renterRatelimitCmd,
+		renterScheduleCmd, renterSetAllowanceCmd, renterSetLocalPathCmd, renterSyncCmd, renterTagCmd, renterTrashCmd, renterTriggerContractRecoveryScanCmd,
+		renterUploadsCmd, renterVersioningCmd, renterVersionsCmd, renterWorkersCmd,
 		renterHealthSummaryCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
@@ -324,17 +361,54 @@ func initCmds() *cobra.Command {
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
+	renterScheduleCmd.AddCommand(renterScheduleAddCmd, renterScheduleClearCmd, renterScheduleRemoveCmd)
+	renterTrashCmd.AddCommand(renterTrashPurgeCmd, renterTrashRestoreCmd, renterTrashRetentionCmd)
+	renterVersioningCmd.AddCommand(renterVersioningDisableCmd, renterVersioningEnableCmd)
+	renterVersionsCmd.AddCommand(renterVersionsDownloadCmd, renterVersionsRestoreCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
 	renterFilesDeleteCmd.Flags().BoolVar(&renterDeleteRoot, "root", false, "Delete files and folders from root instead of from the user home directory")
+	renterFilesDownloadCmd.Flags().StringVar(&renterDownloadArchive, "archive", "", "Download a folder as a single archive file of this format (tar, targz or zip)")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
+	renterFilesDownloadCmd.Flags().StringVar(&renterDownloadPriority, "priority", "", "Priority class of the download (stream, user or batch), defaults to user")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadRecursive, "recursive", "R", false, "Download folder recursively")
 	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadRoot, "root", false, "Download files and folders from root instead of from the user home directory")
 	renterFilesListCmd.Flags().BoolVarP(&renterListRecursive, "recursive", "R", false, "Recursively list files and folders")
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterFilesUploadCmd.Flags().StringVar(&renterUploadChecksum, "checksum", "", "the checksum algorithm used to verify the file (sha256, blake2b or none)")
+	renterFilesUploadCmd.Flags().StringVar(&renterUploadCompression, "compression", "", "the codec used to compress the file before it is encrypted (gzip or none)")
+	renterFilesUploadCmd.Flags().BoolVar(&renterUploadDedup, "dedup", false, "deduplicate the chunks of the file with the renter's other deduplicated files")
+	renterFilesUploadCmd.Flags().StringArrayVar(&renterUploadMeta, "meta", nil, "a key=value pair of metadata of the file, can be repeated")
+	renterFilesUploadCmd.Flags().StringVar(&renterUploadTags, "tags", "", "comma separated tags of the file")
+	renterFindCmd.Flags().StringVar(&renterFindCreatedAfter, "created-after", "", "only find files created after this date (2006-01-02 or RFC3339)")
+	renterFindCmd.Flags().StringVar(&renterFindCreatedBefore, "created-before", "", "only find files created before this date (2006-01-02 or RFC3339)")
+	renterFindCmd.Flags().StringVar(&renterFindMaxHealth, "max-health", "", "only find files with at most this health (0 is full health)")
+	renterFindCmd.Flags().StringVar(&renterFindMaxSize, "max-size", "", "only find files of at most this size, e.g. 10GB")
+	renterFindCmd.Flags().StringArrayVar(&renterFindMeta, "meta", nil, "only find files with this key=value metadata, can be repeated (an empty value matches any value)")
+	renterFindCmd.Flags().StringVar(&renterFindMinHealth, "min-health", "", "only find files with at least this health (0 is full health)")
+	renterFindCmd.Flags().StringVar(&renterFindMinSize, "min-size", "", "only find files of at least this size, e.g. 10GB")
+	renterFindCmd.Flags().StringVar(&renterFindModifiedAfter, "modified-after", "", "only find files modified after this date (2006-01-02 or RFC3339)")
+	renterFindCmd.Flags().StringVar(&renterFindModifiedBefore, "modified-before", "", "only find files modified before this date (2006-01-02 or RFC3339)")
+	renterFindCmd.Flags().StringVar(&renterFindTags, "tags", "", "only find files with all of these comma separated tags")
+	renterSyncCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces uploaded files should be uploaded with")
+	renterSyncCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces uploaded files should be uploaded with")
+	renterSyncCmd.Flags().BoolVar(&renterSyncDelete, "delete", false, "delete files that were removed from the other side")
+	renterSyncCmd.Flags().StringVar(&renterSyncDirection, "direction", "both", "the direction of the sync (upload, download or both)")
+	renterSyncCmd.Flags().BoolVar(&renterSyncDryRun, "dry-run", false, "only print what the sync would do")
+	renterSyncCmd.Flags().StringVar(&renterSyncExclude, "exclude", "", "comma separated glob patterns of files to exclude from the sync")
+	renterSyncCmd.Flags().StringVar(&renterSyncInclude, "include", "", "comma separated glob patterns of files to include in the sync")
+	renterSyncCmd.Flags().StringVar(&renterSyncWatch, "watch", "", "repeat the sync at the given interval (e.g. 5m) until interrupted")
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleDays, "days", "", "comma separated weekdays or ranges of weekdays on which the window starts, e.g. mon-fri (default every day)")
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleDownload, "download", "0", "max download speed during the window (0 for no limit)")
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleName, "name", "", "name of the window")
+	renterScheduleAddCmd.Flags().BoolVar(&renterSchedulePause, "pause-repairs", false, "pause repairs during the window")
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleRepair, "repair", "0", "max repair speed during the window (0 for no limit)")
+	renterScheduleAddCmd.Flags().StringVar(&renterScheduleUpload, "upload", "0", "max upload speed during the window (0 for no limit)")
+	renterVersioningEnableCmd.Flags().Uint64Var(&renterVersioningKeepDays, "keep-days", 0, "remove versions that were superseded more than this many days ago (0 for no limit)")
+	renterVersioningEnableCmd.Flags().Uint64Var(&renterVersioningKeepVers, "keep-versions", 0, "the number of versions kept per file (0 for no limit)")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
 
@@ -374,15 +448,17 @@ func initCmds() *cobra.Command {
 	utilsVerifySeedCmd.Flags().StringVarP(&dictionaryLanguage, "language", "l", "english", "which dictionary you want to use")
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd,
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletBumpCmd, walletChangepasswordCmd,
 		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
 		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletBumpCmd.Flags().StringVarP(&walletBumpFee, "fee", "", "", "Fee per byte the transaction set should pay after the bump, e.g. 1uS (defaults to the estimated fee)")
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
 	walletSendSiacoinsCmd.Flags().BoolVarP(&walletTxnFeeIncluded, "fee-included", "", false, "Take the transaction fee out of the balance being submitted instead of the fee being additional")
+	walletSendSiacoinsCmd.Flags().Uint64VarP(&walletTxnFeeTarget, "fee-target", "", 0, "Pay the fee estimated to confirm the transaction within this many blocks instead of the maximum estimated fee")
 	walletUnlockCmd.Flags().BoolVarP(&insecureInput, "insecure-input", "", false, "Disable shoulder-surf protection (echoing passwords and seeds)")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
 	walletBroadcastCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Decode transaction as base64 instead of JSON")
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"gitlab.com/NebulousLabs/errors"
@@ -23,6 +25,13 @@ var (
 		Run:   wrap(minerstartcmd),
 	}
 
+	minerStratumCmd = &cobra.Command{
+		Use:   "stratum",
+		Short: "View stratum server status",
+		Long:  "View the share statistics of the stratum server and its connected workers.",
+		Run:   wrap(minerstratumcmd),
+	}
+
 	minerStopCmd = &cobra.Command{
 		Use:   "stop",
 		Short: "Stop mining",
@@ -73,3 +82,35 @@ func minerstopcmd() {
 	}
 	fmt.Println("Stopped mining.")
 }
+
+// minerstratumcmd is the handler for the command `siac miner stratum`.
+// Prints the status of the stratum server.
+func minerstratumcmd() {
+	status, err := httpClient.MinerStratumGet()
+	if err != nil {
+		die("Could not get stratum status:", err)
+	}
+	if status.Address == "" {
+		fmt.Println("Stratum server is not running. Start siad with --stratum-addr to enable it.")
+		return
+	}
+	fmt.Printf(`Stratum status:
+Address:  %s
+Accepted: %d
+Stale:    %d
+Rejected: %d
+Blocks:   %d
+`, status.Address, status.AcceptedShares, status.StaleShares, status.RejectedShares, status.BlocksFound)
+	if len(status.Workers) == 0 {
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Worker\tAddress\tDifficulty\tAccepted\tStale\tRejected")
+	for _, worker := range status.Workers {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", worker.Name, worker.RemoteAddress, worker.Difficulty, worker.AcceptedShares, worker.StaleShares, worker.RejectedShares)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
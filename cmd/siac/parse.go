@@ -467,3 +467,36 @@ func sizeString(size uint64) string {
 	}
 	return "0 B"
 }
+
+// parseUserMetadata parses user-defined metadata from a list of key=value
+// pairs.
+func parseUserMetadata(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	md := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("metadata %q isn't of the form key=value", pair)
+		}
+		md[pair[:i]] = pair[i+1:]
+	}
+	return md, nil
+}
+
+// parseDate parses a date of the form '2006-01-02' in local time or an
+// RFC3339 timestamp. An empty string results in the zero time.
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", date, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date of the form 2006-01-02 nor an RFC3339 timestamp", date)
+	}
+	return t, nil
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"math"
 	"math/big"
+	"reflect"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
@@ -366,3 +368,47 @@ func TestSizeString(t *testing.T) {
 		sizeString(fastrand.Uint64n(math.MaxUint64))
 	}
 }
+
+// TestParseUserMetadata probes the parseUserMetadata function
+func TestParseUserMetadata(t *testing.T) {
+	md, err := parseUserMetadata([]string{"owner=alice", "empty=", "eq=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"owner": "alice", "empty": "", "eq": "a=b"}
+	if !reflect.DeepEqual(md, expected) {
+		t.Fatal("wrong metadata", md)
+	}
+	if md, err := parseUserMetadata(nil); err != nil || md != nil {
+		t.Fatal("no pairs should result in nil metadata", md, err)
+	}
+	for _, pair := range []string{"owner", "=alice"} {
+		if _, err := parseUserMetadata([]string{pair}); err == nil {
+			t.Errorf("%q should fail to parse", pair)
+		}
+	}
+}
+
+// TestParseDate probes the parseDate function
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in  string
+		out time.Time
+	}{
+		{"", time.Time{}},
+		{"2020-03-04", time.Date(2020, 3, 4, 0, 0, 0, 0, time.Local)},
+		{"2020-03-04T05:06:07Z", time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		out, err := parseDate(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !out.Equal(test.out) {
+			t.Errorf("parseDate(%v): expected %v, got %v", test.in, test.out, out)
+		}
+	}
+	if _, err := parseDate("04.03.2020"); err == nil {
+		t.Error("invalid date should fail to parse")
+	}
+}
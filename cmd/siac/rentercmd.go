@@ -7,9 +7,11 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,6 +24,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/node/api"
@@ -168,6 +171,15 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Run:     wrap(renterfilesrenamecmd),
 	}
 
+	renterFindCmd = &cobra.Command{
+		Use:   "find [path]",
+		Short: "Search files and folders",
+		Long: `Search the files and folders within the folder at [path] and its subfolders. The
+results can be filtered by tags, metadata, size, health and time ranges. Sizes,
+health and modification times of folders are aggregated over their contents.`,
+		Run: renterfindcmd,
+	}
+
 	renterFuseCmd = &cobra.Command{
 		Use:   "fuse",
 		Short: "Perform fuse actions.",
@@ -212,10 +224,22 @@ local path where the Sia folder is mounted.`,
 		Use:   "upload [source] [path]",
 		Short: "Upload a file or folder",
 		Long: `Upload a file or folder to [path] on the Sia network. The --data-pieces and --parity-pieces
-flags can be used to set a custom redundancy for the file.`,
+flags can be used to set a custom redundancy for the file. The --checksum flag selects the
+algorithm used to compute the checksum of the file, which is verified on downloads. The
+--compression flag compresses the file before it is encrypted and uploaded. The --dedup
+flag reuses the sectors of identical chunks that were uploaded with --dedup before. The
+--tags and --meta flags set the tags and metadata of the uploaded files.`,
 		Run: wrap(renterfilesuploadcmd),
 	}
 
+	renterFilesVerifyCmd = &cobra.Command{
+		Use:   "verify [path] [localfile]",
+		Short: "Verify a local file against an uploaded file",
+		Long: `Compute the checksum of a local file and compare it to the checksum that was
+computed when the file at [path] was uploaded.`,
+		Run: wrap(renterfilesverifycmd),
+	}
+
 	renterFilesUploadPauseCmd = &cobra.Command{
 		Use:   "pause [duration]",
 		Short: "Pause renter uploads for a duration",
@@ -232,6 +256,14 @@ For Example: 'siac renter upload pause 3h' would pause uploads for 3 hours.`,
 		Run:   wrap(renterfilesuploadresumecmd),
 	}
 
+	renterMetadataCmd = &cobra.Command{
+		Use:   "metadata [path] [key=value]...",
+		Short: "Set the metadata of a file or folder",
+		Long: `Replace the user-defined metadata of the file or folder at [path] with the given
+key=value pairs. Without any pairs the metadata is removed.`,
+		Run: rentermetadatacmd,
+	}
+
 	renterPricesCmd = &cobra.Command{
 		Use:   "prices [amount] [period] [hosts] [renew window]",
 		Short: "Display the price of storage and bandwidth",
@@ -255,6 +287,43 @@ Set them to 0 for no limit.`,
 		Run: wrap(renterratelimitcmd),
 	}
 
+	renterScheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "View the renter's bandwidth schedule",
+		Long: `View the windows of the renter's bandwidth schedule. While a window is active,
+its rate limits replace the ones set with 'siac renter ratelimit' and repairs can
+be slowed down or paused. If windows overlap, the first one applies. Times are
+in the local time of siad.`,
+		Run: wrap(renterschedulecmd),
+	}
+
+	renterScheduleAddCmd = &cobra.Command{
+		Use:   "add [start] [end]",
+		Short: "Add a window to the bandwidth schedule",
+		Long: `Add a window from [start] to [end] to the renter's bandwidth schedule. Times are
+given as hh:mm. A window whose end is before its start spans midnight. Speeds are
+given in
+Bytes per second: B/s, KB/s, MB/s, GB/s, TB/s
+or
+Bits per second: Bps, Kbps, Mbps, Gbps, Tbps
+and 0 means no limit.`,
+		Run: wrap(renterscheduleaddcmd),
+	}
+
+	renterScheduleClearCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "Remove all windows from the bandwidth schedule",
+		Long:  "Remove all windows from the renter's bandwidth schedule.",
+		Run:   wrap(renterscheduleclearcmd),
+	}
+
+	renterScheduleRemoveCmd = &cobra.Command{
+		Use:   "remove [number]",
+		Short: "Remove a window from the bandwidth schedule",
+		Long:  "Remove the window with the given number, as listed by 'siac renter schedule', from the bandwidth schedule.",
+		Run:   wrap(renterscheduleremovecmd),
+	}
+
 	renterSetAllowanceCmd = &cobra.Command{
 		Use:   "setallowance",
 		Short: "Set the allowance",
@@ -274,6 +343,30 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run: rentersetallowancecmd,
 	}
 
+	renterSyncCmd = &cobra.Command{
+		Use:   "sync [localdir] [path]",
+		Short: "Synchronize a local folder with a Sia folder",
+		Long: `Synchronize the local folder [localdir] with the folder [path] on the Sia network.
+New and changed files are uploaded and downloaded depending on --direction. Files
+are compared by size, modification time and the checksums stored on upload.
+
+With --delete, files that were removed on one side since the last sync are removed
+on the other side as well. --include and --exclude take comma separated glob
+patterns that are matched against the paths of the files relative to the synced
+folders. Patterns without a '/' are also matched against the file names.
+
+With --watch, the sync is repeated at the given interval until siac is interrupted.`,
+		Run: wrap(rentersynccmd),
+	}
+
+	renterTagCmd = &cobra.Command{
+		Use:   "tag [path] [tags]",
+		Short: "Set the tags of a file or folder",
+		Long: `Replace the tags of the file or folder at [path] with the comma separated [tags].
+Use "" as [tags] to remove all tags.`,
+		Run: wrap(rentertagcmd),
+	}
+
 	renterTriggerContractRecoveryScanCmd = &cobra.Command{
 		Use:   "triggerrecoveryscan",
 		Short: "Triggers a recovery scan.",
@@ -281,6 +374,38 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(rentertriggercontractrecoveryrescancmd),
 	}
 
+	renterTrashCmd = &cobra.Command{
+		Use:   "trash",
+		Short: "View the files and folders in the trash",
+		Long: `View the files and folders in the trash, most recently deleted first. Deleted
+files and folders stay in the trash until they are restored, purged or the trash
+retention has passed.`,
+		Run: wrap(rentertrashcmd),
+	}
+
+	renterTrashPurgeCmd = &cobra.Command{
+		Use:   "purge [id...]",
+		Short: "Permanently delete files and folders in the trash",
+		Long: `Permanently delete the files and folders in the trash with the given ids. If no
+id is given, the whole trash is emptied.`,
+		Run: rentertrashpurgecmd,
+	}
+
+	renterTrashRestoreCmd = &cobra.Command{
+		Use:   "restore [id]",
+		Short: "Restore a file or folder from the trash",
+		Long: `Move the file or folder in the trash with the given id back to the location it
+was deleted from. Restoring fails if a file or folder exists at that location.`,
+		Run: wrap(rentertrashrestorecmd),
+	}
+
+	renterTrashRetentionCmd = &cobra.Command{
+		Use:   "retention [days]",
+		Short: "Set the trash retention",
+		Long:  "Set the number of days after which files and folders are purged from the trash.",
+		Run:   wrap(rentertrashretentioncmd),
+	}
+
 	renterUploadsCmd = &cobra.Command{
 		Use:   "uploads",
 		Short: "View the upload queue",
@@ -288,6 +413,55 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(renteruploadscmd),
 	}
 
+	renterVersioningCmd = &cobra.Command{
+		Use:   "versioning",
+		Short: "View the folders that have versioning enabled",
+		Long: `View the folders that have versioning enabled. Files that are overwritten or
+deleted within these folders are kept as prior versions.`,
+		Run: wrap(renterversioningcmd),
+	}
+
+	renterVersioningDisableCmd = &cobra.Command{
+		Use:   "disable [path]",
+		Short: "Disable versioning for a folder",
+		Long: `Disable versioning for the folder at [path]. Existing versions are kept but no
+longer pruned.`,
+		Run: wrap(renterversioningdisablecmd),
+	}
+
+	renterVersioningEnableCmd = &cobra.Command{
+		Use:   "enable [path]",
+		Short: "Enable versioning for a folder",
+		Long: `Enable versioning for the folder at [path] and its subfolders. Files that are
+overwritten or deleted are kept as prior versions. Versions are removed once there
+are more than --keep-versions newer versions of the file or once they were
+superseded more than --keep-days days ago. Running the command for a folder that
+already has versioning enabled updates its limits.`,
+		Run: wrap(renterversioningenablecmd),
+	}
+
+	renterVersionsCmd = &cobra.Command{
+		Use:   "versions [path]",
+		Short: "List the prior versions of a file",
+		Long:  "List the prior versions of the file at [path], newest first.",
+		Run:   wrap(renterversionscmd),
+	}
+
+	renterVersionsDownloadCmd = &cobra.Command{
+		Use:   "download [path] [version] [destination]",
+		Short: "Download a prior version of a file",
+		Long:  "Download the prior version [version] of the file at [path] to [destination].",
+		Run:   wrap(renterversionsdownloadcmd),
+	}
+
+	renterVersionsRestoreCmd = &cobra.Command{
+		Use:   "restore [path] [version]",
+		Short: "Restore a prior version of a file",
+		Long: `Replace the file at [path] with its prior version [version]. The current file
+is kept as a version itself.`,
+		Run: wrap(renterversionsrestorecmd),
+	}
+
 	renterWorkersCmd = &cobra.Command{
 		Use:   "workers",
 		Short: "View the Renter's workers",
@@ -1651,7 +1825,10 @@ func renterfilesdownloadcmd(path, destination string) {
 		die("Failed to download file:", err)
 	}
 	_, err = httpClient.RenterDirRootGet(siaPath)
-	if err == nil {
+	if err == nil && renterDownloadArchive != "" {
+		renterdirarchive(siaPath, destination)
+		return
+	} else if err == nil {
 		renterdirdownload(path, destination)
 		return
 	} else if !strings.Contains(err.Error(), filesystem.ErrNotExist.Error()) {
@@ -1660,6 +1837,33 @@ func renterfilesdownloadcmd(path, destination string) {
 	die(fmt.Sprintf("Unknown path '%v'", path))
 }
 
+// renterdirarchive downloads a folder as a single archive of the format set
+// with the --archive flag.
+func renterdirarchive(siaPath modules.SiaPath, destination string) {
+	destination = abs(destination)
+	if _, err := os.Stat(destination); err == nil {
+		die(fmt.Sprintf("Destination '%v' already exists", destination))
+	}
+	body, err := httpClient.RenterArchiveGet(siaPath, renterDownloadArchive, false, true)
+	if err != nil {
+		die("Failed to download folder:", err)
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+	f, err := os.Create(destination)
+	if err != nil {
+		die("Failed to create destination file:", err)
+	}
+	start := time.Now()
+	n, err := io.Copy(f, body)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		die("Failed to download folder:", err)
+	}
+	fmt.Printf("Downloaded archive of '%v' to %v (%v, %v).\n", siaPath, destination, modules.FilesizeUnits(uint64(n)), time.Since(start).Round(time.Millisecond))
+}
+
 // rentertriggercontractrecoveryrescancmd starts a new scan for recoverable
 // contracts on the blockchain.
 func rentertriggercontractrecoveryrescancmd() {
@@ -2016,6 +2220,12 @@ func renterfilesuploadcmd(source, path string) {
 	if err != nil {
 		die("Could not parse data and parity pieces:", err)
 	}
+	// Parse the tags and metadata.
+	tags := modules.ParseTags(renterUploadTags)
+	userMetadata, err := parseUserMetadata(renterUploadMeta)
+	if err != nil {
+		die("Could not parse metadata:", err)
+	}
 
 	if stat.IsDir() {
 		// folder
@@ -2046,7 +2256,10 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Couldn't parse SiaPath:", err)
 			}
-			err = httpClient.RenterUploadPost(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces))
+			err = httpClient.RenterUploadOptionsPost(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces), renterUploadChecksum, renterUploadCompression, renterUploadDedup)
+			if err == nil {
+				err = setUploadUserMetadata(fSiaPath, tags, userMetadata)
+			}
 			if err != nil {
 				failed++
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
@@ -2060,14 +2273,443 @@ func renterfilesuploadcmd(source, path string) {
 		if err != nil {
 			die("Couldn't parse SiaPath:", err)
 		}
-		err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		err = httpClient.RenterUploadOptionsPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces), renterUploadChecksum, renterUploadCompression, renterUploadDedup)
 		if err != nil {
 			die("Could not upload file:", err)
 		}
+		if err := setUploadUserMetadata(siaPath, tags, userMetadata); err != nil {
+			die("Could not set the tags and metadata of the file:", err)
+		}
 		fmt.Printf("Uploaded '%s' as '%s'.\n", abs(source), path)
 	}
 }
 
+// renterfilesverifycmd is the handler for the command `siac renter verify
+// [path] [localfile]`. It compares the checksum of a local file to the
+// checksum of an uploaded file.
+func renterfilesverifycmd(path, localFile string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	rf, err := httpClient.RenterFileGet(siaPath)
+	if err != nil {
+		die("Could not get file info:", err)
+	}
+	expected := rf.File.Checksum
+	if !expected.IsSet() {
+		die("No checksum is known for", path)
+	}
+	f, err := os.Open(localFile)
+	if err != nil {
+		die("Could not open local file:", err)
+	}
+	defer f.Close()
+	checksum, err := modules.ComputeChecksum(expected.Algorithm, f)
+	if err != nil {
+		die("Could not compute checksum of local file:", err)
+	}
+	if err := expected.Verify(checksum); err != nil {
+		die(fmt.Sprintf("'%s' doesn't match '%s':", localFile, path), err)
+	}
+	fmt.Printf("'%s' matches '%s' (%v).\n", localFile, path, checksum)
+}
+
+// setUploadUserMetadata sets the tags and metadata of an uploaded file if any
+// were provided.
+func setUploadUserMetadata(siaPath modules.SiaPath, tags []string, userMetadata map[string]string) error {
+	if len(tags) > 0 {
+		if err := httpClient.RenterTagsPost(siaPath, tags); err != nil {
+			return err
+		}
+	}
+	if len(userMetadata) > 0 {
+		return httpClient.RenterUserMetadataPost(siaPath, userMetadata)
+	}
+	return nil
+}
+
+// renterfindcmd is the handler for the command `siac renter find [path]`. It
+// lists the files and folders matching the provided filters.
+func renterfindcmd(cmd *cobra.Command, args []string) {
+	var path string
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	siaPath := modules.RootSiaPath()
+	if path != "" && path != "." && path != "/" {
+		var err error
+		siaPath, err = modules.NewSiaPath(path)
+		if err != nil {
+			die("Couldn't parse SiaPath:", err)
+		}
+	}
+
+	// Build the query from the flags.
+	var query modules.FileQuery
+	var err error
+	query.Tags = modules.ParseTags(renterFindTags)
+	if query.UserMetadata, err = parseUserMetadata(renterFindMeta); err != nil {
+		die("Could not parse metadata:", err)
+	}
+	sizes := []struct {
+		flag string
+		size *uint64
+	}{
+		{renterFindMinSize, &query.MinSize},
+		{renterFindMaxSize, &query.MaxSize},
+	}
+	for _, s := range sizes {
+		if s.flag == "" {
+			continue
+		}
+		str, err := parseFilesize(s.flag)
+		if err != nil {
+			die("Could not parse size:", err)
+		}
+		if *s.size, err = strconv.ParseUint(str, 10, 64); err != nil {
+			die("Could not parse size:", err)
+		}
+	}
+	healths := []struct {
+		flag   string
+		health **float64
+	}{
+		{renterFindMinHealth, &query.MinHealth},
+		{renterFindMaxHealth, &query.MaxHealth},
+	}
+	for _, h := range healths {
+		if h.flag == "" {
+			continue
+		}
+		health, err := strconv.ParseFloat(h.flag, 64)
+		if err != nil {
+			die("Could not parse health:", err)
+		}
+		*h.health = &health
+	}
+	dates := []struct {
+		flag string
+		t    *time.Time
+	}{
+		{renterFindModifiedAfter, &query.ModifiedAfter},
+		{renterFindModifiedBefore, &query.ModifiedBefore},
+		{renterFindCreatedAfter, &query.CreatedAfter},
+		{renterFindCreatedBefore, &query.CreatedBefore},
+	}
+	for _, d := range dates {
+		if *d.t, err = parseDate(d.flag); err != nil {
+			die("Could not parse date:", err)
+		}
+	}
+
+	rfg, err := httpClient.RenterFindGet(siaPath, query)
+	if err != nil {
+		die("Could not search files:", err)
+	}
+	if len(rfg.Files) == 0 && len(rfg.Directories) == 0 {
+		fmt.Println("No files or folders found.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Size\tHealth\tModified\tPath\tTags")
+	for _, dir := range rfg.Directories {
+		fmt.Fprintf(w, "%v\t%.2f%%\t%v\t/%v/\t%v\n", modules.FilesizeUnits(dir.AggregateSize), modules.HealthPercentage(dir.AggregateMaxHealth), dir.AggregateMostRecentModTime.Format(time.RFC3339), dir.SiaPath, strings.Join(dir.Tags, ","))
+	}
+	for _, file := range rfg.Files {
+		fmt.Fprintf(w, "%v\t%.2f%%\t%v\t/%v\t%v\n", modules.FilesizeUnits(file.Filesize), file.MaxHealthPercent, file.ModificationTime.Format(time.RFC3339), file.SiaPath, strings.Join(file.Tags, ","))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// rentermetadatacmd is the handler for the command `siac renter metadata
+// [path] [key=value]...`. It replaces the metadata of a file or folder.
+func rentermetadatacmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	siaPath, err := modules.NewSiaPath(args[0])
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	userMetadata, err := parseUserMetadata(args[1:])
+	if err != nil {
+		die("Could not parse metadata:", err)
+	}
+	if err := httpClient.RenterUserMetadataPost(siaPath, userMetadata); err != nil {
+		die("Could not set metadata:", err)
+	}
+	fmt.Printf("Set the metadata of '%v'.\n", args[0])
+}
+
+// rentertagcmd is the handler for the command `siac renter tag [path] [tags]`.
+// It replaces the tags of a file or folder.
+func rentertagcmd(path, tags string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	if err := httpClient.RenterTagsPost(siaPath, modules.ParseTags(tags)); err != nil {
+		die("Could not set tags:", err)
+	}
+	fmt.Printf("Set the tags of '%v'.\n", path)
+}
+
+// rentersynccmd is the handler for the command `siac renter sync [localdir]
+// [path]`. It synchronizes a local folder with a Sia folder, optionally
+// repeating the sync until interrupted.
+func rentersynccmd(localDir, path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	params := modules.SyncParams{
+		LocalPath: abs(localDir),
+		SiaPath:   siaPath,
+		Direction: modules.SyncDirection(renterSyncDirection),
+		Delete:    renterSyncDelete,
+		DryRun:    renterSyncDryRun,
+	}
+	if renterSyncInclude != "" {
+		params.Include = strings.Split(renterSyncInclude, ",")
+	}
+	if renterSyncExclude != "" {
+		params.Exclude = strings.Split(renterSyncExclude, ",")
+	}
+	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
+	if err != nil {
+		die("Could not parse data and parity pieces:", err)
+	}
+	if numDataPieces > 0 {
+		params.ErasureCode, err = modules.NewRSSubCode(numDataPieces, numParityPieces, crypto.SegmentSize)
+		if err != nil {
+			die("Could not create erasure coder:", err)
+		}
+	}
+	var interval time.Duration
+	if renterSyncWatch != "" {
+		interval, err = time.ParseDuration(renterSyncWatch)
+		if err != nil || interval <= 0 {
+			die("Couldn't parse watch interval:", renterSyncWatch)
+		}
+	}
+
+	for {
+		report, err := httpClient.RenterSyncPost(params)
+		if err != nil && interval == 0 {
+			die("Could not sync folders:", err)
+		} else if err != nil {
+			fmt.Println("Could not sync folders:", err)
+		} else {
+			printSyncReport(report, params.DryRun)
+		}
+		if interval == 0 {
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// printSyncReport prints the result of a sync.
+func printSyncReport(report modules.SyncReport, dryRun bool) {
+	verb := "Synced"
+	if dryRun {
+		verb = "Dry run of sync"
+	}
+	fmt.Printf("%v at %v: %v uploaded, %v downloaded, %v deleted locally, %v deleted remotely, %v unchanged, %v errors\n",
+		verb, time.Now().Format(time.RFC3339), len(report.Uploaded), len(report.Downloaded),
+		len(report.DeletedLocal), len(report.DeletedRemote), report.Unchanged, len(report.Errors))
+	printPaths := func(action string, paths []string) {
+		for _, p := range paths {
+			fmt.Printf("  %-15s %v\n", action, p)
+		}
+	}
+	printPaths("upload", report.Uploaded)
+	printPaths("download", report.Downloaded)
+	printPaths("delete local", report.DeletedLocal)
+	printPaths("delete remote", report.DeletedRemote)
+	printPaths("error", report.Errors)
+}
+
+// rentertrashcmd is the handler for the command `siac renter trash`. It lists
+// the files and folders in the trash.
+func rentertrashcmd() {
+	rtg, err := httpClient.RenterTrashGet()
+	if err != nil {
+		die("Could not get the trash:", err)
+	}
+	fmt.Printf("Deleted files and folders are purged after %v days.\n", rtg.RetentionDays)
+	if len(rtg.Entries) == 0 {
+		fmt.Println("The trash is empty.")
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDeleted\tFiles\tSize\tPath")
+	for _, e := range rtg.Entries {
+		path := "/" + e.SiaPath.String()
+		if e.IsDir {
+			path += "/"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", e.ID, e.Deleted.Format(time.RFC3339), e.NumFiles, modules.FilesizeUnits(e.Size), path)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// rentertrashpurgecmd is the handler for the command `siac renter trash purge
+// [id...]`.
+func rentertrashpurgecmd(cmd *cobra.Command, ids []string) {
+	if len(ids) == 0 {
+		if err := httpClient.RenterTrashPurgePost(""); err != nil {
+			die("Could not empty the trash:", err)
+		}
+		fmt.Println("Emptied the trash.")
+		return
+	}
+	for _, id := range ids {
+		if err := httpClient.RenterTrashPurgePost(id); err != nil {
+			die(fmt.Sprintf("Could not purge %v from the trash: %v", id, err))
+		}
+		fmt.Printf("Purged %v from the trash.\n", id)
+	}
+}
+
+// rentertrashrestorecmd is the handler for the command `siac renter trash
+// restore [id]`.
+func rentertrashrestorecmd(id string) {
+	if err := httpClient.RenterTrashRestorePost(id); err != nil {
+		die("Could not restore from the trash:", err)
+	}
+	fmt.Printf("Restored %v from the trash.\n", id)
+}
+
+// rentertrashretentioncmd is the handler for the command `siac renter trash
+// retention [days]`.
+func rentertrashretentioncmd(daysStr string) {
+	days, err := strconv.ParseUint(daysStr, 10, 64)
+	if err != nil {
+		die("Could not parse the number of days:", err)
+	}
+	if err := httpClient.RenterTrashRetentionPost(days); err != nil {
+		die("Could not set the trash retention:", err)
+	}
+	fmt.Printf("Deleted files and folders are now purged after %v days.\n", days)
+}
+
+// renterversioningcmd is the handler for the command `siac renter versioning`.
+// It lists the folders that have versioning enabled.
+func renterversioningcmd() {
+	rvg, err := httpClient.RenterVersioningGet()
+	if err != nil {
+		die("Could not get versioning policies:", err)
+	}
+	if len(rvg.Policies) == 0 {
+		fmt.Println("No folders have versioning enabled.")
+		return
+	}
+	limit := func(n uint64) string {
+		if n == 0 {
+			return "-"
+		}
+		return fmt.Sprint(n)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Folder\tKeep Versions\tKeep Days")
+	for _, p := range rvg.Policies {
+		fmt.Fprintf(w, "/%v\t%v\t%v\n", p.SiaPath, limit(p.KeepVersions), limit(p.KeepDays))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterversioningdisablecmd is the handler for the command `siac renter
+// versioning disable [path]`.
+func renterversioningdisablecmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	if err := httpClient.RenterVersioningDisablePost(siaPath); err != nil {
+		die("Could not disable versioning:", err)
+	}
+	fmt.Printf("Disabled versioning for '%v'.\n", path)
+}
+
+// renterversioningenablecmd is the handler for the command `siac renter
+// versioning enable [path]`.
+func renterversioningenablecmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	if err := httpClient.RenterVersioningPost(siaPath, renterVersioningKeepVers, renterVersioningKeepDays); err != nil {
+		die("Could not enable versioning:", err)
+	}
+	fmt.Printf("Enabled versioning for '%v'.\n", path)
+}
+
+// renterversionscmd is the handler for the command `siac renter versions
+// [path]`. It lists the prior versions of a file.
+func renterversionscmd(path string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	rvg, err := httpClient.RenterVersionsGet(siaPath)
+	if err != nil {
+		die("Could not get file versions:", err)
+	}
+	if len(rvg.Versions) == 0 {
+		fmt.Printf("'%v' has no prior versions.\n", path)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Version\tSuperseded\tSize\tChecksum")
+	for _, v := range rvg.Versions {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", v.ID, v.Superseded.Format(time.RFC3339), modules.FilesizeUnits(v.Filesize), v.Checksum)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterversionsdownloadcmd is the handler for the command `siac renter
+// versions download [path] [version] [destination]`.
+func renterversionsdownloadcmd(path, version, destination string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	if _, err := httpClient.RenterDownloadVersionGet(siaPath, version, abs(destination)); err != nil {
+		die("Could not download file version:", err)
+	}
+	fmt.Printf("Downloaded version %v of '%v' to %v.\n", version, path, abs(destination))
+}
+
+// renterversionsrestorecmd is the handler for the command `siac renter
+// versions restore [path] [version]`.
+func renterversionsrestorecmd(path, version string) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	if err := httpClient.RenterVersionRestorePost(siaPath, version); err != nil {
+		die("Could not restore file version:", err)
+	}
+	fmt.Printf("Restored version %v of '%v'.\n", version, path)
+}
+
 // renterfilesuploadpausecmd is the handler for the command `siac renter upload
 // pause`.  It pauses all renter uploads for the duration (in minutes)
 // passed in.
@@ -2191,6 +2833,117 @@ func renterratelimitcmd(downloadSpeedStr, uploadSpeedStr string) {
 	fmt.Println("Set renter maxdownloadspeed to ", downloadSpeedInt, " and maxuploadspeed to ", uploadSpeedInt)
 }
 
+// renterschedulecmd is the handler for the command `siac renter schedule`. It
+// lists the windows of the renter's bandwidth schedule.
+func renterschedulecmd() {
+	rbsg, err := httpClient.RenterBandwidthScheduleGet()
+	if err != nil {
+		die("Could not get the bandwidth schedule:", err)
+	}
+	if len(rbsg.Windows) == 0 {
+		fmt.Println("The bandwidth schedule is empty.")
+		return
+	}
+	limit := func(speed int64) string {
+		if speed == 0 {
+			return "-"
+		}
+		return ratelimitUnits(speed)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  #\tName\tDays\tTime\tDownload\tUpload\tRepair")
+	for i, bw := range rbsg.Windows {
+		active := " "
+		if rbsg.Active != nil && reflect.DeepEqual(*rbsg.Active, bw) {
+			active = "*"
+		}
+		days := "every day"
+		if len(bw.Days) > 0 {
+			names := make([]string, 0, len(bw.Days))
+			for _, d := range bw.Days {
+				names = append(names, d.String()[:3])
+			}
+			days = strings.Join(names, ",")
+		}
+		repair := limit(bw.MaxRepairSpeed)
+		if bw.PauseRepairs {
+			repair = "paused"
+		}
+		fmt.Fprintf(w, "%v %v\t%v\t%v\t%v-%v\t%v\t%v\t%v\n", active, i+1, bw.Name, days, bw.Start, bw.End, limit(bw.MaxDownloadSpeed), limit(bw.MaxUploadSpeed), repair)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if rbsg.Active != nil {
+		fmt.Println("\n* active window")
+	}
+}
+
+// renterscheduleaddcmd is the handler for the command `siac renter schedule
+// add [start] [end]`.
+func renterscheduleaddcmd(start, end string) {
+	days, err := modules.ParseWeekdays(renterScheduleDays)
+	if err != nil {
+		die("Could not parse days:", err)
+	}
+	bw := modules.BandwidthWindow{
+		Name:         renterScheduleName,
+		Days:         days,
+		Start:        start,
+		End:          end,
+		PauseRepairs: renterSchedulePause,
+	}
+	for _, speed := range []struct {
+		str   string
+		limit *int64
+		name  string
+	}{
+		{renterScheduleDownload, &bw.MaxDownloadSpeed, "download"},
+		{renterScheduleUpload, &bw.MaxUploadSpeed, "upload"},
+		{renterScheduleRepair, &bw.MaxRepairSpeed, "repair"},
+	} {
+		*speed.limit, err = parseRatelimit(speed.str)
+		if err != nil {
+			die(errors.AddContext(err, "unable to parse "+speed.name+" speed"))
+		}
+	}
+	rbsg, err := httpClient.RenterBandwidthScheduleGet()
+	if err != nil {
+		die("Could not get the bandwidth schedule:", err)
+	}
+	if err := httpClient.RenterBandwidthSchedulePost(append(rbsg.Windows, bw)); err != nil {
+		die("Could not add the window:", err)
+	}
+	fmt.Printf("Added window %v to the bandwidth schedule.\n", len(rbsg.Windows)+1)
+}
+
+// renterscheduleclearcmd is the handler for the command `siac renter schedule
+// clear`.
+func renterscheduleclearcmd() {
+	if err := httpClient.RenterBandwidthSchedulePost(nil); err != nil {
+		die("Could not clear the bandwidth schedule:", err)
+	}
+	fmt.Println("Cleared the bandwidth schedule.")
+}
+
+// renterscheduleremovecmd is the handler for the command `siac renter schedule
+// remove [number]`.
+func renterscheduleremovecmd(numberStr string) {
+	rbsg, err := httpClient.RenterBandwidthScheduleGet()
+	if err != nil {
+		die("Could not get the bandwidth schedule:", err)
+	}
+	number, err := strconv.Atoi(numberStr)
+	if err != nil || number < 1 || number > len(rbsg.Windows) {
+		die(fmt.Sprintf("Window number must be between 1 and %v", len(rbsg.Windows)))
+	}
+	windows := append(rbsg.Windows[:number-1], rbsg.Windows[number:]...)
+	if err := httpClient.RenterBandwidthSchedulePost(windows); err != nil {
+		die("Could not remove the window:", err)
+	}
+	fmt.Printf("Removed window %v from the bandwidth schedule.\n", number)
+}
+
 // renterworkerscmd is the handler for the command `siac renter workers`.
 // It lists the Renter's workers.
 func renterworkerscmd() {
@@ -125,7 +125,7 @@ func downloadDir(siaPath modules.SiaPath, destination string) (tfs []trackedFile
 		}
 		// Download file.
 		totalSize += file.Filesize
-		_, err = httpClient.RenterDownloadFullGet(file.SiaPath, dst, true, true)
+		_, err = httpClient.RenterDownloadFullPriorityGet(file.SiaPath, dst, modules.DownloadPriority(renterDownloadPriority), true, true)
 		if err != nil {
 			err = errors.AddContext(err, "Failed to start download")
 			return
@@ -527,7 +527,7 @@ func renterFilesDownload(path, destination string) {
 	// the call will return before the download has completed. The call is made
 	// as an async call.
 	start := time.Now()
-	cancelID, err := httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+	cancelID, err := httpClient.RenterDownloadFullPriorityGet(siaPath, destination, modules.DownloadPriority(renterDownloadPriority), true, true)
 	if err != nil {
 		die("Download could not be started:", err)
 	}
@@ -56,6 +56,17 @@ be valid. txn may be either JSON, base64, or a file containing either.`,
 		Run: wrap(walletbroadcastcmd),
 	}
 
+	walletBumpCmd = &cobra.Command{
+		Use:   "bump [txid]",
+		Short: "Raise the fee of a stuck transaction",
+		Long: `Raise the fee of an unconfirmed transaction by creating a child transaction
+that spends one of the wallet's outputs of the transaction set and pays enough
+fees for the whole set (child pays for parent). The fee per byte can be
+specified in units, e.g. 1uS. Run 'wallet --help' for a list of units. If no
+fee is supplied, the fee estimated by the transaction pool is used.`,
+		Run: wrap(walletbumpcmd),
+	}
+
 	walletChangepasswordCmd = &cobra.Command{
 		Use:   "change-password",
 		Short: "Change the wallet password",
@@ -426,7 +437,11 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	if walletTxnFeeTarget > 0 {
+		_, err = httpClient.WalletSiacoinsFeeTargetPost(value, hash, walletTxnFeeIncluded, types.BlockHeight(walletTxnFeeTarget))
+	} else {
+		_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	}
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
@@ -513,6 +528,31 @@ func walletbroadcastcmd(txnStr string) {
 	fmt.Println("Transaction has been broadcast successfully")
 }
 
+// walletbumpcmd raises the fee of an unconfirmed transaction set.
+func walletbumpcmd(txidStr string) {
+	var txid crypto.Hash
+	if err := txid.LoadString(txidStr); err != nil {
+		die("Could not parse transaction id:", err)
+	}
+	var feePerByte types.Currency
+	if walletBumpFee != "" {
+		hastings, err := types.ParseCurrency(walletBumpFee)
+		if err != nil {
+			die("Could not parse fee:", err)
+		}
+		if _, err := fmt.Sscan(hastings, &feePerByte); err != nil {
+			die("Failed to parse fee", err)
+		}
+	}
+	wbp, err := httpClient.WalletBumpPost(types.TransactionID(txid), feePerByte)
+	if err != nil {
+		die("Could not bump transaction:", err)
+	}
+	child := wbp.Transactions[len(wbp.Transactions)-1]
+	fmt.Printf("Bumped transaction with child %v paying %v in fees\n", child.ID(), currencyUnits(child.MinerFees[0]))
+	fmt.Printf("Transaction set now pays %v per byte\n", currencyUnits(modules.CalculateFee(wbp.Transactions)))
+}
+
 // walletsweepcmd sweeps coins and funds from a seed.
 func walletsweepcmd() {
 	seed, err := passwordPrompt("Seed: ")
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
@@ -18,14 +20,84 @@ import (
 
 func main() {
 	log.SetFlags(log.Lshortfile)
-	if len(os.Args) != 2 {
-		log.Fatal("Usage: siad-convert-db /path/to/consensus.db")
-	}
-	db, err := bolt.Open(os.Args[1], 0600, nil)
-	if err != nil {
+	dryRun := flag.Bool("dry-run", false, "report what the conversion would change without modifying any files")
+	verify := flag.Bool("verify", false, "check the converted blocks and state against the siad database before removing it")
+	out := flag.String("out", "", "write the converted database to this file instead of converting the input in place")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: siad-convert-db [flags] /path/to/consensus.db")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts := options{
+		dryRun: *dryRun,
+		verify: *verify,
+		out:    *out,
+	}
+	if err := run(flag.Arg(0), opts, os.Stdin); err != nil {
 		log.Fatal(err)
 	}
+}
+
+// options are the options of a conversion.
+type options struct {
+	dryRun bool
+	verify bool
+	out    string
+}
+
+// run converts the siad database at input to the core format. The
+// confirmation of an in-place conversion is read from stdin.
+func run(input string, opts options, stdin io.Reader) error {
+	if opts.out != "" {
+		if same, err := isSamePath(input, opts.out); err != nil {
+			return err
+		} else if same {
+			return errors.New("output must not be the input database, omit -out to convert in place")
+		}
+	}
 	network, genesisBlock := chain.Mainnet()
+
+	if opts.dryRun {
+		db, err := bolt.Open(input, 0600, &bolt.Options{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := db.View(func(btx *bolt.Tx) error {
+			return report(btx, genesisBlock, opts.out)
+		}); err != nil {
+			return fmt.Errorf("dry run failed: %w", err)
+		}
+		return nil
+	}
+
+	// When converting into a separate file, the input is only read and stays
+	// usable by siad. Otherwise the input is both the source and the
+	// destination of the conversion.
+	var src, db *bolt.DB
+	var err error
+	if opts.out == "" {
+		db, err = bolt.Open(input, 0600, nil)
+		if err != nil {
+			return err
+		}
+		src = db
+	} else {
+		src, err = bolt.Open(input, 0600, &bolt.Options{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		db, err = openOutput(src, opts.out)
+		if err != nil {
+			return fmt.Errorf("couldn't create output database: %w", err)
+		}
+	}
+	defer db.Close()
 	start := time.Now()
 
 	err = db.Update(func(btx *bolt.Tx) error {
@@ -41,13 +113,17 @@ func main() {
 			return nil
 		case !isCoreDB && isSiadDB:
 			fmt.Println("siad database detected, ready to convert.")
-			fmt.Println("Once conversion begins, this database will no longer be usable by siad!")
-			fmt.Print("Proceed? (y/n): ")
-			var resp string
-			if _, err := fmt.Scanln(&resp); err != nil {
-				return err
-			} else if resp != "y" {
-				return errors.New("aborted")
+			if opts.out == "" {
+				fmt.Println("Once conversion begins, this database will no longer be usable by siad!")
+				fmt.Print("Proceed? (y/n): ")
+				var resp string
+				if _, err := fmt.Fscanln(stdin, &resp); err != nil {
+					return err
+				} else if resp != "y" {
+					return errors.New("aborted")
+				}
+			} else {
+				fmt.Printf("Converting into %v, %v will not be modified.\n", opts.out, input)
 			}
 
 			// check genesis block
@@ -55,51 +131,34 @@ func main() {
 			if btx.Bucket(bBlockMap).Get(genesisID[:]) == nil {
 				return errors.New("siad database has different genesis block")
 			}
-
-			fmt.Println("Deleting unneeded siad buckets...")
-			err := btx.ForEach(func(name []byte, _ *bolt.Bucket) error {
-				if bytes.Equal(name, bBlockHeight) || bytes.Equal(name, bBlockMap) || bytes.Equal(name, bBlockPath) {
-					return nil
-				}
-				return btx.DeleteBucket(name)
-			})
-			if err != nil {
-				return err
-			}
-
-			fmt.Println("Creating core buckets and applying genesis block...")
-			for _, bucket := range [][]byte{
-				bVersion,
-				bMainChain,
-				bCheckpoints,
-				bFileContracts,
-				bSiacoinOutputs,
-				bSiafundOutputs,
-			} {
-				if _, err := btx.CreateBucket(bucket); err != nil {
-					return err
-				}
-			}
-			tx := &dbTx{tx: btx, n: network}
-			tx.bucket(bVersion).putRaw(bVersion, []byte{1})
-			genesisState := network.GenesisState()
-			cs := consensus.ApplyState(genesisState, tx, genesisBlock)
-			diff := consensus.ApplyDiff(genesisState, tx, genesisBlock)
-			tx.putCheckpoint(chain.Checkpoint{Block: genesisBlock, State: cs, Diff: &diff})
-			tx.applyState(cs)
-			tx.applyDiff(cs, diff)
-			return tx.err
+			return initCoreDB(btx, network, genesisBlock)
 		}
 		return nil
 	})
 	if err != nil {
-		log.Fatalln("Initialization failed:", err)
+		return fmt.Errorf("initialization failed: %w", err)
 	}
 
+	// The siad blocks are normally deleted as they are converted. Verifying
+	// an in-place conversion needs them until the end.
+	keepSiadBlocks := opts.verify && src == db
+
+	// A resumed in-place conversion may have deleted the siad blocks it
+	// converted before, so only the blocks converted by this run can be
+	// verified against them.
+	var verifyHeight uint64
 	const blocksPerDBTx = 1000
-	var converted uint64
+	var p *progress
+	db.View(func(btx *bolt.Tx) error {
+		tx := &dbTx{tx: btx, n: network}
+		if coreHeight := tx.getHeight(); src == db && coreHeight > 0 {
+			verifyHeight = coreHeight + 1
+		}
+		p = newProgress("Converted", tx.getHeight(), tx.getSiadHeight())
+		return nil
+	})
 	for {
-		var siadHeight, coreHeight uint64
+		var siadHeight, coreHeight, stopHeight uint64
 		err := db.Update(func(btx *bolt.Tx) error {
 			tx := &dbTx{tx: btx, n: network}
 			coreHeight = tx.getHeight()
@@ -107,25 +166,38 @@ func main() {
 			if coreHeight == siadHeight {
 				return nil
 			}
-			stopHeight := coreHeight + blocksPerDBTx
+			stopHeight = coreHeight + blocksPerDBTx
 			if stopHeight > siadHeight {
 				stopHeight = siadHeight
 			}
-			return convertBlocks(tx, coreHeight, stopHeight)
+			return convertBlocks(tx, coreHeight, stopHeight, keepSiadBlocks)
 		})
 		if err != nil {
-			log.Fatal(err)
+			return err
 		} else if coreHeight == siadHeight {
 			break
 		}
-		converted += blocksPerDBTx
-		elapsed := time.Since(start)
-		rate := float64(converted) / elapsed.Seconds()
-		rem := (elapsed * time.Duration(siadHeight-coreHeight)) / time.Duration(converted)
-		fmt.Printf("\rConverted %v/%v blocks (%.2f/s), ETA %v (%v m)", coreHeight, siadHeight, rate, time.Now().Add(rem).Format(time.Kitchen), int(rem/time.Minute))
+		p.update(stopHeight)
 	}
 	fmt.Println()
 
+	if opts.verify {
+		if verifyHeight > 0 {
+			fmt.Printf("Verifying blocks from height %v, the blocks below were converted by a previous run.\n", verifyHeight)
+		}
+		if err := verifyBlocks(src, db, network, verifyHeight); err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		if src != db {
+			if err := verifyState(src, db, network); err != nil {
+				return fmt.Errorf("verification failed: %w", err)
+			}
+		} else {
+			fmt.Println("Skipping state verification, the siad state was deleted by the in-place conversion. Use -out to verify it.")
+		}
+		fmt.Println("Verification succeeded.")
+	}
+
 	// conversion complete; delete remaining buckets
 	err = db.Update(func(btx *bolt.Tx) error {
 		for _, bucket := range [][]byte{
@@ -140,13 +212,108 @@ func main() {
 		return nil
 	})
 	if err != nil {
-		log.Fatalln("Couldn't clean up remaining siad buckets:", err)
+		return fmt.Errorf("couldn't clean up remaining siad buckets: %w", err)
 	}
 
 	fmt.Println("Successfully converted database to core format in", time.Since(start))
+	return nil
 }
 
-func convertBlocks(tx *dbTx, curHeight, stopHeight uint64) error {
+// initCoreDB replaces the siad state with the core buckets and applies the
+// genesis block. Only the siad blocks are kept for the conversion.
+func initCoreDB(btx *bolt.Tx, network *consensus.Network, genesisBlock types.Block) error {
+	fmt.Println("Deleting unneeded siad buckets...")
+	err := btx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if isKeptSiadBucket(name) {
+			return nil
+		}
+		return btx.DeleteBucket(name)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Creating core buckets and applying genesis block...")
+	for _, bucket := range coreBuckets {
+		if _, err := btx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+	tx := &dbTx{tx: btx, n: network}
+	tx.bucket(bVersion).putRaw(bVersion, []byte{1})
+	genesisState := network.GenesisState()
+	cs := consensus.ApplyState(genesisState, tx, genesisBlock)
+	diff := consensus.ApplyDiff(genesisState, tx, genesisBlock)
+	tx.putCheckpoint(chain.Checkpoint{Block: genesisBlock, State: cs, Diff: &diff})
+	tx.applyState(cs)
+	tx.applyDiff(cs, diff)
+	return tx.err
+}
+
+// isSamePath returns whether the paths refer to the same file.
+func isSamePath(a, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	if absA == absB {
+		return true, nil
+	}
+	// Different paths can still refer to the same file, e.g. through links.
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB), nil
+}
+
+// isKeptSiadBucket returns whether the siad bucket with the given name is
+// kept until all blocks are converted.
+func isKeptSiadBucket(name []byte) bool {
+	return bytes.Equal(name, bBlockHeight) || bytes.Equal(name, bBlockMap) || bytes.Equal(name, bBlockPath)
+}
+
+// openOutput opens the output database of a conversion into a separate file.
+// A new output is created by copying src. An existing output is only opened
+// if it is a partially converted database, so that the conversion can be
+// resumed.
+func openOutput(src *bolt.DB, path string) (*bolt.DB, error) {
+	if _, err := os.Stat(path); err == nil {
+		db, err := bolt.Open(path, 0600, nil)
+		if err != nil {
+			return nil, err
+		}
+		var partial bool
+		db.View(func(btx *bolt.Tx) error {
+			partial = btx.Bucket(bVersion) != nil && btx.Bucket(bBlockMap) != nil
+			return nil
+		})
+		if !partial {
+			db.Close()
+			return nil, fmt.Errorf("%v already exists and is not a partially converted database", path)
+		}
+		return db, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fmt.Printf("Copying database to %v...\n", path)
+	tmp := path + "_temp"
+	if err := src.View(func(btx *bolt.Tx) error {
+		return btx.CopyFile(tmp, 0600)
+	}); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	return bolt.Open(path, 0600, nil)
+}
+
+func convertBlocks(tx *dbTx, curHeight, stopHeight uint64, keepSiadBlocks bool) error {
 	var cs consensus.State
 	if index, ok := tx.BestIndex(curHeight); !ok {
 		return errors.New("core database is missing best index")
@@ -167,11 +334,49 @@ func convertBlocks(tx *dbTx, curHeight, stopHeight uint64) error {
 		tx.putCheckpoint(chain.Checkpoint{Block: b, State: cs, Diff: &diff})
 		tx.applyState(cs)
 		tx.applyDiff(cs, diff)
-		tx.deleteSiadBlock(curHeight)
+		if !keepSiadBlocks {
+			tx.deleteSiadBlock(curHeight)
+		}
 	}
 	return tx.err
 }
 
+// progress reports the progress of a long running operation and estimates
+// when it will be done.
+type progress struct {
+	label        string
+	start        time.Time
+	initial      uint64
+	total        uint64
+	lastReported time.Time
+}
+
+// newProgress starts reporting progress towards total, starting at initial.
+func newProgress(label string, initial, total uint64) *progress {
+	return &progress{
+		label:   label,
+		start:   time.Now(),
+		initial: initial,
+		total:   total,
+	}
+}
+
+// update reports that done out of the total items are done.
+func (p *progress) update(done uint64) {
+	if done < p.total && time.Since(p.lastReported) < time.Second {
+		return
+	}
+	p.lastReported = time.Now()
+	elapsed := time.Since(p.start)
+	if done <= p.initial || elapsed <= 0 {
+		fmt.Printf("\r%v %v/%v blocks", p.label, done, p.total)
+		return
+	}
+	rate := float64(done-p.initial) / elapsed.Seconds()
+	rem := time.Duration(float64(p.total-done) / rate * float64(time.Second))
+	fmt.Printf("\r%v %v/%v blocks (%.2f/s), ETA %v (%v m)  ", p.label, done, p.total, rate, time.Now().Add(rem).Format(time.Kitchen), int(rem/time.Minute))
+}
+
 var (
 	// siad buckets (that we care about)
 	bBlockHeight = []byte("BlockHeight")
@@ -186,6 +391,16 @@ var (
 	bSiacoinOutputs = []byte("SiacoinOutputs")
 	bSiafundOutputs = []byte("SiafundOutputs")
 
+	// coreBuckets are created when the conversion starts
+	coreBuckets = [][]byte{
+		bVersion,
+		bMainChain,
+		bCheckpoints,
+		bFileContracts,
+		bSiacoinOutputs,
+		bSiafundOutputs,
+	}
+
 	// core keys
 	keyFoundationOutputs = []byte("FoundationOutputs")
 	keyHeight            = []byte("Height")
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"go.sia.tech/core/chain"
+	"go.sia.tech/core/types"
+)
+
+// testChainHeight is the height of the generated siad chain.
+const testChainHeight = 50
+
+// newSiadDB creates a siad database at path with the mainnet genesis block and
+// height empty blocks on top of it.
+func newSiadDB(t *testing.T, path string, height uint64) {
+	t.Helper()
+	_, genesisBlock := chain.Mainnet()
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(btx *bolt.Tx) error {
+		buckets := make(map[string]*bolt.Bucket)
+		for _, name := range [][]byte{bBlockHeight, bBlockMap, bBlockPath, bSiadSiafundPool, bSiacoinOutputs, bSiafundOutputs, bFileContracts} {
+			b, err := btx.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+			buckets[string(name)] = b
+		}
+
+		b := genesisBlock
+		for h := uint64(0); h <= height; h++ {
+			if h > 0 {
+				b = types.Block{
+					ParentID:  b.ID(),
+					Timestamp: b.Timestamp.Add(10 * time.Minute),
+				}
+			}
+			id := b.ID()
+			key := make([]byte, 8)
+			binary.LittleEndian.PutUint64(key, h)
+			if err := buckets[string(bBlockPath)].Put(key, id[:]); err != nil {
+				return err
+			} else if err := buckets[string(bBlockMap)].Put(id[:], encode(b)); err != nil {
+				return err
+			}
+		}
+		heightBuf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(heightBuf, height)
+		if err := buckets[string(bBlockHeight)].Put(bBlockHeight, heightBuf); err != nil {
+			return err
+		}
+
+		// The empty blocks don't pay out anything, so the only state are the
+		// siafund outputs of the genesis block.
+		if err := buckets[string(bSiadSiafundPool)].Put(bSiadSiafundPool, encode(types.ZeroCurrency)); err != nil {
+			return err
+		}
+		txn := genesisBlock.Transactions[0]
+		for i, sfo := range txn.SiafundOutputs {
+			id := txn.SiafundOutputID(i)
+			var buf []byte
+			buf = append(buf, encode(types.NewCurrency64(sfo.Value))...)
+			buf = append(buf, sfo.Address[:]...)
+			buf = append(buf, encode(types.ZeroCurrency)...)
+			if err := buckets[string(bSiafundOutputs)].Put(id[:], buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// convertPartially converts the siad database at path up to the given height
+// without keeping the converted siad blocks, like an interrupted conversion
+// without -verify.
+func convertPartially(t *testing.T, path string, height uint64) {
+	t.Helper()
+	network, genesisBlock := chain.Mainnet()
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(btx *bolt.Tx) error {
+		if err := initCoreDB(btx, network, genesisBlock); err != nil {
+			return err
+		}
+		return convertBlocks(&dbTx{tx: btx, n: network}, 0, height, false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConvert converts and verifies a generated siad database.
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		verify  bool
+		resume  bool
+		wantErr string
+	}{
+		{name: "in place"},
+		{name: "in place verify", verify: true},
+		{name: "in place resume verify", verify: true, resume: true},
+		{name: "out", out: "converted.db"},
+		{name: "out verify", out: "converted.db", verify: true},
+		{name: "out resume verify", out: "converted.db", verify: true, resume: true},
+		{name: "out is input", out: "consensus.db", wantErr: "output must not be the input"},
+		{name: "out links to input", out: "link.db", wantErr: "output must not be the input"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "consensus.db")
+			newSiadDB(t, input, testChainHeight)
+			var out string
+			if test.out != "" {
+				out = filepath.Join(dir, test.out)
+			}
+			if test.out == "link.db" {
+				if err := os.Symlink(input, out); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if test.resume {
+				partial := input
+				if out != "" {
+					src, err := bolt.Open(input, 0600, &bolt.Options{ReadOnly: true})
+					if err != nil {
+						t.Fatal(err)
+					}
+					db, err := openOutput(src, out)
+					src.Close()
+					if err != nil {
+						t.Fatal(err)
+					}
+					db.Close()
+					partial = out
+				}
+				convertPartially(t, partial, testChainHeight/2)
+			}
+
+			err := run(input, options{out: out, verify: test.verify}, strings.NewReader("y\n"))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			// The converted database must be at the height of the siad chain
+			// without any of the siad buckets.
+			converted := input
+			if out != "" {
+				converted = out
+			}
+			network, _ := chain.Mainnet()
+			db, err := bolt.Open(converted, 0600, &bolt.Options{ReadOnly: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			db.View(func(btx *bolt.Tx) error {
+				if height := (&dbTx{tx: btx, n: network}).getHeight(); height != testChainHeight {
+					t.Errorf("converted height is %v, expected %v", height, testChainHeight)
+				}
+				for _, name := range [][]byte{bBlockHeight, bBlockMap, bBlockPath} {
+					if btx.Bucket(name) != nil {
+						t.Errorf("siad bucket %s wasn't deleted", name)
+					}
+				}
+				return nil
+			})
+
+			// A conversion into a separate file must leave the input usable by
+			// siad.
+			if out != "" {
+				src, err := bolt.Open(input, 0600, &bolt.Options{ReadOnly: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer src.Close()
+				src.View(func(btx *bolt.Tx) error {
+					if btx.Bucket(bVersion) != nil || btx.Bucket(bBlockMap) == nil {
+						t.Error("input was modified")
+					}
+					return nil
+				})
+			}
+		})
+	}
+}
+
+// TestConvertVerifyFails checks that the verification detects a converted
+// chain that doesn't match the siad chain.
+func TestConvertVerifyFails(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "consensus.db")
+	newSiadDB(t, input, testChainHeight)
+	out := filepath.Join(dir, "converted.db")
+	err := run(input, options{out: out}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare the converted chain against a different siad chain.
+	network, _ := chain.Mainnet()
+	other := filepath.Join(dir, "other.db")
+	newSiadDB(t, other, testChainHeight)
+	srcDB, err := bolt.Open(other, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srcDB.Close()
+	err = srcDB.Update(func(btx *bolt.Tx) error {
+		// Replace the block at height 10 with a different one.
+		key := make([]byte, 8)
+		binary.LittleEndian.PutUint64(key, 10)
+		var id types.BlockID
+		copy(id[:], btx.Bucket(bBlockPath).Get(key))
+		var b types.Block
+		if err := decode(btx.Bucket(bBlockMap).Get(id[:]), &b); err != nil {
+			return err
+		}
+		b.Nonce++
+		newID := b.ID()
+		if err := btx.Bucket(bBlockMap).Put(newID[:], encode(b)); err != nil {
+			return err
+		}
+		return btx.Bucket(bBlockPath).Put(key, newID[:])
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstDB, err := bolt.Open(out, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dstDB.Close()
+	// The siad buckets of the converted database were deleted, so only the
+	// core side of it is used.
+	err = verifyBlocks(srcDB, dstDB, network, 0)
+	if err == nil || !strings.Contains(err.Error(), "converted block 10") {
+		t.Fatal("expected verification to fail at height 10, got", err)
+	}
+	// Starting after the changed block, the chains match.
+	if err := verifyBlocks(srcDB, dstDB, network, 11); err != nil {
+		t.Fatal(err)
+	}
+}
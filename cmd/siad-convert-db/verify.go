@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"go.sia.tech/core/consensus"
+	"go.sia.tech/core/types"
+)
+
+// siad buckets holding the state that is compared against the converted state
+var (
+	bSiadSiafundPool = []byte("SiafundPool")
+	prefixSiadDSCO   = []byte("dsco_")
+	prefixSiadFCEX   = []byte("fcex_")
+)
+
+// report prints what converting the database would change, without changing
+// anything.
+func report(btx *bolt.Tx, genesisBlock types.Block, out string) error {
+	isCoreDB := btx.Bucket(bVersion) != nil
+	isSiadDB := btx.Bucket(bBlockMap) != nil
+	switch {
+	case !isCoreDB && !isSiadDB:
+		return errors.New("database is not a siad or core database")
+	case isCoreDB && !isSiadDB:
+		return errors.New("database already converted, nothing to do")
+	}
+	tx := &dbTx{tx: btx}
+	siadHeight := tx.getSiadHeight()
+	var coreHeight uint64
+
+	if isCoreDB {
+		coreHeight = tx.getHeight()
+		fmt.Printf("Database is partially converted up to height %v, the conversion would resume.\n", coreHeight)
+	} else {
+		fmt.Println("siad database detected.")
+		genesisID := genesisBlock.ID()
+		if btx.Bucket(bBlockMap).Get(genesisID[:]) == nil {
+			return errors.New("siad database has different genesis block")
+		}
+		// siad keeps a bucket per height for delayed outputs and contract
+		// expirations, which are summarized by prefix.
+		fmt.Println("Buckets that would be deleted:")
+		var prefixBuckets, prefixKeys [2]int
+		err := btx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			for i, prefix := range [][]byte{prefixSiadDSCO, prefixSiadFCEX} {
+				if bytes.HasPrefix(name, prefix) {
+					prefixBuckets[i]++
+					prefixKeys[i] += b.Stats().KeyN
+					return nil
+				}
+			}
+			if !isKeptSiadBucket(name) {
+				fmt.Printf("  %s (%v keys)\n", name, b.Stats().KeyN)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i, prefix := range [][]byte{prefixSiadDSCO, prefixSiadFCEX} {
+			if prefixBuckets[i] != 0 {
+				fmt.Printf("  %v %s* buckets (%v keys)\n", prefixBuckets[i], prefix, prefixKeys[i])
+			}
+		}
+		fmt.Println("Buckets that would be created:")
+		for _, name := range coreBuckets {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+
+	// Check that all blocks that still have to be converted are there.
+	var missing, firstMissing uint64
+	for height := coreHeight + 1; height <= siadHeight; height++ {
+		if _, ok := tx.getSiadBlock(height); !ok {
+			if missing == 0 {
+				firstMissing = height
+			}
+			missing++
+		}
+	}
+	if tx.err != nil {
+		return tx.err
+	}
+	fmt.Printf("Blocks that would be converted: %v (heights %v to %v)\n", siadHeight-coreHeight, coreHeight+1, siadHeight)
+	if missing != 0 {
+		fmt.Printf("WARNING: %v siad blocks are missing, starting at height %v. The conversion would fail.\n", missing, firstMissing)
+	}
+	fmt.Printf("After the conversion, the %s, %s and %s buckets would be deleted.\n", bBlockHeight, bBlockMap, bBlockPath)
+
+	if out == "" {
+		fmt.Println("The database would be converted in place and would no longer be usable by siad.")
+	} else {
+		fmt.Printf("The database (%v MiB) would be copied to %v and converted there, the input would not be modified.\n", btx.Size()>>20, out)
+	}
+	fmt.Println("Dry run complete, no changes were made.")
+	return nil
+}
+
+// verifyBlocks checks that the main chain of the converted database dst
+// consists of the blocks in the BlockMap of the siad database src, starting at
+// the given height.
+func verifyBlocks(src, dst *bolt.DB, n *consensus.Network, height uint64) error {
+	var siadHeight, coreHeight uint64
+	src.View(func(btx *bolt.Tx) error {
+		siadHeight = (&dbTx{tx: btx, n: n}).getSiadHeight()
+		return nil
+	})
+	var parentID types.BlockID
+	dst.View(func(btx *bolt.Tx) error {
+		tx := &dbTx{tx: btx, n: n}
+		coreHeight = tx.getHeight()
+		if height > 0 {
+			parent, _ := tx.BestIndex(height - 1)
+			parentID = parent.ID
+		}
+		return nil
+	})
+	if coreHeight != siadHeight {
+		return fmt.Errorf("converted height %v doesn't match siad height %v", coreHeight, siadHeight)
+	}
+
+	const blocksPerDBTx = 1000
+	p := newProgress("Verified", height, siadHeight)
+	for height <= siadHeight {
+		stopHeight := height + blocksPerDBTx
+		if stopHeight > siadHeight+1 {
+			stopHeight = siadHeight + 1
+		}
+		err := src.View(func(srcTx *bolt.Tx) error {
+			return dst.View(func(dstTx *bolt.Tx) error {
+				stx := &dbTx{tx: srcTx, n: n}
+				dtx := &dbTx{tx: dstTx, n: n}
+				for ; height < stopHeight; height++ {
+					if err := verifyBlock(stx, dtx, height, &parentID); err != nil {
+						return err
+					}
+				}
+				if stx.err != nil {
+					return stx.err
+				}
+				return dtx.err
+			})
+		})
+		if err != nil {
+			return err
+		}
+		p.update(height - 1)
+	}
+	fmt.Println()
+	return nil
+}
+
+// verifyBlock checks the converted block at the given height. parentID is the
+// ID of the previous block and is updated to the ID of this block.
+func verifyBlock(stx, dtx *dbTx, height uint64, parentID *types.BlockID) error {
+	id := stx.getSiadBlockID(height)
+	b, ok := stx.getSiadBlock(height)
+	if id == (types.BlockID{}) || !ok {
+		return fmt.Errorf("siad block %v is missing", height)
+	} else if b.ID() != id {
+		return fmt.Errorf("siad block %v has ID %v, expected %v", height, b.ID(), id)
+	}
+
+	index, ok := dtx.BestIndex(height)
+	if !ok {
+		return fmt.Errorf("converted block %v is missing", height)
+	} else if index.ID != id {
+		return fmt.Errorf("converted block %v is %v, expected %v", height, index.ID, id)
+	}
+	c, ok := dtx.getCheckpoint(id)
+	if !ok {
+		return fmt.Errorf("checkpoint of block %v is missing", height)
+	} else if c.Block.ID() != id {
+		return fmt.Errorf("checkpoint of block %v contains block %v", height, c.Block.ID())
+	} else if c.State.Index != index {
+		return fmt.Errorf("state of block %v has index %v", height, c.State.Index)
+	} else if height > 0 && c.Block.ParentID != *parentID {
+		return fmt.Errorf("block %v doesn't extend block %v", height, *parentID)
+	}
+	*parentID = id
+	return nil
+}
+
+// siadSFO is a siafund output as stored by siad.
+type siadSFO struct {
+	Value      types.Currency
+	Address    types.Address
+	ClaimStart types.Currency
+}
+
+// DecodeFrom implements types.DecoderFrom.
+func (sfo *siadSFO) DecodeFrom(d *types.Decoder) {
+	sfo.Value.DecodeFrom(d)
+	sfo.Address.DecodeFrom(d)
+	sfo.ClaimStart.DecodeFrom(d)
+}
+
+// encode returns the encoding of v.
+func encode(v types.EncoderTo) []byte {
+	var buf bytes.Buffer
+	e := types.NewEncoder(&buf)
+	v.EncodeTo(e)
+	e.Flush()
+	return buf.Bytes()
+}
+
+// decode decodes val into v.
+func decode(val []byte, v types.DecoderFrom) error {
+	d := types.NewBufDecoder(val)
+	v.DecodeFrom(d)
+	return d.Err()
+}
+
+// countIDKeys returns the number of keys in the bucket that are IDs, as
+// opposed to heights or special keys.
+func countIDKeys(b *bolt.Bucket) (n int) {
+	b.ForEach(func(k, _ []byte) error {
+		if len(k) == 32 {
+			n++
+		}
+		return nil
+	})
+	return
+}
+
+// verifyState checks that the state of the converted database dst matches
+// the state of the siad database src. Both are expected to be at the same
+// height.
+func verifyState(src, dst *bolt.DB, n *consensus.Network) error {
+	return src.View(func(srcTx *bolt.Tx) error {
+		return dst.View(func(dstTx *bolt.Tx) error {
+			dtx := &dbTx{tx: dstTx, n: n}
+			if err := verifySiacoinOutputs(srcTx, dtx); err != nil {
+				return err
+			}
+			if err := verifyDelayedSiacoinOutputs(srcTx, dtx); err != nil {
+				return err
+			}
+			if err := verifySiafundOutputs(srcTx, dtx); err != nil {
+				return err
+			}
+			if err := verifyFileContracts(srcTx, dtx); err != nil {
+				return err
+			}
+
+			var pool types.Currency
+			if b := srcTx.Bucket(bSiadSiafundPool); b == nil {
+				return errors.New("siad database is missing the siafund pool")
+			} else if err := decode(b.Get(bSiadSiafundPool), &pool); err != nil {
+				return fmt.Errorf("couldn't decode siad siafund pool: %w", err)
+			}
+			index, _ := dtx.BestIndex(dtx.getHeight())
+			c, ok := dtx.getCheckpoint(index.ID)
+			if !ok {
+				return errors.New("checkpoint of the current block is missing")
+			} else if c.State.SiafundPool != pool {
+				return fmt.Errorf("siafund pool is %v, expected %v", c.State.SiafundPool, pool)
+			}
+			return dtx.err
+		})
+	})
+}
+
+// verifySiacoinOutputs checks that the converted database contains the
+// spendable siacoin outputs of siad.
+func verifySiacoinOutputs(srcTx *bolt.Tx, dtx *dbTx) error {
+	var count int
+	err := srcTx.Bucket(bSiacoinOutputs).ForEach(func(k, v []byte) error {
+		var sco types.SiacoinOutput
+		if err := decode(v, &sco); err != nil {
+			return fmt.Errorf("couldn't decode siad siacoin output %x: %w", k, err)
+		}
+		if !bytes.Equal(dtx.bucket(bSiacoinOutputs).getRaw(k), encode(sco)) {
+			return fmt.Errorf("siacoin output %x doesn't match", k)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n := countIDKeys(dtx.tx.Bucket(bSiacoinOutputs)); n != count {
+		return fmt.Errorf("converted database has %v siacoin outputs, expected %v", n, count)
+	}
+	fmt.Printf("Verified %v siacoin outputs\n", count)
+	return nil
+}
+
+// verifyDelayedSiacoinOutputs checks that the converted database contains the
+// immature siacoin outputs of siad.
+func verifyDelayedSiacoinOutputs(srcTx *bolt.Tx, dtx *dbTx) error {
+	// siad keeps a bucket per maturity height, the core database a key per
+	// maturity height. Compare the outputs regardless of their height.
+	expected := make(map[types.SiacoinOutputID][]byte)
+	err := srcTx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefixSiadDSCO) {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var id types.SiacoinOutputID
+			var sco types.SiacoinOutput
+			copy(id[:], k)
+			if err := decode(v, &sco); err != nil {
+				return fmt.Errorf("couldn't decode siad delayed siacoin output %x: %w", k, err)
+			}
+			expected[id] = encode(sco)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = dtx.tx.Bucket(bSiacoinOutputs).ForEach(func(k, _ []byte) error {
+		if len(k) != 8 {
+			return nil
+		}
+		for _, dscod := range dtx.MaturedSiacoinOutputs(binary.BigEndian.Uint64(k)) {
+			if v, ok := expected[dscod.ID]; !ok || !bytes.Equal(v, encode(dscod.Output)) {
+				return fmt.Errorf("delayed siacoin output %v doesn't match", dscod.ID)
+			}
+			count++
+		}
+		return dtx.err
+	})
+	if err != nil {
+		return err
+	}
+	if count != len(expected) {
+		return fmt.Errorf("converted database has %v delayed siacoin outputs, expected %v", count, len(expected))
+	}
+	fmt.Printf("Verified %v delayed siacoin outputs\n", count)
+	return nil
+}
+
+// verifySiafundOutputs checks that the converted database contains the
+// siafund outputs of siad.
+func verifySiafundOutputs(srcTx *bolt.Tx, dtx *dbTx) error {
+	var count int
+	err := srcTx.Bucket(bSiafundOutputs).ForEach(func(k, v []byte) error {
+		var sfo siadSFO
+		if err := decode(v, &sfo); err != nil {
+			return fmt.Errorf("couldn't decode siad siafund output %x: %w", k, err)
+		}
+		var id types.SiafundOutputID
+		copy(id[:], k)
+		out, claimStart, ok := dtx.SiafundOutput(id)
+		if !ok || sfo.Value.Hi != 0 || out.Value != sfo.Value.Lo || out.Address != sfo.Address || claimStart != sfo.ClaimStart {
+			return fmt.Errorf("siafund output %v doesn't match", id)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n := countIDKeys(dtx.tx.Bucket(bSiafundOutputs)); n != count {
+		return fmt.Errorf("converted database has %v siafund outputs, expected %v", n, count)
+	}
+	fmt.Printf("Verified %v siafund outputs\n", count)
+	return nil
+}
+
+// verifyFileContracts checks that the converted database contains the file
+// contracts of siad.
+func verifyFileContracts(srcTx *bolt.Tx, dtx *dbTx) error {
+	var count int
+	err := srcTx.Bucket(bFileContracts).ForEach(func(k, v []byte) error {
+		var fc types.FileContract
+		if err := decode(v, &fc); err != nil {
+			return fmt.Errorf("couldn't decode siad file contract %x: %w", k, err)
+		}
+		if !bytes.Equal(dtx.bucket(bFileContracts).getRaw(k), encode(fc)) {
+			return fmt.Errorf("file contract %x doesn't match", k)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n := countIDKeys(dtx.tx.Bucket(bFileContracts)); n != count {
+		return fmt.Errorf("converted database has %v file contracts, expected %v", n, count)
+	}
+	fmt.Printf("Verified %v file contracts\n", count)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"go.sia.tech/siad/modules/consensus"
+	"go.sia.tech/siad/types"
+)
+
+func main() {
+	log.SetFlags(log.Lshortfile)
+	if len(os.Args) != 2 && len(os.Args) != 3 {
+		log.Fatal("Usage: siad-prune-db /path/to/consensus.db [depth]")
+	}
+	filename := os.Args[1]
+	depth := consensus.MinPruneDepth
+	if len(os.Args) == 3 {
+		d, err := strconv.ParseUint(os.Args[2], 10, 64)
+		if err != nil {
+			log.Fatal("Invalid depth:", err)
+		}
+		depth = types.BlockHeight(d)
+	}
+
+	fmt.Printf("Pruning all blocks older than %v blocks from %v...\n", depth, filename)
+	fmt.Println("Pruned blocks can't be served to peers or rescanned by modules!")
+	fmt.Print("Proceed? (y/n): ")
+	var resp string
+	if _, err := fmt.Scanln(&resp); err != nil {
+		log.Fatal(err)
+	} else if resp != "y" {
+		log.Fatal("aborted")
+	}
+
+	start := time.Now()
+	pruned, err := consensus.PruneDatabase(filename, depth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Pruned blocks up to height %v in %v\n", pruned, time.Since(start).Round(time.Second))
+
+	// bolt never shrinks its file, copy the database to reclaim the space of
+	// the pruned blocks.
+	fmt.Println("Compacting database...")
+	if err := compact(filename); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Done.")
+}
+
+// compact copies the database at filename into a new file and replaces the
+// original with the copy.
+func compact(filename string) error {
+	tmpFilename := filename + "_compact"
+	src, err := bolt.Open(filename, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := bolt.Open(tmpFilename, 0600, nil)
+	if err != nil {
+		return err
+	}
+
+	err = src.View(func(srcTx *bolt.Tx) error {
+		return srcTx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			return dst.Update(func(dstTx *bolt.Tx) error {
+				dstBucket, err := dstTx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(dstBucket, b)
+			})
+		})
+	})
+	if err != nil {
+		dst.Close()
+		os.Remove(tmpFilename)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := src.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+// copyBucket recursively copies the contents of src into dst.
+func copyBucket(dst, src *bolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+		nested, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(nested, src.Bucket(k))
+	})
+}
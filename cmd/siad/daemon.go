@@ -15,6 +15,7 @@ import (
 	"golang.org/x/term"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api/server"
 	"go.sia.tech/siad/profile"
@@ -117,7 +118,18 @@ func processConfig(config Config) (Config, error) {
 		config.Siad.Profile, err2 = profile.ProcessProfileFlags(config.Siad.Profile)
 	}
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	var err4 error
+	if config.Siad.ConsensusCheckpointHash != "" {
+		var hash crypto.Hash
+		if err := hash.LoadString(config.Siad.ConsensusCheckpointHash); err != nil {
+			err4 = errors.AddContext(err, "unable to parse --consensus-checkpoint-hash flag")
+		}
+	}
+	var err5 error
+	if config.Siad.StratumAddr != "" && !strings.Contains(config.Siad.Modules, "m") {
+		err5 = errors.New("the --stratum-addr flag requires the miner module")
+	}
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
@@ -206,6 +218,12 @@ func startDaemon(config Config) (err error) {
 	// Print a startup message.
 	fmt.Println("Loading...")
 
+	// Route outbound connections through the proxy before any modules are
+	// created.
+	if err := modules.GlobalProxy.SetAddress(config.Siad.SOCKSProxy); err != nil {
+		return errors.AddContext(err, "unable to use --socks-proxy")
+	}
+
 	// Create the node params by parsing the modules specified in the config.
 	nodeParams := parseModules(config)
 	// set the wallet password from the environment variable
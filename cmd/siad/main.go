@@ -37,6 +37,8 @@ type Config struct {
 		HostAddr      string
 		SiaMuxTCPAddr string
 		SiaMuxWSAddr  string
+		StratumAddr   string
+		SOCKSProxy    string
 		AllowAPIBind  bool
 
 		Modules           string
@@ -46,6 +48,10 @@ type Config struct {
 		AuthenticateAPI   bool
 		TempPassword      bool
 
+		ConsensusCheckpoint     string
+		ConsensusCheckpointHash string
+		ConsensusPruneDepth     uint64
+
 		Profile    string
 		ProfileDir string
 
@@ -137,7 +143,8 @@ Host (h):
 		siad -M host
 Miner (m):
 	The miner provides a basic CPU mining implementation as well as an API
-	for external miners to use.
+	for external miners to use. External miners can also connect to a
+	stratum server, which is started with the --stratum-addr flag.
 	The miner requires the consensus set, transaction pool, and wallet.
 	Example:
 		siad -M gctwm
@@ -195,11 +202,16 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", defaultAPIAddr, "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusCheckpoint, "consensus-checkpoint", "", "", "bootstrap a new consensus database from this checkpoint file")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusCheckpointHash, "consensus-checkpoint-hash", "", "", "trusted hash of the consensus checkpoint")
+	root.Flags().Uint64VarP(&globalConfig.Siad.ConsensusPruneDepth, "consensus-prune-depth", "", 0, "prune consensus blocks older than this many blocks, 0 disables pruning")
 	root.Flags().BoolVarP(&globalConfig.Siad.UseUPNP, "upnp", "", true, "use UPnP for port forwarding and external IP discovery")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", defaultRPCAddr, "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaMuxTCPAddr, "siamux-addr", "", defaultRHP3TCPAddr, "which port the SiaMux listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaMuxWSAddr, "siamux-addr-ws", "", defaultRHP3WSAddr, "which port the SiaMux websocket listens on")
+	root.Flags().StringVarP(&globalConfig.Siad.StratumAddr, "stratum-addr", "", "", "which host:port the miner's stratum server listens on, disabled if empty")
+	root.Flags().StringVarP(&globalConfig.Siad.SOCKSProxy, "socks-proxy", "", "", "route outbound gateway and renter connections through the SOCKS5 proxy at this host:port")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "gctwrhfa", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/types"
 )
 
 // createNodeParams parses the provided config and creates the corresponding
@@ -43,11 +44,18 @@ func parseModules(config Config) node.NodeParams {
 	}
 	// Parse remaining fields.
 	params.Bootstrap = !config.Siad.NoBootstrap
+	params.ConsensusPruneDepth = types.BlockHeight(config.Siad.ConsensusPruneDepth)
+	params.ConsensusCheckpoint = config.Siad.ConsensusCheckpoint
+	if config.Siad.ConsensusCheckpointHash != "" {
+		// The hash has been validated by processConfig.
+		_ = params.ConsensusCheckpointHash.LoadString(config.Siad.ConsensusCheckpointHash)
+	}
 	params.UseUPNP = config.Siad.UseUPNP
 	params.HostAddress = config.Siad.HostAddr
 	params.RPCAddress = config.Siad.RPCaddr
 	params.SiaMuxTCPAddress = config.Siad.SiaMuxTCPAddr
 	params.SiaMuxWSAddress = config.Siad.SiaMuxWSAddr
+	params.StratumAddress = config.Siad.StratumAddr
 	params.Dir = config.Siad.SiaDir
 	return params
 }
@@ -0,0 +1,191 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// BandwidthWindowTimeFormat is the format of the start and end times of
+	// a BandwidthWindow.
+	BandwidthWindowTimeFormat = "15:04"
+
+	// MaxBandwidthWindows is the maximum number of windows of a bandwidth
+	// schedule.
+	MaxBandwidthWindows = 64
+)
+
+// ErrInvalidBandwidthWindow is returned if a window of a bandwidth schedule is
+// invalid.
+var ErrInvalidBandwidthWindow = errors.New("invalid bandwidth window")
+
+// BandwidthWindow is a recurring time of day during which the renter uses
+// different rate limits than the ones of its settings. Windows whose end is
+// before their start span midnight.
+type BandwidthWindow struct {
+	// Name is an optional description of the window.
+	Name string `json:"name"`
+
+	// Days are the weekdays on which the window starts. No days means every
+	// day.
+	Days []time.Weekday `json:"days"`
+
+	// Start and End are the local times of day in BandwidthWindowTimeFormat
+	// at which the window starts and ends.
+	Start string `json:"start"`
+	End   string `json:"end"`
+
+	// MaxDownloadSpeed and MaxUploadSpeed replace the rate limits of the
+	// renter's settings while the window is active. MaxRepairSpeed limits the
+	// bytes per second of chunks the repair loop hands to the workers. 0
+	// means no limit.
+	MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
+	MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+	MaxRepairSpeed   int64 `json:"maxrepairspeed"`
+
+	// PauseRepairs pauses the repair of chunks that were uploaded before
+	// while the window is active. New uploads continue.
+	PauseRepairs bool `json:"pauserepairs"`
+}
+
+// minutes returns the start and end of the window in minutes after midnight.
+func (w BandwidthWindow) minutes() (start, end int, err error) {
+	s, err := time.Parse(BandwidthWindowTimeFormat, w.Start)
+	if err != nil {
+		return 0, 0, errors.Compose(ErrInvalidBandwidthWindow, fmt.Errorf("invalid start %q", w.Start))
+	}
+	e, err := time.Parse(BandwidthWindowTimeFormat, w.End)
+	if err != nil {
+		return 0, 0, errors.Compose(ErrInvalidBandwidthWindow, fmt.Errorf("invalid end %q", w.End))
+	}
+	return s.Hour()*60 + s.Minute(), e.Hour()*60 + e.Minute(), nil
+}
+
+// onDay returns whether the window starts on the given weekday.
+func (w BandwidthWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns whether the window is active at the given time. The time is
+// interpreted in its own location.
+func (w BandwidthWindow) Active(t time.Time) bool {
+	start, end, err := w.minutes()
+	if err != nil {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if start < end {
+		return start <= m && m < end && w.onDay(day)
+	}
+	// The window spans midnight. After midnight, it belongs to the day it
+	// started on.
+	if m >= start {
+		return w.onDay(day)
+	}
+	if m < end {
+		return w.onDay((day + 6) % 7)
+	}
+	return false
+}
+
+// Validate returns an error if the window is invalid.
+func (w BandwidthWindow) Validate() error {
+	start, end, err := w.minutes()
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.Compose(ErrInvalidBandwidthWindow, errors.New("start and end can't be the same"))
+	}
+	for _, d := range w.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return errors.Compose(ErrInvalidBandwidthWindow, fmt.Errorf("invalid weekday %v", int(d)))
+		}
+	}
+	if w.MaxDownloadSpeed < 0 || w.MaxUploadSpeed < 0 || w.MaxRepairSpeed < 0 {
+		return errors.Compose(ErrInvalidBandwidthWindow, errors.New("rate limits can't be negative"))
+	}
+	return nil
+}
+
+// ValidateBandwidthSchedule returns an error if a bandwidth schedule has too
+// many windows or one of them is invalid.
+func ValidateBandwidthSchedule(windows []BandwidthWindow) error {
+	if len(windows) > MaxBandwidthWindows {
+		return errors.Compose(ErrInvalidBandwidthWindow, fmt.Errorf("a schedule can't have more than %v windows", MaxBandwidthWindows))
+	}
+	for i, w := range windows {
+		if err := w.Validate(); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("window %v", i+1))
+		}
+	}
+	return nil
+}
+
+// ActiveBandwidthWindow returns the first window of a bandwidth schedule that
+// is active at the given time.
+func ActiveBandwidthWindow(windows []BandwidthWindow, t time.Time) (BandwidthWindow, bool) {
+	for _, w := range windows {
+		if w.Active(t) {
+			return w, true
+		}
+	}
+	return BandwidthWindow{}, false
+}
+
+// ParseWeekdays parses a comma separated list of weekdays and ranges of
+// weekdays, e.g. "mon-fri,sun". Weekdays can be abbreviated to their first
+// three letters. An empty string means every day.
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	parseDay := func(name string) (time.Weekday, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if len(name) >= 3 && strings.HasPrefix(strings.ToLower(d.String()), name) {
+				return d, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown weekday %q", name)
+	}
+	var days []time.Weekday
+	seen := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := parseDay(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = parseDay(bounds[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Ranges may wrap around the end of the week, e.g. "fri-mon".
+		for d := first; ; d = (d + 1) % 7 {
+			if !seen[d] {
+				seen[d] = true
+				days = append(days, d)
+			}
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
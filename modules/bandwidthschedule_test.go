@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestBandwidthWindowActive tests whether bandwidth windows are active at
+// different times.
+func TestBandwidthWindowActive(t *testing.T) {
+	// 2020-10-19 is a Monday.
+	at := func(day int, clock string) time.Time {
+		c, err := time.Parse(BandwidthWindowTimeFormat, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2020, 10, 19+day, c.Hour(), c.Minute(), 0, 0, time.UTC)
+	}
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	office := BandwidthWindow{Days: weekdays, Start: "09:00", End: "17:00"}
+	night := BandwidthWindow{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "06:00"}
+	tests := []struct {
+		w      BandwidthWindow
+		t      time.Time
+		active bool
+	}{
+		{office, at(0, "08:59"), false},
+		{office, at(0, "09:00"), true},
+		{office, at(0, "16:59"), true},
+		{office, at(0, "17:00"), false},
+		{office, at(5, "12:00"), false},
+		{night, at(4, "21:59"), false},
+		{night, at(4, "22:00"), true},
+		{night, at(5, "05:59"), true},
+		{night, at(5, "06:00"), false},
+		{night, at(3, "23:00"), false},
+		{night, at(4, "01:00"), false},
+		{BandwidthWindow{Start: "22:00", End: "06:00"}, at(2, "03:00"), true},
+	}
+	for i, test := range tests {
+		if active := test.w.Active(test.t); active != test.active {
+			t.Errorf("%v: expected %v but got %v", i, test.active, active)
+		}
+	}
+
+	// The first active window of a schedule wins.
+	schedule := []BandwidthWindow{{Name: "office", Days: weekdays, Start: "09:00", End: "17:00"}, {Name: "day", Start: "06:00", End: "22:00"}}
+	if w, ok := ActiveBandwidthWindow(schedule, at(0, "12:00")); !ok || w.Name != "office" {
+		t.Fatal("expected the office window", w, ok)
+	}
+	if w, ok := ActiveBandwidthWindow(schedule, at(6, "12:00")); !ok || w.Name != "day" {
+		t.Fatal("expected the day window", w, ok)
+	}
+	if _, ok := ActiveBandwidthWindow(schedule, at(0, "23:00")); ok {
+		t.Fatal("no window should be active")
+	}
+}
+
+// TestValidateBandwidthSchedule tests validating bandwidth schedules.
+func TestValidateBandwidthSchedule(t *testing.T) {
+	valid := BandwidthWindow{Start: "09:00", End: "17:00", MaxUploadSpeed: 1 << 20}
+	if err := ValidateBandwidthSchedule([]BandwidthWindow{valid}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []BandwidthWindow{
+		{Start: "9", End: "17:00"},
+		{Start: "09:00", End: "24:00"},
+		{Start: "09:00", End: "09:00"},
+		{Start: "09:00", End: "17:00", Days: []time.Weekday{7}},
+		{Start: "09:00", End: "17:00", MaxRepairSpeed: -1},
+	}
+	for _, w := range invalid {
+		if err := ValidateBandwidthSchedule([]BandwidthWindow{valid, w}); !errors.Contains(err, ErrInvalidBandwidthWindow) {
+			t.Errorf("%+v: expected ErrInvalidBandwidthWindow but got %v", w, err)
+		}
+	}
+	if err := ValidateBandwidthSchedule(make([]BandwidthWindow, MaxBandwidthWindows+1)); !errors.Contains(err, ErrInvalidBandwidthWindow) {
+		t.Fatal("expected ErrInvalidBandwidthWindow but got", err)
+	}
+}
+
+// TestParseWeekdays tests parsing lists of weekdays.
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		s    string
+		days []time.Weekday
+	}{
+		{"", nil},
+		{"mon", []time.Weekday{time.Monday}},
+		{"Monday, sun", []time.Weekday{time.Monday, time.Sunday}},
+		{"mon-wed", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday}},
+		{"fri-mon,sat", []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+	}
+	for _, test := range tests {
+		days, err := ParseWeekdays(test.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(days, test.days) {
+			t.Errorf("%q: expected %v but got %v", test.s, test.days, days)
+		}
+	}
+	for _, s := range []string{"mo", "mon-", "funday"} {
+		if _, err := ParseWeekdays(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package modules
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"go.sia.tech/siad/crypto"
+)
+
+const (
+	// ChecksumNone disables computing a checksum of the uploaded data.
+	ChecksumNone = "none"
+
+	// ChecksumSHA256 is the SHA-256 checksum algorithm.
+	ChecksumSHA256 = "sha256"
+
+	// ChecksumBLAKE2b is the BLAKE2b-256 checksum algorithm.
+	ChecksumBLAKE2b = "blake2b"
+
+	// DefaultChecksumAlgorithm is the checksum algorithm used for uploads that
+	// don't specify one.
+	DefaultChecksumAlgorithm = ChecksumSHA256
+)
+
+var (
+	// ErrChecksumMismatch is returned if the checksum of downloaded or local
+	// data doesn't match the checksum of the data that was uploaded.
+	ErrChecksumMismatch = errors.New("checksum doesn't match the checksum of the uploaded file")
+
+	// ErrUnknownChecksumAlgorithm is returned if a checksum algorithm is not
+	// supported.
+	ErrUnknownChecksumAlgorithm = errors.New("unknown checksum algorithm")
+)
+
+// FileChecksum is the checksum of the plaintext of an uploaded file. A
+// FileChecksum with an empty Algorithm means that no checksum is known.
+type FileChecksum struct {
+	Algorithm string      `json:"algorithm"`
+	Hash      crypto.Hash `json:"hash"`
+}
+
+// NewChecksumHasher returns a hasher for the given checksum algorithm.
+func NewChecksumHasher(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumBLAKE2b:
+		return blake2b.New256(nil)
+	default:
+		return nil, errors.AddContext(ErrUnknownChecksumAlgorithm, algorithm)
+	}
+}
+
+// ComputeChecksum computes the checksum of the data read from r until io.EOF
+// using the given algorithm.
+func ComputeChecksum(algorithm string, r io.Reader) (FileChecksum, error) {
+	h, err := NewChecksumHasher(algorithm)
+	if err != nil {
+		return FileChecksum{}, err
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return FileChecksum{}, err
+	}
+	return NewFileChecksum(algorithm, h), nil
+}
+
+// NewFileChecksum creates a FileChecksum from a hasher previously returned by
+// NewChecksumHasher.
+func NewFileChecksum(algorithm string, h hash.Hash) (c FileChecksum) {
+	c.Algorithm = algorithm
+	copy(c.Hash[:], h.Sum(nil))
+	return c
+}
+
+// IsSet returns whether the checksum is known.
+func (c FileChecksum) IsSet() bool {
+	return c.Algorithm != ""
+}
+
+// String implements fmt.Stringer.
+func (c FileChecksum) String() string {
+	if !c.IsSet() {
+		return "-"
+	}
+	return fmt.Sprintf("%v:%v", c.Algorithm, c.Hash)
+}
+
+// Verify compares the checksum to the checksum of other data computed with
+// the same algorithm and returns ErrChecksumMismatch if they differ.
+func (c FileChecksum) Verify(other FileChecksum) error {
+	if c != other {
+		return errors.AddContext(ErrChecksumMismatch, fmt.Sprintf("expected %v but got %v", c, other))
+	}
+	return nil
+}
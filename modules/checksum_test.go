@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestComputeChecksum tests ComputeChecksum against known test vectors.
+func TestComputeChecksum(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		data      string
+		hash      string
+	}{
+		{ChecksumSHA256, "", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{ChecksumSHA256, "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{ChecksumBLAKE2b, "", "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8"},
+		{ChecksumBLAKE2b, "abc", "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319"},
+	}
+	for _, test := range tests {
+		checksum, err := ComputeChecksum(test.algorithm, strings.NewReader(test.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if checksum.Algorithm != test.algorithm {
+			t.Fatal("wrong algorithm", checksum.Algorithm)
+		}
+		if hex.EncodeToString(checksum.Hash[:]) != test.hash {
+			t.Fatalf("wrong %v checksum of %q: %v", test.algorithm, test.data, checksum.Hash)
+		}
+	}
+
+	// Unknown algorithms should be rejected.
+	_, err := ComputeChecksum("md5", strings.NewReader("abc"))
+	if !errors.Contains(err, ErrUnknownChecksumAlgorithm) {
+		t.Fatal("expected ErrUnknownChecksumAlgorithm but got", err)
+	}
+}
+
+// TestFileChecksumVerify tests FileChecksum.Verify.
+func TestFileChecksumVerify(t *testing.T) {
+	checksum, err := ComputeChecksum(ChecksumSHA256, bytes.NewReader([]byte("abc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checksum.Verify(checksum); err != nil {
+		t.Fatal(err)
+	}
+	other, err := ComputeChecksum(ChecksumSHA256, bytes.NewReader([]byte("abd")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checksum.Verify(other); !errors.Contains(err, ErrChecksumMismatch) {
+		t.Fatal("expected ErrChecksumMismatch but got", err)
+	}
+	// The same hash computed with a different algorithm doesn't match either.
+	other = checksum
+	other.Algorithm = ChecksumBLAKE2b
+	if err := checksum.Verify(other); !errors.Contains(err, ErrChecksumMismatch) {
+		t.Fatal("expected ErrChecksumMismatch but got", err)
+	}
+	if (FileChecksum{}).IsSet() || !checksum.IsSet() {
+		t.Fatal("IsSet returned wrong value")
+	}
+}
@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// CompressionNone disables compressing uploaded data.
+	CompressionNone = "none"
+
+	// CompressionGzip compresses uploaded data using gzip.
+	CompressionGzip = "gzip"
+)
+
+// ErrUnknownCompressionCodec is returned if a compression codec is not
+// supported.
+var ErrUnknownCompressionCodec = errors.New("unknown compression codec")
+
+// FileCompression describes how the data of a file was compressed before it
+// was encrypted and uploaded. A FileCompression with an empty Codec means
+// that the file isn't compressed.
+//
+// Every chunk of a compressed file contains frames of independently
+// compressed data. ChunkOffsets contains the offset within the uncompressed
+// data at which each chunk starts, which allows for seeking within the file by
+// decompressing only the chunk that contains the offset.
+type FileCompression struct {
+	Codec        string   `json:"codec"`
+	Size         uint64   `json:"size"`
+	ChunkOffsets []uint64 `json:"chunkoffsets"`
+}
+
+// IsSet returns whether the file is compressed.
+func (c FileCompression) IsSet() bool {
+	return c.Codec != ""
+}
+
+// Copy returns a deep copy of the FileCompression.
+func (c FileCompression) Copy() FileCompression {
+	if c.ChunkOffsets != nil {
+		c.ChunkOffsets = append([]uint64(nil), c.ChunkOffsets...)
+	}
+	return c
+}
+
+// ChunkRange returns the uncompressed offset and length of the data stored
+// within a chunk.
+func (c FileCompression) ChunkRange(chunkIndex uint64) (offset, length uint64) {
+	offset = c.ChunkOffsets[chunkIndex]
+	end := c.Size
+	if chunkIndex+1 < uint64(len(c.ChunkOffsets)) {
+		end = c.ChunkOffsets[chunkIndex+1]
+	}
+	return offset, end - offset
+}
+
+// ChunkIndex returns the index of the chunk which contains the data at the
+// given uncompressed offset.
+func (c FileCompression) ChunkIndex(offset uint64) uint64 {
+	// Find the last chunk that starts at or before the offset.
+	i := sort.Search(len(c.ChunkOffsets), func(i int) bool {
+		return c.ChunkOffsets[i] > offset
+	})
+	if i == 0 {
+		return 0
+	}
+	return uint64(i - 1)
+}
+
+// ValidateCompressionCodec returns an error if a compression codec is not
+// supported. An empty codec is equivalent to CompressionNone.
+func ValidateCompressionCodec(codec string) error {
+	switch codec {
+	case "", CompressionNone, CompressionGzip:
+		return nil
+	default:
+		return errors.AddContext(ErrUnknownCompressionCodec, codec)
+	}
+}
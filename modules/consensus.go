@@ -48,6 +48,12 @@ var (
 	// should be handled by the module, and not reported to the user.
 	ErrInvalidConsensusChangeID = errors.New("consensus subscription has invalid id - files are inconsistent")
 
+	// ErrPrunedConsensusChange indicates that ConsensusSetSubscribe was called
+	// with a consensus change that is older than the history kept by a pruned
+	// consensus set. The subscriber can't be brought up to date by this
+	// consensus set, a consensus set with the full history is required.
+	ErrPrunedConsensusChange = errors.New("consensus subscription requires history that has been pruned from the consensus set")
+
 	// ErrNonExtendingBlock indicates that a block is valid but does not result
 	// in a fork that is the heaviest known fork - the consensus set has not
 	// changed as a result of seeing the block.
@@ -226,6 +232,12 @@ type (
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// ExportCheckpoint writes a checkpoint of the current block to the
+		// writer. A new consensus set can be bootstrapped from the checkpoint.
+		// The id and height of the block and the hash of the checkpoint are
+		// returned.
+		ExportCheckpoint(io.Writer) (types.BlockID, types.BlockHeight, crypto.Hash, error)
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
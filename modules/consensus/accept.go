@@ -317,6 +317,19 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	for i := 0; i < len(changes); i++ {
 		cs.updateSubscribers(changes[i])
 	}
+
+	// Prune the blocks that dropped below the prune depth. The changes need to
+	// be sent to the subscribers first, since they can't be computed for
+	// pruned blocks.
+	if cs.pruneDepth > 0 {
+		err := cs.db.Update(func(tx *bolt.Tx) error {
+			_, err := pruneBlocks(tx, cs.pruneDepth, pruneBatchSize)
+			return err
+		})
+		if err != nil {
+			cs.log.Println("ERROR: unable to prune the consensus database:", err)
+		}
+	}
 	return chainExtended, nil
 }
 
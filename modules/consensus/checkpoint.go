@@ -0,0 +1,535 @@
+package consensus
+
+// checkpoint.go implements trusted checkpoints of the consensus set. A
+// checkpoint is a snapshot of the consensus database at the current block. A
+// new node can be bootstrapped from a checkpoint instead of validating the
+// blockchain from the genesis block, and then synchronizes the remaining
+// blocks normally. The blocks below the checkpoint are not validated by the
+// new node, so the checkpoint has to match a trusted hash.
+//
+// The blocks below the checkpoint are only included as headers, which means
+// that a consensus set created from a checkpoint behaves like a pruned
+// consensus set for those blocks. Subscribers that subscribe from the
+// beginning receive a single consensus change that contains the full current
+// state of the consensus set instead of the history of the blockchain.
+//
+// A checkpoint is a stream of sia-encoded objects. It starts with a
+// checkpointHeader, followed by the database buckets. Each bucket is written
+// as its name followed by its key-value pairs, and is terminated by an empty
+// key. The checkpoint is terminated by an empty bucket name. The content of a
+// checkpoint only depends on the blockchain, so checkpoints exported by
+// different nodes at the same block are identical.
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// checkpointBatchSize is the number of database entries that are written
+	// within a single database transaction when importing a checkpoint.
+	checkpointBatchSize = 10000
+
+	// maxCheckpointEntrySize is the maximum size of a single key or value of
+	// a checkpoint.
+	maxCheckpointEntrySize = 1 << 24
+)
+
+var (
+	// FieldCheckpointHeight is a field in BucketPruning that holds the height
+	// of the checkpoint the consensus set was created from.
+	FieldCheckpointHeight = []byte("CheckpointHeight")
+
+	// TrustedCheckpoints contains the hashes of the checkpoints that are
+	// trusted by this build, keyed by the height of the checkpoint.
+	TrustedCheckpoints = build.Select(build.Var{
+		Standard: map[types.BlockHeight]crypto.Hash{},
+		Testnet:  map[types.BlockHeight]crypto.Hash{},
+		Dev:      map[types.BlockHeight]crypto.Hash{},
+		Testing:  map[types.BlockHeight]crypto.Hash{},
+	}).(map[types.BlockHeight]crypto.Hash)
+
+	// checkpointSpecifier is the specifier at the start of every checkpoint.
+	checkpointSpecifier = types.NewSpecifier("Checkpoint")
+
+	// errCheckpointGenesis is returned when trying to export a checkpoint of a
+	// consensus set that only contains the genesis block.
+	errCheckpointGenesis = errors.New("cannot create a checkpoint of the genesis block")
+
+	// errCheckpointHashMismatch is returned if the hash of a checkpoint
+	// doesn't match the trusted hash.
+	errCheckpointHashMismatch = errors.New("checkpoint does not match the trusted hash")
+
+	// errConsensusDatabaseExists is returned when importing a checkpoint into
+	// a directory that already contains a consensus database.
+	errConsensusDatabaseExists = errors.New("consensus database already exists")
+
+	// errInvalidCheckpoint is returned if a checkpoint is malformed.
+	errInvalidCheckpoint = errors.New("invalid checkpoint")
+
+	// errUntrustedCheckpoint is returned if no trusted hash was provided for
+	// a checkpoint and the build doesn't contain one either.
+	errUntrustedCheckpoint = errors.New("no trusted hash for the checkpoint height")
+)
+
+// checkpointHeader is the first object of a checkpoint.
+type checkpointHeader struct {
+	Specifier types.Specifier
+	BlockID   types.BlockID
+	Height    types.BlockHeight
+}
+
+// checkpointHeight returns the height of the checkpoint the consensus set was
+// created from, or zero if it wasn't created from a checkpoint.
+func checkpointHeight(tx *bolt.Tx) types.BlockHeight {
+	b := tx.Bucket(BucketPruning)
+	if b == nil {
+		return 0
+	}
+	heightBytes := b.Get(FieldCheckpointHeight)
+	if heightBytes == nil {
+		return 0
+	}
+	var height types.BlockHeight
+	err := encoding.Unmarshal(heightBytes, &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// isCheckpointBucket returns true if the bucket with the provided name is
+// part of a checkpoint.
+func isCheckpointBucket(name []byte) bool {
+	for _, bucket := range [][]byte{BlockHeight, BlockPath, BlockMap, BucketOak, FileContracts, SiacoinOutputs, SiafundOutputs, SiafundPool, FoundationUnlockHashes} {
+		if bytes.Equal(name, bucket) {
+			return true
+		}
+	}
+	return bytes.HasPrefix(name, prefixDSCO) || bytes.HasPrefix(name, prefixFCEX)
+}
+
+// writeCheckpoint writes a checkpoint of the current block to w.
+func writeCheckpoint(tx *bolt.Tx, w io.Writer) (types.BlockID, types.BlockHeight, error) {
+	height := blockHeight(tx)
+	if height == 0 {
+		return types.BlockID{}, 0, errCheckpointGenesis
+	}
+	id := currentBlockID(tx)
+
+	enc := encoding.NewEncoder(w)
+	err := enc.Encode(checkpointHeader{
+		Specifier: checkpointSpecifier,
+		BlockID:   id,
+		Height:    height,
+	})
+	if err != nil {
+		return types.BlockID{}, 0, err
+	}
+
+	// writeBucket writes the bucket with the provided name. 'forEach' is
+	// called to write the entries of the bucket.
+	writeBucket := func(name []byte, forEach func(put func(k, v []byte) error) error) error {
+		enc.WritePrefixedBytes(name)
+		err := forEach(func(k, v []byte) error {
+			enc.WritePrefixedBytes(k)
+			return enc.WritePrefixedBytes(v)
+		})
+		if err != nil {
+			return err
+		}
+		return enc.WritePrefixedBytes(nil)
+	}
+	copyBucket := func(name []byte) error {
+		return writeBucket(name, tx.Bucket(name).ForEach)
+	}
+
+	for _, name := range [][]byte{BlockHeight, BlockPath} {
+		if err := copyBucket(name); err != nil {
+			return types.BlockID{}, 0, err
+		}
+	}
+
+	// Only the blocks of the current path are written. All blocks except for
+	// the genesis block and the current block are pruned. The consensus
+	// checksums depend on the build and are dropped.
+	err = writeBucket(BlockMap, func(put func(k, v []byte) error) error {
+		for h := types.BlockHeight(0); h <= height; h++ {
+			pathID, err := getPath(tx, h)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, pathID)
+			if err != nil {
+				return err
+			}
+			if h != 0 && h != height {
+				stripProcessedBlock(pb)
+			}
+			pb.ConsensusChecksum = crypto.Hash{}
+			if err := put(pathID[:], encoding.Marshal(*pb)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.BlockID{}, 0, err
+	}
+
+	// Only the oak fields of the blocks of the current path are written.
+	err = writeBucket(BucketOak, func(put func(k, v []byte) error) error {
+		oak := tx.Bucket(BucketOak)
+		if err := put(FieldOakInit, oak.Get(FieldOakInit)); err != nil {
+			return err
+		}
+		for h := types.BlockHeight(0); h <= height; h++ {
+			pathID, err := getPath(tx, h)
+			if err != nil {
+				return err
+			}
+			totals := oak.Get(pathID[:])
+			if totals == nil {
+				return errNilItem
+			}
+			if err := put(pathID[:], totals); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return types.BlockID{}, 0, err
+	}
+
+	for _, name := range [][]byte{FileContracts, SiacoinOutputs, SiafundOutputs, SiafundPool, FoundationUnlockHashes} {
+		if err := copyBucket(name); err != nil {
+			return types.BlockID{}, 0, err
+		}
+	}
+
+	// Write the delayed siacoin output buckets and the file contract
+	// expiration buckets. Empty expiration buckets may be left behind by
+	// reverted blocks and are skipped.
+	err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefixDSCO) && !bytes.HasPrefix(name, prefixFCEX) {
+			return nil
+		}
+		if bytes.HasPrefix(name, prefixFCEX) {
+			if k, _ := b.Cursor().First(); k == nil {
+				return nil
+			}
+		}
+		return copyBucket(name)
+	})
+	if err != nil {
+		return types.BlockID{}, 0, err
+	}
+
+	// Terminate the checkpoint.
+	if err := enc.WritePrefixedBytes(nil); err != nil {
+		return types.BlockID{}, 0, err
+	}
+	return id, height, nil
+}
+
+// ExportCheckpoint writes a checkpoint of the current block to w. The id and
+// height of the block and the hash of the checkpoint are returned.
+func (cs *ConsensusSet) ExportCheckpoint(w io.Writer) (id types.BlockID, height types.BlockHeight, hash crypto.Hash, err error) {
+	err = cs.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cs.tg.Done()
+
+	h := crypto.NewHash()
+	bw := bufio.NewWriter(io.MultiWriter(w, h))
+	// A bolt read transaction sees a consistent snapshot of the database, so
+	// the consensus set doesn't need to be locked while the checkpoint is
+	// written.
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		id, height, err = writeCheckpoint(tx, bw)
+		return err
+	})
+	if err != nil {
+		return types.BlockID{}, 0, crypto.Hash{}, err
+	}
+	if err = bw.Flush(); err != nil {
+		return types.BlockID{}, 0, crypto.Hash{}, err
+	}
+	h.Sum(hash[:0])
+	return id, height, hash, nil
+}
+
+// readCheckpointBuckets reads the buckets of a checkpoint from r into db.
+func readCheckpointBuckets(db *persist.BoltDatabase, r io.Reader) error {
+	for {
+		name, err := encoding.ReadPrefixedBytes(r, maxCheckpointEntrySize)
+		if err != nil {
+			return err
+		}
+		if len(name) == 0 {
+			return nil
+		}
+		if !isCheckpointBucket(name) {
+			return errors.AddContext(errInvalidCheckpoint, "unexpected bucket "+string(name))
+		}
+
+		tx, err := db.Begin(true)
+		if err != nil {
+			return err
+		}
+		b, err := tx.CreateBucket(name)
+		if err != nil {
+			return errors.Compose(err, tx.Rollback())
+		}
+		for n := 1; ; n++ {
+			k, err := encoding.ReadPrefixedBytes(r, maxCheckpointEntrySize)
+			if err != nil {
+				return errors.Compose(err, tx.Rollback())
+			}
+			if len(k) == 0 {
+				break
+			}
+			v, err := encoding.ReadPrefixedBytes(r, maxCheckpointEntrySize)
+			if err != nil {
+				return errors.Compose(err, tx.Rollback())
+			}
+			if err := b.Put(k, v); err != nil {
+				return errors.Compose(err, tx.Rollback())
+			}
+
+			// Commit large buckets in batches.
+			if n%checkpointBatchSize == 0 {
+				if err := tx.Commit(); err != nil {
+					return err
+				}
+				tx, err = db.Begin(true)
+				if err != nil {
+					return err
+				}
+				b = tx.Bucket(name)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+}
+
+// finalizeCheckpoint checks that the imported checkpoint matches its header
+// and creates the remaining consensus database fields.
+func finalizeCheckpoint(tx *bolt.Tx, header checkpointHeader) error {
+	for _, name := range [][]byte{BlockHeight, BlockPath, BlockMap, BucketOak, FileContracts, SiacoinOutputs, SiafundOutputs, SiafundPool, FoundationUnlockHashes} {
+		if tx.Bucket(name) == nil {
+			return errors.AddContext(errInvalidCheckpoint, "missing bucket "+string(name))
+		}
+	}
+	if blockHeight(tx) != header.Height || currentBlockID(tx) != header.BlockID {
+		return errors.AddContext(errInvalidCheckpoint, "current block does not match the checkpoint")
+	}
+	pb, err := getBlockMap(tx, header.BlockID)
+	if err != nil || pb.Height != header.Height || pb.Block.ID() != header.BlockID {
+		return errors.AddContext(errInvalidCheckpoint, "checkpoint block is missing")
+	}
+	if build.DEBUG {
+		pb.ConsensusChecksum = consensusChecksum(tx)
+		addBlockMap(tx, pb)
+	}
+
+	// The changelog starts with the checkpoint block.
+	cl, err := tx.CreateBucket(ChangeLog)
+	if err != nil {
+		return err
+	}
+	ce := changeEntry{AppliedBlocks: []types.BlockID{header.BlockID}}
+	ceid := ce.ID()
+	err = cl.Put(ceid[:], encoding.Marshal(changeNode{Entry: ce}))
+	if err != nil {
+		return err
+	}
+	err = cl.Put(ChangeLogTailID, ceid[:])
+	if err != nil {
+		return err
+	}
+
+	consistency, err := tx.CreateBucket(Consistency)
+	if err != nil {
+		return err
+	}
+	err = consistency.Put(Consistency, encoding.Marshal(false))
+	if err != nil {
+		return err
+	}
+
+	// The blocks below the checkpoint have been pruned.
+	err = setPrunedHeight(tx, header.Height-1)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(BucketPruning).Put(FieldCheckpointHeight, encoding.Marshal(header.Height))
+}
+
+// ImportCheckpoint creates a new consensus database in persistDir from the
+// checkpoint read from r. The hash of the checkpoint has to match trustedHash.
+// If trustedHash is empty, the checkpoint has to match one of the
+// TrustedCheckpoints of the build. The id and height of the checkpoint block
+// are returned.
+func ImportCheckpoint(persistDir string, r io.Reader, trustedHash crypto.Hash) (_ types.BlockID, _ types.BlockHeight, err error) {
+	filename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(filename); err == nil {
+		return types.BlockID{}, 0, errConsensusDatabaseExists
+	} else if !os.IsNotExist(err) {
+		return types.BlockID{}, 0, err
+	}
+
+	// Hash everything that is read from the checkpoint.
+	h := crypto.NewHash()
+	cr := io.TeeReader(bufio.NewReader(r), h)
+
+	var header checkpointHeader
+	err = encoding.NewDecoder(cr, encoding.DefaultAllocLimit).Decode(&header)
+	if err != nil {
+		return types.BlockID{}, 0, errors.Compose(errInvalidCheckpoint, err)
+	}
+	if header.Specifier != checkpointSpecifier || header.Height == 0 {
+		return types.BlockID{}, 0, errInvalidCheckpoint
+	}
+	if trustedHash == (crypto.Hash{}) {
+		var exists bool
+		trustedHash, exists = TrustedCheckpoints[header.Height]
+		if !exists {
+			return types.BlockID{}, 0, errUntrustedCheckpoint
+		}
+	}
+
+	// Import the checkpoint into a temporary database, which replaces the
+	// consensus database once the checkpoint has been verified.
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		return types.BlockID{}, 0, err
+	}
+	tmpFilename := filename + "_checkpoint"
+	if err := os.RemoveAll(tmpFilename); err != nil {
+		return types.BlockID{}, 0, err
+	}
+	db, err := persist.OpenDatabase(dbMetadata, tmpFilename)
+	if err != nil {
+		return types.BlockID{}, 0, errors.AddContext(err, "unable to create consensus database")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, db.Close(), os.Remove(tmpFilename))
+		}
+	}()
+
+	if err = readCheckpointBuckets(db, cr); err != nil {
+		return types.BlockID{}, 0, errors.AddContext(err, "unable to read checkpoint")
+	}
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	if hash != trustedHash {
+		return types.BlockID{}, 0, errCheckpointHashMismatch
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return finalizeCheckpoint(tx, header)
+	})
+	if err != nil {
+		return types.BlockID{}, 0, err
+	}
+	if err = db.Close(); err != nil {
+		return types.BlockID{}, 0, errors.Compose(err, os.Remove(tmpFilename))
+	}
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		return types.BlockID{}, 0, err
+	}
+	return header.BlockID, header.Height, nil
+}
+
+// computeStateChange returns a consensus change that applies the full current
+// state of the consensus set. It is sent instead of the history of the
+// blockchain to subscribers of a consensus set that was created from a
+// checkpoint.
+func (cs *ConsensusSet) computeStateChange(tx *bolt.Tx) (modules.ConsensusChange, error) {
+	var cc modules.ConsensusChange
+	copy(cc.ID[:], tx.Bucket(ChangeLog).Get(ChangeLogTailID))
+
+	var diffs modules.ConsensusChangeDiffs
+	err := tx.Bucket(SiacoinOutputs).ForEach(func(k, v []byte) error {
+		scod := modules.SiacoinOutputDiff{Direction: modules.DiffApply}
+		copy(scod.ID[:], k)
+		diffs.SiacoinOutputDiffs = append(diffs.SiacoinOutputDiffs, scod)
+		return encoding.Unmarshal(v, &diffs.SiacoinOutputDiffs[len(diffs.SiacoinOutputDiffs)-1].SiacoinOutput)
+	})
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	err = tx.Bucket(FileContracts).ForEach(func(k, v []byte) error {
+		fcd := modules.FileContractDiff{Direction: modules.DiffApply}
+		copy(fcd.ID[:], k)
+		diffs.FileContractDiffs = append(diffs.FileContractDiffs, fcd)
+		return encoding.Unmarshal(v, &diffs.FileContractDiffs[len(diffs.FileContractDiffs)-1].FileContract)
+	})
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	err = tx.Bucket(SiafundOutputs).ForEach(func(k, v []byte) error {
+		sfod := modules.SiafundOutputDiff{Direction: modules.DiffApply}
+		copy(sfod.ID[:], k)
+		diffs.SiafundOutputDiffs = append(diffs.SiafundOutputDiffs, sfod)
+		return encoding.Unmarshal(v, &diffs.SiafundOutputDiffs[len(diffs.SiafundOutputDiffs)-1].SiafundOutput)
+	})
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefixDSCO) {
+			return nil
+		}
+		var maturityHeight types.BlockHeight
+		if err := encoding.Unmarshal(name[len(prefixDSCO):], &maturityHeight); err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			dscod := modules.DelayedSiacoinOutputDiff{
+				Direction:      modules.DiffApply,
+				MaturityHeight: maturityHeight,
+			}
+			copy(dscod.ID[:], k)
+			diffs.DelayedSiacoinOutputDiffs = append(diffs.DelayedSiacoinOutputDiffs, dscod)
+			return encoding.Unmarshal(v, &diffs.DelayedSiacoinOutputDiffs[len(diffs.DelayedSiacoinOutputDiffs)-1].SiacoinOutput)
+		})
+	})
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+	diffs.SiafundPoolDiffs = []modules.SiafundPoolDiff{{
+		Direction: modules.DiffApply,
+		Previous:  types.ZeroCurrency,
+		Adjusted:  getSiafundPool(tx),
+	}}
+
+	pb := currentProcessedBlock(tx)
+	cc.AppliedBlocks = []types.Block{pb.Block}
+	cc.AppliedDiffs = []modules.ConsensusChangeDiffs{diffs}
+	cc.AppendDiffs(diffs)
+	cc.ChildTarget = pb.ChildTarget
+	cc.MinimumValidChildTimestamp = cs.blockRuleHelper.minimumValidChildTimestamp(tx.Bucket(BlockMap), pb)
+	cc.BlockHeight = pb.Height
+	cc.Synced = cs.synced
+	cc.TryTransactionSet = cs.tryTransactionSet
+	return cc, nil
+}
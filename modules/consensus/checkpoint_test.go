@@ -0,0 +1,167 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckpoint exports a checkpoint from one consensus set, bootstraps a
+// second consensus set from it and checks that the second consensus set syncs
+// forward from the checkpoint.
+func TestCheckpoint(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := createConsensusSetTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		if _, err := cst1.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Export a checkpoint twice, the checkpoints should be identical.
+	var buf, buf2 bytes.Buffer
+	id, height, hash, err := cst1.cs.ExportCheckpoint(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != cst1.cs.CurrentBlock().ID() || height != cst1.cs.Height() {
+		t.Fatal("checkpoint is not at the current block")
+	}
+	if hash != crypto.HashBytes(buf.Bytes()) {
+		t.Fatal("wrong checkpoint hash")
+	}
+	_, _, hash2, err := cst1.cs.ExportCheckpoint(&buf2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash2 != hash || !bytes.Equal(buf.Bytes(), buf2.Bytes()) {
+		t.Fatal("checkpoints of the same block should be identical")
+	}
+
+	// A checkpoint without a trusted hash or with the wrong hash is rejected.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"2")
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	_, _, err = ImportCheckpoint(csDir, bytes.NewReader(buf.Bytes()), crypto.Hash{})
+	if !errors.Contains(err, errUntrustedCheckpoint) {
+		t.Fatal("expected errUntrustedCheckpoint, got", err)
+	}
+	_, _, err = ImportCheckpoint(csDir, bytes.NewReader(buf.Bytes()), crypto.Hash{1})
+	if !errors.Contains(err, errCheckpointHashMismatch) {
+		t.Fatal("expected errCheckpointHashMismatch, got", err)
+	}
+	if _, err := os.Stat(filepath.Join(csDir, DatabaseFilename)); !os.IsNotExist(err) {
+		t.Fatal("rejected checkpoint should not create a database", err)
+	}
+	corrupt := append([]byte(nil), buf.Bytes()...)
+	corrupt[len(corrupt)/2]++
+	_, _, err = ImportCheckpoint(csDir, bytes.NewReader(corrupt), hash)
+	if err == nil {
+		t.Fatal("expected corrupted checkpoint to be rejected")
+	}
+
+	// Import the checkpoint.
+	importedID, importedHeight, err := ImportCheckpoint(csDir, bytes.NewReader(buf.Bytes()), hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if importedID != id || importedHeight != height {
+		t.Fatal("imported checkpoint does not match the exported checkpoint")
+	}
+	_, _, err = ImportCheckpoint(csDir, bytes.NewReader(buf.Bytes()), hash)
+	if !errors.Contains(err, errConsensusDatabaseExists) {
+		t.Fatal("expected errConsensusDatabaseExists, got", err)
+	}
+
+	// Create a consensus set tester from the checkpoint. The wallet, tpool
+	// and miner subscribe from the beginning and receive the current state.
+	cst2, err := newConsensusSetTester(testdir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if cst2.cs.CurrentBlock().ID() != id || cst2.cs.Height() != height {
+		t.Fatal("consensus set did not start at the checkpoint")
+	}
+	if _, exists := cst2.cs.BlockAtHeight(height - 1); exists {
+		t.Fatal("blocks below the checkpoint should not be available")
+	}
+	ms := newMockSubscriber()
+	err = cst2.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, cst2.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst2.cs.Unsubscribe(&ms)
+	if len(ms.updates) != 1 {
+		t.Fatal("expected a single state update, got", len(ms.updates))
+	}
+	cc := ms.updates[0]
+	if cc.BlockHeight != height || len(cc.SiacoinOutputDiffs) == 0 || len(cc.SiafundOutputDiffs) == 0 || len(cc.DelayedSiacoinOutputDiffs) == 0 {
+		t.Fatal("state update does not contain the consensus state")
+	}
+	var siacoins types.Currency
+	for _, scod := range cc.SiacoinOutputDiffs {
+		sco, err := cst1.cs.dbGetSiacoinOutput(scod.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siacoins = siacoins.Add(sco.Value)
+	}
+	if len(cc.SiafundPoolDiffs) != 1 || !cc.SiafundPoolDiffs[0].Adjusted.Equals(cst1.cs.dbGetSiafundPool()) {
+		t.Fatal("state update has the wrong siafund pool")
+	}
+	if siacoins.IsZero() {
+		t.Fatal("state update has no siacoins")
+	}
+
+	// The consensus set created from the checkpoint can mine on top of it.
+	if _, err := cst2.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if cst2.cs.Height() != height+1 {
+		t.Fatal("expected the checkpointed consensus set to mine a block")
+	}
+
+	// cst1 mines a longer chain and cst2 syncs to it.
+	for cst1.cs.Height() <= cst2.cs.Height() {
+		if _, err := cst1.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cst2.gateway.Connect(cst1.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if cst1.cs.dbCurrentBlockID() != cst2.cs.dbCurrentBlockID() {
+			return fmt.Errorf("consensus sets did not sync: %v != %v", cst1.cs.Height(), cst2.cs.Height())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
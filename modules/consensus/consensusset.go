@@ -81,6 +81,11 @@ type ConsensusSet struct {
 	// whether the consensus set is synced with the network.
 	synced bool
 
+	// pruneDepth is the number of recent blocks of the current path that keep
+	// their transactions and diffs. Older blocks are pruned. Pruning is
+	// disabled if pruneDepth is zero.
+	pruneDepth types.BlockHeight
+
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       marshaler
 	blockRuleHelper blockRuleHelper
@@ -232,6 +237,9 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 // BlockAtHeight returns the block at a given height.
 func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Block, exists bool) {
 	_ = cs.db.View(func(tx *bolt.Tx) error {
+		if isPruned(tx, height) {
+			return errPrunedBlock
+		}
 		id, err := getPath(tx, height)
 		if err != nil {
 			return err
@@ -254,6 +262,9 @@ func (cs *ConsensusSet) BlockByID(id types.BlockID) (block types.Block, height t
 		if err != nil {
 			return err
 		}
+		if isPruned(tx, pb.Height) {
+			return errPrunedBlock
+		}
 		block = pb.Block
 		height = pb.Height
 		exists = true
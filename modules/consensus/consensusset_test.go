@@ -94,8 +94,12 @@ func (cst *consensusSetTester) mineSiacoins() {
 // blankConsensusSetTester creates a consensusSetTester that has only the
 // genesis block.
 func blankConsensusSetTester(name string, deps modules.Dependencies) (*consensusSetTester, error) {
-	testdir := build.TempDir(modules.ConsensusDir, name)
+	return newConsensusSetTester(build.TempDir(modules.ConsensusDir, name), deps)
+}
 
+// newConsensusSetTester creates a consensusSetTester in testdir. If testdir
+// already contains a consensus database, it is used by the tester.
+func newConsensusSetTester(testdir string, deps modules.Dependencies) (*consensusSetTester, error) {
 	// Create modules.
 	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
 	if err != nil {
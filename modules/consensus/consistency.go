@@ -295,6 +295,10 @@ func (cs *ConsensusSet) checkRevertApply(tx *bolt.Tx) {
 	if current.Height != parent.Height+1 {
 		manageErr(tx, errors.New("parent structure of a block is incorrect"))
 	}
+	// Pruned blocks can't be reverted to.
+	if isPruned(tx, parent.Height) {
+		return
+	}
 	_, _, err = cs.forkBlockchain(tx, parent)
 	if err != nil {
 		manageErr(tx, err)
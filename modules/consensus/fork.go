@@ -19,11 +19,14 @@ var (
 // the former.
 func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
 	path := []*processedBlock{pb}
+	// The ids of pruned blocks can't be computed from the block, so the id
+	// that the block was looked up by is compared instead.
+	id := pb.Block.ID()
 	for {
 		// Error is not checked in production code - an error can only indicate
 		// that pb.Height > blockHeight(tx).
 		currentPathID, err := getPath(tx, pb.Height)
-		if currentPathID == pb.Block.ID() {
+		if currentPathID == id {
 			break
 		}
 		// Sanity check - an error should only indicate that pb.Height >
@@ -34,7 +37,8 @@ func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
 
 		// Prepend the next block to the list of blocks leading from the
 		// current path to the input block.
-		pb, err = getBlockMap(tx, pb.Block.ParentID)
+		id = pb.Block.ParentID
+		pb, err = getBlockMap(tx, id)
 		if build.DEBUG && err != nil {
 			panic(err)
 		}
@@ -47,9 +51,10 @@ func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
 // 'pb' is the current block. Blocks are returned in the order that they were
 // reverted.  'pb' is not reverted.
 func (cs *ConsensusSet) revertToBlock(tx *bolt.Tx, pb *processedBlock) (revertedBlocks []*processedBlock) {
-	// Sanity check - make sure that pb is in the current path.
+	// Sanity check - make sure that pb is in the current path. The id of a
+	// pruned block can't be computed, so only its height is checked.
 	currentPathID, err := getPath(tx, pb.Height)
-	if err != nil || currentPathID != pb.Block.ID() {
+	if err != nil || (currentPathID != pb.Block.ID() && !isPruned(tx, pb.Height)) {
 		if build.DEBUG {
 			panic(errExternalRevert) // needs to be panic for TestRevertToNode
 		} else {
@@ -58,7 +63,7 @@ func (cs *ConsensusSet) revertToBlock(tx *bolt.Tx, pb *processedBlock) (reverted
 	}
 
 	// Rewind blocks until 'pb' is the current block.
-	for currentBlockID(tx) != pb.Block.ID() {
+	for blockHeight(tx) > pb.Height {
 		block := currentProcessedBlock(tx)
 		commitDiffSet(tx, block, modules.DiffRevert)
 		revertedBlocks = append(revertedBlocks, block)
@@ -110,7 +115,12 @@ func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (applie
 // found to be invalid. forkBlockchain is atomic; the ConsensusSet is only
 // updated if the function returns nil.
 func (cs *ConsensusSet) forkBlockchain(tx *bolt.Tx, newBlock *processedBlock) (revertedBlocks, appliedBlocks []*processedBlock, err error) {
+	// The common parent itself isn't reverted, only the blocks after it need
+	// to keep their diffs.
 	commonParent := backtrackToCurrentPath(tx, newBlock)[0]
+	if isPruned(tx, commonParent.Height+1) {
+		return nil, nil, errPrunedFork
+	}
 	revertedBlocks = cs.revertToBlock(tx, commonParent)
 	appliedBlocks, err = cs.applyUntilBlock(tx, newBlock)
 	if err != nil {
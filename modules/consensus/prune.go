@@ -0,0 +1,287 @@
+package consensus
+
+// prune.go implements pruning of the consensus database. A pruned consensus
+// set drops the transactions, miner payouts and diffs of the blocks that are
+// more than the prune depth below the current height. The headers of pruned
+// blocks are kept, because they are still needed by the difficulty adjustment
+// and the timestamp rules. Pruned blocks can't be reverted, served to peers or
+// sent to subscribers.
+
+import (
+	"fmt"
+	"os"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// pruneBatchSize is the number of blocks that are pruned within a single
+	// database transaction.
+	pruneBatchSize = 1000
+)
+
+var (
+	// BucketPruning is the database bucket that contains the pruning state of
+	// the consensus set.
+	BucketPruning = []byte("Pruning")
+
+	// FieldPrunedHeight is a field in BucketPruning that holds the height of
+	// the most recent pruned block. The genesis block is never pruned, a
+	// pruned height of zero means that no block has been pruned.
+	FieldPrunedHeight = []byte("PrunedHeight")
+)
+
+var (
+	// MinPruneDepth is the minimum number of recent blocks that a pruned
+	// consensus set keeps in full. Reorgs deeper than the prune depth can't be
+	// handled by a pruned consensus set.
+	MinPruneDepth = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Testnet:  types.BlockHeight(144),
+		Dev:      types.BlockHeight(50),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// errPruneDepthTooLow is returned if the requested prune depth is lower
+	// than MinPruneDepth.
+	errPruneDepthTooLow = fmt.Errorf("prune depth must be at least %v blocks", MinPruneDepth)
+
+	// errPrunedBlock is returned if a block is requested that has been pruned.
+	errPrunedBlock = errors.New("block has been pruned from the consensus set")
+
+	// errPrunedFork is returned if a fork would require reverting blocks that
+	// have been pruned.
+	errPrunedFork = errors.New("fork requires reverting blocks that have been pruned from the consensus set")
+)
+
+// prunedHeight returns the height of the most recent pruned block.
+func prunedHeight(tx *bolt.Tx) types.BlockHeight {
+	b := tx.Bucket(BucketPruning)
+	if b == nil {
+		return 0
+	}
+	heightBytes := b.Get(FieldPrunedHeight)
+	if heightBytes == nil {
+		return 0
+	}
+	var height types.BlockHeight
+	err := encoding.Unmarshal(heightBytes, &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// setPrunedHeight sets the height of the most recent pruned block.
+func setPrunedHeight(tx *bolt.Tx, height types.BlockHeight) error {
+	b, err := tx.CreateBucketIfNotExists(BucketPruning)
+	if err != nil {
+		return err
+	}
+	return b.Put(FieldPrunedHeight, encoding.Marshal(height))
+}
+
+// isPruned returns true if blocks at the provided height have been pruned.
+func isPruned(tx *bolt.Tx, height types.BlockHeight) bool {
+	return height != 0 && height <= prunedHeight(tx)
+}
+
+// stripProcessedBlock drops the transactions, miner payouts and diffs of a
+// processed block. The header fields of the block are kept.
+func stripProcessedBlock(pb *processedBlock) {
+	pb.Block.MinerPayouts = nil
+	pb.Block.Transactions = nil
+	pb.DiffsGenerated = false
+	pb.SiacoinOutputDiffs = nil
+	pb.FileContractDiffs = nil
+	pb.SiafundOutputDiffs = nil
+	pb.DelayedSiacoinOutputDiffs = nil
+	pb.SiafundPoolDiffs = nil
+}
+
+// pruneBlock drops the transactions, miner payouts and diffs of the block with
+// the provided id.
+func pruneBlock(tx *bolt.Tx, id types.BlockID) error {
+	pb, err := getBlockMap(tx, id)
+	if err != nil {
+		return err
+	}
+	stripProcessedBlock(pb)
+
+	// The id of a pruned block can't be computed from the block anymore, so
+	// addBlockMap can't be used.
+	return tx.Bucket(BlockMap).Put(id[:], encoding.Marshal(*pb))
+}
+
+// pruneBlocks prunes up to 'limit' blocks of the current path that are more
+// than 'depth' blocks below the current height. True is returned if there are
+// no blocks left that need to be pruned.
+func pruneBlocks(tx *bolt.Tx, depth types.BlockHeight, limit int) (bool, error) {
+	height := blockHeight(tx)
+	if depth == 0 || height <= depth {
+		return true, nil
+	}
+	target := height - depth
+	pruned := prunedHeight(tx)
+	if pruned >= target {
+		return true, nil
+	}
+	for i := 0; i < limit && pruned < target; i++ {
+		id, err := getPath(tx, pruned+1)
+		if err != nil {
+			return false, err
+		}
+		err = pruneBlock(tx, id)
+		if err != nil {
+			return false, err
+		}
+		pruned++
+	}
+	err := setPrunedHeight(tx, pruned)
+	if err != nil {
+		return false, err
+	}
+	return pruned == target, nil
+}
+
+// threadedPrune prunes the consensus database in batches until all blocks
+// below the prune depth have been pruned.
+func (cs *ConsensusSet) threadedPrune() {
+	err := cs.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cs.tg.Done()
+
+	for {
+		var done bool
+		cs.mu.Lock()
+		err := cs.db.Update(func(tx *bolt.Tx) error {
+			var err error
+			done, err = pruneBlocks(tx, cs.pruneDepth, pruneBatchSize)
+			return err
+		})
+		cs.mu.Unlock()
+		if err != nil {
+			cs.log.Println("ERROR: unable to prune the consensus database:", err)
+			return
+		}
+		if done {
+			return
+		}
+		select {
+		case <-cs.tg.StopChan():
+			return
+		default:
+		}
+	}
+}
+
+// SetPruneDepth enables pruning of the consensus database. Only the most
+// recent 'depth' blocks keep their transactions and diffs, older blocks are
+// pruned in the background and whenever new blocks are accepted. A depth of
+// zero disables pruning, blocks that have already been pruned stay pruned.
+func (cs *ConsensusSet) SetPruneDepth(depth types.BlockHeight) error {
+	if depth != 0 && depth < MinPruneDepth {
+		return errPruneDepthTooLow
+	}
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	cs.mu.Lock()
+	cs.pruneDepth = depth
+	cs.mu.Unlock()
+	if depth > 0 {
+		go cs.threadedPrune()
+	}
+	return nil
+}
+
+// PruneDatabase prunes the consensus database at the provided path so that
+// only the most recent 'depth' blocks keep their transactions and diffs. In
+// addition to the blocks of the current path, blocks of old forks below the
+// prune depth are pruned as well. The database must not be in use by a
+// consensus set. The height of the most recent pruned block is returned.
+func PruneDatabase(filename string, depth types.BlockHeight) (types.BlockHeight, error) {
+	if depth < MinPruneDepth {
+		return 0, errPruneDepthTooLow
+	}
+	if _, err := os.Stat(filename); err != nil {
+		return 0, err
+	}
+	db, err := persist.OpenDatabase(dbMetadata, filename)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to open consensus database")
+	}
+	defer db.Close()
+
+	// Prune the blocks of the current path.
+	var pruned types.BlockHeight
+	for done := false; !done; {
+		err = db.Update(func(tx *bolt.Tx) error {
+			if tx.Bucket(BlockMap) == nil || tx.Bucket(BlockPath) == nil {
+				return errors.New("database is not a consensus database")
+			}
+			var err error
+			done, err = pruneBlocks(tx, depth, pruneBatchSize)
+			pruned = prunedHeight(tx)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	// Find the blocks that are not part of the current path but are below the
+	// pruned height, and prune them as well.
+	var forkIDs []types.BlockID
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(BlockMap).ForEach(func(k, v []byte) error {
+			var pb processedBlock
+			if err := encoding.Unmarshal(v, &pb); err != nil {
+				return err
+			}
+			if pb.Height == 0 || pb.Height > pruned || (len(pb.Block.Transactions) == 0 && len(pb.Block.MinerPayouts) == 0 && !pb.DiffsGenerated) {
+				return nil
+			}
+			var id types.BlockID
+			copy(id[:], k)
+			forkIDs = append(forkIDs, id)
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	for len(forkIDs) > 0 {
+		n := pruneBatchSize
+		if n > len(forkIDs) {
+			n = len(forkIDs)
+		}
+		err = db.Update(func(tx *bolt.Tx) error {
+			for _, id := range forkIDs[:n] {
+				if err := pruneBlock(tx, id); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		forkIDs = forkIDs[n:]
+	}
+	return pruned, nil
+}
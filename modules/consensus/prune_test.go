@@ -0,0 +1,347 @@
+package consensus
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/types"
+)
+
+// dbPrunedHeight is a convenience function allowing prunedHeight to be called
+// without a bolt.Tx.
+func (cs *ConsensusSet) dbPrunedHeight() (height types.BlockHeight) {
+	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+		height = prunedHeight(tx)
+		return nil
+	})
+	if dbErr != nil {
+		panic(dbErr)
+	}
+	return height
+}
+
+// TestPruneConsensusSet checks that a consensus set with pruning enabled drops
+// old blocks, keeps recent ones and rejects subscribers that require pruned
+// history.
+func TestPruneConsensusSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A prune depth below the minimum is rejected.
+	if err := cst.cs.SetPruneDepth(MinPruneDepth - 1); !errors.Contains(err, errPruneDepthTooLow) {
+		t.Fatal("expected errPruneDepthTooLow, got", err)
+	}
+
+	// Subscribe before pruning to collect the ids of the consensus changes.
+	ms := newMockSubscriber()
+	err = cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.Unsubscribe(&ms)
+
+	// Mine enough blocks for some of them to be pruned.
+	for i := types.BlockHeight(0); i < MinPruneDepth+5; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cst.cs.ConsensusSetSubscribe(&ms, ms.updates[len(ms.updates)-1].ID, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.Unsubscribe(&ms)
+
+	// Enable pruning and wait for the old blocks to be pruned.
+	if err := cst.cs.SetPruneDepth(MinPruneDepth); err != nil {
+		t.Fatal(err)
+	}
+	height := cst.cs.Height()
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if pruned := cst.cs.dbPrunedHeight(); pruned != height-MinPruneDepth {
+			return fmt.Errorf("expected pruned height %v, got %v", height-MinPruneDepth, pruned)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The genesis block and the recent blocks are still available, the pruned
+	// blocks are not.
+	if _, exists := cst.cs.BlockAtHeight(0); !exists {
+		t.Fatal("genesis block should not be pruned")
+	}
+	if _, exists := cst.cs.BlockAtHeight(1); exists {
+		t.Fatal("block at height 1 should be pruned")
+	}
+	if _, exists := cst.cs.BlockAtHeight(height - MinPruneDepth + 1); !exists {
+		t.Fatal("recent block should not be pruned")
+	}
+	if _, _, exists := cst.cs.BlockByID(cst.cs.CurrentBlock().ID()); !exists {
+		t.Fatal("current block should not be pruned")
+	}
+
+	// Subscribing from the beginning or from an old change fails, subscribing
+	// from a recent change succeeds.
+	ms2 := newMockSubscriber()
+	err = cst.cs.ConsensusSetSubscribe(&ms2, modules.ConsensusChangeBeginning, cst.cs.tg.StopChan())
+	if !errors.Contains(err, modules.ErrPrunedConsensusChange) {
+		t.Fatal("expected ErrPrunedConsensusChange, got", err)
+	}
+	err = cst.cs.ConsensusSetSubscribe(&ms2, ms.updates[1].ID, cst.cs.tg.StopChan())
+	if !errors.Contains(err, modules.ErrPrunedConsensusChange) {
+		t.Fatal("expected ErrPrunedConsensusChange, got", err)
+	}
+	err = cst.cs.ConsensusSetSubscribe(&ms2, ms.updates[len(ms.updates)-5].ID, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms2.updates) != 4 {
+		t.Fatal("expected 4 updates, got", len(ms2.updates))
+	}
+	cst.cs.Unsubscribe(&ms2)
+
+	// Pruning continues as new blocks are mined.
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if pruned := cst.cs.dbPrunedHeight(); pruned != height+1-MinPruneDepth {
+		t.Fatalf("expected pruned height %v, got %v", height+1-MinPruneDepth, pruned)
+	}
+}
+
+// TestPrunedFork checks that a pruned consensus set rejects forks that would
+// revert pruned blocks.
+func TestPrunedFork(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := blankConsensusSetTester(t.Name()+"1", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cst2, err := blankConsensusSetTester(t.Name()+"2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Give both consensus sets a common block.
+	b, err := cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst2.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Extend both chains independently, the chain of cst2 is longer.
+	if err := cst1.cs.SetPruneDepth(MinPruneDepth); err != nil {
+		t.Fatal(err)
+	}
+	for i := types.BlockHeight(0); i < MinPruneDepth+5; i++ {
+		if _, err := cst1.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := types.BlockHeight(0); i < MinPruneDepth+10; i++ {
+		if _, err := cst2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cst1.cs.dbPrunedHeight() == 0 {
+		t.Fatal("expected blocks to be pruned")
+	}
+
+	// Switching to the chain of cst2 would require reverting pruned blocks.
+	var blocks []types.Block
+	for height := types.BlockHeight(2); height <= cst2.cs.Height(); height++ {
+		b, exists := cst2.cs.BlockAtHeight(height)
+		if !exists {
+			t.Fatal("missing block at height", height)
+		}
+		blocks = append(blocks, b)
+	}
+	currentID := cst1.cs.CurrentBlock().ID()
+	_, err = cst1.cs.managedAcceptBlocks(blocks)
+	if !errors.Contains(err, errPrunedFork) {
+		t.Fatal("expected errPrunedFork, got", err)
+	}
+	if cst1.cs.CurrentBlock().ID() != currentID {
+		t.Fatal("pruned consensus set switched to a different chain")
+	}
+}
+
+// TestPrunedForkAtPruneDepth checks that a pruned consensus set accepts a fork
+// which reverts exactly the blocks within the prune depth.
+func TestPrunedForkAtPruneDepth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := blankConsensusSetTester(t.Name()+"1", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cst2, err := blankConsensusSetTester(t.Name()+"2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Give both consensus sets two common blocks.
+	for i := 0; i < 2; i++ {
+		b, err := cst1.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cst2.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commonHeight := cst1.cs.Height()
+
+	// Extend the chain of cst1 by the prune depth, which prunes every block
+	// up to the common parent.
+	if err := cst1.cs.SetPruneDepth(MinPruneDepth); err != nil {
+		t.Fatal(err)
+	}
+	for i := types.BlockHeight(0); i < MinPruneDepth; i++ {
+		if _, err := cst1.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pruned := cst1.cs.dbPrunedHeight(); pruned != commonHeight {
+		t.Fatalf("expected pruned height %v, got %v", commonHeight, pruned)
+	}
+
+	// Switching to the longer chain of cst2 only reverts blocks that weren't
+	// pruned.
+	for i := types.BlockHeight(0); i < MinPruneDepth+1; i++ {
+		if _, err := cst2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var blocks []types.Block
+	for height := commonHeight + 1; height <= cst2.cs.Height(); height++ {
+		b, exists := cst2.cs.BlockAtHeight(height)
+		if !exists {
+			t.Fatal("missing block at height", height)
+		}
+		blocks = append(blocks, b)
+	}
+	if _, err := cst1.cs.managedAcceptBlocks(blocks); err != nil {
+		t.Fatal("fork at the prune depth should be accepted:", err)
+	}
+	if cst1.cs.CurrentBlock().ID() != cst2.cs.CurrentBlock().ID() {
+		t.Fatal("consensus set didn't switch to the longer chain")
+	}
+}
+
+// TestPruneDatabase checks that an existing consensus database can be pruned
+// offline and loaded afterwards.
+func TestPruneDatabase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := types.BlockHeight(0); i < MinPruneDepth+5; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	height := cst.cs.Height()
+	currentID := cst.cs.CurrentBlock().ID()
+	if err := cst.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Prune the database.
+	filename := filepath.Join(cst.persistDir, modules.ConsensusDir, DatabaseFilename)
+	if _, err := PruneDatabase(filename, MinPruneDepth-1); !errors.Contains(err, errPruneDepthTooLow) {
+		t.Fatal("expected errPruneDepthTooLow, got", err)
+	}
+	if _, err := PruneDatabase(filename+"_missing", MinPruneDepth); err == nil {
+		t.Fatal("expected an error for a missing database")
+	}
+	pruned, err := PruneDatabase(filename, MinPruneDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != height-MinPruneDepth {
+		t.Fatalf("expected pruned height %v, got %v", height-MinPruneDepth, pruned)
+	}
+
+	// Load the pruned database.
+	g, err := gateway.New("localhost:0", false, filepath.Join(cst.persistDir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cs, errChan := New(g, false, filepath.Join(cst.persistDir, modules.ConsensusDir))
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cs.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if cs.Height() != height || cs.CurrentBlock().ID() != currentID {
+		t.Fatal("pruned consensus set has a different current block")
+	}
+	if cs.dbPrunedHeight() != pruned {
+		t.Fatal("pruned height was not persisted")
+	}
+	if _, exists := cs.BlockAtHeight(pruned); exists {
+		t.Fatal("block at the pruned height should not be available")
+	}
+
+}
@@ -68,6 +68,9 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 			cs.log.Critical("getBlockMap failed in computeConsensusChange:", err)
 			return modules.ConsensusChange{}, err
 		}
+		if isPruned(tx, revertedBlock.Height) {
+			return modules.ConsensusChange{}, modules.ErrPrunedConsensusChange
+		}
 		cc.RevertedBlocks = append(cc.RevertedBlocks, revertedBlock.Block)
 		diffs := computeConsensusChangeDiffs(revertedBlock, false)
 		cc.RevertedDiffs = append(cc.RevertedDiffs, diffs)
@@ -79,6 +82,9 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 			cs.log.Critical("getBlockMap failed in computeConsensusChange:", err)
 			return modules.ConsensusChange{}, err
 		}
+		if isPruned(tx, appliedBlock.Height) {
+			return modules.ConsensusChange{}, modules.ErrPrunedConsensusChange
+		}
 		cc.AppliedBlocks = append(cc.AppliedBlocks, appliedBlock.Block)
 		diffs := computeConsensusChangeDiffs(appliedBlock, true)
 		cc.AppliedDiffs = append(cc.AppliedDiffs, diffs)
@@ -150,6 +156,35 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 		return cs.recentConsensusChangeID()
 	}
 
+	// A consensus set that was created from a checkpoint doesn't have the
+	// history of the blockchain. Subscribers that start from the beginning
+	// receive the current state instead.
+	if start == modules.ConsensusChangeBeginning {
+		var checkpointed bool
+		var cc modules.ConsensusChange
+		cs.mu.RLock()
+		err := cs.db.View(func(tx *bolt.Tx) error {
+			if checkpointHeight(tx) == 0 {
+				return nil
+			}
+			checkpointed = true
+			var err error
+			cc, err = cs.computeStateChange(tx)
+			if err != nil {
+				return err
+			}
+			subscriber.ProcessConsensusChange(cc)
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return modules.ConsensusChangeID{}, err
+		}
+		if checkpointed {
+			return cc.ID, nil
+		}
+	}
+
 	// 'exists' and 'entry' are going to be pointed to the first entry that
 	// has not yet been seen by subscriber.
 	var exists bool
@@ -163,6 +198,9 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 			// the genesis block.
 			entry = cs.genesisEntry()
 			exists = true
+			if prunedHeight(tx) > 0 {
+				return modules.ErrPrunedConsensusChange
+			}
 		} else {
 			// The subscriber has provided an existing consensus change.
 			// Because the subscriber already has this consensus change,
@@ -96,6 +96,38 @@ func isTimeoutErr(err error) bool {
 	return (err.Error() == "Read timeout" || err.Error() == "Write timeout")
 }
 
+// isInvalidBlockErr returns true if err indicates that a block or header is
+// invalid, as opposed to being known, orphaned or from the near future.
+func isInvalidBlockErr(err error) bool {
+	for _, invalidErr := range []error{errDoSBlock, errNonLinearChain, modules.ErrBlockUnsolved, ErrBadMinerPayouts, ErrEarlyTimestamp, ErrLargeBlock} {
+		if errors.Contains(err, invalidErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// managedReportInvalidBlocks reports the peer at addr to the gateway if err
+// indicates that any of the blocks received from it were invalid. Blocks that
+// fail transaction validation are marked as DoS blocks by the consensus set.
+func (cs *ConsensusSet) managedReportInvalidBlocks(addr modules.NetAddress, blocks []types.Block, err error) {
+	if err == nil {
+		return
+	}
+	invalid := isInvalidBlockErr(err)
+	cs.mu.RLock()
+	for _, b := range blocks {
+		if _, exists := cs.dosBlocks[b.ID()]; exists {
+			invalid = true
+		}
+	}
+	cs.mu.RUnlock()
+	if invalid {
+		cs.log.Debugf("WARN: peer %v sent an invalid block: %v", addr, err)
+		cs.gateway.ReportMisbehaviour(addr, modules.MisbehaviourInvalidBlock)
+	}
+}
+
 // blockHistory returns up to 32 block ids, starting with recent blocks and
 // then proving exponentially increasingly less recent blocks. The genesis
 // block is always included as the last block. This block history can be used
@@ -231,7 +263,9 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 		extended, acceptErr := cs.managedAcceptBlocks(newBlocks)
 		if extended {
 			chainExtended = true
+			cs.gateway.ReportBlockRelayed(conn.RPCAddr(), cs.managedCurrentBlock().ID())
 		}
+		cs.managedReportInvalidBlocks(conn.RPCAddr(), newBlocks, acceptErr)
 		// ErrNonExtendingBlock must be ignored until headers-first block
 		// sharing is implemented, block already in database should also be
 		// ignored.
@@ -320,6 +354,11 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 			if pb.Height == csHeight {
 				break
 			}
+			// The blocks following the common block can't be sent if they
+			// have been pruned.
+			if isPruned(tx, pb.Height+1) {
+				break
+			}
 			found = true
 			// Start from the child of the common block.
 			start = pb.Height + 1
@@ -353,6 +392,9 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 		err = cs.db.View(func(tx *bolt.Tx) error {
 			height := blockHeight(tx)
 			for i := start; i <= height && i < start+MaxCatchUpBlocks; i++ {
+				if isPruned(tx, i) {
+					return errPrunedBlock
+				}
 				id, err := getPath(tx, i)
 				if err != nil {
 					cs.log.Critical("Unable to get path: height", height, ":: request", i)
@@ -454,6 +496,9 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 			}
 		}()
 		return nil
+	} else if isInvalidBlockErr(err) {
+		cs.gateway.ReportMisbehaviour(conn.RPCAddr(), modules.MisbehaviourInvalidBlock)
+		return err
 	} else if err != nil {
 		return err
 	}
@@ -505,6 +550,9 @@ func (cs *ConsensusSet) rpcSendBlk(conn modules.PeerConn) error {
 		if err != nil {
 			return err
 		}
+		if isPruned(tx, pb.Height) {
+			return errPrunedBlock
+		}
 		b = pb.Block
 		return nil
 	})
@@ -534,8 +582,10 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 		}
 		chainExtended, err := cs.managedAcceptBlocks([]types.Block{block})
 		if chainExtended {
+			cs.gateway.ReportBlockRelayed(conn.RPCAddr(), block.ID())
 			cs.managedBroadcastBlock(block)
 		}
+		cs.managedReportInvalidBlocks(conn.RPCAddr(), []types.Block{block}, err)
 		if err != nil {
 			return err
 		}
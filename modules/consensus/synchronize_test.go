@@ -1161,6 +1161,130 @@ func TestRelayHeader(t *testing.T) {
 	}
 }
 
+// mockGatewayRecordsMisbehaviour implements modules.Gateway to mock the
+// ReportMisbehaviour method.
+type mockGatewayRecordsMisbehaviour struct {
+	modules.Gateway
+	reports chan modules.PeerMisbehaviour
+	relayed chan types.BlockID
+}
+
+// ReportMisbehaviour is a mock implementation of
+// modules.Gateway.ReportMisbehaviour that sends the reported misbehaviour down
+// a channel.
+func (g *mockGatewayRecordsMisbehaviour) ReportMisbehaviour(addr modules.NetAddress, m modules.PeerMisbehaviour) {
+	g.reports <- m
+}
+
+// ReportBlockRelayed is a mock implementation of
+// modules.Gateway.ReportBlockRelayed that sends the block ID down a channel.
+func (g *mockGatewayRecordsMisbehaviour) ReportBlockRelayed(addr modules.NetAddress, id types.BlockID) {
+	g.relayed <- id
+}
+
+// TestReportInvalidBlocks tests that peers relaying invalid headers and blocks
+// are reported to the gateway, and that peers relaying known blocks are not.
+// Peers relaying valid blocks are credited with the block instead.
+func TestReportInvalidBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	mg := &mockGatewayRecordsMisbehaviour{
+		Gateway: cst.cs.gateway,
+		reports: make(chan modules.PeerMisbehaviour, 10),
+		relayed: make(chan types.BlockID, 10),
+	}
+	cst.cs.gateway = mg
+
+	expectReport := func(want bool) {
+		t.Helper()
+		select {
+		case m := <-mg.reports:
+			if !want {
+				t.Fatal("unexpected report:", m)
+			} else if m != modules.MisbehaviourInvalidBlock {
+				t.Fatal("wrong misbehaviour:", m)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if want {
+				t.Fatal("peer wasn't reported")
+			}
+		}
+	}
+	relayHeader := func(h types.BlockHeader) {
+		t.Helper()
+		p1, p2 := net.Pipe()
+		go encoding.WriteObject(p1, h)
+		cst.cs.threadedRPCRelayHeader(mockPeerConn{p2})
+	}
+
+	// A known header isn't misbehaviour.
+	relayHeader(types.GenesisBlock.Header())
+	expectReport(false)
+
+	// A header with a timestamp that is too early is.
+	b, err := cst.miner.FindBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := b.Header()
+	h.Timestamp = 0
+	relayHeader(h)
+	expectReport(true)
+
+	// So is a block with the wrong miner payouts.
+	bfw, target, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bfw.MinerPayouts[0].Value = bfw.MinerPayouts[0].Value.Add64(1)
+	invalidBlock, _ := cst.miner.SolveBlock(bfw, target)
+	p1, p2 := net.Pipe()
+	go func() {
+		var id types.BlockID
+		encoding.ReadObject(p1, &id, crypto.HashSize)
+		encoding.WriteObject(p1, invalidBlock)
+	}()
+	err = cst.cs.managedReceiveBlock(invalidBlock.ID())(mockPeerConn{p2})
+	if !errors.Contains(err, ErrBadMinerPayouts) {
+		t.Fatal("expected ErrBadMinerPayouts, got", err)
+	}
+	expectReport(true)
+
+	// A valid block is recorded as relayed by the peer.
+	validBlock, err := cst.miner.FindBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, p2 = net.Pipe()
+	go func() {
+		var id types.BlockID
+		encoding.ReadObject(p1, &id, crypto.HashSize)
+		encoding.WriteObject(p1, validBlock)
+	}()
+	if err := cst.cs.managedReceiveBlock(validBlock.ID())(mockPeerConn{p2}); err != nil {
+		t.Fatal(err)
+	}
+	expectReport(false)
+	select {
+	case id := <-mg.relayed:
+		if id != validBlock.ID() {
+			t.Fatal("wrong block reported as relayed")
+		}
+	default:
+		t.Fatal("relayed block wasn't reported")
+	}
+}
+
 // TestIntegrationBroadcastRelayHeader checks that broadcasting RelayHeader
 // causes peers to also broadcast the header (if the block is valid).
 func TestIntegrationBroadcastRelayHeader(t *testing.T) {
@@ -0,0 +1,52 @@
+package modules
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// DownloadPriority is the priority class of a download. Downloads of a higher
+// class are fetched first and get a larger share of the workers, so that
+// background downloads can't starve interactive ones.
+type DownloadPriority string
+
+const (
+	// DownloadPriorityStream is the class of interactive streams, e.g. video
+	// playback. It has the highest priority.
+	DownloadPriorityStream DownloadPriority = "stream"
+
+	// DownloadPriorityUser is the class of downloads started by the user. It
+	// is the default.
+	DownloadPriorityUser DownloadPriority = "user"
+
+	// DownloadPriorityBatch is the class of background downloads, e.g. bulk
+	// restores and repairs. It has the lowest priority.
+	DownloadPriorityBatch DownloadPriority = "batch"
+)
+
+// DownloadPriorities are the download priority classes from highest to lowest
+// priority.
+var DownloadPriorities = []DownloadPriority{
+	DownloadPriorityStream,
+	DownloadPriorityUser,
+	DownloadPriorityBatch,
+}
+
+// ErrInvalidDownloadPriority is returned if a download priority class is
+// unknown.
+var ErrInvalidDownloadPriority = errors.New("invalid download priority")
+
+// ParseDownloadPriority parses a download priority class. An empty string
+// means DownloadPriorityUser.
+func ParseDownloadPriority(s string) (DownloadPriority, error) {
+	if s == "" {
+		return DownloadPriorityUser, nil
+	}
+	for _, p := range DownloadPriorities {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", errors.Compose(ErrInvalidDownloadPriority, fmt.Errorf("unknown class %q, must be one of %v", s, DownloadPriorities))
+}
@@ -0,0 +1,32 @@
+package modules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestParseDownloadPriority tests parsing download priority classes.
+func TestParseDownloadPriority(t *testing.T) {
+	tests := []struct {
+		s        string
+		priority DownloadPriority
+		err      error
+	}{
+		{"", DownloadPriorityUser, nil},
+		{"stream", DownloadPriorityStream, nil},
+		{"user", DownloadPriorityUser, nil},
+		{"batch", DownloadPriorityBatch, nil},
+		{"Batch", "", ErrInvalidDownloadPriority},
+		{"high", "", ErrInvalidDownloadPriority},
+	}
+	for _, test := range tests {
+		p, err := ParseDownloadPriority(test.s)
+		if (test.err == nil && err != nil) || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Errorf("%q: expected error %v but got %v", test.s, test.err, err)
+		}
+		if p != test.priority {
+			t.Errorf("%q: expected %v but got %v", test.s, test.priority, p)
+		}
+	}
+}
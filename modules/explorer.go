@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"errors"
+
 	"go.sia.tech/siad/types"
 )
 
@@ -10,7 +12,28 @@ const (
 	ExplorerDir = "explorer"
 )
 
+var (
+	// ErrExplorerObjectNotFound is returned by paginated explorer lookups of
+	// objects that do not appear in the blockchain.
+	ErrExplorerObjectNotFound = errors.New("object does not appear in the blockchain")
+)
+
 type (
+	// AddressBalance is the balance of an unlock hash at a certain height.
+	// Only spendable outputs count towards the balance, so miner payouts and
+	// storage proof outputs are included once they have matured.
+	AddressBalance struct {
+		UnlockHash     types.UnlockHash  `json:"unlockhash"`
+		Height         types.BlockHeight `json:"height"`
+		SiacoinBalance types.Currency    `json:"siacoinbalance"`
+		SiafundBalance types.Currency    `json:"siafundbalance"`
+
+		// FirstSeen and LastSeen are the first and last heights at which an
+		// output of the unlock hash was created or spent.
+		FirstSeen types.BlockHeight `json:"firstseen"`
+		LastSeen  types.BlockHeight `json:"lastseen"`
+	}
+
 	// BlockFacts returns a bunch of statistics about the consensus set as they
 	// were at a specific block.
 	BlockFacts struct {
@@ -45,6 +68,52 @@ type (
 		TotalRevisionVolume types.Currency `json:"totalrevisionvolume"`
 	}
 
+	// ExplorerHostAnnouncement is a host announcement found in the
+	// blockchain.
+	ExplorerHostAnnouncement struct {
+		NetAddress    NetAddress          `json:"netaddress"`
+		Height        types.BlockHeight   `json:"height"`
+		TransactionID types.TransactionID `json:"transactionid"`
+	}
+
+	// HostContractStats are the file contract statistics of a host at a
+	// certain height. Contracts are attributed to a host once a revision
+	// signed by the host's public key appears in the blockchain, so contracts
+	// that are never revised on chain are not counted.
+	HostContractStats struct {
+		Height types.BlockHeight `json:"height"`
+
+		// ContractCount is the number of contracts attributed to the host.
+		// ActiveContractCount and ActiveContractValue are the number and
+		// total payout of those contracts that have not been resolved yet.
+		ContractCount       uint64         `json:"contractcount"`
+		ActiveContractCount uint64         `json:"activecontractcount"`
+		ActiveContractValue types.Currency `json:"activecontractvalue"`
+
+		// CollateralPosted is the sum of the host's valid proof outputs of
+		// the contracts as they were formed, which contains the collateral
+		// the host locked into the contracts.
+		CollateralPosted types.Currency `json:"collateralposted"`
+
+		// StorageProofCount and MissedProofCount are the number of resolved
+		// contracts for which the host did and did not submit a storage
+		// proof.
+		StorageProofCount uint64 `json:"storageproofcount"`
+		MissedProofCount  uint64 `json:"missedproofcount"`
+	}
+
+	// ExplorerTransactionQuery filters and paginates the transactions returned
+	// by explorer lookups. Transactions are ordered by height, and only
+	// transactions between MinHeight and MaxHeight (inclusive) are returned.
+	// After is the ID of the last transaction of the previous page, or the
+	// zero ID for the first page. At most Limit transactions are returned.
+	ExplorerTransactionQuery struct {
+		MinHeight types.BlockHeight
+		MaxHeight types.BlockHeight
+		After     types.TransactionID
+		Limit     int
+	}
+
 	// Explorer tracks the blockchain and provides tools for gathering
 	// statistics and finding objects or patterns within the blockchain.
 	Explorer interface {
@@ -73,6 +142,49 @@ type (
 		// provided unlock hash.
 		UnlockHash(types.UnlockHash) []types.TransactionID
 
+		// AddressBalance returns the balance of an unlock hash after the block
+		// at the given height was applied. The bool indicates whether the
+		// unlock hash has ever held an output.
+		AddressBalance(types.UnlockHash, types.BlockHeight) (AddressBalance, bool)
+
+		// AddressOutputs returns up to limit unspent outputs of an unlock
+		// hash, ordered by ID and starting after the provided output ID.
+		AddressOutputs(uh types.UnlockHash, after types.OutputID, limit int) []UnspentOutput
+
+		// HostAnnouncements returns all of the announcements of the host with
+		// the provided public key, ordered by height.
+		HostAnnouncements(types.SiaPublicKey) []ExplorerHostAnnouncement
+
+		// HostContractStats returns the current file contract statistics of
+		// the host with the provided public key. The bool indicates whether
+		// any contracts have been attributed to the host.
+		HostContractStats(types.SiaPublicKey) (HostContractStats, bool)
+
+		// HostContractStatsHistory returns the file contract statistics of
+		// the host with the provided public key at every height between the
+		// provided heights (inclusive) at which they changed.
+		HostContractStatsHistory(spk types.SiaPublicKey, minHeight, maxHeight types.BlockHeight) []HostContractStats
+
+		// UnlockHashTransactions returns the page of transaction ids
+		// associated with the provided unlock hash that matches the query.
+		// The bool indicates whether more transactions match the query.
+		UnlockHashTransactions(types.UnlockHash, ExplorerTransactionQuery) ([]types.TransactionID, bool, error)
+
+		// SiacoinOutputTransactions returns the page of transaction ids
+		// associated with the provided siacoin output id that matches the
+		// query. The bool indicates whether more transactions match the query.
+		SiacoinOutputTransactions(types.SiacoinOutputID, ExplorerTransactionQuery) ([]types.TransactionID, bool, error)
+
+		// FileContractTransactions returns the page of transaction ids
+		// associated with the provided file contract id that matches the
+		// query. The bool indicates whether more transactions match the query.
+		FileContractTransactions(types.FileContractID, ExplorerTransactionQuery) ([]types.TransactionID, bool, error)
+
+		// SiafundOutputTransactions returns the page of transaction ids
+		// associated with the provided siafund output id that matches the
+		// query. The bool indicates whether more transactions match the query.
+		SiafundOutputTransactions(types.SiafundOutputID, ExplorerTransactionQuery) ([]types.TransactionID, bool, error)
+
 		// SiacoinOutput will return the siacoin output associated with the
 		// input id.
 		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
@@ -1,24 +1,34 @@
 package explorer
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 
 	"gitlab.com/NebulousLabs/bolt"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
 var (
 	// database buckets
+	bucketAddressHistories      = []byte("AddressHistories")
+	bucketAddressOutputs        = []byte("AddressOutputs")
 	bucketBlockFacts            = []byte("BlockFacts")
 	bucketBlockIDs              = []byte("BlockIDs")
 	bucketBlocksDifficulty      = []byte("BlocksDifficulty")
 	bucketBlockTargets          = []byte("BlockTargets")
+	bucketContractHosts         = []byte("ContractHosts")
 	bucketFileContractHistories = []byte("FileContractHistories")
 	bucketFileContractIDs       = []byte("FileContractIDs")
+	bucketHeightHosts           = []byte("HeightHosts")
+	bucketHostAnnouncements     = []byte("HostAnnouncements")
+	bucketHostContractStats     = []byte("HostContractStats")
 	// bucketInternal is used to store values internal to the explorer
 	bucketInternal         = []byte("Internal")
+	bucketOutputHeights    = []byte("OutputHeights")
 	bucketSiacoinOutputIDs = []byte("SiacoinOutputIDs")
 	bucketSiacoinOutputs   = []byte("SiacoinOutputs")
 	bucketSiafundOutputIDs = []byte("SiafundOutputIDs")
@@ -26,11 +36,13 @@ var (
 	bucketTransactionIDs   = []byte("TransactionIDs")
 	bucketUnlockHashes     = []byte("UnlockHashes")
 
-	errNotExist = errors.New("entry does not exist")
+	errInvalidCursor = errors.New("cursor does not refer to a transaction in the blockchain")
+	errNotExist      = errors.New("entry does not exist")
 
 	// keys for bucketInternal
-	internalBlockHeight  = []byte("BlockHeight")
-	internalRecentChange = []byte("RecentChange")
+	internalBlockHeight     = []byte("BlockHeight")
+	internalDatabaseVersion = []byte("DatabaseVersion")
+	internalRecentChange    = []byte("RecentChange")
 )
 
 // These functions all return a 'func(*bolt.Tx) error', which, allows them to
@@ -71,13 +83,12 @@ func dbGetTransactionIDSet(bucket []byte, key interface{}, ids *[]types.Transact
 		}
 		// decode into a local slice
 		var txids []types.TransactionID
-		err := b.ForEach(func(txid, _ []byte) error {
-			var id types.TransactionID
-			err := encoding.Unmarshal(txid, &id)
+		err := b.ForEach(func(k, _ []byte) error {
+			txid, err := decodeTransactionIDKey(k)
 			if err != nil {
 				return err
 			}
-			txids = append(txids, id)
+			txids = append(txids, txid)
 			return nil
 		})
 		if err != nil {
@@ -89,6 +100,56 @@ func dbGetTransactionIDSet(bucket []byte, key interface{}, ids *[]types.Transact
 	}
 }
 
+// dbGetTransactionIDPage returns a 'func(*bolt.Tx) error' that decodes the
+// page of a bucket of transaction IDs that matches the query. The IDs are
+// ordered by height. If the bucket is nil, dbGetTransactionIDPage returns
+// errNotExist.
+func dbGetTransactionIDPage(bucket []byte, key interface{}, q modules.ExplorerTransactionQuery, ids *[]types.TransactionID, more *bool) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Bucket(encoding.Marshal(key))
+		if b == nil {
+			return errNotExist
+		}
+
+		// Start at the minimum height, or after the cursor if the cursor is
+		// above the minimum height.
+		start := encodeHeight(q.MinHeight)
+		var after []byte
+		if q.After != (types.TransactionID{}) {
+			var height types.BlockHeight
+			if err := dbGetAndDecode(bucketTransactionIDs, q.After, &height)(tx); err != nil {
+				return errInvalidCursor
+			}
+			after = transactionIDKey(height, q.After)
+			if bytes.Compare(after, start) > 0 {
+				start = after
+			}
+		}
+
+		var txids []types.TransactionID
+		hasMore := false
+		c := b.Cursor()
+		for k, _ := c.Seek(start); k != nil; k, _ = c.Next() {
+			if bytes.Equal(k, after) {
+				continue
+			} else if decodeHeight(k[:8]) > q.MaxHeight {
+				break
+			} else if len(txids) == q.Limit {
+				hasMore = true
+				break
+			}
+			txid, err := decodeTransactionIDKey(k)
+			if err != nil {
+				return err
+			}
+			txids = append(txids, txid)
+		}
+		*ids = txids
+		*more = hasMore
+		return nil
+	}
+}
+
 // dbGetBlockFacts returns a 'func(*bolt.Tx) error' that decodes
 // the block facts for `height` into blockfacts
 func (e *Explorer) dbGetBlockFacts(height types.BlockHeight, bf *blockFacts) func(*bolt.Tx) error {
@@ -101,6 +162,152 @@ func (e *Explorer) dbGetBlockFacts(height types.BlockHeight, bf *blockFacts) fun
 	}
 }
 
+// dbGetAddressBalance returns a 'func(*bolt.Tx) error' that decodes the
+// balance of an unlock hash as it was after the block at 'height' was applied.
+// If the unlock hash has never held an output, dbGetAddressBalance returns
+// errNotExist.
+func dbGetAddressBalance(uh types.UnlockHash, height types.BlockHeight, ab *modules.AddressBalance) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAddressHistories).Bucket(encoding.Marshal(uh))
+		if b == nil {
+			return errNotExist
+		}
+		c := b.Cursor()
+		first, _ := c.First()
+		last, _ := c.Last()
+		ab.UnlockHash = uh
+		ab.Height = height
+		ab.FirstSeen = decodeHeight(first)
+		ab.LastSeen = decodeHeight(last)
+
+		// Find the most recent change at or below the requested height. If
+		// the unlock hash was first seen after the requested height, the
+		// balance is zero.
+		k, v := c.Seek(encodeHeight(height))
+		if k == nil || decodeHeight(k) > height {
+			k, v = c.Prev()
+		}
+		if k == nil {
+			return nil
+		}
+		var bal addressBalance
+		if err := encoding.Unmarshal(v, &bal); err != nil {
+			return err
+		}
+		ab.SiacoinBalance = bal.Siacoins
+		ab.SiafundBalance = bal.Siafunds
+		return nil
+	}
+}
+
+// dbGetAddressOutputs returns a 'func(*bolt.Tx) error' that decodes up to
+// 'limit' unspent outputs of an unlock hash, ordered by ID, starting after the
+// output with ID 'after'. The zero ID starts at the first output.
+func dbGetAddressOutputs(uh types.UnlockHash, after types.OutputID, limit int, outputs *[]modules.UnspentOutput) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAddressOutputs).Bucket(encoding.Marshal(uh))
+		if b == nil {
+			return nil
+		}
+		var uos []modules.UnspentOutput
+		c := b.Cursor()
+		k, v := c.Seek(encoding.Marshal(after))
+		if k != nil && bytes.Equal(k, encoding.Marshal(after)) {
+			k, v = c.Next()
+		}
+		for ; k != nil && len(uos) < limit; k, v = c.Next() {
+			var uo modules.UnspentOutput
+			if err := encoding.Unmarshal(v, &uo); err != nil {
+				return err
+			}
+			uos = append(uos, uo)
+		}
+		*outputs = uos
+		return nil
+	}
+}
+
+// dbGetHostAnnouncements returns a 'func(*bolt.Tx) error' that decodes the
+// announcements of a host, ordered by height.
+func dbGetHostAnnouncements(spk types.SiaPublicKey, anns *[]modules.ExplorerHostAnnouncement) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketHostAnnouncements).Bucket(encoding.Marshal(spk))
+		if b == nil {
+			return errNotExist
+		}
+		var hostAnns []modules.ExplorerHostAnnouncement
+		err := b.ForEach(func(_, v []byte) error {
+			var ann modules.ExplorerHostAnnouncement
+			if err := encoding.Unmarshal(v, &ann); err != nil {
+				return err
+			}
+			hostAnns = append(hostAnns, ann)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		*anns = hostAnns
+		return nil
+	}
+}
+
+// dbGetHostContractStatsHistory returns a 'func(*bolt.Tx) error' that decodes
+// the contract statistics of a host at every height between minHeight and
+// maxHeight (inclusive) at which they changed, ordered by height.
+func dbGetHostContractStatsHistory(spk types.SiaPublicKey, minHeight, maxHeight types.BlockHeight, history *[]modules.HostContractStats) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketHostContractStats).Bucket(encoding.Marshal(spk))
+		if b == nil {
+			return errNotExist
+		}
+		var stats []modules.HostContractStats
+		c := b.Cursor()
+		for k, v := c.Seek(encodeHeight(minHeight)); k != nil && decodeHeight(k) <= maxHeight; k, v = c.Next() {
+			var hcs modules.HostContractStats
+			if err := encoding.Unmarshal(v, &hcs); err != nil {
+				return err
+			}
+			stats = append(stats, hcs)
+		}
+		*history = stats
+		return nil
+	}
+}
+
+// transactionIDKey returns the key of a transaction ID in a bucket of
+// transaction IDs. Prefixing the ID with the height orders the bucket by
+// height.
+func transactionIDKey(height types.BlockHeight, txid types.TransactionID) []byte {
+	return append(encodeHeight(height), txid[:]...)
+}
+
+// decodeTransactionIDKey decodes the transaction ID of a key returned by
+// transactionIDKey.
+func decodeTransactionIDKey(k []byte) (txid types.TransactionID, err error) {
+	if len(k) != 8+len(txid) {
+		return types.TransactionID{}, errors.New("invalid transaction ID key")
+	}
+	copy(txid[:], k[8:])
+	return txid, nil
+}
+
+// encodeHeight encodes a block height so that the byte order of the encoded
+// heights matches their numerical order.
+func encodeHeight(height types.BlockHeight) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return b
+}
+
+// decodeHeight decodes a block height encoded by encodeHeight.
+func decodeHeight(b []byte) types.BlockHeight {
+	if len(b) != 8 {
+		return 0
+	}
+	return types.BlockHeight(binary.BigEndian.Uint64(b))
+}
+
 // dbSetInternal sets the specified key of bucketInternal to the encoded value.
 func dbSetInternal(key []byte, val interface{}) func(*bolt.Tx) error {
 	return func(tx *bolt.Tx) error {
@@ -30,6 +30,20 @@ type (
 		StorageProof types.StorageProof
 	}
 
+	// addressBalance is the balance of an unlock hash after the block at a
+	// certain height was applied.
+	addressBalance struct {
+		Siacoins types.Currency
+		Siafunds types.Currency
+	}
+
+	// contractHost is the host that a file contract was attributed to, and
+	// the height of the revision that attributed it.
+	contractHost struct {
+		HostKey types.SiaPublicKey
+		Height  types.BlockHeight
+	}
+
 	// blockFacts contains a set of facts about the consensus set related to a
 	// certain block. The explorer needs some additional information in the
 	// history so that it can calculate certain values, which is one of the
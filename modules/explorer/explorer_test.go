@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -13,6 +14,7 @@ import (
 	"go.sia.tech/siad/modules/miner"
 	"go.sia.tech/siad/modules/transactionpool"
 	"go.sia.tech/siad/modules/wallet"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -137,3 +139,65 @@ func TestExplorerGenesisHeight(t *testing.T) {
 		t.Errorf("genesis block hash wrong height: expected 0, got %v", height)
 	}
 }
+
+// TestExplorerRebuild checks that a database created by an older version of
+// the explorer is rebuilt from the genesis block.
+func TestExplorerRebuild(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	facts := et.explorer.LatestBlockFacts()
+	if err := et.explorer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the database version and an index, as if the database was
+	// created before they existed.
+	persistDir := filepath.Join(et.testdir, modules.ExplorerDir)
+	db, err := persist.OpenDatabase(explorerMetadata, filepath.Join(persistDir, "explorer.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketInternal).Delete(internalDatabaseVersion); err != nil {
+			return err
+		}
+		return tx.DeleteBucket(bucketAddressHistories)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the explorer. The indexes should be rebuilt.
+	e, err := New(et.cs, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if rebuilt := e.LatestBlockFacts(); rebuilt.BlockID != facts.BlockID || rebuilt.TransactionCount != facts.TransactionCount {
+		t.Fatal("rebuilt explorer has different facts")
+	}
+	addrs, err := et.wallet.AllAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, addr := range addrs {
+		_, exists := e.AddressBalance(addr, et.cs.Height())
+		found = found || exists
+	}
+	if !found {
+		t.Fatal("address index was not rebuilt")
+	}
+}
@@ -2,6 +2,8 @@ package explorer
 
 import (
 	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -185,3 +187,112 @@ func (e *Explorer) SiafundOutputID(id types.SiafundOutputID) []types.Transaction
 	}
 	return ids
 }
+
+// AddressBalance returns the siacoin and siafund balance of an unlock hash as
+// it was after the block at the given height was applied, and a bool
+// indicating whether the unlock hash has ever held an output. Heights above
+// the current height return the current balance.
+func (e *Explorer) AddressBalance(uh types.UnlockHash, height types.BlockHeight) (modules.AddressBalance, bool) {
+	var ab modules.AddressBalance
+	err := e.db.View(dbGetAddressBalance(uh, height, &ab))
+	if err != nil {
+		return modules.AddressBalance{}, false
+	}
+	return ab, true
+}
+
+// AddressOutputs returns up to limit unspent siacoin and siafund outputs of an
+// unlock hash, ordered by ID and starting after the output with ID 'after'.
+// The zero ID starts at the first output.
+func (e *Explorer) AddressOutputs(uh types.UnlockHash, after types.OutputID, limit int) []modules.UnspentOutput {
+	var outputs []modules.UnspentOutput
+	err := e.db.View(dbGetAddressOutputs(uh, after, limit, &outputs))
+	if err != nil {
+		outputs = nil
+	}
+	return outputs
+}
+
+// HostAnnouncements returns all of the announcements of the host with the
+// specified public key, ordered by height. An empty set indicates that the
+// host has never announced itself.
+func (e *Explorer) HostAnnouncements(spk types.SiaPublicKey) []modules.ExplorerHostAnnouncement {
+	var anns []modules.ExplorerHostAnnouncement
+	err := e.db.View(dbGetHostAnnouncements(spk, &anns))
+	if err != nil {
+		anns = nil
+	}
+	return anns
+}
+
+// HostContractStats returns the current file contract statistics of the host
+// with the specified public key, and a bool indicating whether any contracts
+// have been attributed to the host.
+func (e *Explorer) HostContractStats(spk types.SiaPublicKey) (modules.HostContractStats, bool) {
+	var hcs modules.HostContractStats
+	err := e.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketHostContractStats).Bucket(encoding.Marshal(spk)) == nil {
+			return errNotExist
+		}
+		hcs = dbGetLatestHostContractStats(tx, spk)
+		return nil
+	})
+	if err != nil {
+		return modules.HostContractStats{}, false
+	}
+	return hcs, true
+}
+
+// HostContractStatsHistory returns the file contract statistics of the host
+// with the specified public key at every height between minHeight and
+// maxHeight (inclusive) at which they changed, ordered by height.
+func (e *Explorer) HostContractStatsHistory(spk types.SiaPublicKey, minHeight, maxHeight types.BlockHeight) []modules.HostContractStats {
+	var history []modules.HostContractStats
+	err := e.db.View(dbGetHostContractStatsHistory(spk, minHeight, maxHeight, &history))
+	if err != nil {
+		history = nil
+	}
+	return history
+}
+
+// transactionPage returns the page of a bucket of transaction IDs that
+// matches the query.
+func (e *Explorer) transactionPage(bucket []byte, key interface{}, q modules.ExplorerTransactionQuery) ([]types.TransactionID, bool, error) {
+	var ids []types.TransactionID
+	var more bool
+	err := e.db.View(dbGetTransactionIDPage(bucket, key, q, &ids, &more))
+	if errors.Contains(err, errNotExist) {
+		return nil, false, modules.ErrExplorerObjectNotFound
+	} else if err != nil {
+		return nil, false, err
+	}
+	return ids, more, nil
+}
+
+// UnlockHashTransactions returns the IDs of the transactions that contain the
+// unlock hash and match the query, and a bool indicating whether more
+// transactions match the query.
+func (e *Explorer) UnlockHashTransactions(uh types.UnlockHash, q modules.ExplorerTransactionQuery) ([]types.TransactionID, bool, error) {
+	return e.transactionPage(bucketUnlockHashes, uh, q)
+}
+
+// SiacoinOutputTransactions returns the IDs of the transactions that contain
+// the siacoin output ID and match the query, and a bool indicating whether
+// more transactions match the query.
+func (e *Explorer) SiacoinOutputTransactions(id types.SiacoinOutputID, q modules.ExplorerTransactionQuery) ([]types.TransactionID, bool, error) {
+	return e.transactionPage(bucketSiacoinOutputIDs, id, q)
+}
+
+// FileContractTransactions returns the IDs of the transactions that contain
+// the file contract ID and match the query, and a bool indicating whether
+// more transactions match the query.
+func (e *Explorer) FileContractTransactions(id types.FileContractID, q modules.ExplorerTransactionQuery) ([]types.TransactionID, bool, error) {
+	return e.transactionPage(bucketFileContractIDs, id, q)
+}
+
+// SiafundOutputTransactions returns the IDs of the transactions that contain
+// the siafund output ID and match the query, and a bool indicating whether
+// more transactions match the query.
+func (e *Explorer) SiafundOutputTransactions(id types.SiafundOutputID, q modules.ExplorerTransactionQuery) ([]types.TransactionID, bool, error) {
+	return e.transactionPage(bucketSiafundOutputIDs, id, q)
+}
@@ -3,9 +3,11 @@ package explorer
 import (
 	"testing"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
@@ -269,3 +271,86 @@ func TestFileContractsPayoutValidProof(t *testing.T) {
 		t.Errorf("expected %v, got %v ", fc.MissedProofOutputs, outputs)
 	}
 }
+
+// TestExplorerTransactionQuery checks that the transactions of an unlock hash
+// are paginated in height order and filtered by height.
+func TestExplorerTransactionQuery(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send coins to an address in three different blocks.
+	uh := types.UnlockHash{1, 2, 3}
+	var txids []types.TransactionID
+	var heights []types.BlockHeight
+	for i := 0; i < 3; i++ {
+		txns, err := et.wallet.SendSiacoins(types.SiacoinPrecision, uh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+		txids = append(txids, txns[len(txns)-1].ID())
+		heights = append(heights, et.cs.Height())
+	}
+
+	// Page through the transactions one at a time.
+	q := modules.ExplorerTransactionQuery{
+		MaxHeight: et.cs.Height(),
+		Limit:     1,
+	}
+	for i := range txids {
+		page, more, err := et.explorer.UnlockHashTransactions(uh, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 1 || page[0] != txids[i] {
+			t.Fatalf("wrong page %v: %v", i, page)
+		}
+		if more != (i < len(txids)-1) {
+			t.Fatalf("wrong more for page %v: %v", i, more)
+		}
+		q.After = page[0]
+	}
+
+	// Filter by height.
+	q = modules.ExplorerTransactionQuery{
+		MinHeight: heights[1],
+		MaxHeight: heights[1],
+		Limit:     10,
+	}
+	page, more, err := et.explorer.UnlockHashTransactions(uh, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0] != txids[1] || more {
+		t.Fatal("wrong filtered page", page, more)
+	}
+
+	// A cursor below the minimum height starts at the minimum height.
+	q.After = txids[0]
+	q.MaxHeight = et.cs.Height()
+	page, _, err = et.explorer.UnlockHashTransactions(uh, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0] != txids[1] || page[1] != txids[2] {
+		t.Fatal("wrong page after cursor", page)
+	}
+
+	// Unknown objects and cursors are rejected.
+	_, _, err = et.explorer.UnlockHashTransactions(types.UnlockHash{4, 5, 6}, q)
+	if !errors.Contains(err, modules.ErrExplorerObjectNotFound) {
+		t.Fatal("expected ErrExplorerObjectNotFound, got", err)
+	}
+	q.After = types.TransactionID{1}
+	_, _, err = et.explorer.UnlockHashTransactions(uh, q)
+	if !errors.Contains(err, errInvalidCursor) {
+		t.Fatal("expected errInvalidCursor, got", err)
+	}
+}
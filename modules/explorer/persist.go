@@ -1,6 +1,7 @@
 package explorer
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 
@@ -12,6 +13,10 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+// databaseVersion is the version of the layout of the explorer's indexes.
+// Databases with a different version are rebuilt when the explorer starts.
+const databaseVersion uint64 = 2
+
 var explorerMetadata = persist.Metadata{
 	Header:  "Sia Explorer",
 	Version: "0.5.2",
@@ -35,13 +40,20 @@ func (e *Explorer) initPersist() error {
 	// Initialize the database
 	err = e.db.Update(func(tx *bolt.Tx) error {
 		buckets := [][]byte{
+			bucketAddressHistories,
+			bucketAddressOutputs,
 			bucketBlockFacts,
 			bucketBlockIDs,
 			bucketBlocksDifficulty,
 			bucketBlockTargets,
+			bucketContractHosts,
 			bucketFileContractHistories,
 			bucketFileContractIDs,
+			bucketHeightHosts,
+			bucketHostAnnouncements,
+			bucketHostContractStats,
 			bucketInternal,
+			bucketOutputHeights,
 			bucketSiacoinOutputIDs,
 			bucketSiacoinOutputs,
 			bucketSiafundOutputIDs,
@@ -49,6 +61,21 @@ func (e *Explorer) initPersist() error {
 			bucketTransactionIDs,
 			bucketUnlockHashes,
 		}
+
+		// Databases created by an older version of the explorer need to be
+		// rebuilt from the beginning of the blockchain. Deleting the buckets
+		// resets the recent change, which causes the explorer to resubscribe
+		// from the genesis block.
+		if b := tx.Bucket(bucketInternal); b != nil && !bytes.Equal(b.Get(internalDatabaseVersion), encoding.Marshal(databaseVersion)) {
+			for _, b := range buckets {
+				if tx.Bucket(b) == nil {
+					continue
+				}
+				if err := tx.DeleteBucket(b); err != nil {
+					return err
+				}
+			}
+		}
 		for _, b := range buckets {
 			_, err := tx.CreateBucketIfNotExists(b)
 			if err != nil {
@@ -61,6 +88,7 @@ func (e *Explorer) initPersist() error {
 			key, val []byte
 		}{
 			{internalBlockHeight, encoding.Marshal(types.BlockHeight(0))},
+			{internalDatabaseVersion, encoding.Marshal(databaseVersion)},
 			{internalRecentChange, encoding.Marshal(modules.ConsensusChangeID{})},
 		}
 		b := tx.Bucket(bucketInternal)
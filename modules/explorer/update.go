@@ -1,6 +1,7 @@
 package explorer
 
 import (
+	"bytes"
 	"fmt"
 
 	"gitlab.com/NebulousLabs/bolt"
@@ -27,6 +28,7 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}()
 
 		// Update cumulative stats for reverted blocks.
+		height := cc.InitialHeight() + types.BlockHeight(len(cc.RevertedBlocks))
 		for _, block := range cc.RevertedBlocks {
 			bid := block.ID()
 			tbid := types.TransactionID(bid)
@@ -40,57 +42,64 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 			dbRemoveBlockTarget(tx, bid, target)
 
-			// Remove miner payouts
+			// Remove miner payouts. Payouts and transactions can reference the
+			// same unlock hash more than once, so the unlock hashes are
+			// collected and removed once each.
+			unlockHashes := make(map[types.UnlockHash]struct{})
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
-				dbRemoveSiacoinOutputID(tx, scoid, tbid)
-				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid)
+				dbRemoveSiacoinOutputID(tx, scoid, tbid, height)
+				unlockHashes[payout.UnlockHash] = struct{}{}
+			}
+			for uh := range unlockHashes {
+				dbRemoveUnlockHash(tx, uh, tbid, height)
 			}
 
 			// Remove transactions
 			for _, txn := range block.Transactions {
 				txid := txn.ID()
 				dbRemoveTransactionID(tx, txid)
+				unlockHashes = make(map[types.UnlockHash]struct{})
 
 				for _, sci := range txn.SiacoinInputs {
-					dbRemoveSiacoinOutputID(tx, sci.ParentID, txid)
-					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbRemoveSiacoinOutputID(tx, sci.ParentID, txid, height)
+					unlockHashes[sci.UnlockConditions.UnlockHash()] = struct{}{}
 				}
 				for k, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(k))
-					dbRemoveSiacoinOutputID(tx, scoid, txid)
-					dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+					dbRemoveSiacoinOutputID(tx, scoid, txid, height)
+					unlockHashes[sco.UnlockHash] = struct{}{}
 					dbRemoveSiacoinOutput(tx, scoid)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
-					dbRemoveFileContractID(tx, fcid, txid)
-					dbRemoveUnlockHash(tx, fc.UnlockHash, txid)
+					dbRemoveFileContractID(tx, fcid, txid, height)
+					unlockHashes[fc.UnlockHash] = struct{}{}
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, height)
+						unlockHashes[sco.UnlockHash] = struct{}{}
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, height)
+						unlockHashes[sco.UnlockHash] = struct{}{}
 					}
 					dbRemoveFileContract(tx, fcid)
 				}
 				for _, fcr := range txn.FileContractRevisions {
-					dbRemoveFileContractID(tx, fcr.ParentID, txid)
-					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbRemoveFileContractID(tx, fcr.ParentID, txid, height)
+					unlockHashes[fcr.UnlockConditions.UnlockHash()] = struct{}{}
+					unlockHashes[fcr.NewUnlockHash] = struct{}{}
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, height)
+						unlockHashes[sco.UnlockHash] = struct{}{}
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, height)
+						unlockHashes[sco.UnlockHash] = struct{}{}
 					}
 					// Remove the file contract revision from the revision chain.
 					dbRemoveFileContractRevision(tx, fcr.ParentID)
@@ -99,19 +108,23 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 					dbRemoveStorageProof(tx, sp.ParentID)
 				}
 				for _, sfi := range txn.SiafundInputs {
-					dbRemoveSiafundOutputID(tx, sfi.ParentID, txid)
-					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbRemoveSiafundOutputID(tx, sfi.ParentID, txid, height)
+					unlockHashes[sfi.UnlockConditions.UnlockHash()] = struct{}{}
+					unlockHashes[sfi.ClaimUnlockHash] = struct{}{}
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
-					dbRemoveSiafundOutputID(tx, sfoid, txid)
-					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid)
+					dbRemoveSiafundOutputID(tx, sfoid, txid, height)
+					unlockHashes[sfo.UnlockHash] = struct{}{}
+				}
+				for uh := range unlockHashes {
+					dbRemoveUnlockHash(tx, uh, txid, height)
 				}
 			}
 
 			// remove the associated block facts
 			dbRemoveBlockFacts(tx, bid)
+			height--
 		}
 
 		blockheight := cc.InitialHeight()
@@ -139,8 +152,8 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			// Catalog the new miner payouts.
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
-				dbAddSiacoinOutputID(tx, scoid, tbid)
-				dbAddUnlockHash(tx, payout.UnlockHash, tbid)
+				dbAddSiacoinOutputID(tx, scoid, tbid, blockheight)
+				dbAddUnlockHash(tx, payout.UnlockHash, tbid, blockheight)
 			}
 
 			// Update cumulative stats for applied transactions.
@@ -150,59 +163,59 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				dbAddTransactionID(tx, txid, blockheight)
 
 				for _, sci := range txn.SiacoinInputs {
-					dbAddSiacoinOutputID(tx, sci.ParentID, txid)
-					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbAddSiacoinOutputID(tx, sci.ParentID, txid, blockheight)
+					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, blockheight)
 				}
 				for j, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(j))
-					dbAddSiacoinOutputID(tx, scoid, txid)
-					dbAddUnlockHash(tx, sco.UnlockHash, txid)
+					dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+					dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
-					dbAddFileContractID(tx, fcid, txid)
-					dbAddUnlockHash(tx, fc.UnlockHash, txid)
+					dbAddFileContractID(tx, fcid, txid, blockheight)
+					dbAddUnlockHash(tx, fc.UnlockHash, txid, blockheight)
 					dbAddFileContract(tx, fcid, fc)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 				}
 				for _, fcr := range txn.FileContractRevisions {
-					dbAddFileContractID(tx, fcr.ParentID, txid)
-					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbAddFileContractID(tx, fcr.ParentID, txid, blockheight)
+					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid, blockheight)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					dbAddFileContractRevision(tx, fcr.ParentID, fcr)
 				}
 				for _, sp := range txn.StorageProofs {
-					dbAddFileContractID(tx, sp.ParentID, txid)
+					dbAddFileContractID(tx, sp.ParentID, txid, blockheight)
 					dbAddStorageProof(tx, sp.ParentID, sp)
 				}
 				for _, sfi := range txn.SiafundInputs {
-					dbAddSiafundOutputID(tx, sfi.ParentID, txid)
-					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbAddSiafundOutputID(tx, sfi.ParentID, txid, blockheight)
+					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid, blockheight)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
-					dbAddSiafundOutputID(tx, sfoid, txid)
-					dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+					dbAddSiafundOutputID(tx, sfoid, txid, blockheight)
+					dbAddUnlockHash(tx, sfo.UnlockHash, txid, blockheight)
 				}
 			}
 
@@ -227,6 +240,12 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}
 
+		// Update the address balances and unspent outputs.
+		dbUpdateAddresses(tx, cc)
+
+		// Update the host announcements and contract statistics.
+		dbUpdateHosts(tx, cc)
+
 		// Compute the changes in the active set. Note, because this is calculated
 		// at the end instead of in a loop, the historic facts may contain
 		// inaccuracies about the active set. This should not be a problem except
@@ -285,9 +304,6 @@ func assertNil(err error) {
 func mustPut(bucket *bolt.Bucket, key, val interface{}) {
 	assertNil(bucket.Put(encoding.Marshal(key), encoding.Marshal(val)))
 }
-func mustPutSet(bucket *bolt.Bucket, key interface{}) {
-	assertNil(bucket.Put(encoding.Marshal(key), nil))
-}
 func mustDelete(bucket *bolt.Bucket, key interface{}) {
 	assertNil(bucket.Delete(encoding.Marshal(key)))
 }
@@ -333,14 +349,18 @@ func dbRemoveFileContract(tx *bolt.Tx, id types.FileContractID) {
 }
 
 // Add/Remove txid from file contract ID bucket
-func dbAddFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID) {
+func dbAddFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketFileContractIDs).CreateBucketIfNotExists(encoding.Marshal(id))
 	assertNil(err)
-	mustPutSet(b, txid)
+	assertNil(b.Put(transactionIDKey(height, txid), nil))
 }
-func dbRemoveFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID) {
+func dbRemoveFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketFileContractIDs).Bucket(encoding.Marshal(id))
-	mustDelete(bucket, txid)
+	if bucket == nil {
+		build.Critical("explorer has no transactions for file contract", id)
+		return
+	}
+	assertNil(bucket.Delete(transactionIDKey(height, txid)))
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketFileContractIDs).DeleteBucket(encoding.Marshal(id))
 	}
@@ -369,14 +389,18 @@ func dbRemoveSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) {
 }
 
 // Add/Remove txid from siacoin output ID bucket
-func dbAddSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID) {
+func dbAddSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketSiacoinOutputIDs).CreateBucketIfNotExists(encoding.Marshal(id))
 	assertNil(err)
-	mustPutSet(b, txid)
+	assertNil(b.Put(transactionIDKey(height, txid), nil))
 }
-func dbRemoveSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID) {
+func dbRemoveSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketSiacoinOutputIDs).Bucket(encoding.Marshal(id))
-	mustDelete(bucket, txid)
+	if bucket == nil {
+		build.Critical("explorer has no transactions for siacoin output", id)
+		return
+	}
+	assertNil(bucket.Delete(transactionIDKey(height, txid)))
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketSiacoinOutputIDs).DeleteBucket(encoding.Marshal(id))
 	}
@@ -388,14 +412,18 @@ func dbAddSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.Siaf
 }
 
 // Add/Remove txid from siafund output ID bucket
-func dbAddSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID) {
+func dbAddSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketSiafundOutputIDs).CreateBucketIfNotExists(encoding.Marshal(id))
 	assertNil(err)
-	mustPutSet(b, txid)
+	assertNil(b.Put(transactionIDKey(height, txid), nil))
 }
-func dbRemoveSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID) {
+func dbRemoveSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketSiafundOutputIDs).Bucket(encoding.Marshal(id))
-	mustDelete(bucket, txid)
+	if bucket == nil {
+		build.Critical("explorer has no transactions for siafund output", id)
+		return
+	}
+	assertNil(bucket.Delete(transactionIDKey(height, txid)))
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketSiafundOutputIDs).DeleteBucket(encoding.Marshal(id))
 	}
@@ -421,19 +449,337 @@ func dbRemoveTransactionID(tx *bolt.Tx, id types.TransactionID) {
 }
 
 // Add/Remove txid from unlock hash bucket
-func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketUnlockHashes).CreateBucketIfNotExists(encoding.Marshal(uh))
 	assertNil(err)
-	mustPutSet(b, txid)
+	assertNil(b.Put(transactionIDKey(height, txid), nil))
 }
-func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketUnlockHashes).Bucket(encoding.Marshal(uh))
-	mustDelete(bucket, txid)
+	if bucket == nil {
+		build.Critical("explorer has no transactions for unlock hash", uh)
+		return
+	}
+	assertNil(bucket.Delete(transactionIDKey(height, txid)))
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketUnlockHashes).DeleteBucket(encoding.Marshal(uh))
 	}
 }
 
+// Update address balances, histories and outputs
+func dbUpdateAddresses(tx *bolt.Tx, cc modules.ConsensusChange) {
+	// Reverting a block removes the balance changes recorded at its height.
+	height := cc.InitialHeight() + types.BlockHeight(len(cc.RevertedBlocks))
+	for _, diffs := range cc.RevertedDiffs {
+		for uh := range dbApplyAddressDiffs(tx, diffs, height) {
+			dbRemoveAddressBalance(tx, uh, height)
+		}
+		height--
+	}
+
+	height = cc.InitialHeight()
+	for i, diffs := range cc.AppliedDiffs {
+		if cc.AppliedBlocks[i].ID() != types.GenesisID {
+			height++
+		}
+		for uh, bal := range dbApplyAddressDiffs(tx, diffs, height) {
+			dbAddAddressBalance(tx, uh, height, bal)
+		}
+	}
+}
+
+// dbApplyAddressDiffs applies the siacoin and siafund output diffs of a block
+// at 'height' to the address outputs, and returns the resulting balances of
+// every unlock hash affected by the diffs.
+func dbApplyAddressDiffs(tx *bolt.Tx, diffs modules.ConsensusChangeDiffs, height types.BlockHeight) map[types.UnlockHash]addressBalance {
+	balances := make(map[types.UnlockHash]addressBalance)
+	getBalance := func(uh types.UnlockHash) addressBalance {
+		bal, exists := balances[uh]
+		if !exists {
+			bal = dbGetLatestAddressBalance(tx, uh)
+		}
+		return bal
+	}
+	for _, scod := range diffs.SiacoinOutputDiffs {
+		uh := scod.SiacoinOutput.UnlockHash
+		bal := getBalance(uh)
+		id := types.OutputID(scod.ID)
+		if scod.Direction == modules.DiffApply {
+			bal.Siacoins = bal.Siacoins.Add(scod.SiacoinOutput.Value)
+			dbAddAddressOutput(tx, id, types.SpecifierSiacoinOutput, uh, scod.SiacoinOutput.Value, height)
+		} else {
+			bal.Siacoins = bal.Siacoins.Sub(scod.SiacoinOutput.Value)
+			dbRemoveAddressOutput(tx, id, uh, height)
+		}
+		balances[uh] = bal
+	}
+	for _, sfod := range diffs.SiafundOutputDiffs {
+		uh := sfod.SiafundOutput.UnlockHash
+		bal := getBalance(uh)
+		id := types.OutputID(sfod.ID)
+		if sfod.Direction == modules.DiffApply {
+			bal.Siafunds = bal.Siafunds.Add(sfod.SiafundOutput.Value)
+			dbAddAddressOutput(tx, id, types.SpecifierSiafundOutput, uh, sfod.SiafundOutput.Value, height)
+		} else {
+			bal.Siafunds = bal.Siafunds.Sub(sfod.SiafundOutput.Value)
+			dbRemoveAddressOutput(tx, id, uh, height)
+		}
+		balances[uh] = bal
+	}
+	return balances
+}
+
+// dbGetLatestAddressBalance returns the most recent balance of an unlock hash.
+func dbGetLatestAddressBalance(tx *bolt.Tx, uh types.UnlockHash) (bal addressBalance) {
+	b := tx.Bucket(bucketAddressHistories).Bucket(encoding.Marshal(uh))
+	if b == nil {
+		return
+	}
+	if _, v := b.Cursor().Last(); v != nil {
+		assertNil(encoding.Unmarshal(v, &bal))
+	}
+	return
+}
+
+// Add/Remove address balance at height
+func dbAddAddressBalance(tx *bolt.Tx, uh types.UnlockHash, height types.BlockHeight, bal addressBalance) {
+	b, err := tx.Bucket(bucketAddressHistories).CreateBucketIfNotExists(encoding.Marshal(uh))
+	assertNil(err)
+	assertNil(b.Put(encodeHeight(height), encoding.Marshal(bal)))
+}
+func dbRemoveAddressBalance(tx *bolt.Tx, uh types.UnlockHash, height types.BlockHeight) {
+	bucket := tx.Bucket(bucketAddressHistories).Bucket(encoding.Marshal(uh))
+	if bucket == nil {
+		return
+	}
+	assertNil(bucket.Delete(encodeHeight(height)))
+	if bucketIsEmpty(bucket) {
+		assertNil(tx.Bucket(bucketAddressHistories).DeleteBucket(encoding.Marshal(uh)))
+	}
+}
+
+// Add/Remove unspent output of an unlock hash. The height at which an output
+// was created is kept after the output is spent so that it can be restored if
+// the spend is reverted.
+func dbAddAddressOutput(tx *bolt.Tx, id types.OutputID, fundType types.Specifier, uh types.UnlockHash, value types.Currency, height types.BlockHeight) {
+	confirmationHeight := height
+	if v := tx.Bucket(bucketOutputHeights).Get(encoding.Marshal(id)); v != nil {
+		// The output is restored by reverting the block that spent it.
+		assertNil(encoding.Unmarshal(v, &confirmationHeight))
+	} else {
+		mustPut(tx.Bucket(bucketOutputHeights), id, height)
+	}
+	b, err := tx.Bucket(bucketAddressOutputs).CreateBucketIfNotExists(encoding.Marshal(uh))
+	assertNil(err)
+	mustPut(b, id, modules.UnspentOutput{
+		ID:                 id,
+		FundType:           fundType,
+		UnlockHash:         uh,
+		Value:              value,
+		ConfirmationHeight: confirmationHeight,
+	})
+}
+func dbRemoveAddressOutput(tx *bolt.Tx, id types.OutputID, uh types.UnlockHash, height types.BlockHeight) {
+	// If the output was created at this height, the block that created it is
+	// being reverted.
+	var confirmationHeight types.BlockHeight
+	assertNil(dbGetAndDecode(bucketOutputHeights, id, &confirmationHeight)(tx))
+	if confirmationHeight == height {
+		mustDelete(tx.Bucket(bucketOutputHeights), id)
+	}
+	bucket := tx.Bucket(bucketAddressOutputs).Bucket(encoding.Marshal(uh))
+	mustDelete(bucket, id)
+	if bucketIsEmpty(bucket) {
+		assertNil(tx.Bucket(bucketAddressOutputs).DeleteBucket(encoding.Marshal(uh)))
+	}
+}
+
+// Update host announcements and contract statistics
+func dbUpdateHosts(tx *bolt.Tx, cc modules.ConsensusChange) {
+	// Reverting a block removes the announcements, statistics and contract
+	// attributions recorded at its height.
+	height := cc.InitialHeight() + types.BlockHeight(len(cc.RevertedBlocks))
+	for _, block := range cc.RevertedBlocks {
+		for _, txn := range block.Transactions {
+			for _, fcr := range txn.FileContractRevisions {
+				var ch contractHost
+				err := dbGetAndDecode(bucketContractHosts, fcr.ParentID, &ch)(tx)
+				if err == nil && ch.Height == height {
+					mustDelete(tx.Bucket(bucketContractHosts), fcr.ParentID)
+				}
+			}
+		}
+		dbRemoveHeightHosts(tx, height)
+		height--
+	}
+
+	height = cc.InitialHeight()
+	for i, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			height++
+		}
+		dbApplyHostBlock(tx, block, cc.AppliedDiffs[i], height)
+	}
+}
+
+// dbApplyHostBlock records the host announcements and the changes to the host
+// contract statistics caused by a block at 'height'.
+func dbApplyHostBlock(tx *bolt.Tx, block types.Block, diffs modules.ConsensusChangeDiffs, height types.BlockHeight) {
+	hosts := make(map[string]types.SiaPublicKey)
+	stats := make(map[string]modules.HostContractStats)
+	getStats := func(spk types.SiaPublicKey) modules.HostContractStats {
+		hcs, exists := stats[spk.String()]
+		if !exists {
+			hcs = dbGetLatestHostContractStats(tx, spk)
+		}
+		hcs.Height = height
+		return hcs
+	}
+
+	proofs := make(map[types.FileContractID]bool)
+	for _, txn := range block.Transactions {
+		txid := txn.ID()
+		for _, arb := range txn.ArbitraryData {
+			addr, spk, err := modules.DecodeAnnouncement(arb)
+			if err != nil {
+				continue
+			}
+			dbAddHostAnnouncement(tx, spk, modules.ExplorerHostAnnouncement{
+				NetAddress:    addr,
+				Height:        height,
+				TransactionID: txid,
+			})
+			hosts[spk.String()] = spk
+		}
+
+		// Attribute contracts to the host that signed their first revision.
+		for _, fcr := range txn.FileContractRevisions {
+			uc := fcr.UnlockConditions
+			if len(uc.PublicKeys) != 2 || uc.SignaturesRequired != 2 {
+				continue
+			}
+			if tx.Bucket(bucketContractHosts).Get(encoding.Marshal(fcr.ParentID)) != nil {
+				continue
+			}
+			var history fileContractHistory
+			assertNil(dbGetAndDecode(bucketFileContractHistories, fcr.ParentID, &history)(tx))
+			spk := uc.PublicKeys[1]
+			mustPut(tx.Bucket(bucketContractHosts), fcr.ParentID, contractHost{
+				HostKey: spk,
+				Height:  height,
+			})
+
+			hcs := getStats(spk)
+			hcs.ContractCount++
+			hcs.ActiveContractCount++
+			hcs.ActiveContractValue = hcs.ActiveContractValue.Add(history.Contract.Payout)
+			if len(history.Contract.ValidProofOutputs) > 1 {
+				hcs.CollateralPosted = hcs.CollateralPosted.Add(history.Contract.ValidProofOutputs[1].Value)
+			}
+			stats[spk.String()] = hcs
+			hosts[spk.String()] = spk
+		}
+		for _, sp := range txn.StorageProofs {
+			proofs[sp.ParentID] = true
+		}
+	}
+
+	// A contract that is removed from the consensus set without being
+	// re-added by a revision has been resolved, either by a storage proof or
+	// by expiring.
+	revised := make(map[types.FileContractID]bool)
+	for _, fcd := range diffs.FileContractDiffs {
+		if fcd.Direction == modules.DiffApply {
+			revised[fcd.ID] = true
+		}
+	}
+	for _, fcd := range diffs.FileContractDiffs {
+		if fcd.Direction != modules.DiffRevert || revised[fcd.ID] {
+			continue
+		}
+		var ch contractHost
+		if err := dbGetAndDecode(bucketContractHosts, fcd.ID, &ch)(tx); err != nil {
+			continue
+		}
+		hcs := getStats(ch.HostKey)
+		hcs.ActiveContractCount--
+		hcs.ActiveContractValue = hcs.ActiveContractValue.Sub(fcd.FileContract.Payout)
+		if proofs[fcd.ID] {
+			hcs.StorageProofCount++
+		} else {
+			hcs.MissedProofCount++
+		}
+		stats[ch.HostKey.String()] = hcs
+		hosts[ch.HostKey.String()] = ch.HostKey
+	}
+
+	for key, hcs := range stats {
+		dbAddHostContractStats(tx, hosts[key], hcs)
+	}
+	if len(hosts) > 0 {
+		var spks []types.SiaPublicKey
+		for _, spk := range hosts {
+			spks = append(spks, spk)
+		}
+		mustPut(tx.Bucket(bucketHeightHosts), height, spks)
+	}
+}
+
+// dbGetLatestHostContractStats returns the most recent contract statistics of
+// a host.
+func dbGetLatestHostContractStats(tx *bolt.Tx, spk types.SiaPublicKey) (hcs modules.HostContractStats) {
+	b := tx.Bucket(bucketHostContractStats).Bucket(encoding.Marshal(spk))
+	if b == nil {
+		return
+	}
+	if _, v := b.Cursor().Last(); v != nil {
+		assertNil(encoding.Unmarshal(v, &hcs))
+	}
+	return
+}
+
+// Add host announcement and contract statistics
+func dbAddHostAnnouncement(tx *bolt.Tx, spk types.SiaPublicKey, ann modules.ExplorerHostAnnouncement) {
+	b, err := tx.Bucket(bucketHostAnnouncements).CreateBucketIfNotExists(encoding.Marshal(spk))
+	assertNil(err)
+	key := append(encodeHeight(ann.Height), ann.TransactionID[:]...)
+	assertNil(b.Put(key, encoding.Marshal(ann)))
+}
+func dbAddHostContractStats(tx *bolt.Tx, spk types.SiaPublicKey, hcs modules.HostContractStats) {
+	b, err := tx.Bucket(bucketHostContractStats).CreateBucketIfNotExists(encoding.Marshal(spk))
+	assertNil(err)
+	assertNil(b.Put(encodeHeight(hcs.Height), encoding.Marshal(hcs)))
+}
+
+// dbRemoveHeightHosts removes the announcements and contract statistics of
+// all hosts that were recorded at 'height'.
+func dbRemoveHeightHosts(tx *bolt.Tx, height types.BlockHeight) {
+	var spks []types.SiaPublicKey
+	if err := dbGetAndDecode(bucketHeightHosts, height, &spks)(tx); err != nil {
+		return
+	}
+	prefix := encodeHeight(height)
+	for _, spk := range spks {
+		key := encoding.Marshal(spk)
+		if b := tx.Bucket(bucketHostAnnouncements).Bucket(key); b != nil {
+			c := b.Cursor()
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+				assertNil(c.Delete())
+			}
+			if bucketIsEmpty(b) {
+				assertNil(tx.Bucket(bucketHostAnnouncements).DeleteBucket(key))
+			}
+		}
+		if b := tx.Bucket(bucketHostContractStats).Bucket(key); b != nil {
+			assertNil(b.Delete(prefix))
+			if bucketIsEmpty(b) {
+				assertNil(tx.Bucket(bucketHostContractStats).DeleteBucket(key))
+			}
+		}
+	}
+	mustDelete(tx.Bucket(bucketHeightHosts), height)
+}
+
 func dbCalculateBlockFacts(tx *bolt.Tx, cs modules.ConsensusSet, block types.Block) blockFacts {
 	// get the parent block facts
 	var bf blockFacts
@@ -527,16 +873,16 @@ func dbAddGenesisBlock(tx *bolt.Tx) {
 		// Add Genesis Siacoin outputs to database
 		for i, sco := range transaction.SiacoinOutputs {
 			scoid := transaction.SiacoinOutputID(uint64(i))
-			dbAddSiacoinOutputID(tx, scoid, txid)
-			dbAddUnlockHash(tx, sco.UnlockHash, txid)
+			dbAddSiacoinOutputID(tx, scoid, txid, 0)
+			dbAddUnlockHash(tx, sco.UnlockHash, txid, 0)
 			dbAddSiacoinOutput(tx, scoid, sco)
 		}
 
 		// Add Geesis Siafund outputs to database
 		for i, sfo := range transaction.SiafundOutputs {
 			sfoid := transaction.SiafundOutputID(uint64(i))
-			dbAddSiafundOutputID(tx, sfoid, txid)
-			dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+			dbAddSiafundOutputID(tx, sfoid, txid, 0)
+			dbAddUnlockHash(tx, sfo.UnlockHash, txid, 0)
 			dbAddSiafundOutput(tx, sfoid, sfo)
 		}
 	}
@@ -1,8 +1,17 @@
 package explorer
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -213,3 +222,420 @@ func TestIntegrationExplorerFileContractMetrics(t *testing.T) {
 	// 	t.Error("post reorg file contract count should be zero, got", facts.FileContractCount)
 	// }
 }
+
+// checkWalletAddressBalances checks that the balances of the wallet's
+// addresses add up to the wallet's confirmed balance.
+func (et *explorerTester) checkWalletAddressBalances() error {
+	scBal, _, _, err := et.wallet.ConfirmedBalance()
+	if err != nil {
+		return err
+	}
+	addrs, err := et.wallet.AllAddresses()
+	if err != nil {
+		return err
+	}
+	height := et.cs.Height()
+	var total types.Currency
+	for _, addr := range addrs {
+		ab, exists := et.explorer.AddressBalance(addr, height)
+		if !exists {
+			continue
+		}
+		total = total.Add(ab.SiacoinBalance)
+
+		var outputTotal types.Currency
+		for _, uo := range et.explorer.AddressOutputs(addr, types.OutputID{}, 1e6) {
+			outputTotal = outputTotal.Add(uo.Value)
+		}
+		if !outputTotal.Equals(ab.SiacoinBalance) {
+			return fmt.Errorf("outputs of %v add up to %v, balance is %v", addr, outputTotal, ab.SiacoinBalance)
+		}
+	}
+	if !total.Equals(scBal) {
+		return fmt.Errorf("address balances add up to %v, wallet has %v", total, scBal)
+	}
+	return nil
+}
+
+// TestExplorerAddressBalances checks that the explorer tracks the balance,
+// balance history and unspent outputs of addresses.
+func TestExplorerAddressBalances(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.checkWalletAddressBalances(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An address that has never been used has no balance.
+	uh := types.UnlockHash{1, 2, 3}
+	if _, exists := et.explorer.AddressBalance(uh, et.cs.Height()); exists {
+		t.Fatal("unused address should not have a balance")
+	}
+
+	// Send coins to the address twice.
+	amount := types.SiacoinPrecision.Mul64(100)
+	var heights []types.BlockHeight
+	for i := 0; i < 2; i++ {
+		if _, err := et.wallet.SendSiacoins(amount, uh); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+		heights = append(heights, et.cs.Height())
+	}
+	if err := et.checkWalletAddressBalances(); err != nil {
+		t.Fatal(err)
+	}
+
+	ab, exists := et.explorer.AddressBalance(uh, et.cs.Height())
+	if !exists {
+		t.Fatal("address should have a balance")
+	}
+	if !ab.SiacoinBalance.Equals(amount.Mul64(2)) || !ab.SiafundBalance.IsZero() {
+		t.Fatal("wrong balance", ab.SiacoinBalance, ab.SiafundBalance)
+	}
+	if ab.FirstSeen != heights[0] || ab.LastSeen != heights[1] {
+		t.Fatal("wrong first or last seen height", ab.FirstSeen, ab.LastSeen)
+	}
+
+	// Check the historic balances.
+	ab, _ = et.explorer.AddressBalance(uh, heights[0])
+	if !ab.SiacoinBalance.Equals(amount) || ab.Height != heights[0] {
+		t.Fatal("wrong balance after the first send", ab.SiacoinBalance)
+	}
+	ab, _ = et.explorer.AddressBalance(uh, heights[0]-1)
+	if !ab.SiacoinBalance.IsZero() {
+		t.Fatal("address should have no balance before it was first seen", ab.SiacoinBalance)
+	}
+
+	// Page through the outputs one at a time.
+	var outputs []modules.UnspentOutput
+	var after types.OutputID
+	for {
+		page := et.explorer.AddressOutputs(uh, after, 1)
+		if len(page) == 0 {
+			break
+		}
+		outputs = append(outputs, page...)
+		after = page[0].ID
+	}
+	if len(outputs) != 2 {
+		t.Fatal("expected 2 outputs, got", len(outputs))
+	}
+	confirmations := make(map[types.BlockHeight]bool)
+	for _, uo := range outputs {
+		if uo.FundType != types.SpecifierSiacoinOutput || uo.UnlockHash != uh || !uo.Value.Equals(amount) {
+			t.Fatal("wrong output", uo)
+		}
+		confirmations[uo.ConfirmationHeight] = true
+	}
+	if !confirmations[heights[0]] || !confirmations[heights[1]] {
+		t.Fatal("wrong confirmation heights", outputs)
+	}
+}
+
+// TestExplorerAddressBalancesReorg checks that the address balances and
+// outputs are updated correctly when blocks are reverted.
+func TestExplorerAddressBalancesReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et1, err := createExplorerTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	et2, err := createExplorerTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send coins to an address on the first chain.
+	uh := types.UnlockHash{1, 2, 3}
+	if _, err := et1.wallet.SendSiacoins(types.SiacoinPrecision, uh); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et1.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := et1.explorer.AddressBalance(uh, et1.cs.Height()); !exists {
+		t.Fatal("address should have a balance")
+	}
+
+	// Mine a longer chain on the second tester and reorg the first tester to
+	// it.
+	for et2.cs.Height() <= et1.cs.Height() {
+		if _, err := et2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := et1.gateway.Connect(et2.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if et1.cs.CurrentBlock().ID() != et2.cs.CurrentBlock().ID() {
+			return errors.New("testers did not sync")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The address no longer has a balance and the balances of the wallet
+	// addresses of the first tester have been reverted.
+	if _, exists := et1.explorer.AddressBalance(uh, et1.cs.Height()); exists {
+		t.Fatal("address should not have a balance after the reorg")
+	}
+	if outputs := et1.explorer.AddressOutputs(uh, types.OutputID{}, 10); len(outputs) != 0 {
+		t.Fatal("address should not have outputs after the reorg")
+	}
+	if err := et1.checkWalletAddressBalances(); err != nil {
+		t.Fatal(err)
+	}
+	if err := et2.checkWalletAddressBalances(); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := et1.wallet.AllAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range addrs {
+		if _, exists := et1.explorer.AddressBalance(addr, et1.cs.Height()); exists {
+			t.Fatal("address of the reverted chain should not have a balance")
+		}
+	}
+}
+
+// TestExplorerHostAnalytics checks that the explorer indexes host
+// announcements and attributes file contracts to hosts.
+func TestExplorerHostAnalytics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	et2, err := createExplorerTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for et.cs.Height() <= 10 {
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	renterSK, renterPK := crypto.GenerateKeyPair()
+	hostSK, hostPK := crypto.GenerateKeyPair()
+	hostKey := types.Ed25519PublicKey(hostPK)
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(renterPK), hostKey},
+		SignaturesRequired: 2,
+	}
+
+	// Announce the host.
+	ann, err := modules.CreateAnnouncement("foo.com:1234", hostKey, hostSK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder, err := et.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.FundSiacoins(types.SiacoinPrecision); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddMinerFee(types.SiacoinPrecision)
+	builder.AddArbitraryData(ann)
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	anns := et.explorer.HostAnnouncements(hostKey)
+	if len(anns) != 1 || anns[0].NetAddress != "foo.com:1234" || anns[0].Height != et.cs.Height() || anns[0].TransactionID != txnSet[len(txnSet)-1].ID() {
+		t.Fatal("wrong announcements", anns)
+	}
+
+	// Form two contracts with the host.
+	file := fastrand.Bytes(4e3)
+	payout := types.SiacoinPrecision.Mul64(10)
+	hostOutput := types.SiacoinPrecision
+	outputs := []types.SiacoinOutput{
+		{Value: types.PostTax(et.cs.Height(), payout).Sub(hostOutput)},
+		{Value: hostOutput},
+	}
+	fc := types.FileContract{
+		FileSize:           uint64(len(file)),
+		FileMerkleRoot:     crypto.MerkleRoot(file),
+		WindowStart:        et.cs.Height() + 4,
+		WindowEnd:          et.cs.Height() + 6,
+		Payout:             payout,
+		ValidProofOutputs:  outputs,
+		MissedProofOutputs: outputs,
+		UnlockHash:         uc.UnlockHash(),
+	}
+	builder, err = et.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.FundSiacoins(payout.Mul64(2)); err != nil {
+		t.Fatal(err)
+	}
+	builder.AddFileContract(fc)
+	builder.AddFileContract(fc)
+	txnSet, err = builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	fcids := []types.FileContractID{txnSet[len(txnSet)-1].FileContractID(0), txnSet[len(txnSet)-1].FileContractID(1)}
+
+	// The contracts are not attributed to the host until they are revised.
+	if _, exists := et.explorer.HostContractStats(hostKey); exists {
+		t.Fatal("unrevised contracts should not be attributed to the host")
+	}
+	revisionTxn := types.Transaction{}
+	for i, fcid := range fcids {
+		revisionTxn.FileContractRevisions = append(revisionTxn.FileContractRevisions, types.FileContractRevision{
+			ParentID:              fcid,
+			UnlockConditions:      uc,
+			NewRevisionNumber:     1,
+			NewFileSize:           fc.FileSize,
+			NewFileMerkleRoot:     fc.FileMerkleRoot,
+			NewWindowStart:        fc.WindowStart,
+			NewWindowEnd:          fc.WindowEnd,
+			NewValidProofOutputs:  fc.ValidProofOutputs,
+			NewMissedProofOutputs: fc.MissedProofOutputs,
+			NewUnlockHash:         fc.UnlockHash,
+		})
+		for j := uint64(0); j < 2; j++ {
+			revisionTxn.TransactionSignatures = append(revisionTxn.TransactionSignatures, types.TransactionSignature{
+				ParentID:       crypto.Hash(fcid),
+				PublicKeyIndex: j,
+				CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{uint64(i)}},
+			})
+		}
+	}
+	for i := range revisionTxn.TransactionSignatures {
+		sk := renterSK
+		if revisionTxn.TransactionSignatures[i].PublicKeyIndex == 1 {
+			sk = hostSK
+		}
+		sig := crypto.SignHash(revisionTxn.SigHash(i, et.cs.Height()), sk)
+		revisionTxn.TransactionSignatures[i].Signature = sig[:]
+	}
+	if err := et.tpool.AcceptTransactionSet([]types.Transaction{revisionTxn}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	revisionHeight := et.cs.Height()
+	hcs, exists := et.explorer.HostContractStats(hostKey)
+	if !exists {
+		t.Fatal("revised contracts should be attributed to the host")
+	}
+	if hcs.Height != revisionHeight || hcs.ContractCount != 2 || hcs.ActiveContractCount != 2 || !hcs.ActiveContractValue.Equals(payout.Mul64(2)) || !hcs.CollateralPosted.Equals(hostOutput.Mul64(2)) {
+		t.Fatal("wrong stats after revision", hcs)
+	}
+
+	// Submit a storage proof for the first contract and let the second
+	// contract expire.
+	for et.cs.Height() < fc.WindowStart {
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	segmentIndex, err := et.cs.StorageProofSegment(fcids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	segment, hashSet := crypto.MerkleProof(file, segmentIndex)
+	sp := types.StorageProof{
+		ParentID: fcids[0],
+		HashSet:  hashSet,
+	}
+	copy(sp.Segment[:], segment)
+	builder, err = et.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.AddStorageProof(sp)
+	txnSet, err = builder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := et.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	for et.cs.Height() <= fc.WindowEnd {
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hcs, _ = et.explorer.HostContractStats(hostKey)
+	if hcs.ContractCount != 2 || hcs.ActiveContractCount != 0 || !hcs.ActiveContractValue.IsZero() || hcs.StorageProofCount != 1 || hcs.MissedProofCount != 1 {
+		t.Fatal("wrong stats after resolution", hcs)
+	}
+	history := et.explorer.HostContractStatsHistory(hostKey, 0, et.cs.Height())
+	if len(history) != 3 || history[0].Height != revisionHeight || history[0].ActiveContractCount != 2 || history[2].MissedProofCount != 1 {
+		t.Fatal("wrong stats history", history)
+	}
+
+	// Reorg to a chain without the host. Its announcements and stats are
+	// removed.
+	for et2.cs.Height() <= et.cs.Height() {
+		if _, err := et2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := et.gateway.Connect(et2.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if et.cs.CurrentBlock().ID() != et2.cs.CurrentBlock().ID() {
+			return errors.New("testers did not sync")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anns := et.explorer.HostAnnouncements(hostKey); len(anns) != 0 {
+		t.Fatal("announcements should be reverted", anns)
+	}
+	if _, exists := et.explorer.HostContractStats(hostKey); exists {
+		t.Fatal("stats should be reverted")
+	}
+	err = et.explorer.db.View(func(tx *bolt.Tx) error {
+		for _, fcid := range fcids {
+			if tx.Bucket(bucketContractHosts).Get(encoding.Marshal(fcid)) != nil {
+				return errors.New("contract attribution should be reverted")
+			}
+		}
+		if !bucketIsEmpty(tx.Bucket(bucketHeightHosts)) {
+			return errors.New("height hosts should be reverted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -13,6 +14,23 @@ const (
 	GatewayDir = "gateway"
 )
 
+const (
+	// MisbehaviourInvalidBlock is reported when a peer relays a block or
+	// header that fails validation.
+	MisbehaviourInvalidBlock PeerMisbehaviour = "invalid block"
+
+	// MisbehaviourHandshake is reported when a peer sends an unacceptable
+	// session header during the handshake.
+	MisbehaviourHandshake PeerMisbehaviour = "failed handshake"
+
+	// MisbehaviourRPCTimeout is reported when an RPC with a peer times out.
+	MisbehaviourRPCTimeout PeerMisbehaviour = "rpc timeout"
+
+	// MisbehaviourShareNodes is reported when a peer responds to the
+	// ShareNodes RPC with too many or invalid addresses.
+	MisbehaviourShareNodes PeerMisbehaviour = "spammy ShareNodes response"
+)
+
 var (
 	// BootstrapPeers is a list of peers that can be used to find other peers -
 	// when a client first connects to the network, the only options for
@@ -118,8 +136,43 @@ type (
 		Local      bool       `json:"local"`
 		NetAddress NetAddress `json:"netaddress"`
 		Version    string     `json:"version"`
+
+		// Score is the misbehaviour score of the peer's host. Hosts that
+		// reach the ban threshold of the gateway are banned temporarily.
+		Score int `json:"score"`
+
+		// Stats contains the activity of the peer's connection.
+		Stats PeerStats `json:"stats"`
+	}
+
+	// PeerStats contains statistics about the connection to a peer. The RPC
+	// counts are keyed by RPC name.
+	PeerStats struct {
+		BytesReceived    uint64            `json:"bytesreceived"`
+		BytesSent        uint64            `json:"bytessent"`
+		RPCsCalled       map[string]uint64 `json:"rpcscalled"`
+		RPCsHandled      map[string]uint64 `json:"rpcshandled"`
+		ConnectedSince   time.Time         `json:"connectedsince"`
+		DisconnectedAt   time.Time         `json:"disconnectedat"`
+		HandshakeLatency time.Duration     `json:"handshakelatency"`
+
+		// LastBlockRelayed is the most recent block received from the peer
+		// that extended the blockchain.
+		LastBlockRelayed     types.BlockID `json:"lastblockrelayed"`
+		LastBlockRelayedTime time.Time     `json:"lastblockrelayedtime"`
 	}
 
+	// PeerBan is a temporary ban of a host that misbehaved.
+	PeerBan struct {
+		Host   string           `json:"host"`
+		Reason PeerMisbehaviour `json:"reason"`
+		Expiry time.Time        `json:"expiry"`
+	}
+
+	// PeerMisbehaviour describes an offence committed by a peer. Each kind of
+	// offence adds a different amount to the misbehaviour score of the peer.
+	PeerMisbehaviour string
+
 	// A PeerConn is the connection type used when communicating with peers during
 	// an RPC. It is identical to a net.Conn with the additional RPCAddr method.
 	// This method acts as an identifier for peers and is the address that the
@@ -183,6 +236,18 @@ type (
 		// SetBlocklist sets the blocklist of the gateway
 		SetBlocklist(addresses []string) error
 
+		// Bans returns the hosts that are temporarily banned for misbehaving.
+		Bans() ([]PeerBan, error)
+
+		// ReportMisbehaviour adds to the misbehaviour score of the host of the
+		// given address. Hosts that reach the ban threshold are disconnected
+		// and banned temporarily.
+		ReportMisbehaviour(addr NetAddress, m PeerMisbehaviour)
+
+		// ReportBlockRelayed records that the peer at the given address
+		// relayed a block that extended the blockchain.
+		ReportBlockRelayed(addr NetAddress, id types.BlockID)
+
 		// Address returns the Gateway's address.
 		Address() NetAddress
 
@@ -190,6 +255,10 @@ type (
 		// to.
 		Peers() []Peer
 
+		// PeerHistory returns the most recently disconnected peers, newest
+		// first, along with the stats of their connections.
+		PeerHistory() []Peer
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
@@ -30,6 +30,16 @@ func newLocalAddr(addr modules.NetAddress) *net.TCPAddr {
 	}
 }
 
+// dialableHost returns true if the host of addr can be dialed by the gateway.
+// Peers are identified by their IP address, with the exception of onion
+// services, which can be dialed if a SOCKS5 proxy is set.
+func dialableHost(addr modules.NetAddress) bool {
+	if addr.IsOnion() {
+		return modules.GlobalProxy.Address() != ""
+	}
+	return net.ParseIP(addr.Host()) != nil
+}
+
 // staticDial will staticDial the input address and return a connection.
 // staticDial appropriately handles things like clean shutdown, fast shutdown,
 // and chooses the correct communication protocol.
@@ -44,7 +54,7 @@ func (g *Gateway) staticDial(addr modules.NetAddress) (net.Conn, error) {
 		dialer.LocalAddr = newLocalAddr(g.myAddr)
 	}
 
-	conn, err := dialer.Dial("tcp", string(addr))
+	conn, err := modules.GlobalProxy.Dial(dialer, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -290,3 +290,50 @@ var (
 		Testing:  100 * time.Millisecond,
 	}).(time.Duration)
 )
+
+const (
+	// banScoreThreshold is the misbehaviour score at which a host is
+	// disconnected and banned.
+	banScoreThreshold = 100
+)
+
+var (
+	// banDuration is the amount of time a host is banned for after reaching
+	// the banScoreThreshold.
+	banDuration = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Testnet:  24 * time.Hour,
+		Dev:      10 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// scoreDecayInterval is the amount of time after which the misbehaviour
+	// score of a host decreases by one point. This allows hosts that misbehave
+	// rarely, e.g. because of an unreliable connection, to never be banned.
+	scoreDecayInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Testnet:  time.Minute,
+		Dev:      10 * time.Second,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// misbehaviourPenalties are the amounts that the different kinds of
+	// misbehaviour add to the score of a host.
+	misbehaviourPenalties = map[modules.PeerMisbehaviour]int{
+		modules.MisbehaviourInvalidBlock: 50,
+		modules.MisbehaviourHandshake:    25,
+		modules.MisbehaviourRPCTimeout:   10,
+		modules.MisbehaviourShareNodes:   25,
+	}
+)
+
+var (
+	// maxPeerHistory is the number of disconnected peers for which the
+	// gateway keeps the stats of their connection.
+	maxPeerHistory = build.Select(build.Var{
+		Standard: 50,
+		Testnet:  50,
+		Dev:      20,
+		Testing:  5,
+	}).(int)
+)
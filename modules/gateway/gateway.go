@@ -123,11 +123,13 @@ type Gateway struct {
 	port     string
 	rl       *ratelimit.RateLimit
 
-	// handlers are the RPCs that the Gateway can handle.
+	// handlers are the RPCs that the Gateway can handle, and handlerNames
+	// are the names they were registered with.
 	//
 	// initRPCs are the RPCs that the Gateway calls upon connecting to a peer.
-	handlers map[rpcID]modules.RPCFunc
-	initRPCs map[string]modules.RPCFunc
+	handlers     map[rpcID]modules.RPCFunc
+	handlerNames map[rpcID]string
+	initRPCs     map[string]modules.RPCFunc
 
 	// blocklist are peers that the gateway shouldn't connect to
 	//
@@ -151,6 +153,17 @@ type Gateway struct {
 	peers     map[modules.NetAddress]*peer
 	peerTG    threadgroup.ThreadGroup
 
+	// bans are hosts that are temporarily banned because their misbehaviour
+	// score reached banScoreThreshold.
+	//
+	// scores are the misbehaviour scores of hosts that misbehaved recently.
+	bans   map[string]modules.PeerBan
+	scores map[string]peerScore
+
+	// peerHistory contains the most recently disconnected peers, oldest
+	// first.
+	peerHistory []modules.Peer
+
 	// Utilities.
 	log           *persist.Logger
 	mu            sync.RWMutex
@@ -173,24 +186,7 @@ func (g *Gateway) addToBlocklist(addresses []string) error {
 	// Add addresses to the blocklist and disconnect from them
 	var err error
 	for _, addr := range addresses {
-		// Check Gateway peer map for address
-		for peerAddr, peer := range g.peers {
-			// If the address corresponds with a peer, close the peer session
-			// and remove the peer from the peer map
-			if peerAddr.Host() == addr {
-				err = errors.Compose(err, peer.sess.Close())
-				delete(g.peers, peerAddr)
-			}
-		}
-		// Check Gateway node map for address
-		for nodeAddr := range g.nodes {
-			// If the address corresponds with a node remove the node from the
-			// node map to prevent the node from being re-connected while
-			// looking for a replacement peer
-			if nodeAddr.Host() == addr {
-				delete(g.nodes, nodeAddr)
-			}
-		}
+		err = errors.Compose(err, g.dropHost(addr))
 
 		// Add address to the blocklist
 		g.blocklist[addr] = struct{}{}
@@ -198,6 +194,31 @@ func (g *Gateway) addToBlocklist(addresses []string) error {
 	return errors.Compose(err, g.saveSync())
 }
 
+// dropHost disconnects from all peers on the given host and removes its nodes
+// from the node list.
+func (g *Gateway) dropHost(host string) error {
+	var err error
+	// Check Gateway peer map for address
+	for peerAddr, peer := range g.peers {
+		// If the address corresponds with a peer, close the peer session
+		// and remove the peer from the peer map
+		if peerAddr.Host() == host {
+			err = errors.Compose(err, peer.sess.Close())
+			delete(g.peers, peerAddr)
+		}
+	}
+	// Check Gateway node map for address
+	for nodeAddr := range g.nodes {
+		// If the address corresponds with a node remove the node from the
+		// node map to prevent the node from being re-connected while
+		// looking for a replacement peer
+		if nodeAddr.Host() == host {
+			delete(g.nodes, nodeAddr)
+		}
+	}
+	return err
+}
+
 // managedSleep will sleep for the given period of time. If the full time
 // elapses, 'true' is returned. If the sleep is interrupted for shutdown,
 // 'false' is returned.
@@ -315,9 +336,10 @@ func (g *Gateway) RemoveFromBlocklist(addresses []string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	// Remove addresses from the blocklist
+	// Remove addresses from the blocklist and lift their bans
 	for _, addr := range addresses {
 		delete(g.blocklist, addr)
+		delete(g.bans, addr)
 	}
 	return g.saveSync()
 }
@@ -373,10 +395,13 @@ func NewCustomGateway(addr string, bootstrap bool, useUPNP bool, persistDir stri
 	}
 
 	g := &Gateway{
-		handlers: make(map[rpcID]modules.RPCFunc),
-		initRPCs: make(map[string]modules.RPCFunc),
+		handlers:     make(map[rpcID]modules.RPCFunc),
+		handlerNames: make(map[rpcID]string),
+		initRPCs:     make(map[string]modules.RPCFunc),
 
 		blocklist: make(map[string]struct{}),
+		bans:      make(map[string]modules.PeerBan),
+		scores:    make(map[string]peerScore),
 		nodes:     make(map[modules.NetAddress]*node),
 		peers:     make(map[modules.NetAddress]*peer),
 
@@ -2,7 +2,6 @@ package gateway
 
 import (
 	"fmt"
-	"net"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -34,7 +33,7 @@ func (g *Gateway) addNode(addr modules.NetAddress) error {
 		return errNodeExists
 	} else if addr.IsStdValid() != nil {
 		return errors.New("address is not valid: " + string(addr))
-	} else if net.ParseIP(addr.Host()) == nil {
+	} else if !dialableHost(addr) {
 		return errors.New("address must be an IP address: " + string(addr))
 	}
 	g.nodes[addr] = &node{
@@ -175,12 +174,20 @@ func (g *Gateway) requestNodes(conn modules.PeerConn) error {
 		return err
 	}
 
+	// Honest peers never share more than maxSharedNodes nodes, so the excess
+	// is ignored and the peer is penalized.
+	spammy := uint64(len(nodes)) > maxSharedNodes
+	if spammy {
+		nodes = nodes[:maxSharedNodes]
+	}
+
 	g.mu.Lock()
 	changed := false
 	for _, node := range nodes {
 		err := g.addNode(node)
 		if err != nil && !errors.Contains(err, errNodeExists) && !errors.Contains(err, errOurAddress) {
 			g.log.Printf("WARN: peer '%v' sent the invalid addr '%v'", conn.RPCAddr(), node)
+			spammy = true
 		}
 		if err == nil {
 			changed = true
@@ -193,6 +200,9 @@ func (g *Gateway) requestNodes(conn modules.PeerConn) error {
 		}
 	}
 	g.mu.Unlock()
+	if spammy {
+		g.managedReportMisbehaviour(conn.RPCAddr(), modules.MisbehaviourShareNodes)
+	}
 	return nil
 }
 
@@ -1,11 +1,11 @@
 package gateway
 
 import (
-	"errors"
 	"fmt"
 	"net"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	connmonitor "gitlab.com/NebulousLabs/monitor"
 	"gitlab.com/NebulousLabs/ratelimit"
@@ -17,8 +17,10 @@ import (
 )
 
 var (
-	errPeerExists       = errors.New("already connected to this peer")
-	errPeerRejectedConn = errors.New("peer rejected connection")
+	errPeerBanned          = errors.New("peer is banned for misbehaving")
+	errPeerExists          = errors.New("already connected to this peer")
+	errPeerRejectedConn    = errors.New("peer rejected connection")
+	errInvalidRemoteHeader = errors.New("invalid remote address")
 
 	// ErrPeerNotConnected is returned when trying to disconnect from a peer
 	// that the gateway is not connected to.
@@ -44,6 +46,7 @@ func (s invalidVersionError) Error() string {
 type peer struct {
 	modules.Peer
 	m    *connmonitor.Monitor
+	ps   peerStats
 	rl   *ratelimit.RateLimit
 	sess streamSession
 }
@@ -163,12 +166,18 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 
 	g.mu.RLock()
 	_, exists := g.blocklist[addr.Host()]
+	banned := g.banned(addr.Host())
 	g.mu.RUnlock()
 	if exists {
 		g.log.Debugf("INFO: %v was rejected. (blocklisted)", addr)
 		conn.Close()
 		return
+	} else if banned {
+		g.log.Debugf("INFO: %v was rejected. (banned)", addr)
+		conn.Close()
+		return
 	}
+	handshakeStart := time.Now()
 	remoteVersion, err := acceptVersionHandshake(conn, ProtocolVersion)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -177,11 +186,14 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 	}
 
 	if err = acceptableVersion(remoteVersion); err == nil {
-		err = g.managedAcceptConnPeer(conn, remoteVersion)
+		err = g.managedAcceptConnPeer(conn, remoteVersion, handshakeStart)
 	}
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect, but failed: %v", addr, err)
 		conn.Close()
+		if isMisbehavingHeaderErr(err) {
+			g.managedReportMisbehaviour(addr, modules.MisbehaviourHandshake)
+		}
 		return
 	}
 
@@ -199,15 +211,22 @@ func acceptableSessionHeader(ourHeader, remoteHeader sessionHeader, remoteAddr s
 	} else if remoteHeader.UniqueID == ourHeader.UniqueID {
 		return errOurAddress
 	} else if err := remoteHeader.NetAddress.IsStdValid(); err != nil {
-		return fmt.Errorf("invalid remote address: %v", err)
+		return errors.Compose(errInvalidRemoteHeader, err)
 	}
 	return nil
 }
 
+// isMisbehavingHeaderErr returns true if a handshake failed because the remote
+// peer sent a session header that an honest peer wouldn't send.
+func isMisbehavingHeaderErr(err error) bool {
+	return errors.Contains(err, errPeerGenesisID) || errors.Contains(err, errInvalidRemoteHeader)
+}
+
 // managedAcceptConnPeer accepts connection requests from peers >= v1.3.1.
 // The requesting peer is added as a node and a peer. The peer is only added if
-// a nil error is returned.
-func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) error {
+// a nil error is returned. handshakeStart is the time at which the version
+// handshake began.
+func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string, handshakeStart time.Time) error {
 	g.log.Debugln("Attempting to Accept Connection from Peer; Sending sessionHeader with address", g.myAddr, g.myAddr.IsLocal())
 	// Perform header handshake.
 	g.mu.RLock()
@@ -237,7 +256,9 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	remoteAddr := modules.NetAddress(net.JoinHostPort(remoteIP, remotePort))
 	g.log.Debugln("Making connection with remote peer", remoteAddr)
 
-	// Accept the peer.
+	// Accept the peer. Its traffic is monitored separately from the rest of
+	// the gateway's.
+	m := connmonitor.NewMonitor()
 	peer := &peer{
 		Peer: modules.Peer{
 			Inbound: true,
@@ -249,9 +270,13 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 			NetAddress: remoteAddr,
 			Version:    remoteVersion,
 		},
-		m:    g.m,
+		m: m,
+		ps: peerStats{
+			connected:        m.StartTime(),
+			handshakeLatency: time.Since(handshakeStart),
+		},
 		rl:   rl,
-		sess: newServerStream(conn, remoteVersion),
+		sess: newServerStream(connmonitor.NewMonitoredConn(conn, m), remoteVersion),
 	}
 	g.mu.Lock()
 	g.acceptPeer(peer)
@@ -403,7 +428,7 @@ func exchangeRemoteHeader(conn net.Conn, ourHeader sessionHeader) (sessionHeader
 	err := acceptableSessionHeader(ourHeader, remoteHeader, conn.RemoteAddr().String())
 	if err != nil {
 		encoding.WriteObject(conn, err.Error()) // error can be ignored
-		return sessionHeader{}, fmt.Errorf("peer's header was not acceptable: %v", err)
+		return sessionHeader{}, errors.AddContext(err, "peer's header was not acceptable")
 	} else if err := encoding.WriteObject(conn, modules.AcceptResponse); err != nil {
 		return sessionHeader{}, fmt.Errorf("failed to write header acceptance: %v", err)
 	}
@@ -450,19 +475,24 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
 	}
-	if net.ParseIP(addr.Host()) == nil {
+	if !dialableHost(addr) {
 		err := errors.New("address must be an IP address")
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
 	}
-	if _, exists := g.blocklist[addr.Host()]; exists {
+	g.mu.RLock()
+	_, blocklisted := g.blocklist[addr.Host()]
+	banned := g.banned(addr.Host())
+	_, exists := g.peers[addr]
+	g.mu.RUnlock()
+	if blocklisted {
 		err := errors.New("can't connect to blocklisted address")
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
+	} else if banned {
+		g.log.Debugln("Unable to connect to", addr, "error:", errPeerBanned)
+		return errPeerBanned
 	}
-	g.mu.RLock()
-	_, exists := g.peers[addr]
-	g.mu.RUnlock()
 	if exists {
 		g.log.Debugln("Unable to connect to", addr, "error:", errPeerExists)
 		return errPeerExists
@@ -477,6 +507,7 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	g.log.Debugln("Created conn; remote and local addr", conn.RemoteAddr(), conn.LocalAddr())
 
 	// Perform peer initialization.
+	handshakeStart := time.Now()
 	remoteVersion, err := connectVersionHandshake(conn, ProtocolVersion)
 	if err != nil {
 		conn.Close()
@@ -490,12 +521,17 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	if err != nil {
 		conn.Close()
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
+		if isMisbehavingHeaderErr(err) {
+			g.managedReportMisbehaviour(addr, modules.MisbehaviourHandshake)
+		}
 		return err
 	}
 
 	// Connection successful, clear the timeout as to maintain a persistent
 	// connection to this peer.
 	conn.SetDeadline(time.Time{})
+	handshakeLatency := time.Since(handshakeStart)
+	m := connmonitor.NewMonitor()
 
 	// Add the peer.
 	g.mu.Lock()
@@ -508,9 +544,13 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 			NetAddress: addr,
 			Version:    remoteVersion,
 		},
-		m:    g.m,
+		m: m,
+		ps: peerStats{
+			connected:        m.StartTime(),
+			handshakeLatency: handshakeLatency,
+		},
 		rl:   g.rl,
-		sess: newClientStream(conn, remoteVersion),
+		sess: newClientStream(connmonitor.NewMonitoredConn(conn, m), remoteVersion),
 	})
 	g.addNode(addr)
 	g.nodes[addr].WasOutboundPeer = true
@@ -569,14 +609,18 @@ func (g *Gateway) Disconnect(addr modules.NetAddress) error {
 
 // ConnectManual is a wrapper for the Connect function. It is specifically used
 // if a user wants to connect to a node manually. This also removes the node
-// from the blocklist.
+// from the blocklist and lifts its ban.
 func (g *Gateway) ConnectManual(addr modules.NetAddress) error {
 	g.log.Debugln("Attempting to Manually Connect to", addr)
 	g.mu.Lock()
 	var err error
-	if _, exists := g.blocklist[addr.Host()]; exists {
+	_, blocklisted := g.blocklist[addr.Host()]
+	_, banned := g.bans[addr.Host()]
+	if blocklisted || banned {
 		g.log.Debugln("Removing", addr, "from the blocklist due to Manually trying to Connect")
 		delete(g.blocklist, addr.Host())
+		delete(g.bans, addr.Host())
+		delete(g.scores, addr.Host())
 		err = g.saveSync()
 	}
 	g.mu.Unlock()
@@ -630,7 +674,10 @@ func (g *Gateway) Peers() []modules.Peer {
 	defer g.mu.RUnlock()
 	var peers []modules.Peer
 	for _, p := range g.peers {
-		peers = append(peers, p.Peer)
+		peer := p.Peer
+		peer.Score = g.hostScore(peer.NetAddress.Host())
+		peer.Stats = p.stats()
+		peers = append(peers, peer)
 	}
 	return peers
 }
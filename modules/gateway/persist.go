@@ -37,6 +37,9 @@ type (
 
 		// blocklisted IPs
 		Blocklist []string
+
+		// temporarily banned hosts
+		Bans []modules.PeerBan
 	}
 )
 
@@ -94,6 +97,12 @@ func (g *Gateway) load() error {
 	for _, ip := range g.persist.Blocklist {
 		g.blocklist[ip] = struct{}{}
 	}
+	// create map from unexpired bans
+	for _, ban := range g.persist.Bans {
+		if time.Now().Before(ban.Expiry) {
+			g.bans[ban.Host] = ban
+		}
+	}
 	return nil
 }
 
@@ -104,6 +113,7 @@ func (g *Gateway) saveSync() error {
 	for ip := range g.blocklist {
 		g.persist.Blocklist = append(g.persist.Blocklist, ip)
 	}
+	g.persist.Bans = g.activeBans()
 	return persist.SaveJSON(persistMetadata, g.persist, filepath.Join(g.persistDir, persistFilename))
 }
 
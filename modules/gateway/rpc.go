@@ -1,6 +1,8 @@
 package gateway
 
 import (
+	stderrors "errors"
+	"net"
 	"sync"
 	"time"
 
@@ -33,6 +35,25 @@ func handlerName(name string) (id rpcID) {
 	return
 }
 
+// isTimeoutErr returns true if err was caused by a network timeout. err may be
+// composed or extended with context, in which case any of its underlying
+// errors may be the timeout.
+func isTimeoutErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if set, ok := err.(errors.Error); ok {
+		for _, err := range set.ErrSet {
+			if isTimeoutErr(err) {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr) && netErr.Timeout()
+}
+
 // managedRPC calls an RPC on the given address. managedRPC cannot be called on
 // an address that the Gateway is not connected to.
 func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RPCFunc) (err error) {
@@ -43,6 +64,7 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 		return errors.New("can't call RPC on unconnected peer " + string(addr))
 	}
 
+	peer.ps.addRPCCalled(name)
 	conn, err := peer.open()
 	if err != nil {
 		// peer probably disconnected without sending a shutdown signal;
@@ -58,6 +80,14 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 		err = errors.Compose(err, conn.Close())
 	}()
 
+	// Peers that time out are penalized. SendBlocks is exempt because it
+	// times out by design during IBD to rotate between peers.
+	defer func() {
+		if isTimeoutErr(err) && name != "SendBlocks" {
+			g.managedReportMisbehaviour(addr, modules.MisbehaviourRPCTimeout)
+		}
+	}()
+
 	// write header
 	conn.SetDeadline(time.Now().Add(rpcStdDeadline))
 	if err := encoding.WriteObject(conn, handlerName(name)); err != nil {
@@ -95,6 +125,7 @@ func (g *Gateway) RegisterRPC(name string, fn modules.RPCFunc) {
 		build.Critical("RPC already registered: " + name)
 	}
 	g.handlers[handlerName(name)] = fn
+	g.handlerNames[handlerName(name)] = name
 }
 
 // UnregisterRPC unregisters an RPC and removes the corresponding RPCFunc from
@@ -106,6 +137,7 @@ func (g *Gateway) UnregisterRPC(name string) {
 		build.Critical("RPC not registered: " + name)
 	}
 	delete(g.handlers, handlerName(name))
+	delete(g.handlerNames, handlerName(name))
 }
 
 // RegisterConnectCall registers a name and RPCFunc to be called on a peer
@@ -162,10 +194,11 @@ func (g *Gateway) threadedListenPeer(p *peer) {
 		case <-peerCloseChan:
 		}
 
-		// Close the session and remove p from the peer list.
+		// Close the session, remove p from the peer list and keep its stats.
 		p.sess.Close()
 		g.mu.Lock()
 		delete(g.peers, p.NetAddress)
+		g.addPeerHistory(p)
 		g.mu.Unlock()
 	}()
 
@@ -218,7 +251,12 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	// call registered handler for this ID
 	g.mu.RLock()
 	fn, ok := g.handlers[id]
+	name := g.handlerNames[id]
+	p, connected := g.peers[conn.RPCAddr()]
 	g.mu.RUnlock()
+	if ok && connected {
+		p.ps.addRPCHandled(name)
+	}
 	if !ok {
 		g.log.Debugf("WARN: incoming conn %v requested unknown RPC \"%v\"", conn.RPCAddr(), id)
 		return
@@ -0,0 +1,110 @@
+package gateway
+
+import (
+	"sort"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// peerScore is the misbehaviour score of a host.
+type peerScore struct {
+	score   int
+	updated time.Time
+}
+
+// current returns the score after applying the decay since the last update.
+func (ps peerScore) current() int {
+	score := ps.score - int(time.Since(ps.updated)/scoreDecayInterval)
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// activeBans returns the bans that haven't expired yet, sorted by host. The
+// caller must hold the lock of the gateway.
+func (g *Gateway) activeBans() []modules.PeerBan {
+	bans := make([]modules.PeerBan, 0, len(g.bans))
+	for _, ban := range g.bans {
+		if time.Now().Before(ban.Expiry) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Host < bans[j].Host
+	})
+	return bans
+}
+
+// banned returns true if the host is banned. The caller must hold the lock of
+// the gateway.
+func (g *Gateway) banned(host string) bool {
+	ban, exists := g.bans[host]
+	return exists && time.Now().Before(ban.Expiry)
+}
+
+// hostScore returns the current misbehaviour score of a host. The caller must
+// hold the lock of the gateway.
+func (g *Gateway) hostScore(host string) int {
+	return g.scores[host].current()
+}
+
+// managedReportMisbehaviour adds the penalty of m to the score of the host of
+// addr, and bans the host if its score reaches banScoreThreshold. Local hosts
+// are never scored, since banning their IP would ban every peer behind it.
+func (g *Gateway) managedReportMisbehaviour(addr modules.NetAddress, m modules.PeerMisbehaviour) {
+	if addr.IsLocal() && !g.staticDeps.Disrupt("ScoreLocalPeers") {
+		g.log.Debugf("INFO: local peer %v misbehaved (%v), not scoring it", addr, m)
+		return
+	}
+	host := addr.Host()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, blocklisted := g.blocklist[host]; blocklisted || g.banned(host) {
+		return
+	}
+
+	score := g.hostScore(host) + misbehaviourPenalties[m]
+	g.log.Debugf("INFO: peer %v misbehaved (%v), score is now %v", addr, m, score)
+	if score < banScoreThreshold {
+		g.scores[host] = peerScore{score: score, updated: time.Now()}
+		return
+	}
+
+	delete(g.scores, host)
+	g.bans[host] = modules.PeerBan{
+		Host:   host,
+		Reason: m,
+		Expiry: time.Now().Add(banDuration),
+	}
+	g.log.Printf("INFO: banning %v until %v because of misbehaviour (%v)", host, g.bans[host].Expiry.Format(time.RFC3339), m)
+	if err := g.dropHost(host); err != nil {
+		g.log.Println("WARN: failed to disconnect from banned host:", err)
+	}
+	if err := g.saveSync(); err != nil {
+		g.log.Println("ERROR: unable to save ban:", err)
+	}
+}
+
+// Bans returns the hosts that are temporarily banned for misbehaving.
+func (g *Gateway) Bans() ([]modules.PeerBan, error) {
+	if err := g.threads.Add(); err != nil {
+		return nil, err
+	}
+	defer g.threads.Done()
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.activeBans(), nil
+}
+
+// ReportMisbehaviour adds to the misbehaviour score of the host of the given
+// address. Hosts that reach the ban threshold are disconnected and banned
+// temporarily.
+func (g *Gateway) ReportMisbehaviour(addr modules.NetAddress, m modules.PeerMisbehaviour) {
+	if err := g.threads.Add(); err != nil {
+		return
+	}
+	defer g.threads.Done()
+	g.managedReportMisbehaviour(addr, m)
+}
@@ -0,0 +1,328 @@
+package gateway
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// newScoringTestingGateway returns a gateway like newNamedTestingGateway that
+// scores and bans local peers, which all the testing gateways are.
+func newScoringTestingGateway(t *testing.T, suffix string) *Gateway {
+	if testing.Short() {
+		panic("newScoringTestingGateway called during short test")
+	}
+
+	g, err := NewCustomGateway("localhost:0", false, false, build.TempDir("gateway", t.Name()+suffix), &dependencies.DependencyScoreLocalPeers{})
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// TestPeerScoreDecay tests that misbehaviour scores decay over time.
+func TestPeerScoreDecay(t *testing.T) {
+	ps := peerScore{score: 10, updated: time.Now()}
+	if ps.current() != 10 {
+		t.Fatal("score shouldn't have decayed yet", ps.current())
+	}
+	ps.updated = time.Now().Add(-3 * scoreDecayInterval)
+	if ps.current() != 7 {
+		t.Fatal("score should have decayed by 3", ps.current())
+	}
+	ps.updated = time.Now().Add(-20 * scoreDecayInterval)
+	if ps.current() != 0 {
+		t.Fatal("score shouldn't decay below 0", ps.current())
+	}
+}
+
+// TestReportMisbehaviour tests that peers are scored and banned for
+// misbehaving, that bans are persisted and that they expire.
+func TestReportMisbehaviour(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newScoringTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newScoringTestingGateway(t, "2")
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+
+	// Report g1 once. Its score should show up in the peer list of g2.
+	g2.ReportMisbehaviour(g1.Address(), modules.MisbehaviourInvalidBlock)
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		peers := g2.Peers()
+		if len(peers) != 1 {
+			return errors.New("g1 should be a peer of g2")
+		}
+		if peers[0].Score != misbehaviourPenalties[modules.MisbehaviourInvalidBlock] {
+			return errors.New("wrong score")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Report g1 again. It should be disconnected and banned.
+	g2.ReportMisbehaviour(g1.Address(), modules.MisbehaviourInvalidBlock)
+	if len(g2.Peers()) != 0 {
+		t.Fatal("banned peer should be disconnected")
+	}
+	bans, err := g2.Bans()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans) != 1 || bans[0].Host != g1.Address().Host() || bans[0].Reason != modules.MisbehaviourInvalidBlock {
+		t.Fatal("unexpected bans", bans)
+	}
+	if err := g2.Connect(g1.Address()); !errors.Contains(err, errPeerBanned) {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+	if err := connectToNode(g1, g2, false); err == nil {
+		t.Fatal("g2 shouldn't accept connections from a banned host")
+	}
+
+	// Restart g2. The ban should still be in place.
+	if err := g2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g2, err = NewCustomGateway("localhost:0", false, false, g2.persistDir, &dependencies.DependencyScoreLocalPeers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if bans, err := g2.Bans(); err != nil || len(bans) != 1 {
+		t.Fatal("ban wasn't persisted", bans, err)
+	}
+	if err := g2.Connect(g1.Address()); !errors.Contains(err, errPeerBanned) {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+
+	// Let the ban expire.
+	g2.mu.Lock()
+	ban := g2.bans[g1.Address().Host()]
+	ban.Expiry = time.Now()
+	g2.bans[g1.Address().Host()] = ban
+	g2.mu.Unlock()
+	if bans, err := g2.Bans(); err != nil || len(bans) != 0 {
+		t.Fatal("ban should have expired", bans, err)
+	}
+	if err := connectToNode(g2, g1, false); err != nil {
+		t.Fatal("failed to connect after the ban expired:", err)
+	}
+}
+
+// TestLocalPeersNotBanned tests that local peers are never scored or banned.
+func TestLocalPeersNotBanned(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+	if !g1.Address().IsLocal() {
+		t.Fatal("testing gateways should have local addresses")
+	}
+
+	// Report g1 often enough to ban it if it wasn't local.
+	for i := 0; i < banScoreThreshold; i++ {
+		g2.ReportMisbehaviour(g1.Address(), modules.MisbehaviourInvalidBlock)
+	}
+	if bans, err := g2.Bans(); err != nil || len(bans) != 0 {
+		t.Fatal("local peer shouldn't be banned", bans, err)
+	}
+	peers := g2.Peers()
+	if len(peers) != 1 {
+		t.Fatal("local peer shouldn't be disconnected")
+	}
+	if peers[0].Score != 0 {
+		t.Fatal("local peer shouldn't be scored", peers[0].Score)
+	}
+}
+
+// TestConnectManualLiftsBan tests that manually connecting to a banned host
+// lifts its ban.
+func TestConnectManualLiftsBan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newScoringTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newScoringTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for i := 0; i < banScoreThreshold; i += misbehaviourPenalties[modules.MisbehaviourHandshake] {
+		g2.ReportMisbehaviour(g1.Address(), modules.MisbehaviourHandshake)
+	}
+	if err := g2.Connect(g1.Address()); !errors.Contains(err, errPeerBanned) {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+	if err := connectToNode(g2, g1, true); err != nil {
+		t.Fatal("manual connect should lift the ban:", err)
+	}
+	if bans, err := g2.Bans(); err != nil || len(bans) != 0 {
+		t.Fatal("ban should have been lifted", bans, err)
+	}
+}
+
+// TestShareNodesSpam tests that peers which respond to ShareNodes with too
+// many nodes are penalized.
+func TestShareNodesSpam(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newScoringTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newScoringTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Replace the ShareNodes RPC of g1 with one that shares too many nodes.
+	g1.UnregisterRPC("ShareNodes")
+	g1.RegisterRPC("ShareNodes", func(conn modules.PeerConn) error {
+		nodes := make([]modules.NetAddress, maxSharedNodes+1)
+		for i := range nodes {
+			nodes[i] = modules.NetAddress("1.2.3." + string(rune('0'+i)) + ":1234")
+		}
+		return encoding.WriteObject(conn, nodes)
+	})
+	if err := connectToNode(g2, g1, false); err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+
+	// g2 requests nodes from g1 upon connecting and periodically afterwards.
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		g2.mu.RLock()
+		defer g2.mu.RUnlock()
+		host := g1.Address().Host()
+		if g2.hostScore(host) == 0 && !g2.banned(host) {
+			return errors.New("spammy peer wasn't penalized")
+		}
+		if uint64(len(g2.nodes)) > maxSharedNodes+1 {
+			return errors.New("excess nodes shouldn't have been added")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestIsTimeoutErr tests that timeouts are detected when they are wrapped.
+func TestIsTimeoutErr(t *testing.T) {
+	timeout := &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}
+	tests := []struct {
+		err     error
+		timeout bool
+	}{
+		{nil, false},
+		{errors.New("foo"), false},
+		{&net.OpError{Op: "read", Err: errors.New("foo")}, false},
+		{timeout, true},
+		{os.ErrDeadlineExceeded, true},
+		{errors.AddContext(timeout, "foo"), true},
+		{errors.Compose(errors.New("foo"), timeout), true},
+		{errors.Compose(errors.New("foo"), errors.AddContext(timeout, "bar")), true},
+		{fmt.Errorf("foo: %w", timeout), true},
+		{errors.Compose(errors.New("foo"), errors.New("bar")), false},
+	}
+	for i, test := range tests {
+		if isTimeoutErr(test.err) != test.timeout {
+			t.Errorf("%v: expected %v for %v", i, test.timeout, test.err)
+		}
+	}
+}
+
+// TestRPCTimeoutPenalized tests that peers are penalized for RPCs that time out
+// even if the RPC wraps the timeout error.
+func TestRPCTimeoutPenalized(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newScoringTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newScoringTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// g1 never responds to the RPC.
+	g1.RegisterRPC("Stall", func(conn modules.PeerConn) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	if err := connectToNode(g2, g1, false); err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+	err := g2.RPC(g1.Address(), "Stall", func(conn modules.PeerConn) error {
+		conn.SetDeadline(time.Now().Add(100 * time.Millisecond))
+		var resp string
+		err := encoding.ReadObject(conn, &resp, 100)
+		return errors.AddContext(err, "couldn't read response")
+	})
+	if !isTimeoutErr(err) {
+		t.Fatal("expected timeout, got", err)
+	}
+	g2.mu.RLock()
+	score := g2.hostScore(g1.Address().Host())
+	g2.mu.RUnlock()
+	if score != misbehaviourPenalties[modules.MisbehaviourRPCTimeout] {
+		t.Fatal("peer wasn't penalized for the timeout, score is", score)
+	}
+}
@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// peerStats tracks the activity of a peer connection that isn't already
+// covered by the peer's bandwidth monitor.
+type peerStats struct {
+	connected        time.Time
+	handshakeLatency time.Duration
+	rpcsCalled       map[string]uint64
+	rpcsHandled      map[string]uint64
+	lastBlock        types.BlockID
+	lastBlockTime    time.Time
+	mu               sync.Mutex
+}
+
+// copyCounts returns a copy of an RPC count map.
+func copyCounts(counts map[string]uint64) map[string]uint64 {
+	c := make(map[string]uint64, len(counts))
+	for name, n := range counts {
+		c[name] = n
+	}
+	return c
+}
+
+// addRPCCalled increments the number of times we called the named RPC on the
+// peer.
+func (ps *peerStats) addRPCCalled(name string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.rpcsCalled == nil {
+		ps.rpcsCalled = make(map[string]uint64)
+	}
+	ps.rpcsCalled[name]++
+}
+
+// addRPCHandled increments the number of times the peer called the named RPC
+// on us.
+func (ps *peerStats) addRPCHandled(name string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.rpcsHandled == nil {
+		ps.rpcsHandled = make(map[string]uint64)
+	}
+	ps.rpcsHandled[name]++
+}
+
+// setLastBlock records a block that was relayed by the peer.
+func (ps *peerStats) setLastBlock(id types.BlockID) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.lastBlock = id
+	ps.lastBlockTime = time.Now()
+}
+
+// stats returns a snapshot of the peer's stats.
+func (p *peer) stats() modules.PeerStats {
+	p.ps.mu.Lock()
+	defer p.ps.mu.Unlock()
+	s := modules.PeerStats{
+		RPCsCalled:           copyCounts(p.ps.rpcsCalled),
+		RPCsHandled:          copyCounts(p.ps.rpcsHandled),
+		ConnectedSince:       p.ps.connected,
+		HandshakeLatency:     p.ps.handshakeLatency,
+		LastBlockRelayed:     p.ps.lastBlock,
+		LastBlockRelayedTime: p.ps.lastBlockTime,
+	}
+	if p.m != nil {
+		s.BytesReceived, s.BytesSent = p.m.Counts()
+	}
+	return s
+}
+
+// addPeerHistory records the stats of a peer that disconnected. Only the most
+// recent maxPeerHistory peers are kept.
+func (g *Gateway) addPeerHistory(p *peer) {
+	peer := p.Peer
+	peer.Score = g.hostScore(peer.NetAddress.Host())
+	peer.Stats = p.stats()
+	peer.Stats.DisconnectedAt = time.Now()
+	g.peerHistory = append(g.peerHistory, peer)
+	if len(g.peerHistory) > maxPeerHistory {
+		g.peerHistory = g.peerHistory[len(g.peerHistory)-maxPeerHistory:]
+	}
+}
+
+// PeerHistory returns the most recently disconnected peers, newest first,
+// along with the stats of their connections.
+func (g *Gateway) PeerHistory() []modules.Peer {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	peers := make([]modules.Peer, 0, len(g.peerHistory))
+	for i := len(g.peerHistory) - 1; i >= 0; i-- {
+		peers = append(peers, g.peerHistory[i])
+	}
+	return peers
+}
+
+// ReportBlockRelayed records that the peer at addr relayed a block that
+// extended the blockchain.
+func (g *Gateway) ReportBlockRelayed(addr modules.NetAddress, id types.BlockID) {
+	g.mu.RLock()
+	p, exists := g.peers[addr]
+	g.mu.RUnlock()
+	if exists {
+		p.ps.setLastBlock(id)
+	}
+}
@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// peerByAddr returns the peer with the given address from peers.
+func peerByAddr(peers []modules.Peer, addr modules.NetAddress) (modules.Peer, bool) {
+	for _, p := range peers {
+		if p.NetAddress == addr {
+			return p, true
+		}
+	}
+	return modules.Peer{}, false
+}
+
+// TestPeerStats tests that the gateway tracks the activity of its peers and
+// keeps their stats after they disconnect.
+func TestPeerStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	g2.RegisterRPC("TestStats", func(conn modules.PeerConn) error {
+		var msg string
+		if err := encoding.ReadObject(conn, &msg, 100); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, msg)
+	})
+	if err := connectToNode(g1, g2, false); err != nil {
+		t.Fatal("failed to connect:", err)
+	}
+	for i := 0; i < 3; i++ {
+		err := g1.RPC(g2.Address(), "TestStats", func(conn modules.PeerConn) error {
+			if err := encoding.WriteObject(conn, "ping"); err != nil {
+				return err
+			}
+			var msg string
+			return encoding.ReadObject(conn, &msg, 100)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both sides should have counted the RPCs and the traffic.
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		p1, ok := peerByAddr(g1.Peers(), g2.Address())
+		if !ok {
+			return errors.New("g2 should be a peer of g1")
+		}
+		p2, ok := peerByAddr(g2.Peers(), g1.Address())
+		if !ok {
+			return errors.New("g1 should be a peer of g2")
+		}
+		if n := p1.Stats.RPCsCalled["TestStats"]; n != 3 {
+			return fmt.Errorf("g1 should have called TestStats 3 times, got %v", n)
+		}
+		if n := p2.Stats.RPCsHandled["TestStats"]; n != 3 {
+			return fmt.Errorf("g2 should have handled TestStats 3 times, got %v", n)
+		}
+		for _, p := range []modules.Peer{p1, p2} {
+			if p.Stats.BytesSent == 0 || p.Stats.BytesReceived == 0 {
+				return errors.New("traffic wasn't counted")
+			}
+			if p.Stats.HandshakeLatency <= 0 {
+				return errors.New("handshake latency wasn't measured")
+			}
+			if p.Stats.ConnectedSince.IsZero() || time.Since(p.Stats.ConnectedSince) > time.Minute {
+				return errors.New("wrong connection time")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Report a relayed block.
+	id := types.BlockID{1, 2, 3}
+	g1.ReportBlockRelayed(g2.Address(), id)
+	p1, _ := peerByAddr(g1.Peers(), g2.Address())
+	if p1.Stats.LastBlockRelayed != id || p1.Stats.LastBlockRelayedTime.IsZero() {
+		t.Fatal("relayed block wasn't recorded", p1.Stats)
+	}
+
+	// Disconnect. The stats should be kept in the history of both gateways.
+	if err := g1.Disconnect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		h1, ok := peerByAddr(g1.PeerHistory(), g2.Address())
+		if !ok {
+			return errors.New("g2 should be in the history of g1")
+		}
+		h2, ok := peerByAddr(g2.PeerHistory(), g1.Address())
+		if !ok {
+			return errors.New("g1 should be in the history of g2")
+		}
+		if h1.Stats.RPCsCalled["TestStats"] != 3 || h1.Stats.LastBlockRelayed != id {
+			return errors.New("history of g1 lost the stats")
+		}
+		if h2.Stats.RPCsHandled["TestStats"] != 3 {
+			return errors.New("history of g2 lost the stats")
+		}
+		if h1.Stats.DisconnectedAt.IsZero() || h2.Stats.DisconnectedAt.IsZero() {
+			return errors.New("disconnect time wasn't set")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPeerHistoryLimit tests that only the most recently disconnected peers
+// are kept in the history.
+func TestPeerHistoryLimit(t *testing.T) {
+	g := &Gateway{}
+	for i := 0; i < maxPeerHistory+3; i++ {
+		g.addPeerHistory(&peer{
+			Peer: modules.Peer{
+				NetAddress: modules.NetAddress(fmt.Sprintf("1.2.3.4:%d", i)),
+			},
+		})
+	}
+	history := g.PeerHistory()
+	if len(history) != maxPeerHistory {
+		t.Fatal("wrong history length", len(history))
+	}
+	if history[0].NetAddress != modules.NetAddress(fmt.Sprintf("1.2.3.4:%d", maxPeerHistory+2)) {
+		t.Fatal("most recent peer should be first", history[0].NetAddress)
+	}
+	if history[len(history)-1].NetAddress != "1.2.3.4:3" {
+		t.Fatal("oldest peers should have been dropped", history[len(history)-1].NetAddress)
+	}
+}
@@ -2,7 +2,9 @@ package modules
 
 import (
 	"io"
+	"time"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
 
@@ -23,6 +25,15 @@ type BlockManager interface {
 	// SubmitBlock accepts a solved block.
 	SubmitBlock(types.Block) error
 
+	// BlockTemplate returns the block the miner is currently working on.
+	// If longPollID is the ID of the current template, BlockTemplate blocks
+	// until the template changes or cancel is closed.
+	BlockTemplate(longPollID string, cancel <-chan struct{}) (BlockTemplate, error)
+
+	// SubmitBlockTemplate accepts a solved block that was assembled from a
+	// block template, possibly with a different selection of transactions.
+	SubmitBlockTemplate(types.Block) error
+
 	// SubmitHeader takes a block header that has been worked on and has a
 	// valid target.
 	SubmitHeader(types.BlockHeader) error
@@ -48,6 +59,70 @@ type CPUMiner interface {
 	StopCPUMining()
 }
 
+type (
+	// BlockTemplate is a candidate block that external miners can assemble
+	// themselves. The miner payouts of the block have to add up to the
+	// subsidy plus the fees of the selected transaction sets.
+	BlockTemplate struct {
+		// ID changes whenever the parent or the selected transactions of
+		// the template change. It is used for long-polling.
+		ID string `json:"id"`
+
+		ParentID     types.BlockID     `json:"parentid"`
+		Height       types.BlockHeight `json:"height"`
+		Timestamp    types.Timestamp   `json:"timestamp"`
+		MinTimestamp types.Timestamp   `json:"mintimestamp"`
+		Target       types.Target      `json:"target"`
+		Subsidy      types.Currency    `json:"subsidy"`
+
+		TransactionSets []BlockTemplateSet `json:"transactionsets"`
+	}
+
+	// BlockTemplateSet is a transaction set selected for a block template.
+	// The transactions of a set have to be included together and in order.
+	BlockTemplateSet struct {
+		ID           crypto.Hash         `json:"id"`
+		Transactions []types.Transaction `json:"transactions"`
+		Fee          types.Currency      `json:"fee"`
+		Size         uint64              `json:"size"`
+	}
+
+	// StratumStats contains the share statistics of the miner's stratum
+	// server.
+	StratumStats struct {
+		// Address is the address the server is listening on. It is empty if
+		// the server is not running.
+		Address string `json:"address"`
+
+		AcceptedShares uint64 `json:"acceptedshares"`
+		StaleShares    uint64 `json:"staleshares"`
+		RejectedShares uint64 `json:"rejectedshares"`
+		BlocksFound    uint64 `json:"blocksfound"`
+
+		Workers []StratumWorker `json:"workers"`
+	}
+
+	// StratumWorker contains the statistics of a single connection to the
+	// stratum server.
+	StratumWorker struct {
+		Name          string  `json:"name"`
+		RemoteAddress string  `json:"remoteaddress"`
+		Difficulty    float64 `json:"difficulty"`
+
+		AcceptedShares uint64    `json:"acceptedshares"`
+		StaleShares    uint64    `json:"staleshares"`
+		RejectedShares uint64    `json:"rejectedshares"`
+		LastShare      time.Time `json:"lastshare"`
+	}
+)
+
+// StratumServer provides access to the stratum server of the miner, which
+// hands out work to external miners over TCP.
+type StratumServer interface {
+	// StratumStats returns the share statistics of the stratum server.
+	StratumStats() StratumStats
+}
+
 // TestMiner provides direct access to block fetching, solving, and
 // manipulation. The primary use of this interface is integration testing.
 type TestMiner interface {
@@ -80,5 +155,6 @@ type TestMiner interface {
 type Miner interface {
 	BlockManager
 	CPUMiner
+	StratumServer
 	io.Closer
 }
@@ -104,6 +104,14 @@ type Miner struct {
 	mining   bool  // indicates if the miner is actually running
 	hashRate int64 // indicates hashes per second
 
+	// stratum is the stratum server of the miner, nil if it isn't running.
+	stratum *stratumServer
+
+	// Block template variables. templateChan is closed and replaced whenever
+	// the template changes.
+	templateChan    chan struct{}
+	templateCounter uint64
+
 	// Utils
 	log        *persist.Logger
 	mu         sync.RWMutex
@@ -190,6 +198,8 @@ func New(cs modules.ConsensusSet, tpool modules.TransactionPool, w modules.Walle
 		splitSetIDFromTxID: make(map[types.TransactionID]splitSetID),
 		unsolvedBlockIndex: make(map[types.TransactionID]int),
 
+		templateChan: make(chan struct{}),
+
 		persistDir: persistDir,
 	}
 
@@ -197,6 +207,11 @@ func New(cs modules.ConsensusSet, tpool modules.TransactionPool, w modules.Walle
 	if err != nil {
 		return nil, errors.New("miner persistence startup failed: " + err.Error())
 	}
+	// The split sets tracking the transactions of the unsolved block are not
+	// persisted. Drop the transactions, the block is rebuilt from the
+	// unconfirmed sets that the transaction pool sends when subscribing,
+	// including the sets it restored from its previous run.
+	m.persist.UnsolvedBlock.Transactions = nil
 
 	err = m.cs.ConsensusSetSubscribe(m, m.persist.RecentChange, m.tg.StopChan())
 	if errors.Contains(err, modules.ErrInvalidConsensusChangeID) {
@@ -0,0 +1,896 @@
+package miner
+
+// stratum.go implements a Stratum server on top of the block manager. Pool
+// software and mining hardware connect over TCP and receive jobs that are
+// pushed whenever the parent block changes and, rate limited, when the
+// transaction pool changes, instead of polling /miner/header.
+//
+// The protocol follows the Sia flavour of Stratum that existing mining
+// software speaks. The arbitrary data transaction that makes headers unique is
+// the last transaction of the block, which means that all hashes of the merkle
+// branch of that transaction are left siblings. A miner builds the
+// transaction as coinbase1 + extranonce1 + extranonce2 + coinbase2, hashes it
+// as a merkle leaf and folds in the branch to get the merkle root of the
+// header.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// stratumExtranonce1Size is the size of the extranonce assigned to each
+	// connection by the server.
+	stratumExtranonce1Size = 4
+
+	// stratumExtranonce2Size is the size of the extranonce that is chosen by
+	// the connected miner.
+	stratumExtranonce2Size = 4
+
+	// stratumMaxMessageSize is the maximum size of a single message sent by a
+	// stratum client.
+	stratumMaxMessageSize = 1 << 14
+
+	// stratumShareTime is the time between two shares of a worker that
+	// variable difficulty aims for.
+	stratumShareTime = 10 * time.Second
+)
+
+// Stratum error codes, as used by the stratum protocol.
+const (
+	stratumErrOther         = 20
+	stratumErrJobNotFound   = 21
+	stratumErrDuplicate     = 22
+	stratumErrLowDifficulty = 23
+	stratumErrUnauthorized  = 24
+	stratumErrNotSubscribed = 25
+)
+
+var (
+	// stratumDefaultDifficulty is the share difficulty a new connection starts
+	// out with.
+	stratumDefaultDifficulty = build.Select(build.Var{
+		Standard: float64(16),
+		Testnet:  float64(16),
+		Dev:      float64(1),
+		Testing:  float64(1),
+	}).(float64)
+
+	// stratumIdleTimeout is the time after which a connection that hasn't
+	// sent a message is closed.
+	stratumIdleTimeout = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Testnet:  10 * time.Minute,
+		Dev:      5 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// stratumJobInterval is the minimum time between two jobs that are
+	// created because of transaction pool changes.
+	stratumJobInterval = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Testnet:  10 * time.Second,
+		Dev:      2 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// stratumJobMemory is the number of jobs the server remembers. Shares for
+	// older jobs are considered stale.
+	stratumJobMemory = build.Select(build.Var{
+		Standard: 16,
+		Testnet:  16,
+		Dev:      8,
+		Testing:  4,
+	}).(int)
+
+	// stratumRetargetInterval is the interval at which the share difficulty
+	// of a connection is adjusted.
+	stratumRetargetInterval = build.Select(build.Var{
+		Standard: 2 * time.Minute,
+		Testnet:  2 * time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
+	// stratumWriteTimeout is the time a write to a connection may take.
+	stratumWriteTimeout = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Testnet:  30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// stratumDiff1Target is the target of a share with difficulty 1. Stratum
+	// miners use the same definition of difficulty as bitcoin miners.
+	stratumDiff1Target = types.Target{0, 0, 0, 0, 255, 255}
+
+	// stratumMinDifficulty is the difficulty of a share that meets the
+	// easiest possible target.
+	stratumMinDifficulty = stratumDifficulty(types.RootDepth)
+
+	errStratumRunning = errors.New("stratum server is already running")
+)
+
+type (
+	// stratumServer hands out jobs to connected miners and keeps track of the
+	// shares they submit.
+	stratumServer struct {
+		listener net.Listener
+
+		conns   map[*stratumConn]struct{}
+		jobs    map[string]*stratumJob
+		jobIDs  []string // oldest first
+		current *stratumJob
+		jobTime time.Time // creation time of the current job
+
+		jobCounter        uint64
+		extranonceCounter uint32
+
+		// jobChan signals the broadcasting thread that there is a new job.
+		jobChan chan struct{}
+
+		acceptedShares uint64
+		staleShares    uint64
+		rejectedShares uint64
+		blocksFound    uint64
+
+		mu sync.Mutex
+	}
+
+	// stratumJob is a block template that was handed out to the connected
+	// miners. The last transaction of the block has placeholder extranonces.
+	stratumJob struct {
+		id        string
+		block     types.Block
+		height    types.BlockHeight
+		target    types.Target
+		branch    []crypto.Hash
+		coinbase1 []byte
+		coinbase2 []byte
+
+		// submitted contains the IDs of the shares that have been submitted
+		// for the job, to detect duplicates.
+		submitted map[types.BlockID]struct{}
+	}
+
+	// stratumConn is a connection to a stratum client. All fields except the
+	// connection itself are protected by the mutex of the server.
+	stratumConn struct {
+		conn        net.Conn
+		extranonce1 [stratumExtranonce1Size]byte
+		writeMu     sync.Mutex
+
+		subscribed bool
+		authorized bool
+		worker     string
+
+		// prevTarget is the share target before the last change of the
+		// variable difficulty. It stays valid until the next job is sent,
+		// since the miner might still be working on shares for the old
+		// difficulty.
+		difficulty      float64
+		fixedDifficulty bool
+		target          types.Target
+		prevTarget      types.Target
+		lastParent      types.BlockID
+
+		acceptedShares uint64
+		staleShares    uint64
+		rejectedShares uint64
+		lastShare      time.Time
+
+		retargetShares uint64
+		retargetTime   time.Time
+	}
+
+	// stratumRequest is a message sent by a stratum client.
+	stratumRequest struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	// stratumResponse is the response to a stratum request.
+	stratumResponse struct {
+		ID     json.RawMessage `json:"id"`
+		Result interface{}     `json:"result"`
+		Error  interface{}     `json:"error"`
+	}
+
+	// stratumNotification is a message sent by the server that is not a
+	// response to a request.
+	stratumNotification struct {
+		ID     interface{}   `json:"id"`
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+	}
+
+	// stratumError is an error that is returned to a stratum client.
+	stratumError struct {
+		code    int
+		message string
+	}
+)
+
+// Error implements the error interface.
+func (e stratumError) Error() string {
+	return e.message
+}
+
+// MarshalJSON encodes the error the way stratum clients expect it.
+func (e stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.code, e.message, nil})
+}
+
+// stratumDifficulty returns the stratum difficulty of a target.
+func stratumDifficulty(target types.Target) float64 {
+	d, _ := new(big.Rat).SetFrac(stratumDiff1Target.Int(), target.Int()).Float64()
+	return d
+}
+
+// stratumTarget returns the target of a share with the provided difficulty.
+func stratumTarget(difficulty float64) types.Target {
+	if difficulty <= stratumMinDifficulty {
+		return types.RootDepth
+	}
+	d := new(big.Rat).SetFloat64(difficulty)
+	return types.RatToTarget(new(big.Rat).Quo(stratumDiff1Target.Rat(), d))
+}
+
+// stratumMerkleLeaf returns the merkle leaf hash of the encoded object.
+func stratumMerkleLeaf(b []byte) crypto.Hash {
+	return crypto.HashBytes(append([]byte{0}, b...))
+}
+
+// stratumMerkleNode returns the merkle hash of the two child nodes.
+func stratumMerkleNode(left, right crypto.Hash) crypto.Hash {
+	return crypto.HashBytes(append(append([]byte{1}, left[:]...), right[:]...))
+}
+
+// stratumMerkleRoot returns the root of a merkle tree, given the leaf hash of
+// its last leaf and the branch of that leaf.
+func stratumMerkleRoot(leaf crypto.Hash, branch []crypto.Hash) crypto.Hash {
+	root := leaf
+	for _, h := range branch {
+		root = stratumMerkleNode(h, root)
+	}
+	return root
+}
+
+// stratumMerkleBranch returns the merkle branch of the last transaction of a
+// block, ordered from the leaf to the root.
+func stratumMerkleBranch(b types.Block) []crypto.Hash {
+	var leaves []crypto.Hash
+	for _, payout := range b.MinerPayouts {
+		leaves = append(leaves, stratumMerkleLeaf(encoding.Marshal(payout)))
+	}
+	for _, txn := range b.Transactions {
+		leaves = append(leaves, stratumMerkleLeaf(encoding.Marshal(txn)))
+	}
+
+	// perfectRoot returns the root of a perfect tree.
+	perfectRoot := func(hashes []crypto.Hash) crypto.Hash {
+		level := append([]crypto.Hash(nil), hashes...)
+		for len(level) > 1 {
+			for i := 0; i < len(level)/2; i++ {
+				level[i] = stratumMerkleNode(level[2*i], level[2*i+1])
+			}
+			level = level[:len(level)/2]
+		}
+		return level[0]
+	}
+
+	// The tree consists of perfect subtrees of decreasing size. The last leaf
+	// is part of the smallest subtree, whose left halves are the first part
+	// of the branch. The remaining subtrees are joined from right to left.
+	n := len(leaves)
+	var branch []crypto.Hash
+	size := n & -n
+	for w := 1; w < size; w *= 2 {
+		branch = append(branch, perfectRoot(leaves[n-2*w:n-w]))
+	}
+	start := n - size
+	for start > 0 {
+		size := start & -start
+		branch = append(branch, perfectRoot(leaves[start-size:start]))
+		start -= size
+	}
+	return branch
+}
+
+// newStratumJob creates a stratum job from a block returned by blockForWork.
+func newStratumJob(id string, b types.Block, height types.BlockHeight, target types.Target) *stratumJob {
+	// Replace the arbitrary data transaction at the beginning of the block
+	// with one at the end. Random bytes keep the merkle roots of jobs with
+	// the same content unique.
+	var arbData []byte
+	arbData = append(arbData, modules.PrefixNonSia[:]...)
+	arbData = append(arbData, fastrand.Bytes(8)...)
+	arbData = append(arbData, make([]byte, stratumExtranonce1Size+stratumExtranonce2Size)...)
+	coinbase := types.Transaction{ArbitraryData: [][]byte{arbData}}
+	txns := make([]types.Transaction, 0, len(b.Transactions))
+	txns = append(txns, b.Transactions[1:]...)
+	b.Transactions = append(txns, coinbase)
+
+	// The extranonces are the last bytes of the arbitrary data, which is
+	// followed by the length prefix of the empty signatures.
+	encoded := encoding.Marshal(coinbase)
+	end := len(encoded) - 8
+	start := end - stratumExtranonce1Size - stratumExtranonce2Size
+	return &stratumJob{
+		id:        id,
+		block:     b,
+		height:    height,
+		target:    target,
+		branch:    stratumMerkleBranch(b),
+		coinbase1: encoded[:start],
+		coinbase2: encoded[end:],
+		submitted: make(map[types.BlockID]struct{}),
+	}
+}
+
+// notifyParams returns the parameters of the mining.notify message for the
+// job.
+func (j *stratumJob) notifyParams(clean bool) []interface{} {
+	branch := make([]string, len(j.branch))
+	for i, h := range j.branch {
+		branch[i] = hex.EncodeToString(h[:])
+	}
+	var ntime [8]byte
+	binary.LittleEndian.PutUint64(ntime[:], uint64(j.block.Timestamp))
+	return []interface{}{
+		j.id,
+		hex.EncodeToString(j.block.ParentID[:]),
+		hex.EncodeToString(j.coinbase1),
+		hex.EncodeToString(j.coinbase2),
+		branch,
+		"",
+		hex.EncodeToString(j.target[:]),
+		hex.EncodeToString(ntime[:]),
+		clean,
+	}
+}
+
+// solvedBlock returns the block of the job with the provided extranonces,
+// timestamp and nonce.
+func (j *stratumJob) solvedBlock(extranonce []byte, timestamp types.Timestamp, nonce types.BlockNonce) types.Block {
+	b := j.block
+	b.Timestamp = timestamp
+	b.Nonce = nonce
+	b.Transactions = append([]types.Transaction(nil), b.Transactions...)
+	coinbase := &b.Transactions[len(b.Transactions)-1]
+	arbData := append([]byte(nil), coinbase.ArbitraryData[0]...)
+	copy(arbData[len(arbData)-len(extranonce):], extranonce)
+	coinbase.ArbitraryData = [][]byte{arbData}
+	return b
+}
+
+// setDifficulty changes the share difficulty of the connection. If grace is
+// true, shares for the previous difficulty are accepted until the next job is
+// sent. The caller must hold the lock of the server.
+func (sc *stratumConn) setDifficulty(difficulty float64, grace bool) {
+	sc.difficulty = math.Max(difficulty, stratumMinDifficulty)
+	if grace && sc.target.Cmp(sc.prevTarget) > 0 {
+		sc.prevTarget = sc.target
+	}
+	sc.target = stratumTarget(sc.difficulty)
+	if !grace {
+		sc.prevTarget = sc.target
+	}
+}
+
+// retarget adjusts the difficulty of a connection that uses variable
+// difficulty, aiming for one share every stratumShareTime. It returns true if
+// the difficulty was changed. The caller must hold the lock of the server.
+func (sc *stratumConn) retarget() bool {
+	elapsed := time.Since(sc.retargetTime)
+	if sc.fixedDifficulty || !sc.subscribed || elapsed < stratumRetargetInterval {
+		return false
+	}
+	factor := float64(sc.retargetShares) * float64(stratumShareTime) / float64(elapsed)
+	factor = math.Max(math.Min(factor, 4), 0.25)
+	sc.retargetShares = 0
+	sc.retargetTime = time.Now()
+	if factor > 0.8 && factor < 1.25 {
+		return false
+	}
+	sc.setDifficulty(sc.difficulty*factor, true)
+	return true
+}
+
+// write sends a message to the stratum client.
+func (sc *stratumConn) write(msg interface{}) error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	if err := sc.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout)); err != nil {
+		return err
+	}
+	return json.NewEncoder(sc.conn).Encode(msg)
+}
+
+// managedNotify sends the job and the difficulty of the connection to the
+// stratum client.
+func (sc *stratumConn) managedNotify(job *stratumJob, difficulty float64, clean bool) error {
+	err := sc.write(stratumNotification{
+		Method: "mining.set_difficulty",
+		Params: []interface{}{difficulty},
+	})
+	if err != nil || job == nil {
+		return err
+	}
+	return sc.write(stratumNotification{
+		Method: "mining.notify",
+		Params: job.notifyParams(clean),
+	})
+}
+
+// newStratumJob replaces the current stratum job with one built from the
+// unsolved block. Jobs are independent of the source block, so that creating a
+// job doesn't evict the headers handed out by HeaderForWork. The caller must
+// hold the lock of the miner.
+func (m *Miner) newStratumJob() {
+	if m.stratum == nil {
+		return
+	}
+	b := m.blockForWork()
+	if m.persist.Address == (types.UnlockHash{}) {
+		return
+	}
+	s := m.stratum
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobCounter++
+	job := newStratumJob(strconv.FormatUint(s.jobCounter, 16), b, m.persist.Height+1, m.persist.Target)
+	s.jobs[job.id] = job
+	s.jobIDs = append(s.jobIDs, job.id)
+	if len(s.jobIDs) > stratumJobMemory {
+		delete(s.jobs, s.jobIDs[0])
+		s.jobIDs = s.jobIDs[1:]
+	}
+	s.current = job
+	s.jobTime = time.Now()
+
+	// Wake up the broadcasting thread.
+	select {
+	case s.jobChan <- struct{}{}:
+	default:
+	}
+}
+
+// managedRefreshStratumJob creates a new stratum job if the current one is
+// missing or outdated.
+func (m *Miner) managedRefreshStratumJob() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stratum == nil {
+		return
+	}
+	m.stratum.mu.Lock()
+	current, jobTime := m.stratum.current, m.stratum.jobTime
+	m.stratum.mu.Unlock()
+	if current == nil || current.block.ParentID != m.persist.UnsolvedBlock.ParentID || time.Since(jobTime) > MaxSourceBlockAge {
+		m.newStratumJob()
+	}
+}
+
+// StartStratum starts a stratum server that listens on the provided address.
+func (m *Miner) StartStratum(addr string) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stratum != nil {
+		return errStratumRunning
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.AddContext(err, "unable to start stratum server")
+	}
+	s := &stratumServer{
+		listener: l,
+		conns:    make(map[*stratumConn]struct{}),
+		jobs:     make(map[string]*stratumJob),
+		jobChan:  make(chan struct{}, 1),
+	}
+	m.stratum = s
+	m.tg.OnStop(func() error {
+		err := l.Close()
+		s.mu.Lock()
+		for sc := range s.conns {
+			err = errors.Compose(err, sc.conn.Close())
+		}
+		s.mu.Unlock()
+		return err
+	})
+	m.log.Println("Stratum server listening on", l.Addr())
+
+	go m.threadedAcceptStratum(s)
+	go m.threadedBroadcastStratumJobs(s)
+	return nil
+}
+
+// threadedAcceptStratum accepts connections of stratum clients until the
+// miner is shut down.
+func (m *Miner) threadedAcceptStratum(s *stratumServer) {
+	if err := m.tg.Add(); err != nil {
+		return
+	}
+	defer m.tg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go m.threadedHandleStratumConn(s, conn)
+	}
+}
+
+// threadedBroadcastStratumJobs sends new jobs to all subscribed connections.
+// It also makes sure that a job is refreshed when it gets too old, which
+// includes transactions that arrived since the last job.
+func (m *Miner) threadedBroadcastStratumJobs(s *stratumServer) {
+	if err := m.tg.Add(); err != nil {
+		return
+	}
+	defer m.tg.Done()
+
+	ticker := time.NewTicker(MaxSourceBlockAge)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.tg.StopChan():
+			return
+		case <-ticker.C:
+			m.managedRefreshStratumJob()
+			continue
+		case <-s.jobChan:
+		}
+
+		type notification struct {
+			sc         *stratumConn
+			difficulty float64
+			clean      bool
+		}
+		var notifications []notification
+		s.mu.Lock()
+		job := s.current
+		for sc := range s.conns {
+			if job == nil {
+				break
+			}
+			if !sc.subscribed {
+				continue
+			}
+			sc.retarget()
+			sc.prevTarget = sc.target
+			clean := sc.lastParent != job.block.ParentID
+			sc.lastParent = job.block.ParentID
+			notifications = append(notifications, notification{sc, sc.difficulty, clean})
+		}
+		s.mu.Unlock()
+
+		for _, n := range notifications {
+			if err := n.sc.managedNotify(job, n.difficulty, n.clean); err != nil {
+				n.sc.conn.Close()
+			}
+		}
+	}
+}
+
+// threadedHandleStratumConn reads requests from a stratum client until the
+// connection is closed.
+func (m *Miner) threadedHandleStratumConn(s *stratumServer, conn net.Conn) {
+	if err := m.tg.Add(); err != nil {
+		conn.Close()
+		return
+	}
+	defer m.tg.Done()
+
+	sc := &stratumConn{
+		conn:         conn,
+		retargetTime: time.Now(),
+	}
+	s.mu.Lock()
+	s.extranonceCounter++
+	binary.BigEndian.PutUint32(sc.extranonce1[:], s.extranonceCounter)
+	sc.setDifficulty(stratumDefaultDifficulty, false)
+	s.conns[sc] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, sc)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), stratumMaxMessageSize)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout)); err != nil {
+			return
+		}
+		if !scanner.Scan() {
+			return
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			m.log.Debugln("Closing stratum connection after invalid message:", err)
+			return
+		}
+		result, err := m.managedHandleStratumRequest(s, sc, req)
+		resp := stratumResponse{ID: req.ID, Result: result}
+		if err != nil {
+			se, ok := err.(stratumError)
+			if !ok {
+				se = stratumError{stratumErrOther, err.Error()}
+			}
+			resp.Result, resp.Error = nil, se
+		}
+		if err := sc.write(resp); err != nil {
+			return
+		}
+		if req.Method == "mining.subscribe" && err == nil {
+			m.managedRefreshStratumJob()
+			s.mu.Lock()
+			job, difficulty := s.current, sc.difficulty
+			if job != nil {
+				sc.lastParent = job.block.ParentID
+			}
+			s.mu.Unlock()
+			if err := sc.managedNotify(job, difficulty, true); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// managedHandleStratumRequest handles a single request of a stratum client
+// and returns the result.
+func (m *Miner) managedHandleStratumRequest(s *stratumServer, sc *stratumConn, req stratumRequest) (interface{}, error) {
+	switch req.Method {
+	case "mining.subscribe":
+		s.mu.Lock()
+		sc.subscribed = true
+		s.mu.Unlock()
+		id := hex.EncodeToString(sc.extranonce1[:])
+		subscriptions := [][]string{{"mining.set_difficulty", id}, {"mining.notify", id}}
+		return []interface{}{subscriptions, id, stratumExtranonce2Size}, nil
+
+	case "mining.authorize":
+		var worker string
+		if len(req.Params) == 0 || json.Unmarshal(req.Params[0], &worker) != nil {
+			return nil, errors.New("missing worker name")
+		}
+		s.mu.Lock()
+		sc.authorized = true
+		sc.worker = worker
+		s.mu.Unlock()
+		return true, nil
+
+	case "mining.extranonce.subscribe":
+		// The extranonce of a connection never changes.
+		return true, nil
+
+	case "mining.suggest_difficulty":
+		var difficulty float64
+		if len(req.Params) == 0 || json.Unmarshal(req.Params[0], &difficulty) != nil || !(difficulty > 0) || math.IsInf(difficulty, 0) {
+			return nil, errors.New("invalid difficulty")
+		}
+		s.mu.Lock()
+		sc.fixedDifficulty = true
+		sc.setDifficulty(difficulty, false)
+		difficulty = sc.difficulty
+		subscribed := sc.subscribed
+		s.mu.Unlock()
+		if subscribed {
+			// The suggested difficulty takes effect right away, the miner
+			// asked for it.
+			go func() {
+				if err := sc.managedNotify(nil, difficulty, false); err != nil {
+					sc.conn.Close()
+				}
+			}()
+		}
+		return true, nil
+
+	case "mining.submit":
+		var params []string
+		for _, p := range req.Params {
+			var str string
+			if json.Unmarshal(p, &str) != nil {
+				return nil, errors.New("invalid share")
+			}
+			params = append(params, str)
+		}
+		return m.managedSubmitStratumShare(s, sc, params)
+
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// managedSubmitStratumShare checks a share submitted by a stratum client,
+// submitting the block if it meets the target of the job.
+func (m *Miner) managedSubmitStratumShare(s *stratumServer, sc *stratumConn, params []string) (interface{}, error) {
+	s.mu.Lock()
+	subscribed, authorized, worker := sc.subscribed, sc.authorized, sc.worker
+	s.mu.Unlock()
+	if !subscribed {
+		return nil, stratumError{stratumErrNotSubscribed, "not subscribed"}
+	}
+	if !authorized {
+		return nil, stratumError{stratumErrUnauthorized, "unauthorized worker"}
+	}
+
+	// Parse the share.
+	if len(params) != 5 {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrOther, "invalid share"}, false)
+	}
+	extranonce2, err1 := hex.DecodeString(params[2])
+	ntime, err2 := hex.DecodeString(params[3])
+	nonce, err3 := hex.DecodeString(params[4])
+	if err := errors.Compose(err1, err2, err3); err != nil || len(extranonce2) != stratumExtranonce2Size || len(ntime) != 8 || len(nonce) != 8 {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrOther, "invalid share"}, false)
+	}
+
+	// Shares for jobs that have been forgotten or that don't extend the
+	// current block are stale.
+	s.mu.Lock()
+	job, current := s.jobs[params[1]], s.current
+	s.mu.Unlock()
+	if job == nil || current == nil || job.block.ParentID != current.block.ParentID {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrJobNotFound, "stale share"}, false)
+	}
+
+	var bn types.BlockNonce
+	copy(bn[:], nonce)
+	timestamp := types.Timestamp(binary.LittleEndian.Uint64(ntime))
+	if timestamp < job.block.Timestamp || timestamp > types.CurrentTimestamp()+types.FutureThreshold {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrOther, "invalid ntime"}, false)
+	}
+	if job.height >= types.ASICHardforkHeight && binary.LittleEndian.Uint64(bn[:])%types.ASICHardforkFactor != 0 {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrOther, "invalid nonce"}, false)
+	}
+
+	// Compute the ID of the header.
+	extranonce := append(sc.extranonce1[:], extranonce2...)
+	coinbase := append(append(append([]byte(nil), job.coinbase1...), extranonce...), job.coinbase2...)
+	header := types.BlockHeader{
+		ParentID:   job.block.ParentID,
+		Nonce:      bn,
+		Timestamp:  timestamp,
+		MerkleRoot: stratumMerkleRoot(stratumMerkleLeaf(coinbase), job.branch),
+	}
+	id := header.ID()
+
+	s.mu.Lock()
+	_, duplicate := job.submitted[id]
+	job.submitted[id] = struct{}{}
+	lowDifficulty := bytes.Compare(id[:], sc.target[:]) > 0 && bytes.Compare(id[:], sc.prevTarget[:]) > 0
+	s.mu.Unlock()
+	if duplicate {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrDuplicate, "duplicate share"}, false)
+	}
+	if lowDifficulty {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrLowDifficulty, "low difficulty share"}, false)
+	}
+	if bytes.Compare(id[:], job.target[:]) > 0 {
+		return true, m.recordStratumShare(s, sc, nil, false)
+	}
+
+	// The share solves the block.
+	b := job.solvedBlock(extranonce, timestamp, bn)
+	if b.ID() != id {
+		m.log.Critical("stratum block reconstruction failed")
+	}
+	err := m.managedSubmitBlock(b)
+	if errors.Contains(err, modules.ErrNonExtendingBlock) {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrJobNotFound, "stale share"}, false)
+	} else if err != nil {
+		return nil, m.recordStratumShare(s, sc, stratumError{stratumErrOther, err.Error()}, false)
+	}
+	m.log.Printf("Block %v found by stratum worker %v\n", id, worker)
+	return true, m.recordStratumShare(s, sc, nil, true)
+}
+
+// recordStratumShare updates the share statistics for the outcome of a share
+// and returns the error that is sent to the client. Shares for jobs with an
+// outdated parent count as stale, all other errors count as rejected.
+func (m *Miner) recordStratumShare(s *stratumServer, sc *stratumConn, err error, block bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	se, _ := err.(stratumError)
+	switch {
+	case err == nil:
+		s.acceptedShares++
+		sc.acceptedShares++
+		sc.retargetShares++
+		sc.lastShare = time.Now()
+		if block {
+			s.blocksFound++
+		}
+		if sc.retarget() {
+			difficulty := sc.difficulty
+			go func() {
+				if err := sc.managedNotify(nil, difficulty, false); err != nil {
+					sc.conn.Close()
+				}
+			}()
+		}
+	case se.code == stratumErrJobNotFound:
+		s.staleShares++
+		sc.staleShares++
+	default:
+		s.rejectedShares++
+		sc.rejectedShares++
+	}
+	return err
+}
+
+// StratumStats returns the share statistics of the stratum server.
+func (m *Miner) StratumStats() modules.StratumStats {
+	m.mu.RLock()
+	s := m.stratum
+	m.mu.RUnlock()
+	if s == nil {
+		return modules.StratumStats{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := modules.StratumStats{
+		Address:        s.listener.Addr().String(),
+		AcceptedShares: s.acceptedShares,
+		StaleShares:    s.staleShares,
+		RejectedShares: s.rejectedShares,
+		BlocksFound:    s.blocksFound,
+		Workers:        make([]modules.StratumWorker, 0, len(s.conns)),
+	}
+	for sc := range s.conns {
+		if !sc.authorized {
+			continue
+		}
+		stats.Workers = append(stats.Workers, modules.StratumWorker{
+			Name:           sc.worker,
+			RemoteAddress:  sc.conn.RemoteAddr().String(),
+			Difficulty:     sc.difficulty,
+			AcceptedShares: sc.acceptedShares,
+			StaleShares:    sc.staleShares,
+			RejectedShares: sc.rejectedShares,
+			LastShare:      sc.lastShare,
+		})
+	}
+	sort.Slice(stats.Workers, func(i, j int) bool {
+		return stats.Workers[i].RemoteAddress < stats.Workers[j].RemoteAddress
+	})
+	return stats
+}
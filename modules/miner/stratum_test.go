@@ -0,0 +1,405 @@
+package miner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// stratumTestClient is a minimal stratum client.
+type stratumTestClient struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	nextID  int
+
+	extranonce1 []byte
+	difficulty  float64
+	jobs        []stratumTestJob
+}
+
+// stratumTestJob is a job received by a stratumTestClient.
+type stratumTestJob struct {
+	id        string
+	parentID  types.BlockID
+	coinbase1 []byte
+	coinbase2 []byte
+	branch    []crypto.Hash
+	target    types.Target
+	ntime     []byte
+	clean     bool
+}
+
+// newStratumTestClient connects to the stratum server at addr.
+func newStratumTestClient(addr string) (*stratumTestClient, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &stratumTestClient{
+		conn:    conn,
+		scanner: bufio.NewScanner(conn),
+	}, nil
+}
+
+// readMessage reads the next message and processes it if it is a
+// notification.
+func (c *stratumTestClient) readMessage() (resp stratumTestResponse, notification bool, err error) {
+	if err := c.conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return resp, false, err
+	}
+	if !c.scanner.Scan() {
+		return resp, false, errors.Compose(errors.New("connection closed"), c.scanner.Err())
+	}
+	var msg struct {
+		stratumTestResponse
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(c.scanner.Bytes(), &msg); err != nil {
+		return resp, false, err
+	}
+	switch msg.Method {
+	case "":
+		return msg.stratumTestResponse, false, nil
+	case "mining.set_difficulty":
+		return resp, true, json.Unmarshal(msg.Params[0], &c.difficulty)
+	case "mining.notify":
+		var params []interface{}
+		for _, p := range msg.Params {
+			var v interface{}
+			if err := json.Unmarshal(p, &v); err != nil {
+				return resp, true, err
+			}
+			params = append(params, v)
+		}
+		job := stratumTestJob{
+			id:    params[0].(string),
+			clean: params[8].(bool),
+		}
+		decode := func(i int) []byte {
+			b, _ := hex.DecodeString(params[i].(string))
+			return b
+		}
+		copy(job.parentID[:], decode(1))
+		job.coinbase1 = decode(2)
+		job.coinbase2 = decode(3)
+		for _, h := range params[4].([]interface{}) {
+			var hash crypto.Hash
+			b, _ := hex.DecodeString(h.(string))
+			copy(hash[:], b)
+			job.branch = append(job.branch, hash)
+		}
+		copy(job.target[:], decode(6))
+		job.ntime = decode(7)
+		c.jobs = append(c.jobs, job)
+		return resp, true, nil
+	}
+	return resp, true, errors.New("unknown notification " + msg.Method)
+}
+
+// stratumTestResponse is the response to a request of a stratumTestClient.
+type stratumTestResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  []interface{}   `json:"error"`
+}
+
+// call sends a request and waits for the response, processing notifications
+// that arrive in the meantime. If the response is an error, its code is
+// returned.
+func (c *stratumTestClient) call(method string, params ...interface{}) (json.RawMessage, int, error) {
+	c.nextID++
+	req, err := json.Marshal(map[string]interface{}{
+		"id":     c.nextID,
+		"method": method,
+		"params": params,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := c.conn.Write(append(req, '\n')); err != nil {
+		return nil, 0, err
+	}
+	for {
+		resp, notification, err := c.readMessage()
+		if err != nil {
+			return nil, 0, err
+		} else if notification {
+			continue
+		}
+		if resp.Error != nil {
+			return nil, int(resp.Error[0].(float64)), nil
+		}
+		return resp.Result, 0, nil
+	}
+}
+
+// waitForJob reads messages until a job with the provided parent arrives.
+func (c *stratumTestClient) waitForJob(parentID types.BlockID) (stratumTestJob, error) {
+	for {
+		if len(c.jobs) > 0 && c.jobs[len(c.jobs)-1].parentID == parentID {
+			return c.jobs[len(c.jobs)-1], nil
+		}
+		if _, _, err := c.readMessage(); err != nil {
+			return stratumTestJob{}, err
+		}
+	}
+}
+
+// share grinds nonces until it finds a share for the job whose ID compares to
+// the target of the job as requested.
+func (c *stratumTestClient) share(job stratumTestJob, belowTarget bool) (extranonce2, nonce []byte) {
+	extranonce2 = fastrand.Bytes(stratumExtranonce2Size)
+	coinbase := append(append(append(append([]byte(nil), job.coinbase1...), c.extranonce1...), extranonce2...), job.coinbase2...)
+	header := make([]byte, 80)
+	copy(header, job.parentID[:])
+	copy(header[40:], job.ntime)
+	root := stratumMerkleRoot(stratumMerkleLeaf(coinbase), job.branch)
+	copy(header[48:], root[:])
+	for n := uint64(0); ; n += types.ASICHardforkFactor {
+		binary.LittleEndian.PutUint64(header[32:40], n)
+		id := crypto.HashBytes(header)
+		if (bytes.Compare(id[:], job.target[:]) <= 0) == belowTarget {
+			return extranonce2, header[32:40]
+		}
+	}
+}
+
+// submit submits a share for the job and returns the error code, which is
+// zero if the share was accepted.
+func (c *stratumTestClient) submit(job stratumTestJob, extranonce2, nonce []byte) (int, error) {
+	_, code, err := c.call("mining.submit", "worker", job.id, hex.EncodeToString(extranonce2), hex.EncodeToString(job.ntime), hex.EncodeToString(nonce))
+	return code, err
+}
+
+// TestStratumMerkleBranch checks that the branch of the last transaction of a
+// block folds into the merkle root of the block.
+func TestStratumMerkleBranch(t *testing.T) {
+	for payouts := 0; payouts < 3; payouts++ {
+		for txns := 1; txns < 20; txns++ {
+			var b types.Block
+			for i := 0; i < payouts; i++ {
+				b.MinerPayouts = append(b.MinerPayouts, types.SiacoinOutput{Value: types.NewCurrency64(fastrand.Uint64n(100))})
+			}
+			for i := 0; i < txns; i++ {
+				b.Transactions = append(b.Transactions, types.Transaction{ArbitraryData: [][]byte{fastrand.Bytes(8)}})
+			}
+			leaf := stratumMerkleLeaf(encoding.Marshal(b.Transactions[txns-1]))
+			if stratumMerkleRoot(leaf, stratumMerkleBranch(b)) != b.MerkleRoot() {
+				t.Fatalf("wrong merkle root for %v payouts and %v transactions", payouts, txns)
+			}
+		}
+	}
+}
+
+// TestStratumDifficulty checks the conversion between stratum difficulties and
+// targets.
+func TestStratumDifficulty(t *testing.T) {
+	if stratumTarget(1) != stratumDiff1Target {
+		t.Fatal("wrong target for difficulty 1")
+	}
+	if stratumTarget(2) != (types.Target{0, 0, 0, 0, 127, 255, 128}) {
+		t.Fatal("wrong target for difficulty 2", stratumTarget(2))
+	}
+	if stratumTarget(stratumMinDifficulty/2) != types.RootDepth {
+		t.Fatal("targets should not exceed the root depth")
+	}
+	if d := stratumDifficulty(stratumTarget(1024)); d != 1024 {
+		t.Fatal("wrong difficulty", d)
+	}
+}
+
+// TestStratumServer mines on the testing chain with a stratum client.
+func TestStratumServer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := mt.miner.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := mt.miner.StartStratum("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.miner.StartStratum("localhost:0"); !errors.Contains(err, errStratumRunning) {
+		t.Fatal("expected errStratumRunning, got", err)
+	}
+	c, err := newStratumTestClient(mt.miner.StratumStats().Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.conn.Close()
+
+	// Shares can't be submitted before authorizing.
+	if _, code, err := c.call("mining.submit", "worker", "1", "00000000", "0000000000000000", "0000000000000000"); err != nil || code != stratumErrNotSubscribed {
+		t.Fatal("expected error code", stratumErrNotSubscribed, "got", code, err)
+	}
+
+	// Subscribe and authorize.
+	result, code, err := c.call("mining.subscribe", "test/1.0")
+	if err != nil || code != 0 {
+		t.Fatal(code, err)
+	}
+	var subscription []json.RawMessage
+	var extranonce1 string
+	var extranonce2Size int
+	if err := json.Unmarshal(result, &subscription); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(subscription[1], &extranonce1); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(subscription[2], &extranonce2Size); err != nil {
+		t.Fatal(err)
+	} else if extranonce2Size != stratumExtranonce2Size {
+		t.Fatal("wrong extranonce2 size", extranonce2Size)
+	}
+	c.extranonce1, _ = hex.DecodeString(extranonce1)
+	if _, code, err := c.call("mining.authorize", "worker", "x"); err != nil || code != 0 {
+		t.Fatal(code, err)
+	}
+
+	// The first job should build on the current block.
+	job, err := c.waitForJob(mt.cs.CurrentBlock().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.jobs[0].clean {
+		t.Fatal("first job should be clean")
+	}
+	if c.difficulty != stratumDefaultDifficulty {
+		t.Fatal("wrong initial difficulty", c.difficulty)
+	}
+
+	// Use the lowest possible difficulty, so that every hash is a share.
+	if _, code, err := c.call("mining.suggest_difficulty", 1e-30); err != nil || code != 0 {
+		t.Fatal(code, err)
+	}
+
+	// Submit a share that doesn't solve the block.
+	extranonce2, nonce := c.share(job, false)
+	if code, err := c.submit(job, extranonce2, nonce); err != nil || code != 0 {
+		t.Fatal("share wasn't accepted", code, err)
+	}
+	if code, err := c.submit(job, extranonce2, nonce); err != nil || code != stratumErrDuplicate {
+		t.Fatal("expected error code", stratumErrDuplicate, "got", code, err)
+	}
+
+	// Submit a share that solves the block.
+	height := mt.cs.Height()
+	extranonce2, nonce = c.share(job, true)
+	if code, err := c.submit(job, extranonce2, nonce); err != nil || code != 0 {
+		t.Fatal("block wasn't accepted", code, err)
+	}
+	if mt.cs.Height() != height+1 {
+		t.Fatal("block wasn't added to consensus")
+	}
+
+	// A new job should be pushed and shares for the old job are stale.
+	newJob, err := c.waitForJob(mt.cs.CurrentBlock().ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newJob.clean {
+		t.Fatal("job for a new block should be clean")
+	}
+	extranonce2, nonce = c.share(job, false)
+	if code, err := c.submit(job, extranonce2, nonce); err != nil || code != stratumErrJobNotFound {
+		t.Fatal("expected error code", stratumErrJobNotFound, "got", code, err)
+	}
+
+	// Shares that don't meet the share difficulty are rejected.
+	if _, code, err := c.call("mining.suggest_difficulty", 1e30); err != nil || code != 0 {
+		t.Fatal(code, err)
+	}
+	extranonce2, nonce = c.share(newJob, false)
+	if code, err := c.submit(newJob, extranonce2, nonce); err != nil || code != stratumErrLowDifficulty {
+		t.Fatal("expected error code", stratumErrLowDifficulty, "got", code, err)
+	}
+
+	// Check the statistics.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		stats := mt.miner.StratumStats()
+		if stats.AcceptedShares != 2 || stats.StaleShares != 1 || stats.RejectedShares != 2 || stats.BlocksFound != 1 {
+			return errors.New("wrong share statistics")
+		}
+		if len(stats.Workers) != 1 {
+			return errors.New("wrong number of workers")
+		}
+		w := stats.Workers[0]
+		if w.Name != "worker" || w.AcceptedShares != 2 || w.StaleShares != 1 || w.RejectedShares != 2 || w.Difficulty != 1e30 {
+			return errors.New("wrong worker statistics")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err, mt.miner.StratumStats())
+	}
+}
+
+// TestStratumJobsKeepSourceBlock checks that stratum jobs for transaction pool
+// updates don't replace the source block of HeaderForWork.
+func TestStratumJobsKeepSourceBlock(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := mt.miner.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := mt.miner.StartStratum("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	header, target, err := mt.miner.HeaderForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobCounter := func() uint64 {
+		mt.miner.stratum.mu.Lock()
+		defer mt.miner.stratum.mu.Unlock()
+		return mt.miner.stratum.jobCounter
+	}
+	mt.miner.mu.Lock()
+	sourceBlock := mt.miner.sourceBlock
+	mt.miner.mu.Unlock()
+	jobs := jobCounter()
+
+	// A transaction pool update after the job interval creates a new job.
+	time.Sleep(stratumJobInterval)
+	mt.miner.ReceiveUpdatedUnconfirmedTransactions(&modules.TransactionPoolDiff{})
+	if jobCounter() != jobs+1 {
+		t.Fatal("transaction pool update didn't create a new job")
+	}
+	mt.miner.mu.Lock()
+	replaced := mt.miner.sourceBlock != sourceBlock
+	mt.miner.mu.Unlock()
+	if replaced {
+		t.Fatal("transaction pool update replaced the source block")
+	}
+
+	// The header can still be submitted.
+	if err := mt.miner.SubmitHeader(solveHeader(header, target)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,150 @@
+package miner
+
+// template.go exposes the block the miner is working on as a template, so that
+// pool software can apply its own transaction policy and miner payouts.
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errTemplatePayout is returned if the miner payouts of a submitted block
+	// don't add up to the subsidy and the fees of its transactions.
+	errTemplatePayout = errors.New("miner payouts don't match the block subsidy")
+
+	// errTemplateStale is returned if a submitted block doesn't build on the
+	// parent of the current block template.
+	errTemplateStale = errors.New("block doesn't extend the current block template")
+)
+
+// templateChanged is called whenever the parent or the transactions of the
+// unsolved block change. It wakes up callers that are long-polling for a new
+// template. The caller must hold the lock of the miner.
+func (m *Miner) templateChanged() {
+	m.templateCounter++
+	close(m.templateChan)
+	m.templateChan = make(chan struct{})
+}
+
+// templateID returns the ID of the current block template. The caller must
+// hold the lock of the miner.
+func (m *Miner) templateID() string {
+	return fmt.Sprintf("%v-%v", m.persist.UnsolvedBlock.ParentID, m.templateCounter)
+}
+
+// blockTemplate returns the current block template. The caller must hold the
+// lock of the miner.
+func (m *Miner) blockTemplate() modules.BlockTemplate {
+	b := m.persist.UnsolvedBlock
+	height := m.persist.Height + 1
+	bt := modules.BlockTemplate{
+		ID:           m.templateID(),
+		ParentID:     b.ParentID,
+		Height:       height,
+		Timestamp:    b.Timestamp,
+		MinTimestamp: b.Timestamp,
+		Target:       m.persist.Target,
+		Subsidy:      types.CalculateCoinbase(height),
+	}
+	if bt.Timestamp < types.CurrentTimestamp() {
+		bt.Timestamp = types.CurrentTimestamp()
+	}
+
+	// Collect the sets in the order they appear in the unsolved block.
+	setIDs := make(map[splitSetID]modules.TransactionSetID)
+	for id, splits := range m.fullSets {
+		for _, split := range splits {
+			setIDs[splitSetID(split)] = id
+		}
+	}
+	elems := append([]*mapElement(nil), m.blockMapHeap.data...)
+	sort.Slice(elems, func(i, j int) bool {
+		ti, tj := elems[i].set.transactions[0].ID(), elems[j].set.transactions[0].ID()
+		return m.unsolvedBlockIndex[ti] < m.unsolvedBlockIndex[tj]
+	})
+	for _, elem := range elems {
+		var fee types.Currency
+		for _, txn := range elem.set.transactions {
+			for _, f := range txn.MinerFees {
+				fee = fee.Add(f)
+			}
+		}
+		bt.TransactionSets = append(bt.TransactionSets, modules.BlockTemplateSet{
+			ID:           crypto.Hash(setIDs[elem.id]),
+			Transactions: append([]types.Transaction(nil), elem.set.transactions...),
+			Fee:          fee,
+			Size:         elem.set.size,
+		})
+	}
+	return bt
+}
+
+// BlockTemplate returns the block the miner is currently working on. If
+// longPollID is the ID of the current template, BlockTemplate blocks until the
+// template changes or cancel is closed, and then returns the template at that
+// time.
+func (m *Miner) BlockTemplate(longPollID string, cancel <-chan struct{}) (modules.BlockTemplate, error) {
+	if err := m.tg.Add(); err != nil {
+		return modules.BlockTemplate{}, err
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for longPollID != "" && longPollID == m.templateID() {
+		c := m.templateChan
+		m.mu.Unlock()
+		select {
+		case <-c:
+		case <-cancel:
+			longPollID = ""
+		case <-m.tg.StopChan():
+			m.mu.Lock()
+			return modules.BlockTemplate{}, errors.New("miner is shutting down")
+		}
+		m.mu.Lock()
+	}
+	return m.blockTemplate(), nil
+}
+
+// SubmitBlockTemplate accepts a solved block that was assembled from a block
+// template. Unlike blocks that were created by the miner itself, the caller
+// chose the transactions and payouts, so an invalid block is the caller's
+// problem and doesn't cause the transaction pool to be purged.
+func (m *Miner) SubmitBlockTemplate(b types.Block) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	m.mu.RLock()
+	parentID := m.persist.UnsolvedBlock.ParentID
+	height := m.persist.Height + 1
+	m.mu.RUnlock()
+	if b.ParentID != parentID {
+		return errTemplateStale
+	}
+	var payout types.Currency
+	for _, sco := range b.MinerPayouts {
+		payout = payout.Add(sco.Value)
+	}
+	if !payout.Equals(b.CalculateSubsidy(height)) {
+		return errTemplatePayout
+	}
+
+	err := m.cs.AcceptBlock(b)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.persist.BlocksFound = append(m.persist.BlocksFound, b.ID())
+	return m.saveSync()
+}
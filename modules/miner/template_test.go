@@ -0,0 +1,163 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// templateBlock assembles and solves a block from the template that includes
+// the provided transaction sets.
+func templateBlock(bt modules.BlockTemplate, sets ...int) types.Block {
+	b := types.Block{
+		ParentID:  bt.ParentID,
+		Timestamp: bt.Timestamp,
+	}
+	payout := bt.Subsidy
+	for _, i := range sets {
+		b.Transactions = append(b.Transactions, bt.TransactionSets[i].Transactions...)
+		payout = payout.Add(bt.TransactionSets[i].Fee)
+	}
+	b.MinerPayouts = []types.SiacoinOutput{{Value: payout}}
+	for {
+		solved, ok := solveBlock(b, bt.Target)
+		if ok {
+			return solved
+		}
+		b.Timestamp++
+	}
+}
+
+// TestBlockTemplate checks that block templates follow the transaction pool
+// and the consensus set, and that blocks assembled from them are accepted.
+func TestBlockTemplate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mine past the ASIC hardfork, which changes the signatures of
+	// transactions.
+	for mt.cs.Height() < types.ASICHardforkHeight {
+		if _, err := mt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bt, err := mt.miner.BlockTemplate("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bt.ParentID != mt.cs.CurrentBlock().ID() || bt.Height != mt.cs.Height()+1 {
+		t.Fatal("template doesn't build on the current block")
+	}
+	if target, _ := mt.cs.ChildTarget(bt.ParentID); bt.Target != target {
+		t.Fatal("wrong target")
+	}
+	if !bt.Subsidy.Equals(types.CalculateCoinbase(bt.Height)) {
+		t.Fatal("wrong subsidy")
+	}
+	if len(bt.TransactionSets) != 0 {
+		t.Fatal("template should not contain transactions")
+	}
+
+	// Long-poll for a new template that is cancelled.
+	cancel := make(chan struct{})
+	close(cancel)
+	same, err := mt.miner.BlockTemplate(bt.ID, cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.ID != bt.ID {
+		t.Fatal("template should not have changed")
+	}
+
+	// Long-poll for a new template and add a transaction.
+	templateChan := make(chan modules.BlockTemplate)
+	go func() {
+		newTemplate, err := mt.miner.BlockTemplate(bt.ID, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		templateChan <- newTemplate
+	}()
+	txns, err := mt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var newTemplate modules.BlockTemplate
+	select {
+	case newTemplate = <-templateChan:
+	case <-time.After(10 * time.Second):
+		t.Fatal("long-poll didn't return")
+	}
+	if newTemplate.ID == bt.ID {
+		t.Fatal("template ID didn't change")
+	}
+	newTemplate, err = mt.miner.BlockTemplate("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newTemplate.TransactionSets) != 1 {
+		t.Fatal("expected one transaction set, got", len(newTemplate.TransactionSets))
+	}
+	set := newTemplate.TransactionSets[0]
+	if len(set.Transactions) != len(txns) || set.Transactions[len(txns)-1].ID() != txns[len(txns)-1].ID() {
+		t.Fatal("template contains the wrong transactions")
+	}
+	if set.Fee.IsZero() || set.Size == 0 {
+		t.Fatal("set is missing fee or size")
+	}
+
+	// A block with wrong payouts is rejected.
+	b := templateBlock(newTemplate)
+	b.MinerPayouts[0].Value = b.MinerPayouts[0].Value.Add64(1)
+	if err := mt.miner.SubmitBlockTemplate(b); !errors.Contains(err, errTemplatePayout) {
+		t.Fatal("expected errTemplatePayout, got", err)
+	}
+
+	// Submit a block that leaves out the transaction.
+	height := mt.cs.Height()
+	if err := mt.miner.SubmitBlockTemplate(templateBlock(newTemplate)); err != nil {
+		t.Fatal(err)
+	}
+	if mt.cs.Height() != height+1 {
+		t.Fatal("block wasn't accepted")
+	}
+
+	// Blocks for the old template are stale.
+	if err := mt.miner.SubmitBlockTemplate(templateBlock(newTemplate, 0)); !errors.Contains(err, errTemplateStale) {
+		t.Fatal("expected errTemplateStale, got", err)
+	}
+
+	// Submit a block that includes the transaction.
+	newTemplate, err = mt.miner.BlockTemplate("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newTemplate.TransactionSets) != 1 {
+		t.Fatal("transaction should still be in the template")
+	}
+	if err := mt.miner.SubmitBlockTemplate(templateBlock(newTemplate, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if mt.cs.Height() != height+2 {
+		t.Fatal("block wasn't accepted")
+	}
+	newTemplate, err = mt.miner.BlockTemplate("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newTemplate.TransactionSets) != 0 {
+		t.Fatal("confirmed transaction should not be in the template")
+	}
+	if good, _ := mt.miner.BlocksMined(); good != 2 {
+		t.Fatal("template blocks should count as mined", good)
+	}
+}
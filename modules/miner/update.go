@@ -2,6 +2,7 @@ package miner
 
 import (
 	"sort"
+	"time"
 
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -266,8 +267,10 @@ func (m *Miner) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// the stale rate as low as possible.
 	if cc.Synced {
 		m.newSourceBlock()
+		m.newStratumJob()
 	}
 	m.persist.RecentChange = cc.ID
+	m.templateChanged()
 }
 
 // ReceiveUpdatedUnconfirmedTransactions will replace the current unconfirmed
@@ -278,6 +281,20 @@ func (m *Miner) ReceiveUpdatedUnconfirmedTransactions(diff *modules.TransactionP
 
 	m.deleteReverts(diff)
 	m.addNewTxns(diff)
+	m.templateChanged()
+
+	// Stratum miners only pick up the new transactions with a new job, which
+	// is rate limited to avoid resetting their work with every transaction.
+	// The source block is left alone, since replacing it would evict the
+	// headers that were handed out by HeaderForWork.
+	if m.stratum != nil {
+		m.stratum.mu.Lock()
+		jobTime := m.stratum.jobTime
+		m.stratum.mu.Unlock()
+		if time.Since(jobTime) > stratumJobInterval {
+			m.newStratumJob()
+		}
+	}
 }
 
 // removeSplitSetFromUnsolvedBlock removes a split set from the miner's unsolved
@@ -94,6 +94,26 @@ func (na NetAddress) IsLocal() bool {
 	return false
 }
 
+// IsOnion returns true if the NetAddress is a Tor onion service address.
+// Onion services can only be reached through a SOCKS5 proxy.
+func (na NetAddress) IsOnion() bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(na.Host(), ".")), ".onion")
+}
+
+// isOnionServiceID returns true if label is a v2 or v3 onion service ID, which
+// is the base32 encoding of the service's key.
+func isOnionServiceID(label string) bool {
+	if len(label) != 16 && len(label) != 56 {
+		return false
+	}
+	for _, r := range strings.ToLower(label) {
+		if !('a' <= r && r <= 'z' || '2' <= r && r <= '7') {
+			return false
+		}
+	}
+	return true
+}
+
 // IsValid is an extension to IsStdValid that also forbids the loopback address.
 //
 // NOTE: IsValid is being phased out in favor of allowing the loopback address
@@ -111,7 +131,8 @@ func (na NetAddress) IsValid() error {
 // is of the form "host:port", such that "host" is either a valid IPv4/IPv6
 // address or a valid hostname, and "port" is an integer in the range [1,65535].
 // Valid IPv4 addresses, IPv6 addresses, and hostnames are detailed in RFCs 791,
-// 2460, and 952, respectively. Loopback addresses are allowed.
+// 2460, and 952, respectively. Loopback addresses are allowed. Hostnames ending
+// in ".onion" must contain a valid v2 or v3 onion service ID.
 func (na NetAddress) IsStdValid() error {
 	// Verify the port number.
 	host, port, err := net.SplitHostPort(string(na))
@@ -150,6 +171,9 @@ func (na NetAddress) IsStdValid() error {
 		if len(labels) == 1 {
 			return errors.New("unqualified hostname")
 		}
+		if na.IsOnion() && !isOnionServiceID(labels[len(labels)-2]) {
+			return errors.New("invalid onion service address")
+		}
 		for _, label := range labels {
 			if len(label) < 1 || len(label) > 63 {
 				return errors.New("hostname contains label with invalid length")
@@ -330,3 +330,36 @@ func TestIsLocal(t *testing.T) {
 		}
 	}
 }
+
+// TestIsOnion checks that onion service addresses are recognized and
+// validated.
+func TestIsOnion(t *testing.T) {
+	t.Parallel()
+
+	testSet := []struct {
+		query    NetAddress
+		onion    bool
+		stdValid bool
+	}{
+		{"3g2upl4pq6kufc4m.onion:9981", true, true},
+		{"2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion:9981", true, true},
+		{"2GZYXA5IHM7NSGGFXNU52RCK2VV4RVMDLKIU3ZZUI5DU4XYCLEN53WID.ONION:9981", true, true},
+		{"sub.3g2upl4pq6kufc4m.onion:9981", true, true},
+		{"3g2upl4pq6kufc4m.onion.:9981", true, true},
+		{"tooshort.onion:9981", true, false},
+		{"3g2upl4pq6kufc4m1.onion:9981", true, false},
+		{"3g2upl4pq6kufc41.onion:9981", true, false},
+		{"onion:9981", false, false},
+		{"notonion.com:9981", false, true},
+		{"3g2upl4pq6kufc4m.onion.com:9981", false, true},
+		{"127.0.0.1:9981", false, true},
+	}
+	for _, test := range testSet {
+		if test.query.IsOnion() != test.onion {
+			t.Errorf("IsOnion returned %v for %q", !test.onion, test.query)
+		}
+		if err := test.query.IsStdValid(); (err == nil) != test.stdValid {
+			t.Errorf("IsStdValid returned %v for %q", err, test.query)
+		}
+	}
+}
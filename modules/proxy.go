@@ -0,0 +1,279 @@
+package modules
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/net/proxy"
+
+	"go.sia.tech/siad/build"
+)
+
+var (
+	// GlobalProxy is the global object for routing outbound connections
+	// through a SOCKS5 proxy. It is used by the gateway for connections to
+	// peers and by the renter and hostdb for connections to hosts.
+	GlobalProxy = new(ProxyDialer)
+
+	// ErrOnionWithoutProxy is returned when dialing an onion service without a
+	// proxy.
+	ErrOnionWithoutProxy = errors.New("onion services can only be reached through a SOCKS5 proxy")
+
+	// ErrTooManyProxyTunnels is returned when a tunnel is requested while
+	// MaxProxyTunnels tunnels are in use or were handed out recently.
+	ErrTooManyProxyTunnels = errors.New("too many proxy tunnels in use")
+
+	// MaxProxyTunnels is the maximum number of proxy tunnels that are open at
+	// once.
+	MaxProxyTunnels = build.Select(build.Var{
+		Standard: 256,
+		Testnet:  256,
+		Dev:      64,
+		Testing:  4,
+	}).(int)
+
+	// proxyTunnelIdleTimeout is how long a proxy tunnel stays open after its
+	// last connection was closed.
+	proxyTunnelIdleTimeout = build.Select(build.Var{
+		Standard: 5 * time.Minute,
+		Testnet:  5 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// proxyTunnelDialTimeout is the timeout for dialing the target of a proxy
+	// tunnel.
+	proxyTunnelDialTimeout = build.Select(build.Var{
+		Standard: 2 * time.Minute,
+		Testnet:  2 * time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// ProxyDialer dials outbound connections either directly or, if a proxy
+	// address is set, through a SOCKS5 proxy. Hostnames are resolved by the
+	// proxy, which allows for reaching Tor onion services.
+	ProxyDialer struct {
+		address string
+		tunnels map[string]*proxyTunnel
+		mu      sync.Mutex
+	}
+
+	// proxyTunnel is a local listener that forwards all connections to a
+	// remote address through the proxy. It is used for connections that are
+	// dialed by libraries which don't support a custom dialer, such as the
+	// SiaMux. A tunnel is closed once it had no connections for
+	// proxyTunnelIdleTimeout.
+	proxyTunnel struct {
+		listener net.Listener
+		target   string
+
+		// active is the number of open connections and lastUsed the time the
+		// last one was closed or the tunnel was created. They are protected
+		// by the mutex of the ProxyDialer.
+		active   int
+		lastUsed time.Time
+	}
+)
+
+// Address returns the address of the SOCKS5 proxy, or the empty string if
+// connections are dialed directly.
+func (pd *ProxyDialer) Address() string {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	return pd.address
+}
+
+// SetAddress sets the address of the SOCKS5 proxy. An empty address disables
+// the proxy. Existing tunnels are closed.
+func (pd *ProxyDialer) SetAddress(address string) error {
+	if address != "" {
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return errors.AddContext(err, "invalid proxy address")
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return errors.New("invalid proxy port")
+		}
+	}
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	pd.address = address
+	var err error
+	for target, t := range pd.tunnels {
+		err = errors.Compose(err, t.listener.Close())
+		delete(pd.tunnels, target)
+	}
+	return err
+}
+
+// Dial dials addr using the timeout, cancel channel and local address of the
+// provided dialer. If a proxy is set, the connection is made through the
+// proxy instead.
+func (pd *ProxyDialer) Dial(d *net.Dialer, addr NetAddress) (net.Conn, error) {
+	address := pd.Address()
+	if address == "" {
+		if addr.IsOnion() {
+			return nil, ErrOnionWithoutProxy
+		}
+		return d.Dial("tcp", string(addr))
+	}
+
+	// The SOCKS5 handshake is bound by the context rather than the dialer, so
+	// translate the dialer's timeout and cancel channel.
+	ctx, cancel := context.WithCancel(context.Background())
+	if d.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), d.Timeout)
+	}
+	defer cancel()
+	if d.Cancel != nil {
+		go func() {
+			select {
+			case <-d.Cancel:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	socks, err := proxy.SOCKS5("tcp", address, nil, d)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create SOCKS5 dialer")
+	}
+	conn, err := socks.(proxy.ContextDialer).DialContext(ctx, "tcp", string(addr))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to dial through SOCKS5 proxy")
+	}
+	return conn, nil
+}
+
+// TunnelAddress returns the address that should be dialed by libraries that
+// don't support a custom dialer in order to reach addr. Without a proxy, that
+// is addr itself. With a proxy, it is the address of a local tunnel that
+// forwards connections to addr through the proxy.
+func (pd *ProxyDialer) TunnelAddress(addr string) (string, error) {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	if pd.address == "" {
+		return addr, nil
+	}
+	if t, exists := pd.tunnels[addr]; exists {
+		// Keep the tunnel open for the caller.
+		t.lastUsed = time.Now()
+		return t.listener.Addr().String(), nil
+	}
+	if len(pd.tunnels) >= MaxProxyTunnels && !pd.closeIdleTunnel() {
+		return "", ErrTooManyProxyTunnels
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.AddContext(err, "failed to create proxy tunnel")
+	}
+	t := &proxyTunnel{
+		listener: l,
+		target:   addr,
+		lastUsed: time.Now(),
+	}
+	if pd.tunnels == nil {
+		pd.tunnels = make(map[string]*proxyTunnel)
+	}
+	pd.tunnels[addr] = t
+	go pd.threadedServeTunnel(t)
+	time.AfterFunc(proxyTunnelIdleTimeout, func() { pd.managedCloseTunnelIfIdle(t) })
+	return l.Addr().String(), nil
+}
+
+// closeIdleTunnel closes the least recently used tunnel that had no
+// connections for proxyTunnelIdleTimeout. It returns false if there is no such
+// tunnel. Tunnels that were used more recently are kept, since their address
+// might have just been handed out and not been dialed yet.
+func (pd *ProxyDialer) closeIdleTunnel() bool {
+	var idle *proxyTunnel
+	for _, t := range pd.tunnels {
+		if t.active > 0 || time.Since(t.lastUsed) < proxyTunnelIdleTimeout {
+			continue
+		}
+		if idle == nil || t.lastUsed.Before(idle.lastUsed) {
+			idle = t
+		}
+	}
+	if idle == nil {
+		return false
+	}
+	pd.closeTunnel(idle)
+	return true
+}
+
+// closeTunnel closes the tunnel's listener and removes it. Connections that
+// are already forwarded stay open.
+func (pd *ProxyDialer) closeTunnel(t *proxyTunnel) {
+	_ = t.listener.Close()
+	if pd.tunnels[t.target] == t {
+		delete(pd.tunnels, t.target)
+	}
+}
+
+// managedCloseTunnelIfIdle closes the tunnel if it had no connections for
+// proxyTunnelIdleTimeout. Otherwise it checks again once the timeout could
+// have passed.
+func (pd *ProxyDialer) managedCloseTunnelIfIdle(t *proxyTunnel) {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	if pd.tunnels[t.target] != t {
+		return // already closed
+	}
+	idle := time.Since(t.lastUsed)
+	if t.active == 0 && idle >= proxyTunnelIdleTimeout {
+		pd.closeTunnel(t)
+		return
+	}
+	wait := proxyTunnelIdleTimeout - idle
+	if t.active > 0 || wait <= 0 {
+		wait = proxyTunnelIdleTimeout
+	}
+	time.AfterFunc(wait, func() { pd.managedCloseTunnelIfIdle(t) })
+}
+
+// threadedServeTunnel accepts connections on the tunnel's listener and
+// forwards them through the proxy until the listener is closed.
+func (pd *ProxyDialer) threadedServeTunnel(t *proxyTunnel) {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		pd.mu.Lock()
+		t.active++
+		pd.mu.Unlock()
+		go func() {
+			defer func() {
+				pd.mu.Lock()
+				t.active--
+				t.lastUsed = time.Now()
+				pd.mu.Unlock()
+			}()
+			defer conn.Close()
+			remote, err := pd.Dial(&net.Dialer{Timeout: proxyTunnelDialTimeout}, NetAddress(t.target))
+			if err != nil {
+				return
+			}
+			defer remote.Close()
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(remote, conn)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(conn, remote)
+				done <- struct{}{}
+			}()
+			<-done
+		}()
+	}
+}
@@ -0,0 +1,425 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// testSOCKS5Server is a minimal SOCKS5 server that supports the CONNECT
+// command without authentication. All connections are forwarded to target,
+// regardless of the requested address, which is recorded instead.
+type testSOCKS5Server struct {
+	listener net.Listener
+	target   string
+
+	requested []string
+	mu        sync.Mutex
+}
+
+// newTestSOCKS5Server creates a SOCKS5 server that forwards all connections to
+// target.
+func newTestSOCKS5Server(t *testing.T, target string) *testSOCKS5Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSOCKS5Server{
+		listener: l,
+		target:   target,
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		l.Close()
+	})
+	return s
+}
+
+// Requested returns the addresses that were requested from the server.
+func (s *testSOCKS5Server) Requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requested...)
+}
+
+// handle performs the SOCKS5 handshake and forwards the connection.
+func (s *testSOCKS5Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// Method negotiation.
+	var hdr [2]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil || hdr[0] != 5 {
+		return
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil || !bytes.Contains(methods, []byte{0}) {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	// Request.
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil || req[1] != 1 {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		var n [1]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(conn, port[:]); err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.requested = append(s.requested, net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))))
+	s.mu.Unlock()
+
+	remote, err := net.Dial("tcp", s.target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer remote.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0}); err != nil {
+		return
+	}
+	go io.Copy(remote, conn)
+	io.Copy(conn, remote)
+}
+
+// newTestEchoServer creates a server that echoes everything it reads.
+func newTestEchoServer(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		l.Close()
+	})
+	return l
+}
+
+// testEcho checks that conn is connected to an echo server.
+func testEcho(conn net.Conn) error {
+	defer conn.Close()
+	return testEchoOpen(conn)
+}
+
+// testEchoOpen is like testEcho but leaves the connection open.
+func testEchoOpen(conn net.Conn) error {
+	msg := []byte("hello")
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	resp := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return err
+	}
+	if !bytes.Equal(resp, msg) {
+		return errors.New("wrong echo")
+	}
+	return nil
+}
+
+// TestProxyDialer checks that connections are dialed directly without a proxy
+// and through the proxy with one.
+func TestProxyDialer(t *testing.T) {
+	t.Parallel()
+
+	echo := newTestEchoServer(t)
+	socks := newTestSOCKS5Server(t, echo.Addr().String())
+	pd := new(ProxyDialer)
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	// Without a proxy the address is dialed directly.
+	conn, err := pd.Dial(dialer, NetAddress(echo.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testEcho(conn); err != nil {
+		t.Fatal(err)
+	}
+	onion := NetAddress("2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion:9982")
+	if _, err := pd.Dial(dialer, onion); !errors.Contains(err, ErrOnionWithoutProxy) {
+		t.Fatal("expected ErrOnionWithoutProxy, got", err)
+	}
+
+	// Invalid proxy addresses are rejected.
+	for _, addr := range []string{"localhost", "localhost:0", "localhost:foo", ":99999"} {
+		if err := pd.SetAddress(addr); err == nil {
+			t.Errorf("proxy address %q should be invalid", addr)
+		}
+	}
+	if pd.Address() != "" {
+		t.Fatal("invalid address was set")
+	}
+
+	// With a proxy, hostnames are resolved by the proxy.
+	if err := pd.SetAddress(socks.listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []NetAddress{"sia.tech:9981", onion} {
+		conn, err := pd.Dial(dialer, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := testEcho(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requested := socks.Requested()
+	if len(requested) != 2 || requested[0] != "sia.tech:9981" || requested[1] != string(onion) {
+		t.Fatal("wrong addresses requested from proxy", requested)
+	}
+
+	// Closing the cancel channel aborts the dial.
+	cancel := make(chan struct{})
+	close(cancel)
+	if _, err := pd.Dial(&net.Dialer{Cancel: cancel}, onion); err == nil {
+		t.Fatal("dial should have been cancelled")
+	}
+
+	// Removing the proxy dials directly again.
+	if err := pd.SetAddress(""); err != nil {
+		t.Fatal(err)
+	}
+	conn, err = pd.Dial(dialer, NetAddress(echo.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testEcho(conn); err != nil {
+		t.Fatal(err)
+	}
+	if len(socks.Requested()) != 2 {
+		t.Fatal("connection shouldn't have used the proxy")
+	}
+}
+
+// TestProxyTunnel checks that tunnels forward connections through the proxy.
+func TestProxyTunnel(t *testing.T) {
+	t.Parallel()
+
+	echo := newTestEchoServer(t)
+	socks := newTestSOCKS5Server(t, echo.Addr().String())
+	pd := new(ProxyDialer)
+
+	// Without a proxy there is no tunnel.
+	target := "2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion:9983"
+	addr, err := pd.TunnelAddress(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != target {
+		t.Fatal("address shouldn't change without a proxy")
+	}
+
+	// With a proxy, connections to the tunnel are forwarded to the target.
+	if err := pd.SetAddress(socks.listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	addr, err = pd.TunnelAddress(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr2, err := pd.TunnelAddress(target); err != nil || addr2 != addr {
+		t.Fatal("tunnel should be reused", addr, addr2, err)
+	}
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := testEcho(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	requested := socks.Requested()
+	if len(requested) != 2 || requested[0] != target || requested[1] != target {
+		t.Fatal("wrong addresses requested from proxy", requested)
+	}
+
+	// Changing the proxy closes the tunnel.
+	if err := pd.SetAddress(""); err != nil {
+		t.Fatal(err)
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Fatal("tunnel should be closed")
+	}
+}
+
+// TestProxyTunnelLimits checks that idle tunnels are closed and that the
+// number of tunnels is capped.
+func TestProxyTunnelLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	echo := newTestEchoServer(t)
+	socks := newTestSOCKS5Server(t, echo.Addr().String())
+	pd := new(ProxyDialer)
+	if err := pd.SetAddress(socks.listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	numTunnels := func() int {
+		pd.mu.Lock()
+		defer pd.mu.Unlock()
+		return len(pd.tunnels)
+	}
+	target := func(i int) string {
+		return fmt.Sprintf("host%d.example.com:9983", i)
+	}
+
+	// A tunnel with an open connection isn't closed while the connection is
+	// open.
+	addr, err := pd.TunnelAddress(target(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testEchoOpen(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill up the tunnels. Once the idle timeout passed, all tunnels except
+	// the one with a connection are closed.
+	for i := 1; i < MaxProxyTunnels; i++ {
+		if _, err := pd.TunnelAddress(target(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(2 * proxyTunnelIdleTimeout)
+	if n := numTunnels(); n != 1 {
+		t.Fatal("expected idle tunnels to be closed", n)
+	}
+	if err := testEchoOpen(conn); err != nil {
+		t.Fatal("connection should still be open", err)
+	}
+
+	// Once the connection is closed the tunnel is closed too.
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * proxyTunnelIdleTimeout)
+	if n := numTunnels(); n != 0 {
+		t.Fatal("expected the tunnel to be closed", n)
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Fatal("tunnel should be closed")
+	}
+
+	// If all tunnels have connections, no new tunnels are created.
+	var conns []net.Conn
+	for i := 0; i < MaxProxyTunnels; i++ {
+		addr, err := pd.TunnelAddress(target(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := testEchoOpen(conn); err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+	if _, err := pd.TunnelAddress(target(MaxProxyTunnels)); !errors.Contains(err, ErrTooManyProxyTunnels) {
+		t.Fatal("expected ErrTooManyProxyTunnels but got", err)
+	}
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// TestProxyTunnelLimitKeepsNewTunnels checks that reaching MaxProxyTunnels
+// doesn't close tunnels that were handed out but not dialed yet.
+func TestProxyTunnelLimitKeepsNewTunnels(t *testing.T) {
+	t.Parallel()
+
+	echo := newTestEchoServer(t)
+	socks := newTestSOCKS5Server(t, echo.Addr().String())
+	pd := new(ProxyDialer)
+	if err := pd.SetAddress(socks.listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	defer pd.SetAddress("")
+	target := func(i int) string {
+		return fmt.Sprintf("host%d.example.com:9983", i)
+	}
+
+	var addrs []string
+	for i := 0; i < MaxProxyTunnels; i++ {
+		addr, err := pd.TunnelAddress(target(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, addr)
+	}
+	if _, err := pd.TunnelAddress(target(MaxProxyTunnels)); !errors.Contains(err, ErrTooManyProxyTunnels) {
+		t.Fatal("expected ErrTooManyProxyTunnels but got", err)
+	}
+
+	// All tunnels that were handed out can still be dialed.
+	for _, addr := range addrs {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := testEcho(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
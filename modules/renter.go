@@ -377,7 +377,11 @@ type DirectoryInfo struct {
 	DirSize             uint64      `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
 	StuckHealth         float64     `json:"stuckhealth"`
 	StuckSize           uint64      `json:"stucksize"`
+	Tags                []string    `json:"tags"`
 	UID                 uint64      `json:"uid"`
+
+	// UserMetadata is the user-defined key/value metadata of the siadir.
+	UserMetadata map[string]string `json:"usermetadata"`
 }
 
 // Name implements os.FileInfo.
@@ -407,6 +411,8 @@ type DownloadInfo struct {
 	Offset          uint64  `json:"offset"`          // The offset within the siafile requested for the download.
 	SiaPath         SiaPath `json:"siapath"`         // The siapath of the file used for the download.
 
+	Priority DownloadPriority `json:"priority"` // The priority class of the download.
+
 	Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 	EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
 	Error                string    `json:"error"`                // Will be the empty string unless there was an error.
@@ -434,6 +440,27 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// ChecksumAlgorithm is the algorithm used to compute the checksum of the
+	// uploaded data. If it is left blank, DefaultChecksumAlgorithm is used.
+	// ChecksumNone disables the checksum.
+	ChecksumAlgorithm string
+
+	// Compression is the codec used to compress the data before it is
+	// encrypted. If it is left blank or set to CompressionNone, the data is
+	// uploaded uncompressed.
+	Compression string
+
+	// Dedup enables deduplicating the chunks of the file. Every chunk is
+	// encrypted with a key derived from its content and the renter's seed.
+	// Chunks which were already uploaded as part of another deduplicated file
+	// reuse the existing sectors instead of being uploaded again.
+	Dedup bool
+
+	// Tags and UserMetadata are the initial tags and user-defined key/value
+	// metadata of the file.
+	Tags         []string
+	UserMetadata map[string]string
 }
 
 // FileInfo provides information about a file.
@@ -441,8 +468,13 @@ type FileInfo struct {
 	AccessTime       time.Time         `json:"accesstime"`
 	Available        bool              `json:"available"`
 	ChangeTime       time.Time         `json:"changetime"`
+	Checksum         FileChecksum      `json:"checksum"`
 	CipherType       string            `json:"ciphertype"`
+	CompressedSize   uint64            `json:"compressedsize"`
+	Compression      string            `json:"compression"`
 	CreateTime       time.Time         `json:"createtime"`
+	Dedup            bool              `json:"dedup"`
+	DedupChunks      uint64            `json:"dedupchunks"`
 	Expiration       types.BlockHeight `json:"expiration"`
 	Filesize         uint64            `json:"filesize"`
 	Health           float64           `json:"health"`
@@ -462,9 +494,11 @@ type FileInfo struct {
 	Stuck            bool              `json:"stuck"`
 	StuckBytes       uint64            `json:"stuckbytes"`
 	StuckHealth      float64           `json:"stuckhealth"`
+	Tags             []string          `json:"tags"`
 	UID              uint64            `json:"uid"`
 	UploadedBytes    uint64            `json:"uploadedbytes"`
 	UploadProgress   float64           `json:"uploadprogress"`
+	UserMetadata     map[string]string `json:"usermetadata"`
 }
 
 // Name implements os.FileInfo.
@@ -874,6 +908,10 @@ type (
 		TotalMaintenanceCoolDown int            `json:"totalmaintenancecooldown"`
 		TotalUploadCoolDown      int            `json:"totaluploadcooldown"`
 		Workers                  []WorkerStatus `json:"workers"`
+
+		// DownloadClasses sums up the download priority classes of all
+		// workers.
+		DownloadClasses []WorkerDownloadClassStatus `json:"downloadclasses"`
 	}
 
 	// WorkerStatus contains information about the status of a worker
@@ -890,6 +928,9 @@ type (
 		DownloadQueueSize     int           `json:"downloadqueuesize"`
 		DownloadTerminated    bool          `json:"downloadterminated"`
 
+		// Download priority class information
+		DownloadClasses []WorkerDownloadClassStatus `json:"downloadclasses"`
+
 		// Upload status information
 		UploadCoolDownError string        `json:"uploadcooldownerror"`
 		UploadCoolDownTime  time.Duration `json:"uploadcooldowntime"`
@@ -926,6 +967,26 @@ type (
 		UpdateRegistryJobsStatus WorkerUpdateRegistryJobStatus `json:"updateregistryjobsstatus"`
 	}
 
+	// WorkerDownloadClassStatus contains information about the downloads of a
+	// priority class.
+	WorkerDownloadClassStatus struct {
+		Priority DownloadPriority `json:"priority"`
+
+		// MaxConcurrency is the maximum number of pieces of the class a
+		// worker downloads at once, 0 means no limit.
+		MaxConcurrency uint64 `json:"maxconcurrency"`
+
+		// ActiveJobs are the pieces that are being downloaded and QueuedJobs
+		// the ones that are waiting for the concurrency limit.
+		ActiveJobs uint64 `json:"activejobs"`
+		QueuedJobs uint64 `json:"queuedjobs"`
+
+		// BytesDownloaded is the amount of data downloaded for the class and
+		// BandwidthShare its fraction of the data downloaded for all classes.
+		BytesDownloaded uint64  `json:"bytesdownloaded"`
+		BandwidthShare  float64 `json:"bandwidthshare"`
+	}
+
 	// WorkerGenericJobsStatus contains the common information for worker jobs.
 	WorkerGenericJobsStatus struct {
 		ConsecutiveFailures uint64    `json:"consecutivefailures"`
@@ -1007,9 +1068,17 @@ type Renter interface {
 	// sorted by preference.
 	ActiveHosts() ([]HostDBEntry, error)
 
+	// ActiveBandwidthWindow returns the window of the bandwidth schedule that
+	// is currently active.
+	ActiveBandwidthWindow() (BandwidthWindow, bool)
+
 	// AllHosts returns the full list of hosts known to the renter.
 	AllHosts() ([]HostDBEntry, error)
 
+	// BandwidthSchedule returns the windows of the renter's bandwidth
+	// schedule.
+	BandwidthSchedule() []BandwidthWindow
+
 	// Close closes the Renter.
 	Close() error
 
@@ -1086,6 +1155,54 @@ type Renter interface {
 	// SetFileStuck sets the 'stuck' status of a file.
 	SetFileStuck(siaPath SiaPath, stuck bool) error
 
+	// SetTags replaces the tags of the file or directory at siaPath.
+	SetTags(siaPath SiaPath, tags []string) error
+
+	// SetUserMetadata replaces the user-defined key/value metadata of the
+	// file or directory at siaPath.
+	SetUserMetadata(siaPath SiaPath, md map[string]string) error
+
+	// Find returns the files and directories within the directory at siaPath
+	// and its subdirectories which match the query.
+	Find(siaPath SiaPath, query FileQuery) ([]FileInfo, []DirectoryInfo, error)
+
+	// FileVersions returns the prior versions of a file, newest first.
+	FileVersions(siaPath SiaPath) ([]FileVersion, error)
+
+	// RestoreFileVersion replaces a file with one of its prior versions. The
+	// current file, if it exists, is kept as a version itself.
+	RestoreFileVersion(siaPath SiaPath, id string) error
+
+	// SetVersioningPolicy enables versioning for a directory with the given
+	// policy, or disables it if the policy is nil.
+	SetVersioningPolicy(siaPath SiaPath, policy *VersioningPolicy) error
+
+	// VersioningPolicies returns the versioning policies of all directories
+	// that have versioning enabled.
+	VersioningPolicies() map[SiaPath]VersioningPolicy
+
+	// PurgeTrash permanently deletes all files and directories in the trash.
+	PurgeTrash() error
+
+	// PurgeTrashEntry permanently deletes a file or directory in the trash.
+	PurgeTrashEntry(id string) error
+
+	// RestoreTrashEntry moves a file or directory in the trash back to the
+	// location it was deleted from.
+	RestoreTrashEntry(id string) error
+
+	// SetTrashRetention sets the number of days after which deleted files
+	// and directories are purged from the trash.
+	SetTrashRetention(days uint64) error
+
+	// TrashEntries returns the files and directories in the trash, most
+	// recently deleted first.
+	TrashEntries() []TrashEntry
+
+	// TrashRetention returns the number of days after which deleted files
+	// and directories are purged from the trash.
+	TrashRetention() uint64
+
 	// UploadBackup uploads a backup to hosts, such that it can be retrieved
 	// using only the seed.
 	UploadBackup(src string, name string) error
@@ -1187,6 +1304,10 @@ type Renter interface {
 	// registry value.
 	UpdateRegistry(spk types.SiaPublicKey, srv SignedRegistryValue, timeout time.Duration) error
 
+	// SetBandwidthSchedule replaces the windows of the renter's bandwidth
+	// schedule. The first window that is active at a time applies.
+	SetBandwidthSchedule(windows []BandwidthWindow) error
+
 	// PauseRepairsAndUploads pauses the renter's repairs and uploads for a time
 	// duration
 	PauseRepairsAndUploads(duration time.Duration) error
@@ -1199,6 +1320,9 @@ type Renter interface {
 	// resource.
 	Streamer(siapath SiaPath, disableLocalFetch bool) (string, Streamer, error)
 
+	// Sync synchronizes a local directory with a siadir.
+	Sync(params SyncParams) (SyncReport, error)
+
 	// Upload uploads a file using the input parameters.
 	Upload(FileUploadParams) error
 
@@ -1254,6 +1378,114 @@ type RenterDownloadParameters struct {
 	SiaPath          SiaPath
 	Destination      string
 	DisableDiskFetch bool
+
+	// Version is the ID of a prior version of the file to download. If it
+	// is empty, the current version is downloaded.
+	Version string
+
+	// Priority is the priority class of the download. If it is empty,
+	// DownloadPriorityUser is used.
+	Priority DownloadPriority
+}
+
+// SyncDirection specifies in which direction a sync propagates changes.
+type SyncDirection string
+
+const (
+	// SyncUpload makes the siadir mirror the local directory.
+	SyncUpload SyncDirection = "upload"
+
+	// SyncDownload makes the local directory mirror the siadir.
+	SyncDownload SyncDirection = "download"
+
+	// SyncBoth propagates changes in both directions. If a file changed on
+	// both sides, the most recently modified version wins.
+	SyncBoth SyncDirection = "both"
+)
+
+// SyncParams are the parameters of a sync between a local directory and a
+// siadir.
+type SyncParams struct {
+	LocalPath string
+	SiaPath   SiaPath
+	Direction SyncDirection
+
+	// Delete enables deleting files that were removed from the other side.
+	Delete bool
+
+	// Include and Exclude are glob patterns which are matched against the
+	// slash-separated path of a file relative to the synced directories.
+	// Patterns without a slash are also matched against the file's name. If
+	// Include is not empty, only files matching one of its patterns are
+	// synced. Files matching a pattern of Exclude are never synced.
+	Include []string
+	Exclude []string
+
+	// DryRun reports the changes a sync would make without making them.
+	DryRun bool
+
+	// ErasureCode is used for uploads. If it is nil, the default erasure
+	// code is used.
+	ErasureCode ErasureCoder
+}
+
+// SyncReport contains the changes made by a sync. Files are identified by
+// their slash-separated path relative to the synced directories.
+type SyncReport struct {
+	Uploaded      []string `json:"uploaded"`
+	Downloaded    []string `json:"downloaded"`
+	DeletedLocal  []string `json:"deletedlocal"`
+	DeletedRemote []string `json:"deletedremote"`
+	Unchanged     uint64   `json:"unchanged"`
+
+	// Errors contains the errors of files that couldn't be synced.
+	Errors []string `json:"errors"`
+}
+
+// VersioningPolicy is the versioning policy of a directory. Files that are
+// overwritten or deleted within the directory or its subdirectories are kept
+// as prior versions instead. Versions are pruned once there are more than
+// KeepVersions newer versions of the file or once they were superseded more
+// than KeepDays days ago. A limit of 0 means that it is not enforced.
+type VersioningPolicy struct {
+	KeepVersions uint64 `json:"keepversions"`
+	KeepDays     uint64 `json:"keepdays"`
+}
+
+// FileVersion is a prior version of a file.
+type FileVersion struct {
+	// ID identifies the version among the versions of the file.
+	ID string `json:"id"`
+
+	// SiaPath is the location of the version within the VersionsFolder.
+	SiaPath SiaPath `json:"siapath"`
+
+	// Superseded is the time the version was overwritten or deleted.
+	Superseded time.Time `json:"superseded"`
+
+	Filesize         uint64       `json:"filesize"`
+	ModificationTime time.Time    `json:"modtime"`
+	Checksum         FileChecksum `json:"checksum"`
+}
+
+// TrashEntry is a deleted file or directory in the trash.
+type TrashEntry struct {
+	// ID identifies the entry within the trash.
+	ID string `json:"id"`
+
+	// SiaPath is the location the file or directory was deleted from.
+	SiaPath SiaPath `json:"siapath"`
+
+	// Deleted is the time the file or directory was deleted.
+	Deleted time.Time `json:"deleted"`
+
+	// IsDir indicates whether the entry is a directory.
+	IsDir bool `json:"isdir"`
+
+	// NumFiles and Size are the number of files and their total size at the
+	// time of the deletion.
+	NumFiles uint64 `json:"numfiles"`
+	Size     uint64 `json:"size"`
 }
 
 // HealthPercentage returns the health in a more human understandable format out
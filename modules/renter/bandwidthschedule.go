@@ -0,0 +1,187 @@
+package renter
+
+// bandwidthschedule.go implements the renter's bandwidth schedule. The
+// schedule is a list of recurring time-of-day windows. While a window is
+// active, its download and upload rate limits replace the ones of the renter's
+// settings, the repair loop hands repair chunks to the workers no faster than
+// the window's repair rate and repairs can be paused entirely. New uploads are
+// never paused by the schedule.
+//
+// A chunk is considered a repair if it is stuck or if some of its pieces were
+// uploaded before.
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// errRepairsPausedBySchedule is returned by the repair loop if it skipped
+// repairs because the active window of the bandwidth schedule pauses them and
+// had no other work.
+var errRepairsPausedBySchedule = errors.New("repairs are paused by the bandwidth schedule")
+
+// bandwidthScheduler tracks the active window of the bandwidth schedule and
+// paces the repairs.
+type bandwidthScheduler struct {
+	// active is the window that is currently active, nil if none is.
+	active *modules.BandwidthWindow
+
+	// nextRepair is the earliest time at which the next repair chunk may be
+	// handed to the workers.
+	nextRepair time.Time
+
+	mu sync.Mutex
+}
+
+// managedActive returns the active window.
+func (bs *bandwidthScheduler) managedActive() (modules.BandwidthWindow, bool) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if bs.active == nil {
+		return modules.BandwidthWindow{}, false
+	}
+	return *bs.active, true
+}
+
+// managedSetActive sets the active window and returns whether it changed.
+func (bs *bandwidthScheduler) managedSetActive(w modules.BandwidthWindow, active bool) bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if !active {
+		changed := bs.active != nil
+		bs.active = nil
+		return changed
+	}
+	changed := bs.active == nil || bs.active.Name != w.Name || bs.active.Start != w.Start || bs.active.End != w.End
+	bs.active = &w
+	if changed {
+		// Don't let the pace of the previous window delay the new one.
+		bs.nextRepair = time.Time{}
+	}
+	return changed
+}
+
+// managedRepairsPaused returns whether the active window pauses repairs.
+func (bs *bandwidthScheduler) managedRepairsPaused() bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return bs.active != nil && bs.active.PauseRepairs
+}
+
+// managedRepairDelay reserves the repair of the given number of bytes and
+// returns how long the caller needs to wait before starting it to stay within
+// the repair rate of the active window.
+func (bs *bandwidthScheduler) managedRepairDelay(size uint64, now time.Time) time.Duration {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if bs.active == nil || bs.active.MaxRepairSpeed == 0 {
+		return 0
+	}
+	start := bs.nextRepair
+	if start.Before(now) {
+		start = now
+	}
+	bs.nextRepair = start.Add(time.Duration(float64(size) / float64(bs.active.MaxRepairSpeed) * float64(time.Second)))
+	return start.Sub(now)
+}
+
+// managedIsRepair returns whether the chunk is a repair of a chunk that was
+// uploaded before rather than a new upload.
+func (uuc *unfinishedUploadChunk) managedIsRepair() bool {
+	uuc.mu.Lock()
+	defer uuc.mu.Unlock()
+	return uuc.stuck || uuc.piecesCompleted > 0
+}
+
+// managedRepairSize returns the number of bytes that need to be uploaded to
+// repair the chunk.
+func (uuc *unfinishedUploadChunk) managedRepairSize() uint64 {
+	uuc.mu.Lock()
+	defer uuc.mu.Unlock()
+	if uuc.piecesCompleted >= uuc.staticPiecesNeeded {
+		return 0
+	}
+	return uint64(uuc.staticPiecesNeeded-uuc.piecesCompleted) * uuc.fileEntry.PieceSize()
+}
+
+// ActiveBandwidthWindow returns the window of the bandwidth schedule that is
+// currently active.
+func (r *Renter) ActiveBandwidthWindow() (modules.BandwidthWindow, bool) {
+	return r.staticBandwidthScheduler.managedActive()
+}
+
+// BandwidthSchedule returns the windows of the renter's bandwidth schedule.
+func (r *Renter) BandwidthSchedule() []modules.BandwidthWindow {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return append([]modules.BandwidthWindow{}, r.persist.BandwidthSchedule...)
+}
+
+// SetBandwidthSchedule replaces the windows of the renter's bandwidth schedule
+// and applies it right away.
+func (r *Renter) SetBandwidthSchedule(windows []modules.BandwidthWindow) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := modules.ValidateBandwidthSchedule(windows); err != nil {
+		return err
+	}
+	id := r.mu.Lock()
+	r.persist.BandwidthSchedule = append([]modules.BandwidthWindow{}, windows...)
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to save the bandwidth schedule")
+	}
+	return r.managedApplyBandwidthSchedule(time.Now())
+}
+
+// managedApplyBandwidthSchedule determines the window of the bandwidth
+// schedule that is active at the given time and sets the renter's rate limits
+// accordingly. Without an active window, the rate limits of the renter's
+// settings apply.
+func (r *Renter) managedApplyBandwidthSchedule(now time.Time) error {
+	id := r.mu.RLock()
+	w, active := modules.ActiveBandwidthWindow(r.persist.BandwidthSchedule, now)
+	download, upload := r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed
+	r.mu.RUnlock(id)
+	if active {
+		download, upload = w.MaxDownloadSpeed, w.MaxUploadSpeed
+	}
+	if r.staticBandwidthScheduler.managedSetActive(w, active) {
+		if active {
+			r.log.Printf("Bandwidth window '%v' (%v-%v) is now active", w.Name, w.Start, w.End)
+		} else {
+			r.log.Println("No bandwidth window is active anymore")
+		}
+		// Wake up the repair loop in case repairs were paused.
+		select {
+		case r.uploadHeap.repairNeeded <- struct{}{}:
+		default:
+		}
+	}
+	return r.setBandwidthLimits(download, upload)
+}
+
+// threadedApplyBandwidthSchedule periodically applies the bandwidth schedule.
+func (r *Renter) threadedApplyBandwidthSchedule() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for {
+		if err := r.managedApplyBandwidthSchedule(time.Now()); err != nil {
+			r.log.Println("WARN: failed to apply the bandwidth schedule:", err)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(bandwidthScheduleInterval):
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestBandwidthSchedulerRepairs tests pausing and pacing repairs.
+func TestBandwidthSchedulerRepairs(t *testing.T) {
+	var bs bandwidthScheduler
+	now := time.Now()
+
+	// Without an active window repairs are neither paused nor delayed.
+	if bs.managedRepairsPaused() {
+		t.Fatal("repairs shouldn't be paused")
+	}
+	if delay := bs.managedRepairDelay(1<<20, now); delay != 0 {
+		t.Fatal("repairs shouldn't be delayed", delay)
+	}
+
+	// Activate a window that limits repairs to 1 KB/s.
+	w := modules.BandwidthWindow{Start: "09:00", End: "17:00", MaxRepairSpeed: 1000}
+	if !bs.managedSetActive(w, true) {
+		t.Fatal("active window should have changed")
+	}
+	if bs.managedSetActive(w, true) {
+		t.Fatal("active window shouldn't have changed")
+	}
+	if delay := bs.managedRepairDelay(2000, now); delay != 0 {
+		t.Fatal("first repair shouldn't be delayed", delay)
+	}
+	if delay := bs.managedRepairDelay(1000, now); delay != 2*time.Second {
+		t.Fatal("expected a delay of 2s but got", delay)
+	}
+	if delay := bs.managedRepairDelay(1000, now.Add(10*time.Second)); delay != 0 {
+		t.Fatal("repair after a break shouldn't be delayed", delay)
+	}
+
+	// Pause repairs.
+	w.PauseRepairs = true
+	w.Name = "pause"
+	if !bs.managedSetActive(w, true) || !bs.managedRepairsPaused() {
+		t.Fatal("repairs should be paused")
+	}
+	if !bs.managedSetActive(modules.BandwidthWindow{}, false) || bs.managedRepairsPaused() {
+		t.Fatal("repairs shouldn't be paused without an active window")
+	}
+}
+
+// TestBandwidthSchedule tests setting and applying the renter's bandwidth
+// schedule.
+func TestBandwidthSchedule(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	if time.Now().Format(modules.BandwidthWindowTimeFormat) == "23:59" {
+		t.Skip("windows ending at 23:59 aren't active during the last minute of the day")
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Invalid schedules are rejected.
+	invalid := []modules.BandwidthWindow{{Start: "09:00", End: "09:00"}}
+	if err := r.SetBandwidthSchedule(invalid); !errors.Contains(err, modules.ErrInvalidBandwidthWindow) {
+		t.Fatal("expected ErrInvalidBandwidthWindow but got", err)
+	}
+
+	// Set the renter's own rate limits.
+	settings, err := r.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.MaxDownloadSpeed = 3000
+	settings.MaxUploadSpeed = 4000
+	if err := r.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a schedule with a window that is active on every day and one that
+	// never is since the first window takes precedence.
+	windows := []modules.BandwidthWindow{
+		{Name: "always", Start: "00:00", End: "23:59", MaxDownloadSpeed: 1000, MaxUploadSpeed: 2000, PauseRepairs: true},
+		{Name: "never", Start: "00:00", End: "23:59"},
+	}
+	if err := r.SetBandwidthSchedule(windows); err != nil {
+		t.Fatal(err)
+	}
+	if w, ok := r.ActiveBandwidthWindow(); !ok || w.Name != "always" {
+		t.Fatal("expected the first window to be active", w, ok)
+	}
+	if download, upload, _ := r.rl.Limits(); download != 1000 || upload != 2000 {
+		t.Fatal("window's rate limits weren't applied", download, upload)
+	}
+	if !r.staticBandwidthScheduler.managedRepairsPaused() {
+		t.Fatal("repairs should be paused")
+	}
+
+	// The window's rate limits take precedence over new settings.
+	if err := r.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if download, upload, _ := r.rl.Limits(); download != 1000 || upload != 2000 {
+		t.Fatal("window's rate limits weren't applied", download, upload)
+	}
+
+	// The schedule is persisted.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.BandwidthSchedule()) != 2 {
+		t.Fatal("schedule wasn't persisted", r.BandwidthSchedule())
+	}
+
+	// Clearing the schedule restores the renter's own rate limits.
+	if err := r.SetBandwidthSchedule(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.ActiveBandwidthWindow(); ok {
+		t.Fatal("no window should be active")
+	}
+	if download, upload, _ := r.rl.Limits(); download != 3000 || upload != 4000 {
+		t.Fatal("settings' rate limits weren't restored", download, upload)
+	}
+}
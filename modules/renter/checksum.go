@@ -0,0 +1,88 @@
+package renter
+
+import (
+	"io"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// checksumAlgorithm returns the checksum algorithm to use for an upload with
+// the given params. An empty string means that no checksum should be
+// computed.
+func checksumAlgorithm(up modules.FileUploadParams) (string, error) {
+	switch up.ChecksumAlgorithm {
+	case "":
+		return modules.DefaultChecksumAlgorithm, nil
+	case modules.ChecksumNone:
+		return "", nil
+	}
+	if _, err := modules.NewChecksumHasher(up.ChecksumAlgorithm); err != nil {
+		return "", err
+	}
+	return up.ChecksumAlgorithm, nil
+}
+
+// threadedComputeChecksum computes the checksum of the source of an upload
+// and stores it in the siafile. The file node is closed afterwards.
+func (r *Renter) threadedComputeChecksum(entry *filesystem.FileNode, source, algorithm string) {
+	defer func() {
+		if err := entry.Close(); err != nil {
+			r.log.Println("WARN: failed to close file node after computing checksum:", err)
+		}
+	}()
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	err := func() error {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		checksum, err := modules.ComputeChecksum(algorithm, &stopReader{r: f, stop: r.tg.StopChan()})
+		if err != nil {
+			return err
+		}
+		return entry.SetChecksum(checksum)
+	}()
+	if err != nil {
+		r.log.Printf("WARN: failed to compute checksum of %v: %v", source, err)
+	}
+}
+
+// stopReader is a reader that returns an error once stop is closed.
+type stopReader struct {
+	r    io.Reader
+	stop <-chan struct{}
+}
+
+// Read implements io.Reader.
+func (sr *stopReader) Read(b []byte) (int, error) {
+	select {
+	case <-sr.stop:
+		return 0, errors.New("interrupted by shutdown")
+	default:
+	}
+	return sr.r.Read(b)
+}
+
+// verifyFileChecksum compares the checksum of the first length bytes of the
+// file at path to checksum.
+func verifyFileChecksum(path string, length int64, checksum modules.FileChecksum) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	actual, err := modules.ComputeChecksum(checksum.Algorithm, io.LimitReader(f, length))
+	if err != nil {
+		return err
+	}
+	return checksum.Verify(actual)
+}
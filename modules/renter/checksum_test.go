@@ -0,0 +1,51 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestVerifyFileChecksum tests verifying the checksum of a downloaded file.
+func TestVerifyFileChecksum(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(1000)
+	checksum, err := modules.ComputeChecksum(modules.ChecksumSHA256, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Trailing data that wasn't part of the download is ignored.
+	path := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(path, append(data, 1, 2, 3), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyFileChecksum(path, int64(len(data)), checksum); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupted data is detected.
+	data[fastrand.Intn(len(data))]++
+	if err := ioutil.WriteFile(path, data, modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyFileChecksum(path, int64(len(data)), checksum); !errors.Contains(err, modules.ErrChecksumMismatch) {
+		t.Fatal("expected ErrChecksumMismatch but got", err)
+	}
+}
@@ -0,0 +1,459 @@
+package renter
+
+// compression.go implements the optional compression of uploads. The
+// plaintext of a file is split into blocks of up to compressionBlockSize bytes
+// which are compressed independently into frames. Each frame starts with a
+// header containing the length of the stored data and the length of the
+// uncompressed data. Frames which don't get smaller by compressing them are
+// stored uncompressed, which is indicated by both lengths being equal.
+//
+// As many frames as fit are packed into every chunk of the file. The rest of
+// the chunk is padded with zeros, so every chunk but the last one is full and
+// chunks can be decompressed independently. The uncompressed offset at which
+// each chunk starts is stored in the siafile's metadata. This allows for
+// seeking within compressed files without decompressing the data before the
+// offset.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// compressionBlockSize is the maximum size of the uncompressed data within
+	// a single frame.
+	compressionBlockSize = 1 << 16
+
+	// compressionFrameHeaderSize is the size of the header of a frame.
+	compressionFrameHeaderSize = 8
+)
+
+var (
+	// errCorruptCompressedChunk is returned if the frames of a chunk can't be
+	// decompressed.
+	errCorruptCompressedChunk = errors.New("compressed chunk is corrupt")
+)
+
+// compressionCodec returns the codec that is used to compress an upload.
+// CompressionNone is returned as an empty codec.
+func compressionCodec(up modules.FileUploadParams) (string, error) {
+	if err := modules.ValidateCompressionCodec(up.Compression); err != nil {
+		return "", err
+	}
+	if up.Compression == modules.CompressionNone {
+		return "", nil
+	}
+	return up.Compression, nil
+}
+
+// compressionFrame is a single frame of compressed data including its
+// header.
+type compressionFrame struct {
+	data      []byte
+	plainSize uint64
+}
+
+// encodeCompressionFrame compresses a block of data into a frame.
+func encodeCompressionFrame(codec string, plain []byte) (compressionFrame, error) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, compressionFrameHeaderSize))
+	switch codec {
+	case modules.CompressionGzip:
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(plain); err != nil {
+			return compressionFrame{}, err
+		}
+		if err := gw.Close(); err != nil {
+			return compressionFrame{}, err
+		}
+	default:
+		return compressionFrame{}, errors.AddContext(modules.ErrUnknownCompressionCodec, codec)
+	}
+	data := buf.Bytes()
+	// Store the block uncompressed if compressing it doesn't save space.
+	if len(data)-compressionFrameHeaderSize >= len(plain) {
+		data = append(data[:compressionFrameHeaderSize], plain...)
+	}
+	binary.LittleEndian.PutUint32(data[:4], uint32(len(data)-compressionFrameHeaderSize))
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(plain)))
+	return compressionFrame{
+		data:      data,
+		plainSize: uint64(len(plain)),
+	}, nil
+}
+
+// decompressChunk decompresses the frames of a chunk and appends the
+// uncompressed data to dst.
+func decompressChunk(codec string, chunk, dst []byte) ([]byte, error) {
+	for len(chunk) >= compressionFrameHeaderSize {
+		storedSize := uint64(binary.LittleEndian.Uint32(chunk[:4]))
+		plainSize := uint64(binary.LittleEndian.Uint32(chunk[4:8]))
+		chunk = chunk[compressionFrameHeaderSize:]
+		// A zero length marks the start of the padding.
+		if storedSize == 0 {
+			break
+		}
+		if storedSize > uint64(len(chunk)) || storedSize > plainSize {
+			return nil, errCorruptCompressedChunk
+		}
+		data := chunk[:storedSize]
+		chunk = chunk[storedSize:]
+		if storedSize == plainSize {
+			dst = append(dst, data...)
+			continue
+		}
+		var r io.Reader
+		switch codec {
+		case modules.CompressionGzip:
+			gr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, errors.Compose(errCorruptCompressedChunk, err)
+			}
+			r = gr
+		default:
+			return nil, errors.AddContext(modules.ErrUnknownCompressionCodec, codec)
+		}
+		start := len(dst)
+		dst = append(dst, make([]byte, plainSize)...)
+		if _, err := io.ReadFull(r, dst[start:]); err != nil {
+			return nil, errors.Compose(errCorruptCompressedChunk, err)
+		}
+	}
+	return dst, nil
+}
+
+// compressingReader compresses the data read from an underlying reader and
+// packs the frames into chunks.
+type compressingReader struct {
+	staticBlockSize int
+	staticChunkSize uint64
+	staticCodec     string
+	staticSource    io.Reader
+
+	buf     []byte            // data of the current chunk that wasn't read yet
+	eof     bool              // whether the source was read completely
+	offset  uint64            // uncompressed size of the frames packed so far
+	offsets []uint64          // uncompressed offsets of the chunks
+	pending *compressionFrame // frame that didn't fit into the previous chunk
+}
+
+// newCompressingReader creates a reader which compresses the data of r using
+// the codec for a file with the given chunk size.
+func newCompressingReader(r io.Reader, codec string, chunkSize uint64) *compressingReader {
+	// Every frame needs to fit into a chunk, even if it is stored
+	// uncompressed.
+	blockSize := uint64(compressionBlockSize)
+	if blockSize > chunkSize-compressionFrameHeaderSize {
+		blockSize = chunkSize - compressionFrameHeaderSize
+	}
+	return &compressingReader{
+		staticBlockSize: int(blockSize),
+		staticChunkSize: chunkSize,
+		staticCodec:     codec,
+		staticSource:    r,
+	}
+}
+
+// Compression returns the FileCompression of the data that was read so far.
+func (cr *compressingReader) Compression() modules.FileCompression {
+	return modules.FileCompression{
+		Codec:        cr.staticCodec,
+		Size:         cr.offset,
+		ChunkOffsets: append([]uint64(nil), cr.offsets...),
+	}
+}
+
+// Read implements io.Reader.
+func (cr *compressingReader) Read(b []byte) (int, error) {
+	for len(cr.buf) == 0 {
+		if cr.eof && cr.pending == nil {
+			return 0, io.EOF
+		}
+		if err := cr.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, cr.buf)
+	cr.buf = cr.buf[n:]
+	return n, nil
+}
+
+// nextChunk packs the next frames into a chunk.
+func (cr *compressingReader) nextChunk() error {
+	chunk := make([]byte, 0, cr.staticChunkSize)
+	start := cr.offset
+	for {
+		frame := cr.pending
+		cr.pending = nil
+		if frame == nil {
+			f, err := cr.nextFrame()
+			if errors.Contains(err, io.EOF) {
+				cr.eof = true
+				break
+			} else if err != nil {
+				return err
+			}
+			frame = &f
+		}
+		if uint64(len(chunk)+len(frame.data)) > cr.staticChunkSize {
+			cr.pending = frame
+			break
+		}
+		chunk = append(chunk, frame.data...)
+		cr.offset += frame.plainSize
+	}
+	if len(chunk) == 0 {
+		return nil
+	}
+	cr.offsets = append(cr.offsets, start)
+	// All chunks but the last one are padded.
+	if cr.pending != nil {
+		chunk = chunk[:cr.staticChunkSize]
+	}
+	cr.buf = chunk
+	return nil
+}
+
+// nextFrame reads and compresses the next block of data from the source.
+func (cr *compressingReader) nextFrame() (compressionFrame, error) {
+	plain := make([]byte, cr.staticBlockSize)
+	n, err := io.ReadFull(cr.staticSource, plain)
+	if errors.Contains(err, io.EOF) {
+		return compressionFrame{}, io.EOF
+	} else if err != nil && !errors.Contains(err, io.ErrUnexpectedEOF) {
+		return compressionFrame{}, err
+	}
+	return encodeCompressionFrame(cr.staticCodec, plain[:n])
+}
+
+// compressedRange returns the range of the stored data of a compressed file
+// which contains the given range of uncompressed data. The range always
+// starts at the beginning of a chunk.
+func compressedRange(c modules.FileCompression, chunkSize, storedSize, offset, length uint64) (storedOffset, storedLength uint64) {
+	if length == 0 {
+		return 0, 0
+	}
+	firstChunk := c.ChunkIndex(offset)
+	lastChunk := c.ChunkIndex(offset + length - 1)
+	storedOffset = firstChunk * chunkSize
+	end := (lastChunk + 1) * chunkSize
+	if end > storedSize {
+		end = storedSize
+	}
+	return storedOffset, end - storedOffset
+}
+
+// decompressedChunk decompresses a chunk of a compressed file and checks that
+// it contains the expected amount of data.
+func decompressedChunk(c modules.FileCompression, chunkIndex uint64, chunk, dst []byte) ([]byte, error) {
+	if chunkIndex >= uint64(len(c.ChunkOffsets)) {
+		return nil, errors.AddContext(errCorruptCompressedChunk, fmt.Sprintf("chunk %v is missing from the index", chunkIndex))
+	}
+	_, length := c.ChunkRange(chunkIndex)
+	data, err := decompressChunk(c.Codec, chunk, dst)
+	if err != nil {
+		return nil, errors.AddContext(err, fmt.Sprintf("unable to decompress chunk %v", chunkIndex))
+	}
+	if uint64(len(data)) != length {
+		return nil, errors.AddContext(errCorruptCompressedChunk, fmt.Sprintf("chunk %v contains %v bytes instead of %v", chunkIndex, len(data), length))
+	}
+	return data, nil
+}
+
+// decompressingStreamer is a modules.Streamer which decompresses the data of
+// a streamer of a compressed file.
+type decompressingStreamer struct {
+	staticChunkSize   uint64
+	staticCompression modules.FileCompression
+	staticStoredSize  uint64
+	staticStreamer    modules.Streamer
+
+	chunk      []byte // uncompressed data of the current chunk
+	chunkIndex uint64 // index of the current chunk
+	offset     int64
+}
+
+// newDecompressingStreamer wraps the streamer of the stored data of a
+// compressed file.
+func newDecompressingStreamer(s modules.Streamer, c modules.FileCompression, chunkSize, storedSize uint64) *decompressingStreamer {
+	return &decompressingStreamer{
+		staticChunkSize:   chunkSize,
+		staticCompression: c,
+		staticStoredSize:  storedSize,
+		staticStreamer:    s,
+	}
+}
+
+// Close implements io.Closer.
+func (ds *decompressingStreamer) Close() error {
+	return ds.staticStreamer.Close()
+}
+
+// Read implements io.Reader.
+func (ds *decompressingStreamer) Read(b []byte) (int, error) {
+	if ds.offset >= int64(ds.staticCompression.Size) {
+		return 0, io.EOF
+	}
+	chunkIndex := ds.staticCompression.ChunkIndex(uint64(ds.offset))
+	if ds.chunk == nil || chunkIndex != ds.chunkIndex {
+		if err := ds.loadChunk(chunkIndex); err != nil {
+			return 0, err
+		}
+	}
+	chunkOffset, _ := ds.staticCompression.ChunkRange(chunkIndex)
+	n := copy(b, ds.chunk[uint64(ds.offset)-chunkOffset:])
+	ds.offset += int64(n)
+	return n, nil
+}
+
+// loadChunk reads and decompresses a chunk.
+func (ds *decompressingStreamer) loadChunk(chunkIndex uint64) error {
+	storedOffset := chunkIndex * ds.staticChunkSize
+	if storedOffset >= ds.staticStoredSize {
+		return errors.AddContext(errCorruptCompressedChunk, fmt.Sprintf("chunk %v is beyond the end of the file", chunkIndex))
+	}
+	length := ds.staticStoredSize - storedOffset
+	if length > ds.staticChunkSize {
+		length = ds.staticChunkSize
+	}
+	if _, err := ds.staticStreamer.Seek(int64(storedOffset), io.SeekStart); err != nil {
+		return err
+	}
+	chunk := make([]byte, length)
+	if _, err := io.ReadFull(ds.staticStreamer, chunk); err != nil {
+		return errors.AddContext(err, "unable to read compressed chunk")
+	}
+	data, err := decompressedChunk(ds.staticCompression, chunkIndex, chunk, ds.chunk[:0])
+	if err != nil {
+		ds.chunk = nil
+		return err
+	}
+	ds.chunk = data
+	ds.chunkIndex = chunkIndex
+	return nil
+}
+
+// Seek implements io.Seeker.
+func (ds *decompressingStreamer) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = ds.offset + offset
+	case io.SeekEnd:
+		newOffset = int64(ds.staticCompression.Size) + offset
+	default:
+		return ds.offset, errors.New("invalid whence")
+	}
+	if newOffset < 0 {
+		return ds.offset, errors.New("cannot seek to negative offset")
+	}
+	ds.offset = newOffset
+	return newOffset, nil
+}
+
+// decompressingWriter decompresses the stored data of a compressed file which
+// is written to it sequentially, chunk by chunk, and writes a range of the
+// uncompressed data to the underlying writer.
+type decompressingWriter struct {
+	staticChunkSize    uint64
+	staticCompression  modules.FileCompression
+	staticStoredLength uint64
+	staticWriter       io.Writer
+
+	buf        []byte // stored data of the current chunk
+	chunkIndex uint64 // index of the current chunk
+	remaining  uint64 // uncompressed bytes which still need to be written
+	skip       uint64 // uncompressed bytes which still need to be skipped
+	written    uint64 // stored bytes written so far
+}
+
+// newDecompressingWriter creates a writer for the stored data of a compressed
+// file returned by compressedRange for the uncompressed range [offset;
+// offset+length).
+func newDecompressingWriter(w io.Writer, c modules.FileCompression, chunkSize, storedOffset, storedLength, offset, length uint64) *decompressingWriter {
+	dw := &decompressingWriter{
+		staticChunkSize:    chunkSize,
+		staticCompression:  c,
+		staticStoredLength: storedLength,
+		staticWriter:       w,
+
+		chunkIndex: storedOffset / chunkSize,
+		remaining:  length,
+	}
+	if length > 0 {
+		chunkOffset, _ := c.ChunkRange(dw.chunkIndex)
+		dw.skip = offset - chunkOffset
+	}
+	return dw
+}
+
+// Write implements io.Writer.
+func (dw *decompressingWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		if dw.written >= dw.staticStoredLength {
+			return 0, errors.New("write exceeds the requested range")
+		}
+		toCopy := dw.staticChunkSize - uint64(len(dw.buf))
+		if toCopy > uint64(len(b)) {
+			toCopy = uint64(len(b))
+		}
+		dw.buf = append(dw.buf, b[:toCopy]...)
+		b = b[toCopy:]
+		dw.written += toCopy
+		if uint64(len(dw.buf)) == dw.staticChunkSize || dw.written == dw.staticStoredLength {
+			if err := dw.writeChunk(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// writeChunk decompresses the current chunk and writes the requested part of
+// it to the underlying writer.
+func (dw *decompressingWriter) writeChunk() error {
+	data, err := decompressedChunk(dw.staticCompression, dw.chunkIndex, dw.buf, nil)
+	if err != nil {
+		return err
+	}
+	skip := dw.skip
+	if skip > uint64(len(data)) {
+		skip = uint64(len(data))
+	}
+	data = data[skip:]
+	dw.skip -= skip
+	if uint64(len(data)) > dw.remaining {
+		data = data[:dw.remaining]
+	}
+	if _, err := dw.staticWriter.Write(data); err != nil {
+		return err
+	}
+	dw.remaining -= uint64(len(data))
+	dw.chunkIndex++
+	dw.buf = dw.buf[:0]
+	return nil
+}
+
+// downloadDestinationCompressedFile is a downloadDestination which writes the
+// decompressed data of a compressed file to a file.
+type downloadDestinationCompressedFile struct {
+	*downloadDestinationWriter
+	f *os.File
+}
+
+// Close implements the io.Closer interface for
+// downloadDestinationCompressedFile.
+func (ddcf *downloadDestinationCompressedFile) Close() error {
+	return errors.Compose(ddcf.downloadDestinationWriter.Close(), ddcf.f.Close())
+}
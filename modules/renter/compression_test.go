@@ -0,0 +1,223 @@
+package renter
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+)
+
+// bytesStreamer is a modules.Streamer for a byte slice.
+type bytesStreamer struct {
+	*bytes.Reader
+}
+
+// Close implements io.Closer.
+func (bs bytesStreamer) Close() error { return nil }
+
+// compressTestData compresses data using a compressingReader and returns the
+// stored data and its FileCompression.
+func compressTestData(t *testing.T, data []byte, chunkSize uint64) ([]byte, modules.FileCompression) {
+	cr := newCompressingReader(bytes.NewReader(data), modules.CompressionGzip, chunkSize)
+	stored, err := ioutil.ReadAll(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored, cr.Compression()
+}
+
+// compressibleTestData returns data which is partially compressible.
+func compressibleTestData(size int) []byte {
+	data := make([]byte, size)
+	for i := 0; i < size; i += 1000 {
+		end := i + 500
+		if end > size {
+			end = size
+		}
+		fastrand.Read(data[i:end])
+	}
+	return data
+}
+
+// TestCompressingReader tests that the chunks created by a compressingReader
+// can be decompressed independently.
+func TestCompressingReader(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      []byte
+		chunkSize uint64
+	}{
+		{"empty", nil, 4096},
+		{"tiny chunks", compressibleTestData(10000), 64},
+		{"compressible", compressibleTestData(300000), 4096},
+		{"zeros", make([]byte, 300000), 4096},
+		{"random", fastrand.Bytes(100000), 4096},
+		{"large chunks", compressibleTestData(300000), 1 << 18},
+	}
+	for _, test := range tests {
+		stored, c := compressTestData(t, test.data, test.chunkSize)
+		if c.Size != uint64(len(test.data)) {
+			t.Fatalf("%v: expected size %v but got %v", test.name, len(test.data), c.Size)
+		}
+		numChunks := (uint64(len(stored)) + test.chunkSize - 1) / test.chunkSize
+		if uint64(len(c.ChunkOffsets)) != numChunks {
+			t.Fatalf("%v: expected %v chunk offsets but got %v", test.name, numChunks, len(c.ChunkOffsets))
+		}
+
+		// Decompress every chunk on its own.
+		var decompressed []byte
+		for i := uint64(0); i < numChunks; i++ {
+			end := (i + 1) * test.chunkSize
+			if end > uint64(len(stored)) {
+				end = uint64(len(stored))
+			}
+			data, err := decompressedChunk(c, i, stored[i*test.chunkSize:end], nil)
+			if err != nil {
+				t.Fatalf("%v: %v", test.name, err)
+			}
+			decompressed = append(decompressed, data...)
+		}
+		if !bytes.Equal(decompressed, test.data) {
+			t.Fatalf("%v: decompressed data doesn't match", test.name)
+		}
+	}
+
+	// Compressible data should actually shrink.
+	data := make([]byte, 300000)
+	if stored, _ := compressTestData(t, data, 4096); len(stored) >= len(data)/10 {
+		t.Fatalf("zeros weren't compressed: %v bytes stored", len(stored))
+	}
+}
+
+// TestDecompressingStreamer tests reading from and seeking within a
+// decompressingStreamer.
+func TestDecompressingStreamer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	chunkSize := uint64(4096)
+	data := compressibleTestData(200000)
+	stored, c := compressTestData(t, data, chunkSize)
+	newStreamer := func() *decompressingStreamer {
+		return newDecompressingStreamer(bytesStreamer{bytes.NewReader(stored)}, c, chunkSize, uint64(len(stored)))
+	}
+
+	// Read the whole file.
+	b, err := ioutil.ReadAll(newStreamer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, data) {
+		t.Fatal("data doesn't match")
+	}
+
+	// Seek to random offsets and read random lengths.
+	ds := newStreamer()
+	for i := 0; i < 100; i++ {
+		offset := fastrand.Intn(len(data))
+		length := fastrand.Intn(len(data)-offset) + 1
+		if _, err := ds.Seek(int64(offset), io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, length)
+		if _, err := io.ReadFull(ds, b); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, data[offset:offset+length]) {
+			t.Fatalf("data at offset %v with length %v doesn't match", offset, length)
+		}
+	}
+
+	// Reading at the end of the file returns io.EOF.
+	if _, err := ds.Seek(0, io.SeekEnd); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ds.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatal("expected io.EOF but got", err)
+	}
+
+	// Corrupt chunks are detected.
+	corrupt := append([]byte(nil), stored...)
+	fastrand.Read(corrupt[:chunkSize])
+	ds = newDecompressingStreamer(bytesStreamer{bytes.NewReader(corrupt)}, c, chunkSize, uint64(len(corrupt)))
+	if _, err := ds.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected error for corrupt chunk")
+	}
+}
+
+// TestDecompressingWriter tests decompressing random ranges of a compressed
+// file with a decompressingWriter.
+func TestDecompressingWriter(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	chunkSize := uint64(4096)
+	data := compressibleTestData(200000)
+	stored, c := compressTestData(t, data, chunkSize)
+
+	for i := 0; i < 100; i++ {
+		offset := uint64(fastrand.Intn(len(data)))
+		length := uint64(fastrand.Intn(len(data)-int(offset))) + 1
+		storedOffset, storedLength := compressedRange(c, chunkSize, uint64(len(stored)), offset, length)
+		if storedOffset%chunkSize != 0 {
+			t.Fatal("stored range doesn't start at a chunk", storedOffset)
+		}
+
+		// Write the stored range in random pieces.
+		var buf bytes.Buffer
+		dw := newDecompressingWriter(&buf, c, chunkSize, storedOffset, storedLength, offset, length)
+		storedData := stored[storedOffset : storedOffset+storedLength]
+		for len(storedData) > 0 {
+			n := fastrand.Intn(len(storedData)) + 1
+			if _, err := dw.Write(storedData[:n]); err != nil {
+				t.Fatal(err)
+			}
+			storedData = storedData[n:]
+		}
+		if !bytes.Equal(buf.Bytes(), data[offset:offset+length]) {
+			t.Fatalf("data at offset %v with length %v doesn't match", offset, length)
+		}
+
+		// Writing beyond the range fails.
+		if _, err := dw.Write([]byte{0}); err == nil {
+			t.Fatal("expected error when writing beyond the range")
+		}
+	}
+}
+
+// TestCompressionCodec tests validating the codec of an upload.
+func TestCompressionCodec(t *testing.T) {
+	t.Parallel()
+
+	for codec, expected := range map[string]string{
+		"":                      "",
+		modules.CompressionNone: "",
+		modules.CompressionGzip: modules.CompressionGzip,
+	} {
+		c, err := compressionCodec(modules.FileUploadParams{Compression: codec})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != expected {
+			t.Fatalf("expected codec %q for %q but got %q", expected, codec, c)
+		}
+	}
+	_, err := compressionCodec(modules.FileUploadParams{Compression: "zip"})
+	if !errors.Contains(err, modules.ErrUnknownCompressionCodec) {
+		t.Fatal("expected ErrUnknownCompressionCodec but got", err)
+	}
+}
@@ -42,6 +42,62 @@ var (
 		Testing:  time.Second,
 	}).(time.Duration)
 
+	// fileVersionPruneInterval is how often the renter removes file versions
+	// which are no longer retained by the versioning policy of their
+	// directory.
+	fileVersionPruneInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// dedupIndexPersistInterval is how often the renter saves the index of
+	// deduplicated chunks if it changed.
+	dedupIndexPersistInterval = build.Select(build.Var{
+		Dev:      time.Second * 10,
+		Standard: time.Minute,
+		Testnet:  time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// trashPurgeInterval is how often the renter purges the files and
+	// directories which have been in the trash for longer than the trash
+	// retention.
+	trashPurgeInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// bandwidthScheduleInterval is how often the renter checks which window
+	// of its bandwidth schedule is active.
+	bandwidthScheduleInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testnet:  time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// userDownloadConcurrency is the maximum number of pieces of user
+	// downloads a worker downloads at once.
+	userDownloadConcurrency = build.Select(build.Var{
+		Dev:      8,
+		Standard: 8,
+		Testnet:  8,
+		Testing:  4,
+	}).(int)
+
+	// batchDownloadConcurrency is the maximum number of pieces of batch
+	// downloads a worker downloads at once. Streams are not limited.
+	batchDownloadConcurrency = build.Select(build.Var{
+		Dev:      2,
+		Standard: 2,
+		Testnet:  2,
+		Testing:  1,
+	}).(int)
+
 	// cachedUtilitiesUpdateInterval is how often the renter updates the
 	// cachedUtilities.
 	cachedUtilitiesUpdateInterval = build.Select(build.Var{
@@ -163,6 +219,10 @@ const (
 	DefaultMaxUploadSpeed = 0
 )
 
+// DefaultTrashRetentionDays is the default number of days after which deleted
+// files and directories are purged from the trash.
+const DefaultTrashRetentionDays = 30
+
 // Naming conventions for code readability.
 const (
 	// destinationTypeSeekStream is the destination type used for downloads
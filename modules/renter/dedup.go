@@ -0,0 +1,409 @@
+package renter
+
+// dedup.go implements the deduplication of chunks across the renter's files.
+// The chunks of files which are uploaded with deduplication enabled are
+// encrypted with a key derived from their content ID instead of the file's
+// master key. The content ID is a hash of the chunk's data and a secret derived
+// from the renter seed, which means that identical chunks result in identical
+// sectors while the sectors still can't be linked to their content by anyone
+// but the renter.
+//
+// The renter keeps an index of the sectors of every deduplicated chunk it
+// uploaded. When another chunk with the same content ID is uploaded, the
+// sectors from the index are added to its file instead of uploading them
+// again. The entries of the index are reference counted by the chunks of the
+// renter's files, and an entry is released once the last file referencing it
+// is deleted.
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// dedupIndexFilename is the name of the file within the renter's persist
+	// directory which contains the index of deduplicated chunks.
+	dedupIndexFilename = "dedup.json"
+)
+
+var (
+	// errDedupCipherType is returned if deduplication is enabled for an
+	// upload with a cipher type other than threefish.
+	errDedupCipherType = errors.New("deduplication requires the threefish cipher type")
+
+	// dedupIndexMetadata is the metadata of the persisted dedup index.
+	dedupIndexMetadata = persist.Metadata{
+		Header:  "Dedup Index",
+		Version: "1.0",
+	}
+
+	// dedupKeySpecifier is the specifier used for deriving the secret which
+	// the content IDs of deduplicated chunks are derived from.
+	dedupKeySpecifier = types.NewSpecifier("dedup")
+)
+
+type (
+	// dedupIndex is the index of the sectors of the renter's deduplicated
+	// chunks.
+	dedupIndex struct {
+		entries map[crypto.Hash]*dedupEntry
+		dirty   bool
+
+		staticPath string
+		mu         sync.Mutex
+	}
+
+	// dedupEntry is the entry of a deduplicated chunk within the index. Refs
+	// is the number of chunks of the renter's files with the entry's content
+	// ID. Pieces is empty until a chunk with the ID finished uploading.
+	dedupEntry struct {
+		ID     crypto.Hash       `json:"id"`
+		Pieces [][]siafile.Piece `json:"pieces"`
+		Refs   uint64            `json:"refs"`
+	}
+
+	// dedupIndexPersist is the persisted form of the dedup index.
+	dedupIndexPersist struct {
+		Entries []dedupEntry `json:"entries"`
+	}
+)
+
+// newDedupIndex loads the dedup index from disk or creates a new one if it
+// doesn't exist yet.
+func newDedupIndex(path string) (*dedupIndex, error) {
+	di := &dedupIndex{
+		entries:    make(map[crypto.Hash]*dedupEntry),
+		staticPath: path,
+	}
+	var p dedupIndexPersist
+	err := persist.LoadJSON(dedupIndexMetadata, &p, path)
+	if os.IsNotExist(err) {
+		return di, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load dedup index")
+	}
+	for i := range p.Entries {
+		di.entries[p.Entries[i].ID] = &p.Entries[i]
+	}
+	return di, nil
+}
+
+// managedAcquire adds a reference to the entry with the given content ID. The
+// entry is created if it doesn't exist yet.
+func (di *dedupIndex) managedAcquire(id crypto.Hash) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists {
+		entry = &dedupEntry{ID: id}
+		di.entries[id] = entry
+	}
+	entry.Refs++
+	di.dirty = true
+}
+
+// managedRelease removes a reference from the entries with the given content
+// IDs. Entries without references are removed from the index.
+func (di *dedupIndex) managedRelease(ids []crypto.Hash) {
+	if len(ids) == 0 {
+		return
+	}
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	for _, id := range ids {
+		entry, exists := di.entries[id]
+		if !exists {
+			continue
+		}
+		entry.Refs--
+		if entry.Refs == 0 {
+			delete(di.entries, id)
+		}
+	}
+	di.dirty = true
+}
+
+// managedLen returns the number of entries within the index.
+func (di *dedupIndex) managedLen() int {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	return len(di.entries)
+}
+
+// managedPieces returns the pieces of the chunk with the given content ID. The
+// returned pieces are nil if no chunk with the ID was uploaded yet.
+func (di *dedupIndex) managedPieces(id crypto.Hash) [][]siafile.Piece {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists || entry.Pieces == nil {
+		return nil
+	}
+	pieces := make([][]siafile.Piece, len(entry.Pieces))
+	for i := range entry.Pieces {
+		pieces[i] = append([]siafile.Piece(nil), entry.Pieces[i]...)
+	}
+	return pieces
+}
+
+// managedSetPieces sets the pieces of the chunk with the given content ID. It
+// is a no-op if the index doesn't contain the ID.
+func (di *dedupIndex) managedSetPieces(id crypto.Hash, pieces [][]siafile.Piece) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists {
+		return
+	}
+	entry.Pieces = pieces
+	di.dirty = true
+}
+
+// managedSave saves the index to disk if it changed since it was last saved.
+func (di *dedupIndex) managedSave() error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	if !di.dirty {
+		return nil
+	}
+	p := dedupIndexPersist{
+		Entries: make([]dedupEntry, 0, len(di.entries)),
+	}
+	for _, entry := range di.entries {
+		p.Entries = append(p.Entries, *entry)
+	}
+	if err := persist.SaveJSON(dedupIndexMetadata, p, di.staticPath); err != nil {
+		return errors.AddContext(err, "unable to save dedup index")
+	}
+	di.dirty = false
+	return nil
+}
+
+// validateDedup checks whether deduplication can be enabled for an upload.
+func validateDedup(up modules.FileUploadParams) error {
+	if !up.Dedup {
+		return nil
+	}
+	if up.Repair {
+		return errors.New("can't enable deduplication when doing repairs")
+	}
+	var ct crypto.CipherType
+	if up.CipherKey != nil {
+		ct = up.CipherKey.Type()
+	} else if up.CipherType != ct {
+		ct = up.CipherType
+	} else {
+		ct = crypto.TypeDefaultRenter
+	}
+	if ct != crypto.TypeThreefish {
+		return errDedupCipherType
+	}
+	return nil
+}
+
+// dedupChunkID computes the content ID of a chunk from its erasure coded
+// logical data. Besides the data, the ID depends on the erasure code and the
+// piece size of the file since those determine the chunk's sectors.
+func dedupChunkID(secret crypto.Hash, ec modules.ErasureCoder, pieceSize uint64, logicalChunkData [][]byte) crypto.Hash {
+	h := crypto.NewHash()
+	for _, piece := range logicalChunkData[:ec.MinPieces()] {
+		_, _ = h.Write(piece)
+	}
+	var dataHash crypto.Hash
+	copy(dataHash[:], h.Sum(nil))
+	return crypto.HashAll(secret, ec.Identifier(), pieceSize, dataHash)
+}
+
+// managedDedupSecret derives the secret used for computing content IDs from
+// the renter seed. The secret should be wiped once it's no longer in use.
+func (r *Renter) managedDedupSecret() (crypto.Hash, error) {
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	return crypto.HashAll(rs, dedupKeySpecifier), nil
+}
+
+// managedAssignDedupID assigns a content ID to a chunk of a deduplicated file
+// before it is encrypted for the first time. Chunks which already have an ID
+// keep it, which makes sure that repairs recreate the same sectors.
+func (r *Renter) managedAssignDedupID(uc *unfinishedUploadChunk) error {
+	if !uc.fileEntry.Dedup() {
+		return nil
+	}
+	if _, exists := uc.fileEntry.DedupChunk(uc.staticIndex); exists {
+		return nil
+	}
+	secret, err := r.managedDedupSecret()
+	if err != nil {
+		return err
+	}
+	defer fastrand.Read(secret[:])
+	id := dedupChunkID(secret, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize(), uc.logicalChunkData)
+	if err := uc.fileEntry.SetDedupChunk(uc.staticIndex, id); err != nil {
+		return errors.AddContext(err, "unable to set the content ID of the chunk")
+	}
+	r.staticDedupIndex.managedAcquire(id)
+	return nil
+}
+
+// managedReuseDedupSectors adds the sectors of an identical chunk which was
+// uploaded before to a deduplicated chunk. The pieces which are covered by
+// those sectors don't need to be uploaded anymore and the memory reserved for
+// them is returned. It returns whether the chunk is complete.
+func (r *Renter) managedReuseDedupSectors(uc *unfinishedUploadChunk) bool {
+	id, exists := uc.fileEntry.DedupChunk(uc.staticIndex)
+	if !exists {
+		return false
+	}
+	pieces := r.staticDedupIndex.managedPieces(id)
+	if len(pieces) != len(uc.pieceUsage) {
+		return false
+	}
+
+	uc.mu.Lock()
+	var released uint64
+	for pieceIndex, pieceSet := range pieces {
+		if uc.pieceUsage[pieceIndex] {
+			continue
+		}
+		// Use the first piece which is stored on a host that doesn't store
+		// any other piece of the chunk yet.
+		for _, piece := range pieceSet {
+			hpk := piece.HostPubKey.String()
+			if _, unused := uc.unusedHosts[hpk]; !unused {
+				continue
+			}
+			err := uc.fileEntry.AddPiece(piece.HostPubKey, uc.staticIndex, uint64(pieceIndex), piece.MerkleRoot)
+			if err != nil {
+				r.log.Printf("WARN: unable to add deduplicated piece to chunk %v of %v: %v", uc.staticIndex, uc.staticSiaPath, err)
+				break
+			}
+			delete(uc.unusedHosts, hpk)
+			uc.pieceUsage[pieceIndex] = true
+			uc.piecesCompleted++
+			uc.logicalChunkData[pieceIndex] = nil
+			released += modules.SectorSize
+			break
+		}
+	}
+	uc.memoryReleased += released
+	complete := uc.piecesCompleted >= uc.staticPiecesNeeded
+	uc.mu.Unlock()
+
+	if released > 0 {
+		uc.staticMemoryManager.Return(released)
+		r.repairLog.Printf("Reused %v deduplicated sectors for chunk %v of %s", released/modules.SectorSize, uc.staticIndex, uc.staticSiaPath)
+	}
+	return complete
+}
+
+// managedRegisterDedupChunk records the sectors of a deduplicated chunk which
+// finished uploading in the index for other chunks to reuse.
+func (r *Renter) managedRegisterDedupChunk(uc *unfinishedUploadChunk) {
+	id, exists := uc.fileEntry.DedupChunk(uc.staticIndex)
+	if !exists {
+		return
+	}
+	uc.mu.Lock()
+	available := uc.piecesCompleted >= uc.staticMinimumPieces
+	uc.mu.Unlock()
+	if !available {
+		return
+	}
+	pieces, err := uc.fileEntry.Pieces(uc.staticIndex)
+	if err != nil {
+		r.log.Printf("WARN: unable to get the pieces of deduplicated chunk %v of %v: %v", uc.staticIndex, uc.staticSiaPath, err)
+		return
+	}
+	r.staticDedupIndex.managedSetPieces(id, pieces)
+}
+
+// managedDedupChunkIDs returns the content IDs of the deduplicated chunks of a
+// file. Missing files don't have any.
+func (r *Renter) managedDedupChunkIDs(siaPath modules.SiaPath) ([]crypto.Hash, error) {
+	if r.staticDedupIndex.managedLen() == 0 {
+		return nil, nil
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = entry.Close()
+	}()
+	var ids []crypto.Hash
+	for _, id := range entry.DedupChunks() {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// managedDedupDirChunkIDs returns the content IDs of the deduplicated chunks of
+// all the files within a directory and its subdirectories.
+func (r *Renter) managedDedupDirChunkIDs(siaPath modules.SiaPath) ([]crypto.Hash, error) {
+	if r.staticDedupIndex.managedLen() == 0 {
+		return nil, nil
+	}
+	var mu sync.Mutex
+	var siaPaths []modules.SiaPath
+	err := r.staticFileSystem.CachedList(siaPath, true, func(fi modules.FileInfo) {
+		if fi.DedupChunks == 0 {
+			return
+		}
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []crypto.Hash
+	for _, sp := range siaPaths {
+		fileIDs, err := r.managedDedupChunkIDs(sp)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, fileIDs...)
+	}
+	return ids, nil
+}
+
+// threadedPersistDedupIndex periodically saves the dedup index.
+func (r *Renter) threadedPersistDedupIndex() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(dedupIndexPersistInterval):
+		}
+		if err := r.staticDedupIndex.managedSave(); err != nil {
+			r.log.Println("WARN: failed to save dedup index:", err)
+		}
+	}
+}
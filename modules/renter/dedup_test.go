@@ -0,0 +1,160 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
+)
+
+// TestDedupIndex tests reference counting the entries of the dedup index and
+// persisting it.
+func TestDedupIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, dedupIndexFilename)
+	di, err := newDedupIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Acquire two references to one ID and one to another.
+	var id1, id2 crypto.Hash
+	fastrand.Read(id1[:])
+	fastrand.Read(id2[:])
+	di.managedAcquire(id1)
+	di.managedAcquire(id1)
+	di.managedAcquire(id2)
+	if di.managedLen() != 2 {
+		t.Fatal("wrong number of entries", di.managedLen())
+	}
+	if di.managedPieces(id1) != nil {
+		t.Fatal("entry shouldn't have pieces before they are set")
+	}
+	var hpk types.SiaPublicKey
+	hpk.Key = fastrand.Bytes(32)
+	pieces := [][]siafile.Piece{{{HostPubKey: hpk, MerkleRoot: crypto.Hash{1}}}, nil}
+	di.managedSetPieces(id1, pieces)
+
+	// Changing the returned pieces doesn't change the index.
+	di.managedPieces(id1)[0][0].MerkleRoot = crypto.Hash{2}
+	if !reflect.DeepEqual(di.managedPieces(id1), pieces) {
+		t.Fatal("wrong pieces", di.managedPieces(id1), pieces)
+	}
+
+	// Reload the index.
+	if err := di.managedSave(); err != nil {
+		t.Fatal(err)
+	}
+	di, err = newDedupIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if di.managedLen() != 2 {
+		t.Fatal("wrong number of entries after reload", di.managedLen())
+	}
+	if !reflect.DeepEqual(di.managedPieces(id1), pieces) {
+		t.Fatal("wrong pieces after reload", di.managedPieces(id1), pieces)
+	}
+
+	// Entries are removed once their last reference is released.
+	di.managedRelease([]crypto.Hash{id1, id2})
+	if di.managedLen() != 1 || di.managedPieces(id1) == nil {
+		t.Fatal("entry was removed while still referenced")
+	}
+	di.managedRelease([]crypto.Hash{id1, id2})
+	if di.managedLen() != 0 {
+		t.Fatal("entries weren't removed", di.managedLen())
+	}
+}
+
+// TestDedupChunkID tests that content IDs only depend on the data of a chunk,
+// its erasure code and the secret.
+func TestDedupChunkID(t *testing.T) {
+	t.Parallel()
+
+	ec, err := modules.NewRSSubCode(2, 3, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(2 * modules.SectorSize))
+	encode := func(data []byte) [][]byte {
+		pieces, err := ec.Encode(append([]byte(nil), data...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pieces
+	}
+	var secret crypto.Hash
+	fastrand.Read(secret[:])
+	id := dedupChunkID(secret, ec, modules.SectorSize, encode(data))
+
+	// Identical data results in the same ID.
+	if dedupChunkID(secret, ec, modules.SectorSize, encode(data)) != id {
+		t.Fatal("identical data resulted in different IDs")
+	}
+	// Different data, erasure codes and secrets result in different IDs.
+	data2 := append([]byte(nil), data...)
+	data2[0]++
+	if dedupChunkID(secret, ec, modules.SectorSize, encode(data2)) == id {
+		t.Fatal("different data resulted in the same ID")
+	}
+	ec2, err := modules.NewRSSubCode(2, 4, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces, err := ec2.Encode(append([]byte(nil), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dedupChunkID(secret, ec2, modules.SectorSize, pieces) == id {
+		t.Fatal("different erasure codes resulted in the same ID")
+	}
+	var secret2 crypto.Hash
+	fastrand.Read(secret2[:])
+	if dedupChunkID(secret2, ec, modules.SectorSize, encode(data)) == id {
+		t.Fatal("different secrets resulted in the same ID")
+	}
+}
+
+// TestValidateDedup tests validating the upload params of deduplicated
+// uploads.
+func TestValidateDedup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		up  modules.FileUploadParams
+		err error
+	}{
+		{modules.FileUploadParams{}, nil},
+		{modules.FileUploadParams{Dedup: true}, nil},
+		{modules.FileUploadParams{Dedup: true, CipherType: crypto.TypeThreefish}, nil},
+		{modules.FileUploadParams{Dedup: true, CipherType: crypto.TypeXChaCha20}, errDedupCipherType},
+		{modules.FileUploadParams{Dedup: true, CipherKey: crypto.GenerateSiaKey(crypto.TypePlain)}, errDedupCipherType},
+		{modules.FileUploadParams{CipherType: crypto.TypePlain}, nil},
+	}
+	for i, test := range tests {
+		if err := validateDedup(test.up); err != test.err && !errors.Contains(err, test.err) {
+			t.Fatalf("%v: expected %v but got %v", i, test.err, err)
+		}
+	}
+	if err := validateDedup(modules.FileUploadParams{Dedup: true, Repair: true}); err == nil {
+		t.Fatal("dedup and repair shouldn't be allowed together")
+	}
+}
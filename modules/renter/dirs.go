@@ -26,7 +26,56 @@ func (r *Renter) DeleteDir(siaPath modules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticFileSystem.DeleteDir(siaPath)
+	if err := r.managedKeepDirVersions(siaPath); err != nil {
+		return errors.AddContext(err, "unable to keep versions of the files in the directory")
+	}
+	trashed, err := r.managedMoveToTrash(siaPath, true)
+	if err != nil {
+		return errors.AddContext(err, "unable to move directory to the trash")
+	}
+	if trashed {
+		return nil
+	}
+	return r.managedDeleteDir(siaPath)
+}
+
+// managedDeleteDir permanently deletes a directory and releases the
+// deduplicated chunks of its files.
+func (r *Renter) managedDeleteDir(siaPath modules.SiaPath) error {
+	dedupIDs, err := r.managedDedupDirChunkIDs(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get the deduplicated chunks of the directory")
+	}
+	if err := r.staticFileSystem.DeleteDir(siaPath); err != nil {
+		return err
+	}
+	r.staticDedupIndex.managedRelease(dedupIDs)
+	r.managedForgetTrashEntries(siaPath)
+	return nil
+}
+
+// managedKeepDirVersions keeps the files within a directory that is about to
+// be deleted as prior versions if versioning is enabled for them.
+func (r *Renter) managedKeepDirVersions(siaPath modules.SiaPath) error {
+	if len(r.VersioningPolicies()) == 0 || isVersionSiaPath(siaPath) {
+		return nil
+	}
+	var mu sync.Mutex
+	var files []modules.SiaPath
+	err := r.staticFileSystem.CachedList(siaPath, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		files = append(files, fi.SiaPath)
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if _, err := r.managedKeepVersion(file); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DirList lists the directories in a siadir
@@ -38,10 +87,14 @@ func (r *Renter) DirList(siaPath modules.SiaPath) (dis []modules.DirectoryInfo,
 	return r.managedDirList(siaPath)
 }
 
-// managedDirList lists the directories in a siadir
+// managedDirList lists the directories in a siadir. The trash is not listed as
+// a subdirectory of the root directory.
 func (r *Renter) managedDirList(siaPath modules.SiaPath) (dis []modules.DirectoryInfo, _ error) {
 	var mu sync.Mutex
 	dlf := func(di modules.DirectoryInfo) {
+		if di.SiaPath.Equals(modules.TrashFolder) {
+			return
+		}
 		mu.Lock()
 		dis = append(dis, di)
 		mu.Unlock()
@@ -33,6 +33,7 @@ type (
 		chunksRemaining uint64        // Number of chunks whose downloads are incomplete.
 		completeChan    chan struct{} // Closed once the download is complete.
 		err             error         // Only set if there was an error which prevented the download from completing.
+		verifying       bool          // Set while the downloaded data is verified before completing the download.
 
 		// downloadCompleteFunc is a slice of functions which are called when
 		// completeChan is closed.
@@ -65,17 +66,22 @@ type (
 
 	// downloadParams is the set of parameters to use when downloading a file.
 	downloadParams struct {
-		destination       downloadDestination // The place to write the downloaded data.
-		destinationType   string              // "file", "buffer", "http stream", etc.
-		destinationString string              // The string to report to the user for the destination.
-		disableLocalFetch bool                // Whether or not the file can be fetched from disk if available.
-		file              *siafile.Snapshot   // The file to download.
-		latencyTarget     time.Duration       // Workers above this latency will be automatically put on standby initially.
-		length            uint64              // Length of download. Cannot be 0.
-		needsMemory       bool                // Whether new memory needs to be allocated to perform the download.
-		offset            uint64              // Offset within the file to start the download. Must be less than the total filesize.
-		overdrive         int                 // How many extra pieces to download to prevent slow hosts from being a bottleneck.
-		priority          uint64              // Files with a higher priority will be downloaded first.
+		destination       downloadDestination      // The place to write the downloaded data.
+		destinationType   string                   // "file", "buffer", "http stream", etc.
+		destinationString string                   // The string to report to the user for the destination.
+		disableLocalFetch bool                     // Whether or not the file can be fetched from disk if available.
+		file              *siafile.Snapshot        // The file to download.
+		latencyTarget     time.Duration            // Workers above this latency will be automatically put on standby initially.
+		length            uint64                   // Length of download. Cannot be 0.
+		needsMemory       bool                     // Whether new memory needs to be allocated to perform the download.
+		offset            uint64                   // Offset within the file to start the download. Must be less than the total filesize.
+		overdrive         int                      // How many extra pieces to download to prevent slow hosts from being a bottleneck.
+		priority          modules.DownloadPriority // Downloads of a higher priority class will be downloaded first.
+
+		// verify is called before a download that didn't fail is marked as
+		// complete. If it returns an error, the download fails with that
+		// error. It is called without holding the lock of the download.
+		verify func() error
 
 		staticMemoryManager *memoryManager
 
@@ -97,6 +103,15 @@ func (d *download) managedFail(err error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	// If the downloaded data is being verified, the verification will complete
+	// the download with the error.
+	if d.verifying {
+		if d.err == nil {
+			d.err = err
+		}
+		return
+	}
+
 	// If the download is already complete, extend the error.
 	complete := d.staticComplete()
 	if complete && d.err != nil {
@@ -114,7 +129,41 @@ func (d *download) managedFail(err error) {
 // markComplete is a helper method which closes the completeChan and and
 // executes the downloadCompleteFuncs. The completeChan should always be closed
 // using this method.
+//
+// If the downloaded data needs to be verified, the verification runs in a
+// separate thread which completes the download once it's done, since it has to
+// read the whole destination without holding the lock of the download.
 func (d *download) markComplete() {
+	if d.err == nil && d.staticParams.verify != nil && !d.verifying && !d.staticComplete() {
+		d.verifying = true
+		if err := d.r.tg.Add(); err != nil {
+			d.err = err
+			d.finishComplete()
+			return
+		}
+		go func() {
+			defer d.r.tg.Done()
+			d.threadedVerify()
+		}()
+		return
+	}
+	d.finishComplete()
+}
+
+// threadedVerify verifies the downloaded data and completes the download.
+func (d *download) threadedVerify() {
+	err := d.staticParams.verify()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+	d.finishComplete()
+}
+
+// finishComplete closes the completeChan and executes the
+// downloadCompleteFuncs.
+func (d *download) finishComplete() {
 	// Avoid calling markComplete multiple times. In a production build
 	// build.Critical won't panic which is fine since we set
 	// downloadCompleteFunc to nil after executing them. We still don't want to
@@ -245,8 +294,18 @@ func (r *Renter) DownloadAsync(p modules.RenterDownloadParameters, f func(error)
 // returns the download object and an error that indicates if the download
 // setup was successful.
 func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *download, err error) {
+	// Downloads of prior versions read the siafile of the version but are
+	// still reported under the siapath of the file.
+	siaPath := p.SiaPath
+	if p.Version != "" {
+		siaPath, err = r.managedFileVersionSiaPath(p.SiaPath, p.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Lookup the file associated with the nickname.
-	entry, err := r.staticFileSystem.OpenSiaFile(p.SiaPath)
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
 	if err != nil {
 		return nil, err
 	}
@@ -269,38 +328,85 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if p.Destination != "" && !filepath.IsAbs(p.Destination) {
 		return nil, errors.New("destination must be an absolute path")
 	}
-	if p.Offset == entry.Size() && entry.Size() != 0 {
+	priority, err := modules.ParseDownloadPriority(string(p.Priority))
+	if err != nil {
+		return nil, err
+	}
+	// The offset and length of compressed files refer to the uncompressed
+	// data.
+	compression := entry.Compression()
+	fileSize := entry.Size()
+	if compression.IsSet() {
+		fileSize = compression.Size
+	}
+	if p.Offset == fileSize && fileSize != 0 {
 		return nil, errors.New("offset equals filesize")
 	}
 	// Sentinel: if length == 0, download the entire file.
 	if p.Length == 0 {
-		if p.Offset > entry.Size() {
+		if p.Offset > fileSize {
 			return nil, errors.New("offset cannot be greater than file size")
 		}
-		p.Length = entry.Size() - p.Offset
+		p.Length = fileSize - p.Offset
 	}
 	// Check whether offset and length is valid.
-	if p.Offset < 0 || p.Offset+p.Length > entry.Size() {
-		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", entry.Size()-1)
+	if p.Offset < 0 || p.Offset+p.Length > fileSize {
+		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", fileSize-1)
+	}
+
+	// Compressed files are downloaded chunk by chunk and decompressed while
+	// they are written to the destination.
+	offset, length := p.Offset, p.Length
+	var decompress func(io.Writer) io.Writer
+	if compression.IsSet() {
+		offset, length = compressedRange(compression, entry.ChunkSize(), entry.Size(), p.Offset, p.Length)
+		decompress = func(w io.Writer) io.Writer {
+			return newDecompressingWriter(w, compression, entry.ChunkSize(), offset, length, p.Offset, p.Length)
+		}
 	}
 
+	// Full downloads of files with a known checksum are verified once they
+	// are complete. Downloads to http responses aren't verified since the data
+	// was already sent to the client by then and it would never see the
+	// error.
+	checksum := entry.Checksum()
+	verifyChecksum := checksum.IsSet() && p.Offset == 0 && p.Length == fileSize && !isHTTPResp
+	var verify func() error
+
 	// Instantiate the correct downloadWriter implementation.
 	var dw downloadDestination
 	var destinationType string
 	if isHTTPResp {
-		dw = newDownloadDestinationWriter(p.Httpwriter)
+		w := p.Httpwriter
+		if decompress != nil {
+			w = decompress(w)
+		}
+		dw = newDownloadDestinationWriter(w)
 		destinationType = "http stream"
 	} else {
 		osFile, err := os.OpenFile(p.Destination, os.O_CREATE|os.O_WRONLY, entry.Mode())
 		if err != nil {
 			return nil, err
 		}
-		dw = &downloadDestinationFile{
-			deps:            r.deps,
-			f:               osFile,
-			staticChunkSize: int64(entry.ChunkSize()),
+		if decompress != nil {
+			dw = &downloadDestinationCompressedFile{
+				downloadDestinationWriter: newDownloadDestinationWriter(decompress(osFile)),
+				f:                         osFile,
+			}
+		} else {
+			dw = &downloadDestinationFile{
+				deps:            r.deps,
+				f:               osFile,
+				staticChunkSize: int64(entry.ChunkSize()),
+			}
 		}
 		destinationType = "file"
+		if verifyChecksum {
+			destination, length := p.Destination, int64(p.Length)
+			verify = func() error {
+				return verifyFileChecksum(destination, length, checksum)
+			}
+		}
 	}
 
 	// If the destination is a httpWriter, we set the Content-Length in the
@@ -313,7 +419,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	}
 
 	// Prepare snapshot.
-	snap, err := entry.SnapshotRange(p.SiaPath, p.Offset, p.Length)
+	snap, err := entry.SnapshotRange(p.SiaPath, offset, length)
 	if err != nil {
 		return nil, err
 	}
@@ -326,11 +432,12 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		file:              snap,
 
 		latencyTarget: 25e3 * time.Millisecond, // TODO: high default until full latency support is added.
-		length:        p.Length,
+		length:        length,
 		needsMemory:   true,
-		offset:        p.Offset,
+		offset:        offset,
 		overdrive:     3, // TODO: moderate default until full overdrive support is added.
-		priority:      5, // TODO: moderate default until full priority support is added.
+		priority:      priority,
+		verify:        verify,
 
 		staticMemoryManager:    r.userDownloadMemoryManager, // user initiated download
 		staticSpendingCategory: categoryDownload,
@@ -398,7 +505,7 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		staticOffset:          params.offset,
 		staticOverdrive:       params.overdrive,
 		staticSiaPath:         params.file.SiaPath(),
-		staticPriority:        params.priority,
+		staticPriority:        downloadHeapPriority(params.priority),
 
 		r:            r,
 		staticParams: params,
@@ -475,7 +582,6 @@ func (d *download) Start() error {
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
 			erasureCode: params.file.ErasureCode(),
-			masterKey:   params.file.MasterKey(),
 
 			staticChunkIndex: i,
 			staticCacheID:    fmt.Sprintf("%v:%v", d.staticSiaPath, i),
@@ -497,7 +603,8 @@ func (d *download) Start() error {
 			staticDisableDiskFetch: params.disableLocalFetch,
 			staticLatencyTarget:    d.staticLatencyTarget + (25 * time.Duration(i-minChunk)), // Increase target by 25ms per chunk.
 			staticNeedsMemory:      params.needsMemory,
-			staticPriority:         params.priority,
+			staticPriority:         d.staticPriority,
+			staticPriorityClass:    params.priority,
 
 			completedPieces:   make([]bool, params.file.ErasureCode().NumPieces()),
 			physicalChunkData: make([][]byte, params.file.ErasureCode().NumPieces()),
@@ -560,6 +667,8 @@ func (r *Renter) DownloadByUID(uid modules.DownloadID) (modules.DownloadInfo, bo
 		Offset:          d.staticOffset,
 		SiaPath:         d.staticSiaPath,
 
+		Priority: d.staticParams.priority,
+
 		Completed:            d.staticComplete(),
 		EndTime:              d.endTime,
 		Received:             atomic.LoadUint64(&d.atomicDataReceived),
@@ -604,6 +713,8 @@ func (r *Renter) DownloadHistory() []modules.DownloadInfo {
 			Offset:          d.staticOffset,
 			SiaPath:         d.staticSiaPath,
 
+			Priority: d.staticParams.priority,
+
 			Completed:            d.staticComplete(),
 			EndTime:              d.endTime,
 			Received:             atomic.LoadUint64(&d.atomicDataReceived),
@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/modules"
 
 	"go.sia.tech/siad/types"
@@ -313,3 +315,73 @@ func checkDownloadHistory(downloads []modules.DownloadInfo, check []int64) bool
 	}
 	return true
 }
+
+// TestDownloadVerify tests that downloads are verified without holding the
+// lock of the download and before they are marked as complete.
+func TestDownloadVerify(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	errVerify := errors.New("verification failed")
+	newDownload := func(verify func() error) *download {
+		return &download{
+			completeChan: make(chan struct{}),
+			r:            rt.renter,
+			staticParams: downloadParams{verify: verify},
+		}
+	}
+
+	// The lock of the download isn't held during the verification and the
+	// download isn't complete until it's done.
+	release := make(chan struct{})
+	var d *download
+	d = newDownload(func() error {
+		if d.Err() != nil || d.staticComplete() {
+			return errors.New("download shouldn't be complete during the verification")
+		}
+		<-release
+		return errVerify
+	})
+	d.mu.Lock()
+	d.markComplete()
+	d.mu.Unlock()
+	close(release)
+	select {
+	case <-d.completeChan:
+	case <-time.After(10 * time.Second):
+		t.Fatal("download wasn't completed")
+	}
+	if !errors.Contains(d.Err(), errVerify) {
+		t.Fatal("expected the verification error but got", d.Err())
+	}
+
+	// Failing a download during the verification completes it with the
+	// failure once the verification is done.
+	release = make(chan struct{})
+	d = newDownload(func() error {
+		<-release
+		return nil
+	})
+	d.mu.Lock()
+	d.markComplete()
+	d.mu.Unlock()
+	d.managedCancel()
+	if d.staticComplete() {
+		t.Fatal("download shouldn't be complete before the verification is done")
+	}
+	close(release)
+	<-d.completeChan
+	if !errors.Contains(d.Err(), modules.ErrDownloadCancelled) {
+		t.Fatal("expected ErrDownloadCancelled but got", d.Err())
+	}
+}
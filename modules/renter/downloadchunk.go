@@ -38,7 +38,6 @@ type unfinishedDownloadChunk struct {
 	// Fetch + Write instructions - read only or otherwise thread safe.
 	destination downloadDestination // Where to write the recovered logical chunk.
 	erasureCode modules.ErasureCoder
-	masterKey   crypto.CipherKey
 
 	// Fetch + Write instructions - read only or otherwise thread safe.
 	staticChunkIndex  uint64                       // Required for deriving the encryption keys for each piece.
@@ -60,6 +59,7 @@ type unfinishedDownloadChunk struct {
 	staticMemoryManager    *memoryManager
 	staticOverdrive        int
 	staticPriority         uint64
+	staticPriorityClass    modules.DownloadPriority
 
 	// Download chunk state - need mutex to access.
 	completedPieces   []bool    // Which pieces were downloaded successfully.
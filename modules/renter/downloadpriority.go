@@ -0,0 +1,173 @@
+package renter
+
+// downloadpriority.go implements the priority classes of downloads. The class
+// of a download determines the priority of its chunks in the download heap,
+// the priority of the memory they request and the read queue of the workers
+// that fetches their pieces. On top of that, every worker limits the number
+// of pieces of a class it downloads at once, so that batch downloads can't use
+// up all the bandwidth of the workers while a stream is waiting for data.
+
+import (
+	"sync/atomic"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// workerDownloadClasses limits and tracks the downloads of a worker by
+	// priority class.
+	workerDownloadClasses map[modules.DownloadPriority]*workerDownloadClass
+
+	// workerDownloadClass limits and tracks the downloads of a priority class
+	// of a worker.
+	workerDownloadClass struct {
+		atomicActive          uint64
+		atomicBytesDownloaded uint64
+		atomicQueued          uint64
+
+		// staticSlots limits the number of pieces that are downloaded at once.
+		// It is nil if the class isn't limited.
+		staticSlots chan struct{}
+	}
+)
+
+// downloadHeapPriority returns the priority of the chunks of a download of the
+// given class in the download heap.
+func downloadHeapPriority(p modules.DownloadPriority) uint64 {
+	switch p {
+	case modules.DownloadPriorityStream:
+		return 1000
+	case modules.DownloadPriorityBatch:
+		return 0
+	default:
+		return 5
+	}
+}
+
+// downloadMemoryPriority returns the priority of the memory requested by the
+// chunks of a download of the given class.
+func downloadMemoryPriority(p modules.DownloadPriority) bool {
+	return p != modules.DownloadPriorityBatch
+}
+
+// downloadConcurrency returns the maximum number of pieces of the given class
+// a worker downloads at once. 0 means no limit.
+func downloadConcurrency(p modules.DownloadPriority) int {
+	switch p {
+	case modules.DownloadPriorityUser:
+		return userDownloadConcurrency
+	case modules.DownloadPriorityBatch:
+		return batchDownloadConcurrency
+	default:
+		return 0
+	}
+}
+
+// newWorkerDownloadClasses creates the download classes of a worker.
+func newWorkerDownloadClasses() workerDownloadClasses {
+	classes := make(workerDownloadClasses)
+	for _, p := range modules.DownloadPriorities {
+		class := &workerDownloadClass{}
+		if n := downloadConcurrency(p); n > 0 {
+			class.staticSlots = make(chan struct{}, n)
+		}
+		classes[p] = class
+	}
+	return classes
+}
+
+// callAcquire blocks until a piece of the class may be downloaded. It returns
+// false if cancel or stop is closed first.
+func (c *workerDownloadClass) callAcquire(cancel, stop <-chan struct{}) bool {
+	if c.staticSlots != nil {
+		atomic.AddUint64(&c.atomicQueued, 1)
+		defer atomic.AddUint64(&c.atomicQueued, ^uint64(0))
+		select {
+		case c.staticSlots <- struct{}{}:
+		case <-cancel:
+			return false
+		case <-stop:
+			return false
+		}
+	}
+	atomic.AddUint64(&c.atomicActive, 1)
+	return true
+}
+
+// callRelease releases a piece acquired with callAcquire and adds the bytes
+// that were downloaded for it.
+func (c *workerDownloadClass) callRelease(downloaded uint64) {
+	atomic.AddUint64(&c.atomicActive, ^uint64(0))
+	atomic.AddUint64(&c.atomicBytesDownloaded, downloaded)
+	if c.staticSlots != nil {
+		<-c.staticSlots
+	}
+}
+
+// callStatus returns the status of the download classes.
+func (classes workerDownloadClasses) callStatus() []modules.WorkerDownloadClassStatus {
+	statuses := make([]modules.WorkerDownloadClassStatus, 0, len(modules.DownloadPriorities))
+	for _, p := range modules.DownloadPriorities {
+		status := modules.WorkerDownloadClassStatus{
+			Priority:       p,
+			MaxConcurrency: uint64(downloadConcurrency(p)),
+		}
+		if c, ok := classes[p]; ok {
+			status.ActiveJobs = atomic.LoadUint64(&c.atomicActive)
+			status.QueuedJobs = atomic.LoadUint64(&c.atomicQueued)
+			status.BytesDownloaded = atomic.LoadUint64(&c.atomicBytesDownloaded)
+		}
+		statuses = append(statuses, status)
+	}
+	setBandwidthShares(statuses)
+	return statuses
+}
+
+// addDownloadClassStatuses adds the jobs and bytes of the statuses to the
+// totals and updates the bandwidth shares of the totals. Both need to be
+// ordered like modules.DownloadPriorities.
+func addDownloadClassStatuses(totals, statuses []modules.WorkerDownloadClassStatus) {
+	for i := range totals {
+		if i >= len(statuses) {
+			break
+		}
+		totals[i].ActiveJobs += statuses[i].ActiveJobs
+		totals[i].QueuedJobs += statuses[i].QueuedJobs
+		totals[i].BytesDownloaded += statuses[i].BytesDownloaded
+	}
+	setBandwidthShares(totals)
+}
+
+// setBandwidthShares sets the bandwidth share of every status to its fraction
+// of the bytes downloaded for all of them.
+func setBandwidthShares(statuses []modules.WorkerDownloadClassStatus) {
+	var total uint64
+	for _, s := range statuses {
+		total += s.BytesDownloaded
+	}
+	for i := range statuses {
+		statuses[i].BandwidthShare = 0
+		if total > 0 {
+			statuses[i].BandwidthShare = float64(statuses[i].BytesDownloaded) / float64(total)
+		}
+	}
+}
+
+// staticDownloadClass returns the download class of the chunk. Workers that
+// weren't created by newWorker don't limit their downloads.
+func (w *worker) staticDownloadClass(udc *unfinishedDownloadChunk) *workerDownloadClass {
+	if c, ok := w.staticDownloadClasses[udc.staticPriorityClass]; ok {
+		return c
+	}
+	return &workerDownloadClass{}
+}
+
+// staticDownloadReadQueue returns the read queue that fetches the pieces of
+// the chunk. Streams use the regular read queue which the worker launches
+// before the low priority one.
+func (w *worker) staticDownloadReadQueue(udc *unfinishedDownloadChunk) *jobReadQueue {
+	if udc.staticPriorityClass == modules.DownloadPriorityStream {
+		return w.staticJobReadQueue
+	}
+	return w.staticJobLowPrioReadQueue
+}
@@ -0,0 +1,89 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestDownloadPriorityClasses tests the heap and memory priorities of the
+// download priority classes.
+func TestDownloadPriorityClasses(t *testing.T) {
+	stream := downloadHeapPriority(modules.DownloadPriorityStream)
+	user := downloadHeapPriority(modules.DownloadPriorityUser)
+	batch := downloadHeapPriority(modules.DownloadPriorityBatch)
+	if !(stream > user && user > batch) {
+		t.Fatal("classes aren't ordered", stream, user, batch)
+	}
+	if !downloadMemoryPriority(modules.DownloadPriorityStream) || !downloadMemoryPriority(modules.DownloadPriorityUser) {
+		t.Fatal("streams and user downloads should request high priority memory")
+	}
+	if downloadMemoryPriority(modules.DownloadPriorityBatch) {
+		t.Fatal("batch downloads should request low priority memory")
+	}
+}
+
+// TestWorkerDownloadClasses tests limiting and tracking the downloads of a
+// worker by priority class.
+func TestWorkerDownloadClasses(t *testing.T) {
+	classes := newWorkerDownloadClasses()
+	stop := make(chan struct{})
+
+	// Streams aren't limited.
+	stream := classes[modules.DownloadPriorityStream]
+	for i := 0; i < batchDownloadConcurrency+userDownloadConcurrency+1; i++ {
+		if !stream.callAcquire(nil, stop) {
+			t.Fatal("stream should never block")
+		}
+	}
+
+	// Batch downloads block once the limit is reached.
+	batch := classes[modules.DownloadPriorityBatch]
+	for i := 0; i < batchDownloadConcurrency; i++ {
+		if !batch.callAcquire(nil, stop) {
+			t.Fatal("failed to acquire batch slot")
+		}
+	}
+	acquired := make(chan bool)
+	go func() {
+		acquired <- batch.callAcquire(nil, stop)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("batch download should block")
+	case <-time.After(100 * time.Millisecond):
+	}
+	status := classes.callStatus()
+	if status[2].Priority != modules.DownloadPriorityBatch || status[2].QueuedJobs != 1 || status[2].ActiveJobs != uint64(batchDownloadConcurrency) {
+		t.Fatal("unexpected batch status", status[2])
+	}
+
+	// Releasing a slot unblocks the queued download.
+	batch.callRelease(100)
+	if !<-acquired {
+		t.Fatal("batch download should have acquired the released slot")
+	}
+
+	// A canceled download doesn't wait for a slot.
+	cancel := make(chan struct{})
+	close(cancel)
+	if batch.callAcquire(cancel, stop) {
+		t.Fatal("canceled download shouldn't acquire a slot")
+	}
+
+	// Bandwidth shares are computed from the downloaded bytes.
+	stream.callRelease(300)
+	status = classes.callStatus()
+	if status[0].BandwidthShare != 0.75 || status[2].BandwidthShare != 0.25 || status[1].BandwidthShare != 0 {
+		t.Fatal("unexpected bandwidth shares", status)
+	}
+
+	// The totals of the worker pool add up the workers.
+	totals := workerDownloadClasses(nil).callStatus()
+	addDownloadClassStatuses(totals, status)
+	addDownloadClassStatuses(totals, status)
+	if totals[0].BytesDownloaded != 600 || totals[2].BytesDownloaded != 200 || totals[0].BandwidthShare != 0.75 {
+		t.Fatal("unexpected totals", totals)
+	}
+}
@@ -180,8 +180,8 @@ func (s *streamer) managedFillCache() bool {
 		length:        uint64(fetchLen),
 		needsMemory:   true,
 		offset:        uint64(fetchOffset),
-		overdrive:     5,    // TODO: high default until full overdrive support is added.
-		priority:      1000, // TODO: high default until full priority support is added.
+		overdrive:     5, // TODO: high default until full overdrive support is added.
+		priority:      modules.DownloadPriorityStream,
 
 		staticMemoryManager:    s.r.userDownloadMemoryManager, // user initiated download
 		staticSpendingCategory: categoryDownload,
@@ -516,7 +516,7 @@ func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch boo
 }
 
 // managedStreamer creates a streamer from a siafile snapshot and starts filling
-// its cache.
+// its cache. The data of compressed files is decompressed transparently.
 func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch bool) modules.Streamer {
 	s := &streamer{
 		staticFile: snapshot,
@@ -528,5 +528,8 @@ func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch b
 		targetCacheSize:         initialStreamerCacheSize,
 	}
 	go s.threadedFillCache()
+	if compression := snapshot.Compression(); compression.IsSet() {
+		return newDecompressingStreamer(s, compression, snapshot.ChunkSize(), snapshot.Size())
+	}
 	return s
 }
@@ -17,11 +17,31 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
+	return r.managedRemoveFile(siaPath, true)
+}
 
-	// Perform the delete operation.
-	err = r.staticFileSystem.DeleteFile(siaPath)
+// managedRemoveFile removes a file from the renter. The file is kept as a
+// prior version if its directory has versioning enabled. Otherwise it is moved
+// to the trash if useTrash is set, and deleted permanently if not. Files which
+// are already in the trash are always deleted permanently.
+func (r *Renter) managedRemoveFile(siaPath modules.SiaPath, useTrash bool) error {
+	versioned, err := r.managedKeepVersion(siaPath)
 	if err != nil {
-		return errors.AddContext(err, "unable to delete siafile from filesystem")
+		return errors.AddContext(err, "unable to keep a version of the siafile")
+	}
+	if !versioned {
+		trashed := false
+		if useTrash {
+			trashed, err = r.managedMoveToTrash(siaPath, false)
+			if err != nil {
+				return errors.AddContext(err, "unable to move siafile to the trash")
+			}
+		}
+		if !trashed {
+			if err := r.managedDeleteFile(siaPath); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Update the filesystem metadata.
@@ -43,13 +63,38 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 	return nil
 }
 
+// managedDeleteFile permanently deletes a file and releases its deduplicated
+// chunks.
+func (r *Renter) managedDeleteFile(siaPath modules.SiaPath) error {
+	dedupIDs, err := r.managedDedupChunkIDs(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get the deduplicated chunks of the siafile")
+	}
+	err = r.staticFileSystem.DeleteFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to delete siafile from filesystem")
+	}
+	r.staticDedupIndex.managedRelease(dedupIDs)
+	r.managedForgetTrashEntries(siaPath)
+	return nil
+}
+
 // FileList loops over all the files within the directory specified by siaPath
-// and will then call the provided listing function on the file.
+// and will then call the provided listing function on the file. Files in the
+// trash are only listed when listing the trash itself.
 func (r *Renter) FileList(siaPath modules.SiaPath, recursive, cached bool, flf modules.FileListFunc) error {
 	if err := r.tg.Add(); err != nil {
 		return err
 	}
 	defer r.tg.Done()
+	if !isTrashSiaPath(siaPath) {
+		listFunc := flf
+		flf = func(fi modules.FileInfo) {
+			if !isTrashSiaPath(fi.SiaPath) {
+				listFunc(fi)
+			}
+		}
+	}
 	var err error
 	if cached {
 		err = r.staticFileSystem.CachedList(siaPath, recursive, flf, func(modules.DirectoryInfo) {})
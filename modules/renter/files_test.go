@@ -200,7 +200,11 @@ func TestRenterDeleteFile(t *testing.T) {
 		t.Error(err)
 	}
 
-	// Check that all .sia files have been deleted.
+	// Deleted files are moved to the trash. Check that all .sia files have
+	// been deleted after purging it.
+	if err := rt.renter.PurgeTrash(); err != nil {
+		t.Fatal(err)
+	}
 	var walkStr string
 	rt.renter.staticFileSystem.Walk(modules.RootSiaPath(), func(path string, _ os.FileInfo, _ error) error {
 		// capture only .sia files
@@ -134,6 +134,28 @@ func (n *DirNode) Path() (string, error) {
 	return sd.Path(), nil
 }
 
+// SetTags is a wrapper for SiaDir.SetTags.
+func (n *DirNode) SetTags(tags []string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetTags(tags)
+}
+
+// SetUserMetadata is a wrapper for SiaDir.SetUserMetadata.
+func (n *DirNode) SetUserMetadata(md map[string]string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetUserMetadata(md)
+}
+
 // UpdateBubbledMetadata is a wrapper for SiaDir.UpdateBubbledMetadata.
 func (n *DirNode) UpdateBubbledMetadata(md siadir.Metadata) error {
 	n.mu.Lock()
@@ -608,7 +630,9 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		StuckHealth:         metadata.StuckHealth,
 		StuckSize:           metadata.StuckSize,
 		SiaPath:             siaPath,
+		Tags:                append([]string(nil), metadata.Tags...),
 		UID:                 n.staticUID,
+		UserMetadata:        modules.CopyUserMetadata(metadata.UserMetadata),
 	}, nil
 }
 
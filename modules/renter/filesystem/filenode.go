@@ -141,8 +141,11 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		AccessTime:       n.AccessTime(),
 		Available:        redundancy >= 1,
 		ChangeTime:       n.ChangeTime(),
+		Checksum:         n.Checksum(),
 		CipherType:       n.MasterKey().Type().String(),
 		CreateTime:       n.CreateTime(),
+		Dedup:            n.Dedup(),
+		DedupChunks:      uint64(len(n.DedupChunks())),
 		Expiration:       n.Expiration(contracts),
 		Filesize:         n.Size(),
 		Health:           health,
@@ -160,10 +163,13 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		Stuck:            numStuckChunks > 0,
 		StuckHealth:      stuckHealth,
 		StuckBytes:       stuckBytes,
+		Tags:             n.Tags(),
 		UID:              n.staticUID,
 		UploadedBytes:    uploadedBytes,
 		UploadProgress:   uploadProgress,
+		UserMetadata:     n.UserMetadata(),
 	}
+	setFileInfoCompression(&fileInfo, n.Compression())
 	return fileInfo, nil
 }
 
@@ -225,8 +231,11 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		AccessTime:       md.AccessTime,
 		Available:        md.CachedUserRedundancy >= 1,
 		ChangeTime:       md.ChangeTime,
+		Checksum:         md.Checksum,
 		CipherType:       md.StaticMasterKeyType.String(),
 		CreateTime:       md.CreateTime,
+		Dedup:            md.Dedup,
+		DedupChunks:      uint64(len(md.DedupChunks)),
 		Expiration:       md.CachedExpiration,
 		Filesize:         uint64(md.FileSize),
 		Health:           md.CachedHealth,
@@ -244,9 +253,23 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		Stuck:            md.NumStuckChunks > 0,
 		StuckBytes:       md.CachedStuckBytes,
 		StuckHealth:      md.CachedStuckHealth,
+		Tags:             append([]string(nil), md.Tags...),
 		UID:              n.staticUID,
 		UploadedBytes:    md.CachedUploadedBytes,
 		UploadProgress:   md.CachedUploadProgress,
+		UserMetadata:     modules.CopyUserMetadata(md.UserMetadata),
 	}
+	setFileInfoCompression(&fileInfo, md.Compression)
 	return fileInfo, nil
 }
+
+// setFileInfoCompression sets the compression fields of a FileInfo. The
+// Filesize of compressed files is the size of the uncompressed data.
+func setFileInfoCompression(fi *modules.FileInfo, compression modules.FileCompression) {
+	if !compression.IsSet() {
+		return
+	}
+	fi.Compression = compression.Codec
+	fi.CompressedSize = fi.Filesize
+	fi.Filesize = compression.Size
+}
@@ -149,6 +149,8 @@ func (sd *SiaDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
+	metadata.Tags = sd.metadata.Tags
+	metadata.UserMetadata = sd.metadata.UserMetadata
 	metadata.Version = sd.metadata.Version
 	return sd.updateMetadata(metadata)
 }
@@ -164,6 +166,32 @@ func (sd *SiaDir) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHe
 	return sd.updateMetadata(md)
 }
 
+// SetTags replaces the tags of the SiaDir and saves the change to disk. The
+// tags are expected to be normalized already.
+func (sd *SiaDir) SetTags(tags []string) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Tags = nil
+	if len(tags) > 0 {
+		md.Tags = append([]string(nil), tags...)
+	}
+	return sd.updateMetadata(md)
+}
+
+// SetUserMetadata replaces the user-defined metadata of the SiaDir and saves
+// the change to disk.
+func (sd *SiaDir) SetUserMetadata(userMetadata map[string]string) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.UserMetadata = nil
+	if len(userMetadata) > 0 {
+		md.UserMetadata = modules.CopyUserMetadata(userMetadata)
+	}
+	return sd.updateMetadata(md)
+}
+
 // UpdateMetadata updates the SiaDir metadata on disk
 func (sd *SiaDir) UpdateMetadata(metadata Metadata) error {
 	sd.mu.Lock()
@@ -225,6 +253,9 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.StuckHealth = metadata.StuckHealth
 	sd.metadata.StuckSize = metadata.StuckSize
 
+	sd.metadata.Tags = metadata.Tags
+	sd.metadata.UserMetadata = metadata.UserMetadata
+
 	sd.metadata.Version = metadata.Version
 
 	// Testing check to ensure new fields aren't missed
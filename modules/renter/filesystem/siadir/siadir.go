@@ -87,6 +87,11 @@ type (
 		StuckHealth         float64     `json:"stuckhealth"`
 		StuckSize           uint64      `json:"stucksize"`
 
+		// Tags and UserMetadata are the user-defined tags and key/value
+		// metadata of the siadir. They are not bubbled.
+		Tags         []string          `json:"tags"`
+		UserMetadata map[string]string `json:"usermetadata"`
+
 		// Version is the used version of the header file.
 		Version string `json:"version"`
 	}
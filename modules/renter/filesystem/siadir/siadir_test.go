@@ -3,6 +3,7 @@ package siadir
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -20,6 +21,7 @@ func TestSiaDir(t *testing.T) {
 	t.Run("Basic", testSiaDirBasic)
 	t.Run("Delete", testSiaDirDelete)
 	t.Run("UpdatedMetadata", testUpdateMetadata)
+	t.Run("TagsAndUserMetadata", testTagsAndUserMetadata)
 }
 
 // testSiaDirBasic tests the basic functionality of the siadir
@@ -237,3 +239,50 @@ func testUpdateMetadata(t *testing.T) {
 
 	// TODO Add checks for other update metadata methods
 }
+
+// testTagsAndUserMetadata tests that the tags and user-defined metadata of a
+// SiaDir are persisted and not overwritten when metadata is bubbled.
+func testTagsAndUserMetadata(t *testing.T) {
+	rootDir, err := newRootDir(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaDirSysPath := filepath.Join(rootDir, "TestDir")
+	siaDir, err := New(siaDirSysPath, rootDir, modules.DefaultDirPerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := []string{"bar", "foo"}
+	userMetadata := map[string]string{"owner": "alice"}
+	if err := siaDir.SetTags(tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := siaDir.SetUserMetadata(userMetadata); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bubbling doesn't change the tags and metadata.
+	if err := siaDir.UpdateBubbledMetadata(randomMetadata()); err != nil {
+		t.Fatal(err)
+	}
+	siaDir, err = LoadSiaDir(siaDirSysPath, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := siaDir.Metadata()
+	if !reflect.DeepEqual(md.Tags, tags) || !reflect.DeepEqual(md.UserMetadata, userMetadata) {
+		t.Fatal("wrong tags or metadata", md.Tags, md.UserMetadata)
+	}
+
+	// Empty tags and metadata are removed.
+	if err := siaDir.SetTags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := siaDir.SetUserMetadata(map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	md = siaDir.Metadata()
+	if md.Tags != nil || md.UserMetadata != nil {
+		t.Fatal("tags or metadata weren't removed", md.Tags, md.UserMetadata)
+	}
+}
@@ -49,6 +49,26 @@ type (
 		StaticPieceSize     uint64   `json:"piecesize"`     // size of a single piece of the file
 		LocalPath           string   `json:"localpath"`     // file to the local copy of the file used for repairing
 
+		// Checksum is the checksum of the plaintext of the uploaded file.
+		Checksum modules.FileChecksum `json:"checksum"`
+
+		// Compression describes how the file was compressed before it was
+		// encrypted. If it is set, FileSize is the size of the compressed
+		// data.
+		Compression modules.FileCompression `json:"compression"`
+
+		// Dedup indicates that the chunks of the file are encrypted with keys
+		// derived from their content, which allows identical chunks of
+		// different files to share their sectors. DedupChunks contains the
+		// content IDs of the chunks that were encrypted that way.
+		Dedup       bool                   `json:"dedup"`
+		DedupChunks map[uint64]crypto.Hash `json:"dedupchunks"`
+
+		// Tags and UserMetadata are the user-defined tags and key/value
+		// metadata of the file. Tags are sorted.
+		Tags         []string          `json:"tags"`
+		UserMetadata map[string]string `json:"usermetadata"`
+
 		// Fields for encryption
 		StaticMasterKey      []byte            `json:"masterkey"` // masterkey used to encrypt pieces
 		StaticMasterKeyType  crypto.CipherType `json:"masterkeytype"`
@@ -203,6 +223,57 @@ func (sf *SiaFile) PartialChunks() []PartialChunkInfo {
 	return sf.staticMetadata.PartialChunks
 }
 
+// Checksum returns the checksum of the plaintext of the file.
+func (sf *SiaFile) Checksum() modules.FileChecksum {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Checksum
+}
+
+// Compression returns how the file was compressed before it was uploaded.
+func (sf *SiaFile) Compression() modules.FileCompression {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Compression.Copy()
+}
+
+// Dedup returns whether the chunks of the file are deduplicated.
+func (sf *SiaFile) Dedup() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Dedup
+}
+
+// DedupChunk returns the content ID of a deduplicated chunk. The boolean is
+// false if the chunk wasn't deduplicated.
+func (sf *SiaFile) DedupChunk(chunkIndex uint64) (crypto.Hash, bool) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	id, ok := sf.staticMetadata.DedupChunks[chunkIndex]
+	return id, ok
+}
+
+// DedupChunks returns the content IDs of the deduplicated chunks of the file.
+func (sf *SiaFile) DedupChunks() map[uint64]crypto.Hash {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return copyDedupChunks(sf.staticMetadata.DedupChunks)
+}
+
+// Tags returns the tags of the file.
+func (sf *SiaFile) Tags() []string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return copyTags(sf.staticMetadata.Tags)
+}
+
+// UserMetadata returns the user-defined metadata of the file.
+func (sf *SiaFile) UserMetadata() map[string]string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return modules.CopyUserMetadata(sf.staticMetadata.UserMetadata)
+}
+
 // CreateTime returns the CreateTime timestamp of the file.
 func (sf *SiaFile) CreateTime() time.Time {
 	sf.mu.RLock()
@@ -309,6 +380,12 @@ func (md Metadata) backup() (b Metadata) {
 	b.UniqueID = md.UniqueID
 	b.FileSize = md.FileSize
 	b.LocalPath = md.LocalPath
+	b.Checksum = md.Checksum
+	b.Compression = md.Compression.Copy()
+	b.Dedup = md.Dedup
+	b.DedupChunks = copyDedupChunks(md.DedupChunks)
+	b.Tags = copyTags(md.Tags)
+	b.UserMetadata = modules.CopyUserMetadata(md.UserMetadata)
 	b.DisablePartialChunk = md.DisablePartialChunk
 	b.HasPartialChunk = md.HasPartialChunk
 	b.ModTime = md.ModTime
@@ -360,6 +437,12 @@ func (md *Metadata) restore(b Metadata) {
 	md.UniqueID = b.UniqueID
 	md.FileSize = b.FileSize
 	md.LocalPath = b.LocalPath
+	md.Checksum = b.Checksum
+	md.Compression = b.Compression
+	md.Dedup = b.Dedup
+	md.DedupChunks = b.DedupChunks
+	md.Tags = b.Tags
+	md.UserMetadata = b.UserMetadata
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
@@ -496,6 +579,155 @@ func (sf *SiaFile) SetMode(mode os.FileMode) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetChecksum sets the checksum of the plaintext of the file.
+func (sf *SiaFile) SetChecksum(checksum modules.FileChecksum) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.Checksum = checksum
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetCompression sets how the file was compressed before it was uploaded.
+func (sf *SiaFile) SetCompression(compression modules.FileCompression) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.Compression = compression.Copy()
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetDedup sets whether the chunks of the file are deduplicated.
+func (sf *SiaFile) SetDedup(dedup bool) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.Dedup = dedup
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetTags replaces the tags of the file. The tags are expected to be
+// normalized already.
+func (sf *SiaFile) SetTags(tags []string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	if len(tags) == 0 {
+		tags = nil
+	}
+	sf.staticMetadata.Tags = copyTags(tags)
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetUserMetadata replaces the user-defined metadata of the file.
+func (sf *SiaFile) SetUserMetadata(md map[string]string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	if len(md) == 0 {
+		md = nil
+	}
+	sf.staticMetadata.UserMetadata = modules.CopyUserMetadata(md)
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetDedupChunk sets the content ID of a chunk. From then on the pieces of the
+// chunk are encrypted with a key derived from the ID instead of the master
+// key.
+func (sf *SiaFile) SetDedupChunk(chunkIndex uint64, id crypto.Hash) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if chunkIndex >= uint64(sf.numChunks) {
+		return fmt.Errorf("chunk index %v out of bounds (%v)", chunkIndex, sf.numChunks)
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	dedupChunks := copyDedupChunks(sf.staticMetadata.DedupChunks)
+	if dedupChunks == nil {
+		dedupChunks = make(map[uint64]crypto.Hash)
+	}
+	dedupChunks[chunkIndex] = id
+	sf.staticMetadata.DedupChunks = dedupChunks
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // SetLastHealthCheckTime sets the LastHealthCheckTime in memory to the current
 // time but does not update and write to disk.
 //
@@ -596,6 +828,53 @@ func (sf *SiaFile) staticMasterKey() crypto.CipherKey {
 	return sk
 }
 
+// copyDedupChunks returns a copy of the content IDs of a file's chunks.
+func copyDedupChunks(dedupChunks map[uint64]crypto.Hash) map[uint64]crypto.Hash {
+	if dedupChunks == nil {
+		return nil
+	}
+	c := make(map[uint64]crypto.Hash, len(dedupChunks))
+	for chunkIndex, id := range dedupChunks {
+		c[chunkIndex] = id
+	}
+	return c
+}
+
+// copyTags returns a copy of the tags of a file. The copy of nil tags is nil.
+func copyTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(tags)), tags...)
+}
+
+// dedupChunkKey returns the key of a deduplicated chunk with the given content
+// ID. Chunks with the same content ID are encrypted with the same key,
+// independent of the file they belong to and their index within that file.
+func dedupChunkKey(id crypto.Hash) crypto.CipherKey {
+	entropy := make([]byte, 0, 2*crypto.HashSize)
+	for i := uint64(0); i < 2; i++ {
+		h := crypto.HashAll(id, i)
+		entropy = append(entropy, h[:]...)
+	}
+	sk, err := crypto.NewSiaKey(crypto.TypeThreefish, entropy)
+	if err != nil {
+		// This should never happen since the entropy has the size of a
+		// threefish key.
+		panic(errors.AddContext(err, "failed to create key of deduplicated chunk"))
+	}
+	return sk
+}
+
+// PieceKey returns the key used to encrypt a piece of the file.
+func (sf *SiaFile) PieceKey(chunkIndex, pieceIndex uint64) crypto.CipherKey {
+	id, ok := sf.DedupChunk(chunkIndex)
+	if ok {
+		return dedupChunkKey(id).Derive(0, pieceIndex)
+	}
+	return sf.staticMasterKey().Derive(chunkIndex, pieceIndex)
+}
+
 // uniqueID creates a random unique SiafileUID.
 func uniqueID() SiafileUID {
 	return SiafileUID(persist.UID())
@@ -1,6 +1,7 @@
 package siafile
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/writeaheadlog"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -110,6 +112,17 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		sf.staticMetadata.UniqueID = SiafileUID(fmt.Sprint(fastrand.Intn(100)))
 		sf.staticMetadata.FileSize = int64(fastrand.Intn(100))
 		sf.staticMetadata.LocalPath = string(fastrand.Bytes(100))
+		sf.staticMetadata.Checksum.Algorithm = modules.ChecksumBLAKE2b
+		fastrand.Read(sf.staticMetadata.Checksum.Hash[:])
+		sf.staticMetadata.Compression = modules.FileCompression{
+			Codec:        modules.CompressionGzip,
+			Size:         fastrand.Uint64n(100),
+			ChunkOffsets: []uint64{0, fastrand.Uint64n(100)},
+		}
+		sf.staticMetadata.Dedup = !sf.staticMetadata.Dedup
+		sf.staticMetadata.DedupChunks = map[uint64]crypto.Hash{fastrand.Uint64n(100): {}}
+		sf.staticMetadata.Tags = []string{fmt.Sprint(fastrand.Intn(100))}
+		sf.staticMetadata.UserMetadata = map[string]string{"key": fmt.Sprint(fastrand.Intn(100))}
 		sf.staticMetadata.DisablePartialChunk = !sf.staticMetadata.DisablePartialChunk
 		sf.staticMetadata.HasPartialChunk = !sf.staticMetadata.HasPartialChunk
 		sf.staticMetadata.PartialChunks = nil
@@ -150,3 +163,185 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		t.Fatalf("metadata wasn't restored successfully %v %v", mdBefore, sf.staticMetadata)
 	}
 }
+
+// TestSetChecksum tests that the checksum of a SiaFile is persisted.
+func TestSetChecksum(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	if sf.Checksum().IsSet() {
+		t.Fatal("checksum of new file shouldn't be set")
+	}
+	checksum, err := modules.ComputeChecksum(modules.ChecksumSHA256, bytes.NewReader(fastrand.Bytes(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetChecksum(checksum); err != nil {
+		t.Fatal(err)
+	}
+	if sf.Checksum() != checksum {
+		t.Fatal("wrong checksum", sf.Checksum(), checksum)
+	}
+
+	// Reload the file and check the checksum again.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf2.Checksum() != checksum {
+		t.Fatal("wrong checksum after reload", sf2.Checksum(), checksum)
+	}
+}
+
+// TestSetCompression tests that the compression of a SiaFile is persisted and
+// included in snapshots.
+func TestSetCompression(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	if sf.Compression().IsSet() {
+		t.Fatal("new file shouldn't be compressed")
+	}
+	compression := modules.FileCompression{
+		Codec:        modules.CompressionGzip,
+		Size:         1000,
+		ChunkOffsets: []uint64{0, 400, 700},
+	}
+	if err := sf.SetCompression(compression); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sf.Compression(), compression) {
+		t.Fatal("wrong compression", sf.Compression(), compression)
+	}
+
+	// Changing the returned compression doesn't change the file.
+	sf.Compression().ChunkOffsets[1] = 0
+	if !reflect.DeepEqual(sf.Compression(), compression) {
+		t.Fatal("compression was changed", sf.Compression(), compression)
+	}
+
+	// Reload the file and check the compression again.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sf2.Compression(), compression) {
+		t.Fatal("wrong compression after reload", sf2.Compression(), compression)
+	}
+	snap, err := sf2.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap.Compression(), compression) {
+		t.Fatal("wrong compression in snapshot", snap.Compression(), compression)
+	}
+}
+
+// TestSetDedupChunk tests that the content IDs of a deduplicated SiaFile's
+// chunks are persisted and used for deriving the keys of their pieces.
+func TestSetDedupChunk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	if sf.Dedup() || len(sf.DedupChunks()) != 0 {
+		t.Fatal("new file shouldn't be deduplicated")
+	}
+	if err := sf.SetDedup(true); err != nil {
+		t.Fatal(err)
+	}
+	keyBefore := sf.PieceKey(0, 1)
+	var id crypto.Hash
+	fastrand.Read(id[:])
+	if err := sf.SetDedupChunk(0, id); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetDedupChunk(sf.NumChunks(), id); err == nil {
+		t.Fatal("setting the ID of an out-of-bounds chunk should fail")
+	}
+	if chunkID, ok := sf.DedupChunk(0); !ok || chunkID != id {
+		t.Fatal("wrong content ID", chunkID, id)
+	}
+
+	// The key of the chunk's pieces only depends on the content ID.
+	key := sf.PieceKey(0, 1)
+	if bytes.Equal(key.Key(), keyBefore.Key()) {
+		t.Fatal("piece key didn't change")
+	}
+	if !bytes.Equal(key.Key(), dedupChunkKey(id).Derive(0, 1).Key()) {
+		t.Fatal("piece key wasn't derived from the content ID")
+	}
+
+	// Reload the file and check the content ID again.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf2.Dedup() || !reflect.DeepEqual(sf2.DedupChunks(), map[uint64]crypto.Hash{0: id}) {
+		t.Fatal("wrong content IDs after reload", sf2.DedupChunks())
+	}
+	snap, err := sf2.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snap.PieceKey(0, 1).Key(), key.Key()) {
+		t.Fatal("wrong piece key in snapshot")
+	}
+}
+
+// TestSetTagsAndUserMetadata tests that the tags and user-defined metadata of a
+// SiaFile are persisted and included in snapshots.
+func TestSetTagsAndUserMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf := newTestFile()
+	if sf.Tags() != nil || sf.UserMetadata() != nil {
+		t.Fatal("new file shouldn't have tags or metadata")
+	}
+	tags := []string{"bar", "foo"}
+	userMetadata := map[string]string{"owner": "alice", "empty": ""}
+	if err := sf.SetTags(tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetUserMetadata(userMetadata); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the returned values doesn't change the file.
+	sf.Tags()[0] = "baz"
+	sf.UserMetadata()["owner"] = "bob"
+	if !reflect.DeepEqual(sf.Tags(), tags) || !reflect.DeepEqual(sf.UserMetadata(), userMetadata) {
+		t.Fatal("tags or metadata were changed", sf.Tags(), sf.UserMetadata())
+	}
+
+	// Reload the file and check the tags and metadata again.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sf2.Tags(), tags) || !reflect.DeepEqual(sf2.UserMetadata(), userMetadata) {
+		t.Fatal("wrong tags or metadata after reload", sf2.Tags(), sf2.UserMetadata())
+	}
+
+	// Empty tags and metadata are removed.
+	if err := sf2.SetTags([]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf2.SetUserMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+	if sf2.Tags() != nil || sf2.UserMetadata() != nil {
+		t.Fatal("tags or metadata weren't removed", sf2.Tags(), sf2.UserMetadata())
+	}
+}
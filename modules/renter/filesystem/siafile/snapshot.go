@@ -19,6 +19,8 @@ type (
 	// representation of a siafile which only exists in memory.
 	Snapshot struct {
 		staticChunks          []Chunk
+		staticCompression     modules.FileCompression
+		staticDedupChunks     map[uint64]crypto.Hash
 		staticFileSize        int64
 		staticPieceSize       uint64
 		staticErasureCode     modules.ErasureCoder
@@ -113,6 +115,11 @@ func (s *Snapshot) ChunkSize() uint64 {
 	return s.staticPieceSize * uint64(s.staticErasureCode.MinPieces())
 }
 
+// Compression returns how the file was compressed before it was uploaded.
+func (s *Snapshot) Compression() modules.FileCompression {
+	return s.staticCompression
+}
+
 // PartialChunks returns the snapshot's PartialChunks.
 func (s *Snapshot) PartialChunks() []PartialChunkInfo {
 	return s.staticPartialChunks
@@ -154,6 +161,14 @@ func (s *Snapshot) MasterKey() crypto.CipherKey {
 	return s.staticMasterKey
 }
 
+// PieceKey returns the key used to encrypt a piece of the file.
+func (s *Snapshot) PieceKey(chunkIndex, pieceIndex uint64) crypto.CipherKey {
+	if id, ok := s.staticDedupChunks[chunkIndex]; ok {
+		return dedupChunkKey(id).Derive(0, pieceIndex)
+	}
+	return s.staticMasterKey.Derive(chunkIndex, pieceIndex)
+}
+
 // Mode returns the FileMode of the file.
 func (s *Snapshot) Mode() os.FileMode {
 	return s.staticMode
@@ -280,9 +295,13 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 	hasPartial := sf.staticMetadata.HasPartialChunk
 	pcs := sf.staticMetadata.PartialChunks
 	localPath := sf.staticMetadata.LocalPath
+	compression := sf.staticMetadata.Compression.Copy()
+	dedupChunks := copyDedupChunks(sf.staticMetadata.DedupChunks)
 
 	return &Snapshot{
 		staticChunks:          exportedChunks,
+		staticCompression:     compression,
+		staticDedupChunks:     dedupChunks,
 		staticPartialChunks:   pcs,
 		staticHasPartialChunk: hasPartial,
 		staticFileSize:        fileSize,
@@ -35,8 +35,12 @@ func NewFilter(resolver modules.Resolver) *Filter {
 // or more IP addresses, extract the subnets used by those addresses and
 // add the subnets to the filter. Add doesn't return an error, but if the
 // addresses of a host can't be resolved it will be handled as if the host
-// had no addresses associated with it.
+// had no addresses associated with it. Onion services don't resolve to an IP
+// address and are never added.
 func (af *Filter) Add(host modules.NetAddress) {
+	if host.IsOnion() {
+		return
+	}
 	// Translate the hostname to one or multiple IPs. If the argument is an IP
 	// address LookupIP will just return that IP.
 	addresses, err := af.resolver.LookupIP(host.Host())
@@ -66,7 +70,11 @@ func (af *Filter) Add(host modules.NetAddress) {
 // that was previously added to the filter. If it is in use, or if the host is
 // associated with 2 addresses of the same type (e.g. IPv4 and IPv4) or if it
 // is associated with more than 2 addresses, Filtered will return 'true'.
+// Onion services are never filtered since their subnets are unknown.
 func (af *Filter) Filtered(host modules.NetAddress) bool {
+	if host.IsOnion() {
+		return false
+	}
 	// Translate the hostname to one or multiple IPs. If the argument is an IP
 	// address LookupIP will just return that IP.
 	addresses, err := af.resolver.LookupIP(host.Host())
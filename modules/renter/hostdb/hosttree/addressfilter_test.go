@@ -103,6 +103,19 @@ func TestTooManyAddresses(t *testing.T) {
 	}
 }
 
+// TestOnionAddresses checks that onion services are never filtered, even if
+// the resolver would return too many addresses for them.
+func TestOnionAddresses(t *testing.T) {
+	filter := NewFilter(testTooManyAddressesResolver{})
+	host1 := modules.NetAddress("3g2upl4pq6kufc4m.onion:1234")
+	host2 := modules.NetAddress("2gzyxa5ihm7nsggfxnu52rck2vv4rvmdlkiu3zzui5du4xyclen53wid.onion:1234")
+
+	filter.Add(host1)
+	if filter.Filtered(host1) || filter.Filtered(host2) {
+		t.Fatal("onion services shouldn't be filtered")
+	}
+}
+
 // TestTwoAddresses checks that hosts with two addresses will be filtered if
 // they have the same address type.
 func TestTwoAddresses(t *testing.T) {
@@ -334,8 +334,12 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 // staticLookupIPNets returns string representations of the CIDR subnets used by
 // the host. In case of an error we return nil. We don't really care about the
 // error because we don't update host entries if we are offline anyway. So if we
-// fail to resolve a hostname, the problem is not related to us.
+// fail to resolve a hostname, the problem is not related to us. Onion services
+// have no subnets.
 func (hdb *HostDB) staticLookupIPNets(address modules.NetAddress) (ipNets []string, err error) {
+	if address.IsOnion() {
+		return nil, nil
+	}
 	// Lookup the IP addresses of the host.
 	addresses, err := hdb.staticDeps.Resolver().LookupIP(address.Host())
 	if err != nil {
@@ -420,7 +424,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			Timeout: timeout,
 		}
 		start := time.Now()
-		conn, err := dialer.Dial("tcp", string(netAddr))
+		conn, err := modules.GlobalProxy.Dial(dialer, netAddr)
 		latency = time.Since(start)
 		if err != nil {
 			return err
@@ -679,6 +683,12 @@ func (hdb *HostDB) threadedScan() {
 // TCP connections. Otherwise we would end up with one TCP connection for every
 // host in the network after scanning the whole network.
 func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Duration, hpk mux.ED25519PublicKey) (_ *modules.RPCPriceTable, err error) {
+	// The SiaMux dials the host itself, so route it through the proxy tunnel
+	// if a proxy is set.
+	hostAddr, err = modules.GlobalProxy.TunnelAddress(hostAddr)
+	if err != nil {
+		return nil, err
+	}
 	stream, err := siamux.NewEphemeralStream(modules.HostSiaMuxSubscriberName, hostAddr, timeout, hpk)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to create ephemeral stream")
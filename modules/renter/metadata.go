@@ -97,6 +97,11 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 				r.log.Println("unable to join siapath with dirpath while calculating directory metadata:", err)
 				continue
 			}
+			// The trash is not part of the aggregate metadata of the root
+			// directory.
+			if dirSiaPath.Equals(modules.TrashFolder) {
+				continue
+			}
 			dirSiaPaths = append(dirSiaPaths, dirSiaPath)
 		}
 	}
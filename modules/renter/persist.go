@@ -60,6 +60,20 @@ type (
 		MaxUploadSpeed   int64
 		UploadedBackups  []modules.UploadedBackup
 		SyncedContracts  []types.FileContractID
+
+		// VersioningPolicies are the policies of the directories that have
+		// versioning enabled, by siapath.
+		VersioningPolicies map[string]modules.VersioningPolicy
+
+		// Trash contains the entries of the trash by their ID.
+		// TrashRetentionDays is the number of days after which they are
+		// purged.
+		Trash              map[string]modules.TrashEntry
+		TrashRetentionDays uint64
+
+		// BandwidthSchedule are the windows of the renter's bandwidth
+		// schedule.
+		BandwidthSchedule []modules.BandwidthWindow
 	}
 )
 
@@ -76,6 +90,7 @@ func (r *Renter) managedLoadSettings() error {
 		// No persistence yet, set the defaults and continue.
 		r.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
 		r.persist.MaxUploadSpeed = DefaultMaxUploadSpeed
+		r.persist.TrashRetentionDays = DefaultTrashRetentionDays
 		id := r.mu.Lock()
 		err = r.saveSync()
 		r.mu.Unlock(id)
@@ -108,6 +123,12 @@ func (r *Renter) managedLoadSettings() error {
 		return err
 	}
 
+	// Persistence created before the trash existed doesn't have a retention
+	// yet.
+	if r.persist.TrashRetentionDays == 0 {
+		r.persist.TrashRetentionDays = DefaultTrashRetentionDays
+	}
+
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
 	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
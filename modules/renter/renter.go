@@ -209,6 +209,16 @@ type Renter struct {
 	// staticBubbleScheduler manages the bubble requests for the renter
 	staticBubbleScheduler *bubbleScheduler
 
+	// staticSyncSet tracks the running syncs of local directories.
+	staticSyncSet *syncSet
+
+	// staticDedupIndex is the index of the sectors of deduplicated chunks.
+	staticDedupIndex *dedupIndex
+
+	// staticBandwidthScheduler tracks the active window of the bandwidth
+	// schedule and paces the repair loop.
+	staticBandwidthScheduler *bandwidthScheduler
+
 	// cachedUtilities contain contract information used when calculating metadata
 	// information about the filesystem, such as health. This information is used
 	// in various functions such as listing filesystem information and bubble.
@@ -630,6 +640,12 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 		return err
 	}
 
+	// The rate limits of an active window of the bandwidth schedule take
+	// precedence over the settings.
+	if err := r.managedApplyBandwidthSchedule(time.Now()); err != nil {
+		return err
+	}
+
 	// Update the worker pool so that the changes are immediately apparent to
 	// users.
 	r.staticWorkerPool.callUpdate()
@@ -926,6 +942,8 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		tpool:          tpool,
 	}
 	r.staticBubbleScheduler = newBubbleScheduler(r)
+	r.staticSyncSet = newSyncSet()
+	r.staticBandwidthScheduler = &bandwidthScheduler{}
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
 	r.staticRRS = newReadRegistryStats(ReadRegistryBackgroundTimeout, readRegistryStatsInterval, readRegistryStatsDecay, readRegistryStatsPercentile)
@@ -979,6 +997,14 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	r.staticDedupIndex, err = newDedupIndex(filepath.Join(r.persistDir, dedupIndexFilename))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tg.OnStop(r.staticDedupIndex.managedSave); err != nil {
+		return nil, err
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
@@ -1005,6 +1031,18 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	// for bubble updates are processed.
 	go r.staticBubbleScheduler.callThreadedProcessBubbleUpdates()
 
+	// Spin up the thread which enforces the retention of file versions.
+	go r.threadedPruneFileVersions()
+
+	// Spin up the thread which purges expired files from the trash.
+	go r.threadedPurgeTrash()
+
+	// Spin up the thread which applies the bandwidth schedule.
+	go r.threadedApplyBandwidthSchedule()
+
+	// Spin up the thread which saves the index of deduplicated chunks.
+	go r.threadedPersistDedupIndex()
+
 	// Unsubscribe on shutdown.
 	err = r.tg.OnStop(func() error {
 		cs.Unsubscribe(r)
@@ -316,8 +316,8 @@ func (r *Renter) managedStuckDirectory() (modules.SiaPath, error) {
 				return siaPath, nil
 			}
 
-			// Skip directories with no stuck chunks
-			if directories[i].AggregateNumStuckChunks == uint64(0) {
+			// Skip directories with no stuck chunks and the trash
+			if directories[i].AggregateNumStuckChunks == uint64(0) || isTrashSiaPath(directories[i].SiaPath) {
 				continue
 			}
 
@@ -424,7 +424,8 @@ func (r *Renter) managedStuckFile(dirSiaPath modules.SiaPath) (siapath modules.S
 }
 
 // managedSubDirectories reads a directory and returns a slice of all the sub
-// directory SiaPaths
+// directory SiaPaths. The trash is skipped since it is neither repaired nor
+// health checked.
 func (r *Renter) managedSubDirectories(siaPath modules.SiaPath) ([]modules.SiaPath, error) {
 	// Read directory
 	fileinfos, err := r.staticFileSystem.ReadDir(siaPath)
@@ -439,6 +440,9 @@ func (r *Renter) managedSubDirectories(siaPath modules.SiaPath) ([]modules.SiaPa
 			if err != nil {
 				return nil, err
 			}
+			if subDir.Equals(modules.TrashFolder) {
+				continue
+			}
 			folders = append(folders, subDir)
 		}
 	}
@@ -0,0 +1,514 @@
+package renter
+
+// sync.go implements synchronizing a local directory with a siadir. Every
+// sync pass compares the files on both sides with the state recorded after
+// the previous pass. The state makes it possible to tell files that were
+// deleted on one side apart from files that were created on the other side.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// syncStateDir is the directory within the renter's persist directory
+	// which contains the state of the syncs.
+	syncStateDir = "sync"
+
+	// syncTempSuffix is appended to the names of files while they are being
+	// downloaded by a sync. Files with this suffix are ignored by syncs.
+	syncTempSuffix = ".siasync"
+)
+
+var (
+	// errSyncInProgress is returned if a sync of the same directories is
+	// already running.
+	errSyncInProgress = errors.New("a sync of these directories is already in progress")
+
+	// syncStateMetadata is the metadata of the persisted sync state.
+	syncStateMetadata = persist.Metadata{
+		Header:  "Sync State",
+		Version: "1.0",
+	}
+)
+
+type (
+	// syncSet tracks the syncs that are currently running.
+	syncSet struct {
+		active map[string]struct{}
+		mu     sync.Mutex
+	}
+
+	// syncState is the state of the files after the last sync of two
+	// directories.
+	syncState struct {
+		Files map[string]syncFileState `json:"files"`
+	}
+
+	// syncFileState is the state of a file after it was synced. The remote
+	// file is identified by its creation time and checksum since its
+	// modification time changes whenever the file is repaired.
+	syncFileState struct {
+		Size             uint64               `json:"size"`
+		LocalModTime     time.Time            `json:"localmodtime"`
+		RemoteCreateTime time.Time            `json:"remotecreatetime"`
+		RemoteChecksum   modules.FileChecksum `json:"remotechecksum"`
+	}
+
+	// syncLocalFile is a file in the local directory of a sync.
+	syncLocalFile struct {
+		path    string
+		size    uint64
+		modTime time.Time
+	}
+
+	// syncOp is the operation a sync performs on a file.
+	syncOp int
+)
+
+const (
+	syncNone syncOp = iota
+	syncRecord
+	syncUpload
+	syncDownload
+	syncDeleteLocal
+	syncDeleteRemote
+)
+
+// newSyncSet creates a new syncSet.
+func newSyncSet() *syncSet {
+	return &syncSet{
+		active: make(map[string]struct{}),
+	}
+}
+
+// managedStart marks a sync as running. It returns errSyncInProgress if the
+// sync is already running.
+func (ss *syncSet) managedStart(key string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if _, exists := ss.active[key]; exists {
+		return errSyncInProgress
+	}
+	ss.active[key] = struct{}{}
+	return nil
+}
+
+// managedDone marks a sync as done.
+func (ss *syncSet) managedDone(key string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.active, key)
+}
+
+// syncKey returns the key that identifies the syncs of two directories.
+func syncKey(localPath string, siaPath modules.SiaPath) string {
+	h := sha256.Sum256([]byte(localPath + "\n" + siaPath.String()))
+	return hex.EncodeToString(h[:16])
+}
+
+// syncMatch returns whether a file with the given relative path is part of a
+// sync with the given include and exclude patterns.
+func syncMatch(relPath string, include, exclude []string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, relPath); ok {
+				return true
+			}
+			if !strings.Contains(pattern, "/") {
+				if ok, _ := path.Match(pattern, path.Base(relPath)); ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if len(include) > 0 && !matches(include) {
+		return false
+	}
+	return !matches(exclude)
+}
+
+// validateSyncParams checks the params of a sync and fills in the defaults.
+func validateSyncParams(params *modules.SyncParams) error {
+	if !filepath.IsAbs(params.LocalPath) {
+		return errors.New("local path must be absolute")
+	}
+	switch params.Direction {
+	case "":
+		params.Direction = modules.SyncBoth
+	case modules.SyncUpload, modules.SyncDownload, modules.SyncBoth:
+	default:
+		return fmt.Errorf("unknown sync direction '%v'", params.Direction)
+	}
+	for _, pattern := range append(append([]string{}, params.Include...), params.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("invalid pattern '%v'", pattern))
+		}
+	}
+	return nil
+}
+
+// syncDecide returns the operation a sync with the given direction performs
+// on a file. l and r are the local and remote file, s is the state of the
+// file after the last sync. Any of them may be nil. sameContent is only called
+// if both files exist and returns whether they have the same content.
+func syncDecide(direction modules.SyncDirection, del bool, l *syncLocalFile, r *modules.FileInfo, s *syncFileState, sameContent func() bool) syncOp {
+	switch {
+	case l == nil && r == nil:
+		return syncNone
+	case r == nil:
+		// The remote file was either deleted since the last sync or the
+		// local file is new.
+		if del && (direction == modules.SyncDownload || (direction == modules.SyncBoth && s != nil)) {
+			return syncDeleteLocal
+		}
+		if direction != modules.SyncDownload {
+			return syncUpload
+		}
+		return syncNone
+	case l == nil:
+		if del && (direction == modules.SyncUpload || (direction == modules.SyncBoth && s != nil)) {
+			return syncDeleteRemote
+		}
+		if direction != modules.SyncUpload {
+			return syncDownload
+		}
+		return syncNone
+	}
+
+	localChanged := s == nil || l.size != s.Size || !l.modTime.Equal(s.LocalModTime)
+	remoteChanged := s == nil || r.Filesize != s.Size || !r.CreateTime.Equal(s.RemoteCreateTime) || r.Checksum != s.RemoteChecksum
+	if !localChanged && !remoteChanged {
+		return syncNone
+	}
+	if sameContent() {
+		return syncRecord
+	}
+	switch {
+	case direction == modules.SyncUpload:
+		return syncUpload
+	case direction == modules.SyncDownload:
+		return syncDownload
+	case localChanged && !remoteChanged:
+		return syncUpload
+	case remoteChanged && !localChanged:
+		return syncDownload
+	case l.modTime.After(r.ModificationTime):
+		return syncUpload
+	default:
+		return syncDownload
+	}
+}
+
+// Sync synchronizes a local directory with a siadir.
+func (r *Renter) Sync(params modules.SyncParams) (report modules.SyncReport, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SyncReport{}, err
+	}
+	defer r.tg.Done()
+	if err := validateSyncParams(&params); err != nil {
+		return modules.SyncReport{}, err
+	}
+	params.LocalPath = filepath.Clean(params.LocalPath)
+	key := syncKey(params.LocalPath, params.SiaPath)
+	if err := r.staticSyncSet.managedStart(key); err != nil {
+		return modules.SyncReport{}, err
+	}
+	defer r.staticSyncSet.managedDone(key)
+
+	// Load the state of the last sync.
+	statePath := filepath.Join(r.persistDir, syncStateDir, key+".json")
+	var state syncState
+	err = persist.LoadJSON(syncStateMetadata, &state, statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return modules.SyncReport{}, errors.AddContext(err, "unable to load sync state")
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]syncFileState)
+	}
+
+	// List both sides.
+	local, err := r.managedSyncListLocal(params)
+	if err != nil {
+		return modules.SyncReport{}, errors.AddContext(err, "unable to list local directory")
+	}
+	remote, err := r.managedSyncListRemote(params)
+	if err != nil {
+		return modules.SyncReport{}, errors.AddContext(err, "unable to list siadir")
+	}
+
+	// Collect and sort the paths of all files to get a deterministic order.
+	paths := make(map[string]struct{})
+	for p := range local {
+		paths[p] = struct{}{}
+	}
+	for p := range remote {
+		paths[p] = struct{}{}
+	}
+	for p := range state.Files {
+		if syncMatch(p, params.Include, params.Exclude) {
+			paths[p] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	for _, relPath := range sorted {
+		select {
+		case <-r.tg.StopChan():
+			return report, errors.New("sync interrupted by shutdown")
+		default:
+		}
+		l, hasLocal := local[relPath]
+		rf, hasRemote := remote[relPath]
+		s, hasState := state.Files[relPath]
+		var lp *syncLocalFile
+		var rp *modules.FileInfo
+		var sp *syncFileState
+		if hasLocal {
+			lp = &l
+		}
+		if hasRemote {
+			rp = &rf
+		}
+		if hasState {
+			sp = &s
+		}
+
+		var sameErr error
+		op := syncDecide(params.Direction, params.Delete, lp, rp, sp, func() (same bool) {
+			same, sameErr = syncSameContent(l, rf, hasState)
+			return
+		})
+		if sameErr != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%v: %v", relPath, sameErr))
+			continue
+		}
+		if params.DryRun {
+			addToSyncReport(&report, op, relPath)
+			continue
+		}
+		newState, err := r.managedSyncFile(params, op, relPath, l, rf)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%v: %v", relPath, err))
+			continue
+		}
+		addToSyncReport(&report, op, relPath)
+
+		// Update the state of the file. Files that only exist on one side
+		// after the sync are forgotten.
+		switch {
+		case newState != nil:
+			state.Files[relPath] = *newState
+		case op == syncNone && hasLocal && hasRemote:
+		default:
+			delete(state.Files, relPath)
+		}
+	}
+
+	if params.DryRun {
+		return report, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), modules.DefaultDirPerm); err != nil {
+		return report, errors.AddContext(err, "unable to create sync state dir")
+	}
+	if err := persist.SaveJSON(syncStateMetadata, state, statePath); err != nil {
+		return report, errors.AddContext(err, "unable to save sync state")
+	}
+	return report, nil
+}
+
+// addToSyncReport adds a file that a sync performed op on to the report.
+func addToSyncReport(report *modules.SyncReport, op syncOp, relPath string) {
+	switch op {
+	case syncNone, syncRecord:
+		report.Unchanged++
+	case syncUpload:
+		report.Uploaded = append(report.Uploaded, relPath)
+	case syncDownload:
+		report.Downloaded = append(report.Downloaded, relPath)
+	case syncDeleteLocal:
+		report.DeletedLocal = append(report.DeletedLocal, relPath)
+	case syncDeleteRemote:
+		report.DeletedRemote = append(report.DeletedRemote, relPath)
+	}
+}
+
+// syncSameContent returns whether a local and a remote file of the same size
+// have the same content. If the checksum of the remote file is unknown, the
+// files are only assumed to be the same if they were never synced before.
+func syncSameContent(l syncLocalFile, rf modules.FileInfo, synced bool) (bool, error) {
+	if l.size != rf.Filesize {
+		return false, nil
+	}
+	if !rf.Checksum.IsSet() {
+		return !synced, nil
+	}
+	f, err := os.Open(l.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	checksum, err := modules.ComputeChecksum(rf.Checksum.Algorithm, f)
+	if err != nil {
+		return false, err
+	}
+	return checksum == rf.Checksum, nil
+}
+
+// managedSyncListLocal returns the files within the local directory of a sync
+// by their relative paths.
+func (r *Renter) managedSyncListLocal(params modules.SyncParams) (map[string]syncLocalFile, error) {
+	files := make(map[string]syncLocalFile)
+	err := filepath.Walk(params.LocalPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || strings.HasSuffix(info.Name(), syncTempSuffix) {
+			return nil
+		}
+		relPath, err := filepath.Rel(params.LocalPath, p)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if !syncMatch(relPath, params.Include, params.Exclude) {
+			return nil
+		}
+		files[relPath] = syncLocalFile{
+			path:    p,
+			size:    uint64(info.Size()),
+			modTime: info.ModTime(),
+		}
+		return nil
+	})
+	// The local directory is created by the first download.
+	if os.IsNotExist(err) && params.Direction != modules.SyncUpload {
+		return files, nil
+	}
+	return files, err
+}
+
+// managedSyncListRemote returns the files within the siadir of a sync by their
+// relative paths.
+func (r *Renter) managedSyncListRemote(params modules.SyncParams) (map[string]modules.FileInfo, error) {
+	files := make(map[string]modules.FileInfo)
+	var mu sync.Mutex
+	err := r.FileList(params.SiaPath, true, true, func(fi modules.FileInfo) {
+		relPath, err := fi.SiaPath.Rebase(params.SiaPath, modules.RootSiaPath())
+		if err != nil {
+			r.log.Printf("WARN: sync found file %v outside of %v", fi.SiaPath, params.SiaPath)
+			return
+		}
+		if !syncMatch(relPath.String(), params.Include, params.Exclude) {
+			return
+		}
+		mu.Lock()
+		files[relPath.String()] = fi
+		mu.Unlock()
+	})
+	// The siadir is created by the first upload.
+	if errors.Contains(err, filesystem.ErrNotExist) && params.Direction != modules.SyncDownload {
+		return files, nil
+	}
+	return files, err
+}
+
+// managedSyncFile performs op on a file and returns its new sync state.
+func (r *Renter) managedSyncFile(params modules.SyncParams, op syncOp, relPath string, l syncLocalFile, rf modules.FileInfo) (*syncFileState, error) {
+	switch op {
+	case syncRecord:
+		return &syncFileState{
+			Size:             l.size,
+			LocalModTime:     l.modTime,
+			RemoteCreateTime: rf.CreateTime,
+			RemoteChecksum:   rf.Checksum,
+		}, nil
+
+	case syncUpload:
+		siaPath, err := params.SiaPath.Join(relPath)
+		if err != nil {
+			return nil, err
+		}
+		err = r.Upload(modules.FileUploadParams{
+			Source:              l.path,
+			SiaPath:             siaPath,
+			ErasureCode:         params.ErasureCode,
+			Force:               true,
+			DisablePartialChunk: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		fi, err := r.File(siaPath)
+		if err != nil {
+			return nil, err
+		}
+		return &syncFileState{
+			Size:             l.size,
+			LocalModTime:     l.modTime,
+			RemoteCreateTime: fi.CreateTime,
+			RemoteChecksum:   fi.Checksum,
+		}, nil
+
+	case syncDownload:
+		// Download to a temporary file first to not leave a partially
+		// downloaded file behind.
+		dest := filepath.Join(params.LocalPath, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(dest), modules.DefaultDirPerm); err != nil {
+			return nil, err
+		}
+		tmp := dest + syncTempSuffix
+		if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		_, start, err := r.Download(modules.RenterDownloadParameters{
+			SiaPath:     rf.SiaPath,
+			Destination: tmp,
+		})
+		if err == nil {
+			err = start()
+		}
+		if err == nil {
+			err = os.Rename(tmp, dest)
+		}
+		if err != nil {
+			return nil, errors.Compose(err, os.RemoveAll(tmp))
+		}
+		info, err := os.Stat(dest)
+		if err != nil {
+			return nil, err
+		}
+		return &syncFileState{
+			Size:             uint64(info.Size()),
+			LocalModTime:     info.ModTime(),
+			RemoteCreateTime: rf.CreateTime,
+			RemoteChecksum:   rf.Checksum,
+		}, nil
+
+	case syncDeleteLocal:
+		return nil, os.Remove(l.path)
+
+	case syncDeleteRemote:
+		return nil, r.DeleteFile(rf.SiaPath)
+	}
+	return nil, nil
+}
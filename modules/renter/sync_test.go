@@ -0,0 +1,136 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestSyncMatch tests the include and exclude patterns of syncs.
+func TestSyncMatch(t *testing.T) {
+	tests := []struct {
+		path             string
+		include, exclude []string
+		match            bool
+	}{
+		{"a.txt", nil, nil, true},
+		{"dir/a.txt", []string{"*.txt"}, nil, true},
+		{"dir/a.txt", []string{"dir/*"}, nil, true},
+		{"dir/a.txt", []string{"*.jpg"}, nil, false},
+		{"dir/a.txt", []string{"other/*"}, nil, false},
+		{"dir/a.txt", nil, []string{"*.txt"}, false},
+		{"dir/a.txt", []string{"*.txt"}, []string{"dir/*"}, false},
+		{"dir/a.txt", nil, []string{"a.txt"}, false},
+		{"dir/sub/a.txt", nil, []string{"dir/*"}, true},
+	}
+	for _, test := range tests {
+		if match := syncMatch(test.path, test.include, test.exclude); match != test.match {
+			t.Errorf("syncMatch(%v, %v, %v) = %v, expected %v", test.path, test.include, test.exclude, match, test.match)
+		}
+	}
+}
+
+// TestValidateSyncParams tests validateSyncParams.
+func TestValidateSyncParams(t *testing.T) {
+	params := modules.SyncParams{LocalPath: "/tmp/sync"}
+	if err := validateSyncParams(&params); err != nil {
+		t.Fatal(err)
+	}
+	if params.Direction != modules.SyncBoth {
+		t.Fatal("direction should default to both", params.Direction)
+	}
+
+	invalid := []modules.SyncParams{
+		{LocalPath: "relative/path"},
+		{LocalPath: "/tmp/sync", Direction: "sideways"},
+		{LocalPath: "/tmp/sync", Include: []string{"[a-"}},
+		{LocalPath: "/tmp/sync", Exclude: []string{"[a-"}},
+	}
+	for _, params := range invalid {
+		if err := validateSyncParams(&params); err == nil {
+			t.Error("expected params to be invalid", params)
+		}
+	}
+}
+
+// TestSyncDecide tests the operations chosen by syncs.
+func TestSyncDecide(t *testing.T) {
+	now := time.Now()
+	older := now.Add(-time.Hour)
+	checksum := modules.FileChecksum{Algorithm: modules.ChecksumSHA256}
+
+	// synced is the state of a file that didn't change since the last sync.
+	synced := syncFileState{
+		Size:             10,
+		LocalModTime:     older,
+		RemoteCreateTime: older,
+		RemoteChecksum:   checksum,
+	}
+	local := syncLocalFile{size: 10, modTime: older}
+	remote := modules.FileInfo{Filesize: 10, CreateTime: older, ModificationTime: older, Checksum: checksum}
+
+	// Variants of the files that changed since the last sync.
+	localChanged := local
+	localChanged.modTime = now
+	remoteChanged := remote
+	remoteChanged.CreateTime = now
+	remoteChanged.ModificationTime = now
+	remoteChanged.Checksum.Hash[0] = 1
+	remoteChangedOlder := remoteChanged
+	remoteChangedOlder.ModificationTime = older.Add(-time.Hour)
+
+	same := func() bool { return true }
+	different := func() bool { return false }
+
+	tests := []struct {
+		name      string
+		direction modules.SyncDirection
+		del       bool
+		l         *syncLocalFile
+		r         *modules.FileInfo
+		s         *syncFileState
+		same      func() bool
+		op        syncOp
+	}{
+		// New files are copied in the direction of the sync.
+		{"new local", modules.SyncBoth, true, &local, nil, nil, same, syncUpload},
+		{"new local upload", modules.SyncUpload, true, &local, nil, nil, same, syncUpload},
+		{"new local download", modules.SyncDownload, false, &local, nil, nil, same, syncNone},
+		{"new remote", modules.SyncBoth, true, nil, &remote, nil, same, syncDownload},
+		{"new remote download", modules.SyncDownload, false, nil, &remote, nil, same, syncDownload},
+		{"new remote upload", modules.SyncUpload, false, nil, &remote, nil, same, syncNone},
+
+		// Deleted files are only deleted on the other side if del is set.
+		{"deleted remote", modules.SyncBoth, true, &local, nil, &synced, same, syncDeleteLocal},
+		{"deleted remote no delete", modules.SyncBoth, false, &local, nil, &synced, same, syncUpload},
+		{"deleted local", modules.SyncBoth, true, nil, &remote, &synced, same, syncDeleteRemote},
+		{"deleted local no delete", modules.SyncBoth, false, nil, &remote, &synced, same, syncDownload},
+		{"mirror upload", modules.SyncUpload, true, nil, &remote, nil, same, syncDeleteRemote},
+		{"mirror download", modules.SyncDownload, true, &local, nil, nil, same, syncDeleteLocal},
+		{"deleted both", modules.SyncBoth, true, nil, nil, &synced, same, syncNone},
+
+		// Unchanged files are left alone without comparing their content.
+		{"unchanged", modules.SyncBoth, true, &local, &remote, &synced, nil, syncNone},
+
+		// Files with the same content are only recorded.
+		{"first sync same", modules.SyncBoth, true, &local, &remote, nil, same, syncRecord},
+		{"changed same", modules.SyncBoth, true, &localChanged, &remote, &synced, same, syncRecord},
+
+		// Changed files are copied from the changed side.
+		{"local changed", modules.SyncBoth, true, &localChanged, &remote, &synced, different, syncUpload},
+		{"remote changed", modules.SyncBoth, true, &local, &remoteChanged, &synced, different, syncDownload},
+		{"remote changed upload", modules.SyncUpload, true, &local, &remoteChanged, &synced, different, syncUpload},
+		{"local changed download", modules.SyncDownload, true, &localChanged, &remote, &synced, different, syncDownload},
+
+		// If both sides changed, the newer file wins.
+		{"both changed remote newer", modules.SyncBoth, true, &localChanged, &remoteChanged, &synced, different, syncDownload},
+		{"both changed local newer", modules.SyncBoth, true, &localChanged, &remoteChangedOlder, &synced, different, syncUpload},
+		{"first sync different", modules.SyncBoth, true, &local, &remoteChanged, nil, different, syncDownload},
+	}
+	for _, test := range tests {
+		if op := syncDecide(test.direction, test.del, test.l, test.r, test.s, test.same); op != test.op {
+			t.Errorf("%v: expected op %v but got %v", test.name, test.op, op)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package renter
+
+// trash.go implements the trash of the renter. Deleted files and directories
+// are moved into the TrashFolder instead of being deleted right away. Every
+// deleted file or directory becomes an entry of the trash which is named after
+// the time of the deletion in nanoseconds. The entries are tracked in the
+// renter's persistence, which remembers where they were deleted from, until
+// they are restored or purged.
+//
+// The TrashFolder is excluded from the aggregate metadata of the root
+// directory and from the repair, stuck and health loops. Since the siafiles
+// of the entries still exist, their sectors are kept on the hosts as long as
+// the contracts are renewed.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errTrashEntryNotFound is returned if there is no entry with the
+	// requested ID in the trash.
+	errTrashEntryNotFound = errors.New("trash entry not found")
+
+	// errTrashRetentionZero is returned when trying to set a trash retention
+	// of zero days.
+	errTrashRetentionZero = errors.New("trash retention must be at least one day")
+)
+
+// isTrashSiaPath returns whether a siapath is within the TrashFolder.
+func isTrashSiaPath(siaPath modules.SiaPath) bool {
+	return siaPath.Equals(modules.TrashFolder) || strings.HasPrefix(siaPath.String(), modules.TrashFolder.String()+"/")
+}
+
+// trashEntrySiaPath returns the siapath of the file or directory of a trash
+// entry.
+func trashEntrySiaPath(id string) (modules.SiaPath, error) {
+	return modules.TrashFolder.Join(id)
+}
+
+// expiredTrashEntries returns the entries which were deleted more than
+// retentionDays days before now.
+func expiredTrashEntries(entries map[string]modules.TrashEntry, retentionDays uint64, now time.Time) []modules.TrashEntry {
+	maxAge := time.Duration(retentionDays) * 24 * time.Hour
+	var expired []modules.TrashEntry
+	for _, entry := range entries {
+		if now.Sub(entry.Deleted) > maxAge {
+			expired = append(expired, entry)
+		}
+	}
+	return expired
+}
+
+// managedMoveToTrash moves a file or directory into the trash. It returns
+// false if the file or directory can't be trashed because it is the root
+// directory or already within the TrashFolder.
+func (r *Renter) managedMoveToTrash(siaPath modules.SiaPath, isDir bool) (bool, error) {
+	if siaPath.IsRoot() || isTrashSiaPath(siaPath) {
+		return false, nil
+	}
+
+	// Remember the number and size of the files before moving them.
+	entry := modules.TrashEntry{
+		SiaPath: siaPath,
+		Deleted: time.Now(),
+		IsDir:   isDir,
+	}
+	if isDir {
+		var mu sync.Mutex
+		err := r.staticFileSystem.CachedList(siaPath, true, func(fi modules.FileInfo) {
+			mu.Lock()
+			entry.NumFiles++
+			entry.Size += fi.Filesize
+			mu.Unlock()
+		}, func(modules.DirectoryInfo) {})
+		if err != nil {
+			return false, err
+		}
+	} else {
+		fi, err := r.staticFileSystem.CachedFileInfo(siaPath)
+		if err != nil {
+			return false, err
+		}
+		entry.NumFiles = 1
+		entry.Size = fi.Filesize
+	}
+
+	// Entries that are deleted within the same nanosecond get the next free
+	// ID.
+	nanos := entry.Deleted.UnixNano()
+	for {
+		entry.ID = strconv.FormatInt(nanos, 10)
+		trashPath, err := trashEntrySiaPath(entry.ID)
+		if err != nil {
+			return false, err
+		}
+		if isDir {
+			err = r.staticFileSystem.RenameDir(siaPath, trashPath)
+		} else {
+			err = r.staticFileSystem.RenameFile(siaPath, trashPath)
+		}
+		if errors.Contains(err, filesystem.ErrExists) {
+			nanos++
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		break
+	}
+
+	id := r.mu.Lock()
+	if r.persist.Trash == nil {
+		r.persist.Trash = make(map[string]modules.TrashEntry)
+	}
+	r.persist.Trash[entry.ID] = entry
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return true, errors.AddContext(err, "unable to save trash entry")
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(modules.TrashFolder)
+	if parent, err := siaPath.Dir(); err == nil && isDir {
+		_ = r.staticBubbleScheduler.callQueueBubble(parent)
+	}
+	return true, nil
+}
+
+// managedForgetTrashEntries removes the entries from the trash which were
+// deleted permanently together with siaPath.
+func (r *Renter) managedForgetTrashEntries(siaPath modules.SiaPath) {
+	if !isTrashSiaPath(siaPath) {
+		return
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	forgotten := false
+	for entryID := range r.persist.Trash {
+		trashPath, err := trashEntrySiaPath(entryID)
+		if err != nil || siaPath.Equals(modules.TrashFolder) || siaPath.Equals(trashPath) {
+			delete(r.persist.Trash, entryID)
+			forgotten = true
+		}
+	}
+	if !forgotten {
+		return
+	}
+	if err := r.saveSync(); err != nil {
+		r.log.Println("WARN: unable to save the trash:", err)
+	}
+}
+
+// managedTrashEntry returns the trash entry with the given ID.
+func (r *Renter) managedTrashEntry(entryID string) (modules.TrashEntry, error) {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	entry, ok := r.persist.Trash[entryID]
+	if !ok {
+		return modules.TrashEntry{}, errTrashEntryNotFound
+	}
+	return entry, nil
+}
+
+// TrashEntries returns the files and directories in the trash, most recently
+// deleted first.
+func (r *Renter) TrashEntries() []modules.TrashEntry {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	entries := make([]modules.TrashEntry, 0, len(r.persist.Trash))
+	for _, entry := range r.persist.Trash {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Deleted.Equal(entries[j].Deleted) {
+			return entries[i].Deleted.After(entries[j].Deleted)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+	return entries
+}
+
+// RestoreTrashEntry moves a file or directory in the trash back to the
+// location it was deleted from. Restoring fails if a file or directory exists
+// at that location.
+func (r *Renter) RestoreTrashEntry(entryID string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	entry, err := r.managedTrashEntry(entryID)
+	if err != nil {
+		return err
+	}
+	trashPath, err := trashEntrySiaPath(entry.ID)
+	if err != nil {
+		return err
+	}
+	if entry.IsDir {
+		err = r.staticFileSystem.RenameDir(trashPath, entry.SiaPath)
+	} else {
+		err = r.staticFileSystem.RenameFile(trashPath, entry.SiaPath)
+	}
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to restore '%v'", entry.SiaPath))
+	}
+
+	id := r.mu.Lock()
+	delete(r.persist.Trash, entry.ID)
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to save the trash")
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(modules.TrashFolder)
+	if entry.IsDir {
+		_ = r.staticBubbleScheduler.callQueueBubble(entry.SiaPath)
+	} else if parent, err := entry.SiaPath.Dir(); err == nil {
+		_ = r.staticBubbleScheduler.callQueueBubble(parent)
+	}
+	return nil
+}
+
+// PurgeTrashEntry permanently deletes a file or directory in the trash.
+func (r *Renter) PurgeTrashEntry(entryID string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	entry, err := r.managedTrashEntry(entryID)
+	if err != nil {
+		return err
+	}
+	return r.managedPurgeTrashEntry(entry)
+}
+
+// managedPurgeTrashEntry permanently deletes the file or directory of a trash
+// entry and removes the entry from the trash.
+func (r *Renter) managedPurgeTrashEntry(entry modules.TrashEntry) error {
+	trashPath, err := trashEntrySiaPath(entry.ID)
+	if err != nil {
+		return err
+	}
+	if entry.IsDir {
+		err = r.managedDeleteDir(trashPath)
+	} else {
+		err = r.managedDeleteFile(trashPath)
+	}
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		// The entry was already deleted some other way.
+		r.managedForgetTrashEntries(trashPath)
+		err = nil
+	}
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to purge '%v' from the trash", entry.SiaPath))
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(modules.TrashFolder)
+	return nil
+}
+
+// PurgeTrash permanently deletes all files and directories in the trash.
+func (r *Renter) PurgeTrash() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	exists, err := r.staticFileSystem.DirExists(modules.TrashFolder)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := r.managedDeleteDir(modules.TrashFolder); err != nil {
+			return errors.AddContext(err, "unable to purge the trash")
+		}
+	}
+	r.managedForgetTrashEntries(modules.TrashFolder)
+	return nil
+}
+
+// SetTrashRetention sets the number of days after which deleted files and
+// directories are purged from the trash.
+func (r *Renter) SetTrashRetention(days uint64) error {
+	if days == 0 {
+		return errTrashRetentionZero
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.persist.TrashRetentionDays = days
+	return r.saveSync()
+}
+
+// TrashRetention returns the number of days after which deleted files and
+// directories are purged from the trash.
+func (r *Renter) TrashRetention() uint64 {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.persist.TrashRetentionDays
+}
+
+// threadedPurgeTrash periodically purges the files and directories which have
+// been in the trash for longer than the trash retention.
+func (r *Renter) threadedPurgeTrash() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(trashPurgeInterval):
+		}
+		if err := r.managedPurgeExpiredTrash(time.Now()); err != nil {
+			r.log.Println("WARN: failed to purge the trash:", err)
+		}
+	}
+}
+
+// managedPurgeExpiredTrash purges the entries of the trash which have expired
+// at the given time.
+func (r *Renter) managedPurgeExpiredTrash(now time.Time) error {
+	id := r.mu.RLock()
+	expired := expiredTrashEntries(r.persist.Trash, r.persist.TrashRetentionDays, now)
+	r.mu.RUnlock(id)
+	for _, entry := range expired {
+		if err := r.managedPurgeTrashEntry(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
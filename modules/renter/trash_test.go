@@ -0,0 +1,207 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// TestExpiredTrashEntries tests which entries of the trash expire.
+func TestExpiredTrashEntries(t *testing.T) {
+	now := time.Now()
+	entries := map[string]modules.TrashEntry{
+		"a": {ID: "a", Deleted: now.Add(-time.Hour)},
+		"b": {ID: "b", Deleted: now.Add(-36 * time.Hour)},
+		"c": {ID: "c", Deleted: now.Add(-72 * time.Hour)},
+	}
+	tests := []struct {
+		days    uint64
+		expired int
+	}{
+		{7, 0},
+		{2, 1},
+		{1, 2},
+	}
+	for _, test := range tests {
+		if expired := expiredTrashEntries(entries, test.days, now); len(expired) != test.expired {
+			t.Errorf("retention of %v days: expected %v expired entries but got %v", test.days, test.expired, len(expired))
+		}
+	}
+}
+
+// TestTrash tests moving files and directories to the trash, restoring and
+// purging them.
+func TestTrash(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	dir, err := modules.UserFolder.Join("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := dir.Join("sub/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// createFile creates the file at siaPath.
+	createFile := func() {
+		_, rsc := testingFileParams()
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// numFiles returns the number of files listed from the root.
+	numFiles := func() (n int) {
+		err := r.FileList(modules.RootSiaPath(), true, true, func(modules.FileInfo) { n++ })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// Deleted files are moved to the trash and no longer listed.
+	createFile()
+	if err := r.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("file should have been deleted", err)
+	}
+	if n := numFiles(); n != 0 {
+		t.Fatal("files in the trash shouldn't be listed", n)
+	}
+	entries := r.TrashEntries()
+	if len(entries) != 1 || !entries[0].SiaPath.Equals(siaPath) || entries[0].IsDir || entries[0].NumFiles != 1 {
+		t.Fatal("expected the file in the trash", entries)
+	}
+	trashPath, err := trashEntrySiaPath(entries[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(trashPath); err != nil {
+		t.Fatal("file should be in the trash folder", err)
+	}
+
+	// Restore the file.
+	if err := r.RestoreTrashEntry(entries[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) != 0 {
+		t.Fatal("trash should be empty", r.TrashEntries())
+	}
+	if err := r.RestoreTrashEntry(entries[0].ID); !errors.Contains(err, errTrashEntryNotFound) {
+		t.Fatal("expected errTrashEntryNotFound but got", err)
+	}
+
+	// Delete the directory and the file it contained after recreating it.
+	if err := r.DeleteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	createFile()
+	if err := r.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	entries = r.TrashEntries()
+	if len(entries) != 2 || entries[0].IsDir || !entries[1].IsDir || !entries[1].SiaPath.Equals(dir) {
+		t.Fatal("expected the file and the directory in the trash, newest first", entries)
+	}
+
+	// Restoring the directory fails since it was recreated.
+	if err := r.RestoreTrashEntry(entries[1].ID); err == nil {
+		t.Fatal("restoring over an existing directory should fail")
+	}
+
+	// The trash is persisted.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) != 2 {
+		t.Fatal("trash wasn't persisted", r.TrashEntries())
+	}
+
+	// Purge the file.
+	if err := r.PurgeTrashEntry(entries[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	trashPath, err = trashEntrySiaPath(entries[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(trashPath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("file should have been purged", err)
+	}
+
+	// Expired entries are purged.
+	if err := r.SetTrashRetention(0); !errors.Contains(err, errTrashRetentionZero) {
+		t.Fatal("expected errTrashRetentionZero but got", err)
+	}
+	if err := r.SetTrashRetention(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedPurgeExpiredTrash(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) != 1 {
+		t.Fatal("the directory shouldn't have expired yet", r.TrashEntries())
+	}
+	if err := r.managedPurgeExpiredTrash(time.Now().Add(48 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) != 0 {
+		t.Fatal("trash should be empty", r.TrashEntries())
+	}
+
+	// Purging the trash removes all entries.
+	createFile()
+	if err := r.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) != 1 {
+		t.Fatal("expected the file in the trash", r.TrashEntries())
+	}
+	if err := r.PurgeTrash(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) != 0 {
+		t.Fatal("trash should be empty", r.TrashEntries())
+	}
+	if exists, err := r.staticFileSystem.DirExists(modules.TrashFolder); err != nil || exists {
+		t.Fatal("trash folder should have been deleted", exists, err)
+	}
+
+	// Files overwritten by forced uploads are removed without the trash.
+	createFile()
+	if err := r.managedRemoveFile(siaPath, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("file should have been deleted", err)
+	}
+	if len(r.TrashEntries()) != 0 {
+		t.Fatal("overwritten file shouldn't be in the trash", r.TrashEntries())
+	}
+}
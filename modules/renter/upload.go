@@ -51,14 +51,22 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	if err != nil {
 		return errors.AddContext(err, "unable to close file after checking permissions")
 	}
-
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
-	if up.Force {
-		err := r.DeleteFile(up.SiaPath)
-		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
-			return errors.AddContext(err, "unable to delete existing file")
-		}
+	checksumAlgo, err := checksumAlgorithm(up)
+	if err != nil {
+		return err
+	}
+	codec, err := compressionCodec(up)
+	if err != nil {
+		return err
+	}
+	if err := validateDedup(up); err != nil {
+		return err
+	}
+	tags, err := validateUploadUserMetadata(up)
+	if err != nil {
+		return err
 	}
+	up.Tags = tags
 
 	// Fill in any missing upload params with sensible defaults.
 	if up.ErasureCode == nil {
@@ -75,6 +83,20 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return fmt.Errorf("not enough contracts to upload file: got %v, needed %v", numContracts, (up.ErasureCode.NumPieces()+up.ErasureCode.MinPieces())/2)
 	}
 
+	// Compressed files are uploaded from a stream in the background.
+	if codec != "" {
+		return r.managedUploadCompressed(up, sourceInfo.Mode())
+	}
+
+	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// The overwritten file isn't moved to the trash since it is replaced.
+	if up.Force {
+		err := r.managedRemoveFile(up.SiaPath, false)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, "unable to delete existing file")
+		}
+	}
+
 	// Create the directory path on disk. Renter directory is already present so
 	// only files not in top level directory need to have directories created
 	dirSiaPath, err := up.SiaPath.Dir()
@@ -91,6 +113,11 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	// Generate a key using the cipher type.
 	cipherKey := crypto.GenerateSiaKey(up.CipherType)
 
+	// Deduplicated chunks can't be combined with the chunks of other files.
+	if up.Dedup {
+		up.DisablePartialChunk = true
+	}
+
 	// Create the Siafile and add to renter
 	err = r.staticFileSystem.NewSiaFile(up.SiaPath, up.Source, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode(), up.DisablePartialChunk)
 	if err != nil {
@@ -100,6 +127,20 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	if err != nil {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
+	if up.Dedup {
+		if err := entry.SetDedup(true); err != nil {
+			return errors.Compose(errors.AddContext(err, "could not enable deduplication"), entry.Close())
+		}
+	}
+	if err := setUploadUserMetadata(entry, up); err != nil {
+		return errors.Compose(err, entry.Close())
+	}
+
+	// Compute the checksum of the source in the background while the file
+	// is being uploaded.
+	if checksumAlgo != "" {
+		go r.threadedComputeChecksum(entry.Copy(), up.Source, checksumAlgo)
+	}
 
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
@@ -128,3 +169,46 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	}
 	return nil
 }
+
+// managedUploadCompressed uploads a local file with compression. The chunks of
+// a compressed file don't match the chunks of its source, so the file is
+// uploaded from a stream in the background and it can't be repaired from
+// disk.
+func (r *Renter) managedUploadCompressed(up modules.FileUploadParams, mode os.FileMode) error {
+	file, err := os.Open(up.Source)
+	if err != nil {
+		return errors.AddContext(err, "unable to open the source file")
+	}
+	up.Source = ""
+	up.DisablePartialChunk = true
+	fileNode, err := r.managedInitUploadStream(up)
+	if err == nil {
+		err = fileNode.SetMode(mode)
+		if err != nil {
+			err = errors.Compose(err, fileNode.Close())
+		}
+	}
+	if err != nil {
+		return errors.Compose(err, file.Close())
+	}
+
+	go func() {
+		defer func() {
+			_ = file.Close()
+		}()
+		if err := r.tg.Add(); err != nil {
+			_ = fileNode.Close()
+			return
+		}
+		defer r.tg.Done()
+		node, err := r.callUploadStreamToFileNode(fileNode, up, file)
+		if err != nil {
+			r.log.Printf("WARN: compressed upload of %v failed: %v", up.SiaPath, err)
+			return
+		}
+		if err := node.Close(); err != nil {
+			r.log.Printf("WARN: unable to close %v after compressed upload: %v", up.SiaPath, err)
+		}
+	}()
+	return nil
+}
@@ -236,11 +236,12 @@ func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][
 // padAndEncryptPiece will add padding to a unfinishedUploadChunk's piece at
 // index i and then encrypt it.
 func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int) {
-	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
+	padAndEncryptPiece(uint64(i), uc.logicalChunkData, uc.fileEntry.PieceKey(uc.staticIndex, uint64(i)))
 }
 
-// padAndEncryptPiece will add padding to a piece and then encrypt it.
-func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte, masterKey crypto.CipherKey) {
+// padAndEncryptPiece will add padding to a piece and then encrypt it with the
+// piece's key.
+func padAndEncryptPiece(pieceIndex uint64, logicalChunkData [][]byte, key crypto.CipherKey) {
 	// If the piece is not a full sector, pad it with empty bytes. The padding
 	// is done before applying encryption, meaning the data fed to the host does
 	// not have a bunch of zeroes in it.
@@ -254,7 +255,7 @@ func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte
 		logicalChunkData[pieceIndex] = append(logicalChunkData[pieceIndex], make([]byte, short)...)
 	}
 	// Encrypt the piece.
-	key := masterKey.Derive(chunkIndex, pieceIndex)
+	//
 	// TODO: Switch this to perform in-place encryption.
 	logicalChunkData[pieceIndex] = key.EncryptBytes(logicalChunkData[pieceIndex])
 }
@@ -294,8 +295,8 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 		length:        downloadLength,
 		needsMemory:   false, // We already requested memory, the download memory fits inside of that.
 		offset:        uint64(chunk.offset),
-		overdrive:     0, // No need to rush the latency on repair downloads.
-		priority:      0, // Repair downloads are completely de-prioritized.
+		overdrive:     0,                             // No need to rush the latency on repair downloads.
+		priority:      modules.DownloadPriorityBatch, // Repair downloads are completely de-prioritized.
 
 		staticMemoryManager:    chunk.staticMemoryManager, // Same memory manager as upload chunk
 		staticSpendingCategory: categoryRepairDownload,
@@ -421,6 +422,17 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	// fetching, where the erasure coding occurs.
 	chunk.staticMemoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
 	chunk.memoryReleased += erasureCodingMemory + pieceCompletedMemory
+
+	// Reuse the sectors of an identical chunk that was uploaded before. If
+	// they cover all the pieces, there is nothing left to upload.
+	if r.managedReuseDedupSectors(chunk) {
+		chunk.mu.Lock()
+		chunk.logicalChunkData = nil
+		chunk.mu.Unlock()
+		r.managedCleanUpUploadChunk(chunk)
+		return
+	}
+
 	// Swap the physical chunk data and the logical chunk data. There is
 	// probably no point to having both, given that we perform such a clean
 	// handoff here, but since the code is already written this way, it may be
@@ -517,6 +529,11 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 		return errors.AddContext(err, "unable to read the chunk data from the source reader")
 	}
 
+	// Deduplicated chunks need their content ID before they are encrypted.
+	if err := r.managedAssignDedupID(uc); err != nil {
+		return errors.AddContext(err, "unable to deduplicate the chunk")
+	}
+
 	// Perform an integrity check on the data that was pulled from the reader.
 	err = uc.staticEncryptAndCheckIntegrity()
 	if err != nil {
@@ -580,6 +597,9 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
 		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
+		if err := r.managedAssignDedupID(uc); err != nil {
+			return errors.AddContext(err, "unable to deduplicate the chunk")
+		}
 		err = uc.staticEncryptAndCheckIntegrity()
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
@@ -691,6 +711,9 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	if chunkComplete && !released {
 		r.managedUpdateUploadChunkStuckStatus(uc)
 
+		// Make the sectors of deduplicated chunks available to other files.
+		r.managedRegisterDedupChunk(uc)
+
 		// Update the file's metadata.
 		offlineMap, goodForRenewMap, contracts, used := r.callRenterContractsAndUtilities()
 		err := r.managedUpdateFileMetadata(uc.fileEntry, offlineMap, goodForRenewMap, contracts, used)
@@ -1360,6 +1360,10 @@ func (r *Renter) managedRepairLoop() error {
 	// that changes to the directory heap take effect sooner rather than later.
 	repairBreakTime := time.Now().Add(maxRepairLoopTime)
 
+	// Track whether repairs were skipped because the bandwidth schedule
+	// pauses them and whether any chunk was handed to the workers.
+	skippedRepairs, preparedChunks := false, false
+
 	// Work through the heap repairing chunks until heap is empty for
 	// smallRepairs or heap drops below minUploadHeapSize for larger repairs, or
 	// until the total amount of time spent in one repair iteration has elapsed.
@@ -1391,9 +1395,19 @@ func (r *Renter) managedRepairLoop() error {
 		if nextChunk == nil {
 			// The heap is empty so reset it to free memory and return.
 			r.uploadHeap.managedReset()
-			return nil
+			break
 		}
 		chunkPath := nextChunk.staticSiaPath
+
+		// Skip repairs while the bandwidth schedule pauses them. New uploads
+		// continue.
+		isRepair := nextChunk.managedIsRepair()
+		if isRepair && r.staticBandwidthScheduler.managedRepairsPaused() {
+			skippedRepairs = true
+			nextChunk.fileEntry.Close()
+			r.uploadHeap.managedMarkRepairDone(nextChunk)
+			continue
+		}
 		r.repairLog.Printf("Repairing chunk %v of %s, currently have %v out of %v pieces", nextChunk.staticIndex, chunkPath, nextChunk.piecesCompleted, nextChunk.staticPiecesNeeded)
 
 		// Make sure we have enough workers for this chunk to reach minimum
@@ -1431,6 +1445,18 @@ func (r *Renter) managedRepairLoop() error {
 			continue
 		}
 
+		// Wait to stay within the repair rate of the bandwidth schedule.
+		if isRepair {
+			delay := r.staticBandwidthScheduler.managedRepairDelay(nextChunk.managedRepairSize(), time.Now())
+			select {
+			case <-time.After(delay):
+			case <-r.tg.StopChan():
+				nextChunk.fileEntry.Close()
+				r.uploadHeap.managedMarkRepairDone(nextChunk)
+				return errors.New("Repair loop interrupted because renter is shutting down")
+			}
+		}
+
 		// Perform the work. managedPrepareNextChunk will block until
 		// enough memory is available to perform the work, slowing this
 		// thread down to using only the resources that are available.
@@ -1449,6 +1475,10 @@ func (r *Renter) managedRepairLoop() error {
 			r.uploadHeap.managedMarkRepairDone(nextChunk)
 			continue
 		}
+		preparedChunks = true
+	}
+	if skippedRepairs && !preparedChunks {
+		return errRepairsPausedBySchedule
 	}
 	return nil
 }
@@ -1618,7 +1648,17 @@ func (r *Renter) threadedUploadAndRepair() {
 			r.repairLog.Printf("Executing an upload and repair cycle, uploadHeap has %v chunks in it", uploadHeapLen)
 		}
 		err = r.managedRepairLoop()
-		if err != nil {
+		if errors.Contains(err, errRepairsPausedBySchedule) {
+			// There were only repairs to do. Instead of rebuilding the same
+			// heap right away, wait for new uploads or for the bandwidth
+			// schedule to change.
+			select {
+			case <-r.uploadHeap.newUploads:
+			case <-time.After(bandwidthScheduleInterval):
+			case <-r.tg.StopChan():
+				return
+			}
+		} else if err != nil {
 			// If there was an error with the repair loop sleep for a little bit
 			// and then try again. Here we do not skip to the next iteration as
 			// we want to call bubble on the impacted directories
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"fmt"
+	"hash"
 	"io"
 	"sync"
 
@@ -137,8 +138,26 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 // SiaFile for the upload.
 func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesystem.FileNode, error) {
 	siaPath, ec, force, repair, cipherType := up.SiaPath, up.ErasureCode, up.Force, up.Repair, up.CipherType
+	// Check the checksum and compression settings.
+	if _, err := checksumAlgorithm(up); err != nil {
+		return nil, err
+	}
+	codec, err := compressionCodec(up)
+	if err != nil {
+		return nil, err
+	}
+	if codec != "" && repair {
+		return nil, errors.New("can't provide compression settings when doing repairs")
+	}
+	if err := validateDedup(up); err != nil {
+		return nil, err
+	}
+	tags, err := validateUploadUserMetadata(up)
+	if err != nil {
+		return nil, err
+	}
+	up.Tags = tags
 	// Check if ec was set. If not use defaults.
-	var err error
 	if ec == nil && !repair {
 		ec = modules.NewRSSubCodeDefault()
 		up.ErasureCode = ec
@@ -152,8 +171,9 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	}
 
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// The overwritten file isn't moved to the trash since it is replaced.
 	if force {
-		err := r.DeleteFile(siaPath)
+		err := r.managedRemoveFile(siaPath, false)
 		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 			return nil, err
 		}
@@ -183,12 +203,29 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 		cipherKey = crypto.GenerateSiaKey(cipherType)
 	}
 
+	// Deduplicated chunks can't be combined with the chunks of other files.
+	if up.Dedup {
+		up.DisablePartialChunk = true
+	}
+
 	// Create the Siafile and add to renter
 	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, defaultFilePerm, up.DisablePartialChunk)
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenSiaFile(siaPath)
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if up.Dedup {
+		if err := entry.SetDedup(true); err != nil {
+			return nil, errors.Compose(errors.AddContext(err, "could not enable deduplication"), entry.Close())
+		}
+	}
+	if err := setUploadUserMetadata(entry, up); err != nil {
+		return nil, errors.Compose(err, entry.Close())
+	}
+	return entry, nil
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
@@ -200,28 +237,79 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 // the Sia network, this will happen faster than the entire upload is complete -
 // the streamer may continue uploading in the background after returning while
 // it is boosting redundancy.
-func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (fileNode *filesystem.FileNode, err error) {
-	// Check the upload params first.
-	fileNode, err = r.managedInitUploadStream(up)
+func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (*filesystem.FileNode, error) {
+	fileNode, err := r.managedInitUploadStream(up)
 	if err != nil {
 		return nil, err
 	}
-	// Need to make a copy of this value for the defer statement. Because
-	// 'fileNode' is a named value, if you run the call `return nil, err`, then
-	// 'fileNode' will be set to 'nil' when 'fileNode.Close()' gets called in
-	// the 'defer'.
-	fn := fileNode
+	return r.callUploadStreamToFileNode(fileNode, up, reader)
+}
+
+// callUploadStreamToFileNode uploads the data read from the provided reader
+// until io.EOF is reached to a SiaFile prepared by managedInitUploadStream.
+// The fileNode is closed if an error is returned.
+func (r *Renter) callUploadStreamToFileNode(fileNode *filesystem.FileNode, up modules.FileUploadParams, reader io.Reader) (_ *filesystem.FileNode, err error) {
 	defer func() {
 		// Ensure the fileNode is closed if there is an error upon return.
 		if err != nil {
-			err = errors.Compose(err, fn.Close())
+			err = errors.Compose(err, fileNode.Close())
 		}
 	}()
 
+	// Repairs keep the checksum of the original upload.
+	algorithm, err := checksumAlgorithm(up)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := compressionCodec(up)
+	if err != nil {
+		return nil, err
+	}
+	source := reader
+	var hasher hash.Hash
+	if algorithm != "" && !up.Repair {
+		hasher, _ = modules.NewChecksumHasher(algorithm)
+		reader = io.TeeReader(reader, hasher)
+	}
+	// Compress the data after computing the checksum of the plaintext. Repairs
+	// need to compress the data the same way it was compressed initially.
+	if compression := fileNode.Compression(); up.Repair && compression.IsSet() {
+		codec = compression.Codec
+	}
+	var cr *compressingReader
+	if codec != "" {
+		cr = newCompressingReader(reader, codec, fileNode.ChunkSize())
+		reader = cr
+	}
+	// The compression is stored before the first chunk is uploaded and
+	// updated whenever a chunk was read, so that the chunks which were
+	// uploaded can be decompressed if the upload is interrupted.
+	updateCompression := func() error {
+		if cr == nil || up.Repair {
+			return nil
+		}
+		return errors.AddContext(fileNode.SetCompression(cr.Compression()), "unable to set compression")
+	}
+	if err := updateCompression(); err != nil {
+		return nil, err
+	}
+	setMetadata := func() error {
+		if err := updateCompression(); err != nil {
+			return err
+		}
+		if hasher == nil {
+			return nil
+		}
+		return fileNode.SetChecksum(modules.NewFileChecksum(algorithm, hasher))
+	}
+
 	// Check if stream has at least one byte. No need to upload empty data.
 	peek := []byte{0}
 	_, err = io.ReadFull(reader, peek)
 	if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
+		if err := setMetadata(); err != nil {
+			return nil, errors.AddContext(err, "unable to update the metadata of the file")
+		}
 		return fileNode, nil
 	} else if err != nil {
 		return nil, err
@@ -253,7 +341,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		// Disrupt the upload by closing the reader and simulating losing
 		// connectivity during the upload.
 		if r.deps.Disrupt("DisruptUploadStream") {
-			c, ok := reader.(io.Closer)
+			c, ok := source.(io.Closer)
 			if ok {
 				c.Close()
 			}
@@ -307,6 +395,9 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			return nil, errors.New("interrupted by shutdown")
 		case <-ss.signalChan:
 		}
+		if err := updateCompression(); err != nil {
+			return nil, err
+		}
 
 		// If an io.EOF error occurred or less than chunkSize was read, we are
 		// done. Otherwise we report the error.
@@ -346,5 +437,8 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	if r.deps.Disrupt("failUploadStreamFromReader") {
 		return nil, errors.New("disrupted by failUploadStreamFromReader")
 	}
+	if err := setMetadata(); err != nil {
+		return nil, errors.AddContext(err, "unable to update the metadata of the file")
+	}
 	return fileNode, nil
 }
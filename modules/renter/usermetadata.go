@@ -0,0 +1,151 @@
+package renter
+
+// usermetadata.go contains the methods for setting the user-defined tags and
+// key/value metadata of files and directories and for searching the renter's
+// files by them. The tags and metadata are stored within the siafile and
+// siadir metadata, which means that they are also part of backups.
+
+import (
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// SetTags replaces the tags of the file or directory at siaPath.
+func (r *Renter) SetTags(siaPath modules.SiaPath, tags []string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	tags, err := modules.NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	return r.managedUpdateUserMetadata(siaPath, func(entry *filesystem.FileNode) error {
+		return entry.SetTags(tags)
+	}, func(entry *filesystem.DirNode) error {
+		return entry.SetTags(tags)
+	})
+}
+
+// SetUserMetadata replaces the user-defined key/value metadata of the file or
+// directory at siaPath.
+func (r *Renter) SetUserMetadata(siaPath modules.SiaPath, md map[string]string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := modules.ValidateUserMetadata(md); err != nil {
+		return err
+	}
+	return r.managedUpdateUserMetadata(siaPath, func(entry *filesystem.FileNode) error {
+		return entry.SetUserMetadata(md)
+	}, func(entry *filesystem.DirNode) error {
+		return entry.SetUserMetadata(md)
+	})
+}
+
+// Find returns the files and directories within the directory at siaPath and
+// its subdirectories which match the query. The results are sorted by siapath
+// and don't include the directory itself. Like FileList, Find only returns
+// entries of the trash and prior versions of files when searching within the
+// TrashFolder or VersionsFolder.
+func (r *Renter) Find(siaPath modules.SiaPath, query modules.FileQuery) ([]modules.FileInfo, []modules.DirectoryInfo, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, nil, err
+	}
+	defer r.tg.Done()
+	var mu sync.Mutex
+	var files []modules.FileInfo
+	var dirs []modules.DirectoryInfo
+	err := r.staticFileSystem.CachedList(siaPath, true, func(fi modules.FileInfo) {
+		if isHiddenFromFind(siaPath, fi.SiaPath) || !query.MatchFile(fi) {
+			return
+		}
+		mu.Lock()
+		files = append(files, fi)
+		mu.Unlock()
+	}, func(di modules.DirectoryInfo) {
+		if di.SiaPath.Equals(siaPath) || isHiddenFromFind(siaPath, di.SiaPath) || !query.MatchDir(di) {
+			return
+		}
+		mu.Lock()
+		dirs = append(dirs, di)
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].SiaPath.String() < files[j].SiaPath.String()
+	})
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].SiaPath.String() < dirs[j].SiaPath.String()
+	})
+	return files, dirs, nil
+}
+
+// isHiddenFromFind returns whether the file or directory at siaPath is
+// omitted from the results of searching the directory at searchPath.
+func isHiddenFromFind(searchPath, siaPath modules.SiaPath) bool {
+	if isTrashSiaPath(siaPath) && !isTrashSiaPath(searchPath) {
+		return true
+	}
+	return isVersionSiaPath(siaPath) && !isVersionSiaPath(searchPath)
+}
+
+// managedUpdateUserMetadata calls updateFile with the file at siaPath or, if
+// there is no such file, updateDir with the directory at siaPath.
+func (r *Renter) managedUpdateUserMetadata(siaPath modules.SiaPath, updateFile func(*filesystem.FileNode) error, updateDir func(*filesystem.DirNode) error) (err error) {
+	fileEntry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err == nil {
+		defer func() {
+			err = errors.Compose(err, fileEntry.Close())
+		}()
+		return updateFile(fileEntry)
+	}
+	if !errors.Contains(err, filesystem.ErrNotExist) {
+		return err
+	}
+	dirEntry, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dirEntry.Close())
+	}()
+	return updateDir(dirEntry)
+}
+
+// validateUploadUserMetadata validates the tags and user-defined metadata of
+// an upload and returns the normalized tags.
+func validateUploadUserMetadata(up modules.FileUploadParams) ([]string, error) {
+	if up.Repair && (len(up.Tags) > 0 || len(up.UserMetadata) > 0) {
+		return nil, errors.New("can't provide tags or metadata when doing repairs")
+	}
+	tags, err := modules.NormalizeTags(up.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return tags, modules.ValidateUserMetadata(up.UserMetadata)
+}
+
+// setUploadUserMetadata sets the tags and user-defined metadata of an upload
+// on its new siafile.
+func setUploadUserMetadata(entry *filesystem.FileNode, up modules.FileUploadParams) error {
+	if len(up.Tags) > 0 {
+		if err := entry.SetTags(up.Tags); err != nil {
+			return errors.AddContext(err, "could not set tags")
+		}
+	}
+	if len(up.UserMetadata) > 0 {
+		if err := entry.SetUserMetadata(up.UserMetadata); err != nil {
+			return errors.AddContext(err, "could not set metadata")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package renter
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestIsHiddenFromFind tests which files and directories are omitted from
+// search results.
+func TestIsHiddenFromFind(t *testing.T) {
+	join := func(parent modules.SiaPath, path string) modules.SiaPath {
+		siaPath, err := parent.Join(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return siaPath
+	}
+	root := modules.RootSiaPath()
+	tests := []struct {
+		search  modules.SiaPath
+		siaPath modules.SiaPath
+		hidden  bool
+	}{
+		{root, join(modules.UserFolder, "file"), false},
+		{root, modules.TrashFolder, true},
+		{root, join(modules.TrashFolder, "1/file"), true},
+		{root, modules.VersionsFolder, true},
+		{root, join(modules.VersionsFolder, "home/user/file/1"), true},
+		{modules.TrashFolder, join(modules.TrashFolder, "1/file"), false},
+		{join(modules.TrashFolder, "1"), join(modules.TrashFolder, "1/file"), false},
+		{modules.VersionsFolder, join(modules.VersionsFolder, "home/user/file/1"), false},
+		{modules.TrashFolder, join(modules.VersionsFolder, "home/user/file/1"), true},
+	}
+	for i, test := range tests {
+		if isHiddenFromFind(test.search, test.siaPath) != test.hidden {
+			t.Errorf("%v: searching %v, %v should be hidden: %v", i, test.search, test.siaPath, test.hidden)
+		}
+	}
+}
+
+// TestFindHidesTrashAndVersions tests that Find only returns deleted files and
+// prior versions of files when searching the TrashFolder or VersionsFolder.
+func TestFindHidesTrashAndVersions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// createFile creates a tagged file at path within the UserFolder.
+	createFile := func(path string) modules.SiaPath {
+		siaPath, err := modules.UserFolder.Join(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rsc := testingFileParams()
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.SetTags(siaPath, []string{"tag"}); err != nil {
+			t.Fatal(err)
+		}
+		return siaPath
+	}
+	// find returns the number of files with the tag within siaPath.
+	query := modules.FileQuery{Tags: []string{"tag"}}
+	find := func(siaPath modules.SiaPath) int {
+		files, _, err := r.Find(siaPath, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(files)
+	}
+
+	// Move one file to the trash and keep another one as a prior version.
+	createFile("kept")
+	if err := r.DeleteFile(createFile("trashed")); err != nil {
+		t.Fatal(err)
+	}
+	versioned, err := modules.UserFolder.Join("versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetVersioningPolicy(versioned, &modules.VersioningPolicy{KeepVersions: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteFile(createFile("versioned/file")); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.TrashEntries()) == 0 {
+		t.Fatal("file should be in the trash")
+	}
+
+	if n := find(modules.RootSiaPath()); n != 1 {
+		t.Fatal("only the kept file should be found, found", n)
+	}
+	if n := find(modules.TrashFolder); n == 0 {
+		t.Fatal("trashed file should be found when searching the trash")
+	}
+	if n := find(modules.VersionsFolder); n != 1 {
+		t.Fatal("prior version should be found when searching the versions, found", n)
+	}
+}
@@ -0,0 +1,341 @@
+package renter
+
+// versioning.go implements keeping prior versions of files which are
+// overwritten or deleted within directories that have versioning enabled.
+// Instead of deleting the siafile, it is moved into a directory within the
+// VersionsFolder which mirrors the siapath of the file. The name of a version
+// is the time it was superseded in nanoseconds. Since versions are regular
+// siafiles, they can be downloaded and are repaired like any other file until
+// they are pruned.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errVersionNotFound is returned if a file doesn't have a version with
+	// the requested ID.
+	errVersionNotFound = errors.New("file version not found")
+
+	// errVersioningVersionsFolder is returned when trying to enable versioning
+	// for the VersionsFolder.
+	errVersioningVersionsFolder = errors.New("versioning can't be enabled for the versions folder")
+)
+
+// isVersionSiaPath returns whether a siapath is within the VersionsFolder.
+func isVersionSiaPath(siaPath modules.SiaPath) bool {
+	return siaPath.Equals(modules.VersionsFolder) || strings.HasPrefix(siaPath.String(), modules.VersionsFolder.String()+"/")
+}
+
+// versionDirSiaPath returns the siapath of the directory which contains the
+// versions of a file.
+func versionDirSiaPath(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	return modules.VersionsFolder.Join(siaPath.String())
+}
+
+// fileVersionFromInfo creates a FileVersion from the FileInfo of the siafile
+// of a version. It returns false if the siafile isn't a version.
+func fileVersionFromInfo(fi modules.FileInfo) (modules.FileVersion, bool) {
+	nanos, err := strconv.ParseInt(fi.SiaPath.Name(), 10, 64)
+	if err != nil {
+		return modules.FileVersion{}, false
+	}
+	return modules.FileVersion{
+		ID:               fi.SiaPath.Name(),
+		SiaPath:          fi.SiaPath,
+		Superseded:       time.Unix(0, nanos),
+		Filesize:         fi.Filesize,
+		ModificationTime: fi.ModificationTime,
+		Checksum:         fi.Checksum,
+	}, true
+}
+
+// sortFileVersions sorts versions from newest to oldest.
+func sortFileVersions(versions []modules.FileVersion) {
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Superseded.After(versions[j].Superseded)
+	})
+}
+
+// versionsToPrune returns the versions which are no longer retained by a
+// policy. The versions must be sorted from newest to oldest.
+func versionsToPrune(versions []modules.FileVersion, policy modules.VersioningPolicy, now time.Time) []modules.FileVersion {
+	maxAge := time.Duration(policy.KeepDays) * 24 * time.Hour
+	var prune []modules.FileVersion
+	for i, v := range versions {
+		tooMany := policy.KeepVersions > 0 && uint64(i) >= policy.KeepVersions
+		tooOld := policy.KeepDays > 0 && now.Sub(v.Superseded) > maxAge
+		if tooMany || tooOld {
+			prune = append(prune, v)
+		}
+	}
+	return prune
+}
+
+// SetVersioningPolicy enables versioning for a directory with the given
+// policy, or disables it if the policy is nil. Disabling versioning doesn't
+// remove existing versions, but they are no longer pruned either.
+func (r *Renter) SetVersioningPolicy(siaPath modules.SiaPath, policy *modules.VersioningPolicy) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if isVersionSiaPath(siaPath) {
+		return errVersioningVersionsFolder
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	if policy == nil {
+		delete(r.persist.VersioningPolicies, siaPath.String())
+		return r.saveSync()
+	}
+	if r.persist.VersioningPolicies == nil {
+		r.persist.VersioningPolicies = make(map[string]modules.VersioningPolicy)
+	}
+	r.persist.VersioningPolicies[siaPath.String()] = *policy
+	return r.saveSync()
+}
+
+// VersioningPolicies returns the versioning policies of all directories that
+// have versioning enabled.
+func (r *Renter) VersioningPolicies() map[modules.SiaPath]modules.VersioningPolicy {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	policies := make(map[modules.SiaPath]modules.VersioningPolicy, len(r.persist.VersioningPolicies))
+	for dir, policy := range r.persist.VersioningPolicies {
+		var siaPath modules.SiaPath
+		if err := siaPath.LoadString(dir); err != nil && dir != "" {
+			r.log.Printf("WARN: invalid siapath '%v' in versioning policies: %v", dir, err)
+			continue
+		}
+		policies[siaPath] = policy
+	}
+	return policies
+}
+
+// managedVersioningPolicy returns the policy of the closest directory of a
+// file that has versioning enabled.
+func (r *Renter) managedVersioningPolicy(siaPath modules.SiaPath) (modules.VersioningPolicy, bool) {
+	if isVersionSiaPath(siaPath) {
+		return modules.VersioningPolicy{}, false
+	}
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	if len(r.persist.VersioningPolicies) == 0 {
+		return modules.VersioningPolicy{}, false
+	}
+	for dir := siaPath; !dir.IsRoot(); {
+		var err error
+		dir, err = dir.Dir()
+		if err != nil {
+			return modules.VersioningPolicy{}, false
+		}
+		if policy, ok := r.persist.VersioningPolicies[dir.String()]; ok {
+			return policy, true
+		}
+	}
+	return modules.VersioningPolicy{}, false
+}
+
+// managedKeepVersion moves a file into the VersionsFolder if it is within a
+// directory that has versioning enabled. It returns whether the file was
+// moved.
+func (r *Renter) managedKeepVersion(siaPath modules.SiaPath) (bool, error) {
+	if _, ok := r.managedVersioningPolicy(siaPath); !ok {
+		return false, nil
+	}
+	err := r.managedMoveToVersions(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// managedMoveToVersions moves a file into the directory of its versions.
+func (r *Renter) managedMoveToVersions(siaPath modules.SiaPath) error {
+	versionDir, err := versionDirSiaPath(siaPath)
+	if err != nil {
+		return err
+	}
+	// Versions that are superseded within the same nanosecond get the next
+	// free ID.
+	nanos := time.Now().UnixNano()
+	for {
+		versionPath, err := versionDir.Join(strconv.FormatInt(nanos, 10))
+		if err != nil {
+			return err
+		}
+		err = r.staticFileSystem.RenameFile(siaPath, versionPath)
+		if errors.Contains(err, filesystem.ErrExists) {
+			nanos++
+			continue
+		}
+		if err != nil {
+			return errors.AddContext(err, "unable to move file to the versions folder")
+		}
+		break
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(versionDir)
+	return nil
+}
+
+// managedFileVersionSiaPath returns the siapath of a version of a file.
+func (r *Renter) managedFileVersionSiaPath(siaPath modules.SiaPath, id string) (modules.SiaPath, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return modules.SiaPath{}, errors.AddContext(errVersionNotFound, "invalid version id")
+	}
+	versionDir, err := versionDirSiaPath(siaPath)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	versionPath, err := versionDir.Join(id)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	exists, err := r.staticFileSystem.FileExists(versionPath)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if !exists {
+		return modules.SiaPath{}, errVersionNotFound
+	}
+	return versionPath, nil
+}
+
+// FileVersions returns the prior versions of a file, newest first.
+func (r *Renter) FileVersions(siaPath modules.SiaPath) ([]modules.FileVersion, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	versionDir, err := versionDirSiaPath(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	versions := []modules.FileVersion{}
+	err = r.staticFileSystem.CachedList(versionDir, false, func(fi modules.FileInfo) {
+		if v, ok := fileVersionFromInfo(fi); ok {
+			mu.Lock()
+			versions = append(versions, v)
+			mu.Unlock()
+		}
+	}, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sortFileVersions(versions)
+	return versions, nil
+}
+
+// RestoreFileVersion replaces a file with one of its prior versions. The
+// current file, if it exists, is kept as a version itself. The restored
+// version is removed from the versions of the file.
+func (r *Renter) RestoreFileVersion(siaPath modules.SiaPath, id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	versionPath, err := r.managedFileVersionSiaPath(siaPath, id)
+	if err != nil {
+		return err
+	}
+	err = r.managedMoveToVersions(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to keep the current version of the file")
+	}
+	return r.RenameFile(versionPath, siaPath)
+}
+
+// threadedPruneFileVersions periodically removes the file versions which are
+// no longer retained by the versioning policy of their directory.
+func (r *Renter) threadedPruneFileVersions() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(fileVersionPruneInterval):
+		}
+		if err := r.managedPruneFileVersions(time.Now()); err != nil {
+			r.log.Println("WARN: failed to prune file versions:", err)
+		}
+	}
+}
+
+// managedPruneFileVersions removes the file versions which are no longer
+// retained at the given time. Versions of files in directories which no
+// longer have versioning enabled are kept.
+func (r *Renter) managedPruneFileVersions(now time.Time) error {
+	id := r.mu.RLock()
+	numPolicies := len(r.persist.VersioningPolicies)
+	r.mu.RUnlock(id)
+	if numPolicies == 0 {
+		return nil
+	}
+
+	// Group the versions by the file they belong to.
+	var mu sync.Mutex
+	versions := make(map[modules.SiaPath][]modules.FileVersion)
+	err := r.staticFileSystem.CachedList(modules.VersionsFolder, true, func(fi modules.FileInfo) {
+		v, ok := fileVersionFromInfo(fi)
+		if !ok {
+			return
+		}
+		versionDir, err := fi.SiaPath.Dir()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		versions[versionDir] = append(versions[versionDir], v)
+		mu.Unlock()
+	}, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for versionDir, fileVersions := range versions {
+		siaPath, err := versionDir.Rebase(modules.VersionsFolder, modules.RootSiaPath())
+		if err != nil {
+			return err
+		}
+		policy, ok := r.managedVersioningPolicy(siaPath)
+		if !ok {
+			continue
+		}
+		sortFileVersions(fileVersions)
+		prune := versionsToPrune(fileVersions, policy, now)
+		for _, v := range prune {
+			dedupIDs, err := r.managedDedupChunkIDs(v.SiaPath)
+			if err != nil {
+				return errors.AddContext(err, "unable to get the deduplicated chunks of the file version")
+			}
+			if err := r.staticFileSystem.DeleteFile(v.SiaPath); err != nil {
+				return errors.AddContext(err, "unable to delete file version")
+			}
+			r.staticDedupIndex.managedRelease(dedupIDs)
+		}
+		if len(prune) > 0 {
+			_ = r.staticBubbleScheduler.callQueueBubble(versionDir)
+		}
+	}
+	return nil
+}
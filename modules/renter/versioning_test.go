@@ -0,0 +1,198 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// TestVersionsToPrune tests the retention of versions by policies.
+func TestVersionsToPrune(t *testing.T) {
+	now := time.Now()
+	versions := []modules.FileVersion{
+		{ID: "a", Superseded: now.Add(-time.Hour)},
+		{ID: "b", Superseded: now.Add(-36 * time.Hour)},
+		{ID: "c", Superseded: now.Add(-72 * time.Hour)},
+	}
+	ids := func(versions []modules.FileVersion) (ids string) {
+		for _, v := range versions {
+			ids += v.ID
+		}
+		return
+	}
+	tests := []struct {
+		policy modules.VersioningPolicy
+		pruned string
+	}{
+		{modules.VersioningPolicy{}, ""},
+		{modules.VersioningPolicy{KeepVersions: 5}, ""},
+		{modules.VersioningPolicy{KeepVersions: 2}, "c"},
+		{modules.VersioningPolicy{KeepVersions: 1}, "bc"},
+		{modules.VersioningPolicy{KeepDays: 2}, "c"},
+		{modules.VersioningPolicy{KeepDays: 1}, "bc"},
+		{modules.VersioningPolicy{KeepVersions: 2, KeepDays: 1}, "bc"},
+		{modules.VersioningPolicy{KeepVersions: 1, KeepDays: 7}, "bc"},
+	}
+	for _, test := range tests {
+		if pruned := ids(versionsToPrune(versions, test.policy, now)); pruned != test.pruned {
+			t.Errorf("policy %+v: expected %q to be pruned but got %q", test.policy, test.pruned, pruned)
+		}
+	}
+}
+
+// TestFileVersioning tests keeping, restoring and pruning versions of files.
+func TestFileVersioning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	dir, err := modules.UserFolder.Join("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := dir.Join("sub/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// createFile creates the file at siaPath.
+	createFile := func() {
+		_, rsc := testingFileParams()
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// numVersions returns the number of versions of the file.
+	numVersions := func() int {
+		versions, err := r.FileVersions(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(versions)
+	}
+
+	// Without versioning, deleted files are gone.
+	createFile()
+	if err := r.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if n := numVersions(); n != 0 {
+		t.Fatal("expected no versions but got", n)
+	}
+
+	// Versioning can't be enabled for the versions folder.
+	if err := r.SetVersioningPolicy(modules.VersionsFolder, &modules.VersioningPolicy{}); !errors.Contains(err, errVersioningVersionsFolder) {
+		t.Fatal("expected errVersioningVersionsFolder but got", err)
+	}
+
+	// Enable versioning and delete the file twice.
+	if err := r.SetVersioningPolicy(dir, &modules.VersioningPolicy{KeepVersions: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		createFile()
+		if err := r.DeleteFile(siaPath); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.File(siaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+			t.Fatal("file should have been deleted", err)
+		}
+	}
+	versions, err := r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || !versions[0].Superseded.After(versions[1].Superseded) {
+		t.Fatal("expected 2 versions, newest first", versions)
+	}
+	if !isVersionSiaPath(versions[0].SiaPath) {
+		t.Fatal("version should be in the versions folder", versions[0].SiaPath)
+	}
+
+	// Deleting a file that doesn't exist still fails.
+	dne, err := dir.Join("dne")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteFile(dne); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("expected ErrNotExist but got", err)
+	}
+
+	// Restore the older version. The current file is kept as a version.
+	createFile()
+	if err := r.RestoreFileVersion(siaPath, versions[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if n := numVersions(); n != 2 {
+		t.Fatal("expected 2 versions but got", n)
+	}
+	if err := r.RestoreFileVersion(siaPath, versions[1].ID); !errors.Contains(err, errVersionNotFound) {
+		t.Fatal("expected errVersionNotFound but got", err)
+	}
+
+	// Deleting the directory keeps versions of its files.
+	if err := r.DeleteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if n := numVersions(); n != 3 {
+		t.Fatal("expected 3 versions but got", n)
+	}
+
+	// The policy is persisted.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy, ok := r.VersioningPolicies()[dir]; !ok || policy.KeepVersions != 3 {
+		t.Fatal("policy wasn't persisted", r.VersioningPolicies())
+	}
+
+	// Prune the versions.
+	if err := r.SetVersioningPolicy(dir, &modules.VersioningPolicy{KeepVersions: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedPruneFileVersions(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if n := numVersions(); n != 1 {
+		t.Fatal("expected 1 version but got", n)
+	}
+	if err := r.SetVersioningPolicy(dir, &modules.VersioningPolicy{KeepDays: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedPruneFileVersions(time.Now().Add(48 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if n := numVersions(); n != 0 {
+		t.Fatal("expected no versions but got", n)
+	}
+
+	// Disable versioning.
+	if err := r.SetVersioningPolicy(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.VersioningPolicies()) != 0 {
+		t.Fatal("versioning should be disabled", r.VersioningPolicies())
+	}
+}
@@ -88,6 +88,10 @@ type (
 		staticJobUpdateRegistryQueue   *jobUpdateRegistryQueue
 		staticJobUploadSnapshotQueue   *jobUploadSnapshotQueue
 
+		// staticDownloadClasses limits and tracks the pieces the worker
+		// downloads by priority class.
+		staticDownloadClasses workerDownloadClasses
+
 		// Upload variables.
 		unprocessedChunks         *uploadChunks // Yet unprocessed work items.
 		uploadConsecutiveFailures int           // How many times in a row uploading has failed.
@@ -251,6 +255,8 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 			atomicWriteDataLimit: initialConcurrentAsyncWriteData,
 		},
 
+		staticDownloadClasses: newWorkerDownloadClasses(),
+
 		unprocessedChunks: newUploadChunks(),
 		wakeChan:          make(chan struct{}, 1),
 		renter:            r,
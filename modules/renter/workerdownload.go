@@ -129,11 +129,26 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 		return
 	}
 
+	// Wait until the worker may download another piece of the chunk's
+	// priority class.
+	class := w.staticDownloadClass(udc)
+	if !class.callAcquire(udc.download.completeChan, w.staticTG.StopChan()) {
+		udc.managedUnregisterWorker(w)
+		return
+	}
+
 	// Fetch the sector. If fetching the sector fails, the worker needs to be
-	// unregistered with the chunk.
+	// unregistered with the chunk. Streams are fetched through the regular
+	// read queue, all other downloads through the low priority one.
 	fetchOffset, fetchLength := sectorOffsetAndLength(udc.staticFetchOffset, udc.staticFetchLength, udc.erasureCode)
 	root := udc.staticChunkMap[w.staticHostPubKey.String()].root
-	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	var pieceData []byte
+	if udc.staticPriorityClass == modules.DownloadPriorityStream {
+		pieceData, err = w.ReadSector(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	} else {
+		pieceData, err = w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	}
+	class.callRelease(uint64(len(pieceData)))
 	if err != nil {
 		w.renter.log.Debugln("worker failed to download sector:", err)
 		udc.managedUnregisterWorker(w)
@@ -151,7 +166,7 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 	// a large overdrive. It shouldn't be a bottleneck though since bandwidth
 	// is usually a lot more scarce than CPU processing power.
 	pieceIndex := udc.staticChunkMap[w.staticHostPubKey.String()].index
-	key := udc.masterKey.Derive(udc.staticChunkIndex, pieceIndex)
+	key := udc.renterFile.PieceKey(udc.staticChunkIndex, pieceIndex)
 	decryptedPiece, err := key.DecryptBytesInPlace(pieceData, uint64(fetchOffset/crypto.SegmentSize))
 	if err != nil {
 		w.renter.log.Debugln("worker failed to decrypt piece:", err)
@@ -209,7 +224,8 @@ func (udc *unfinishedDownloadChunk) managedUnregisterWorker(w *worker) {
 //
 // If no immediate action is required, 'nil' will be returned.
 func (w *worker) managedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfinishedDownloadChunk {
-	onCooldown := w.staticJobLowPrioReadQueue.callOnCooldown()
+	queue := w.staticDownloadReadQueue(udc)
+	onCooldown := queue.callOnCooldown()
 
 	// Determine whether the worker needs to drop the chunk. If so, remove the
 	// worker and return nil. Worker only needs to be removed if worker is being
@@ -225,7 +241,7 @@ func (w *worker) managedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfi
 
 		// Extra check - if a worker is unusable, drop all the queued jobs.
 		if onCooldown {
-			w.staticJobLowPrioReadQueue.callDiscardAll(errors.New("managedProcessDownloadChunk: worker on cooldown, discard all jobs"))
+			queue.callDiscardAll(errors.New("managedProcessDownloadChunk: worker on cooldown, discard all jobs"))
 		}
 		return nil
 	}
@@ -68,7 +68,7 @@ func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, resp
 			staticResponseChan: respChan,
 			staticLength:       length,
 
-			jobGeneric: newJobGeneric(ctx, w.staticJobReadQueue, jobReadMetadata{
+			jobGeneric: newJobGeneric(ctx, queue, jobReadMetadata{
 				staticSectorRoot:       root,
 				staticSpendingCategory: category,
 				staticWorker:           w,
@@ -79,14 +79,14 @@ func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, resp
 	}
 }
 
-// ReadSector is a helper method to run a ReadSector job with low priority on a
-// worker.
+// ReadSectorLowPrio is a helper method to run a ReadSector job with low
+// priority on a worker.
 func (w *worker) ReadSectorLowPrio(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64) ([]byte, error) {
 	readSectorRespChan := make(chan *jobReadResponse)
 	jro := w.newJobReadSector(ctx, w.staticJobLowPrioReadQueue, readSectorRespChan, category, root, offset, length)
 
 	// Add the job to the queue.
-	if !w.staticJobReadQueue.callAdd(jro) {
+	if !w.staticJobLowPrioReadQueue.callAdd(jro) {
 		return nil, errors.New("worker unavailable")
 	}
 
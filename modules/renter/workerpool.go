@@ -41,6 +41,7 @@ func (wp *workerPool) callStatus() modules.WorkerPoolStatus {
 
 	var totalDownloadCoolDown, totalMaintenanceCoolDown, totalUploadCoolDown int
 	var statuss []modules.WorkerStatus // Plural of status is statuss, deal with it.
+	downloadClasses := workerDownloadClasses(nil).callStatus()
 	workers := wp.callWorkers()
 
 	// Loop all workers and collect their status objects.
@@ -55,6 +56,7 @@ func (wp *workerPool) callStatus() modules.WorkerPoolStatus {
 		if status.UploadOnCoolDown {
 			totalUploadCoolDown++
 		}
+		addDownloadClassStatuses(downloadClasses, status.DownloadClasses)
 		statuss = append(statuss, status)
 	}
 	return modules.WorkerPoolStatus{
@@ -63,6 +65,7 @@ func (wp *workerPool) callStatus() modules.WorkerPoolStatus {
 		TotalMaintenanceCoolDown: totalMaintenanceCoolDown,
 		TotalUploadCoolDown:      totalUploadCoolDown,
 		Workers:                  statuss,
+		DownloadClasses:          downloadClasses,
 	}
 }
 
@@ -172,8 +172,13 @@ func (w *worker) staticNewStream() (siamux.Stream, error) {
 		return nil, errors.New("InterruptNewStreamTimeout")
 	}
 
-	// Create a stream with a reasonable dial up timeout.
-	stream, err := w.renter.staticMux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, w.staticCache().staticHostMuxAddress, timeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
+	// Create a stream with a reasonable dial up timeout. If a proxy is set,
+	// the SiaMux dials the host through a local tunnel.
+	muxAddr, err := modules.GlobalProxy.TunnelAddress(w.staticCache().staticHostMuxAddress)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := w.renter.staticMux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, muxAddr, timeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
 	if err != nil {
 		return nil, err
 	}
@@ -52,6 +52,9 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		DownloadQueueSize:     downloadQueueSize,
 		DownloadTerminated:    downloadTerminated,
 
+		// Download priority class information
+		DownloadClasses: w.staticDownloadClasses.callStatus(),
+
 		// Upload information
 		UploadCoolDownError: uploadCoolDownErr,
 		UploadCoolDownTime:  uploadCoolDownTime,
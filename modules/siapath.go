@@ -55,6 +55,15 @@ var (
 
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
+
+	// VersionsFolder is the Sia folder where the renter keeps prior versions
+	// of overwritten and deleted files within directories that have
+	// versioning enabled.
+	VersionsFolder = NewGlobalSiaPath("/versions")
+
+	// TrashFolder is the Sia folder where the renter keeps deleted files and
+	// directories until they are restored or purged.
+	TrashFolder = NewGlobalSiaPath("/trash")
 )
 
 type (
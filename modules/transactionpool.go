@@ -68,8 +68,10 @@ type (
 	TransactionSetID crypto.Hash
 
 	// A TransactionPoolDiff indicates the adding or removal of a transaction set to
-	// the transaction pool. The transactions in the pool are not persisted, so at
-	// startup modules should assume an empty transaction pool.
+	// the transaction pool. Unconfirmed transactions that were persisted during a
+	// previous run are only restored once the pool has caught up with the
+	// consensus set, so at startup modules should assume an empty transaction
+	// pool.
 	TransactionPoolDiff struct {
 		AppliedTransactions  []*UnconfirmedTransactionSet
 		RevertedTransactions []TransactionSetID
@@ -123,6 +125,14 @@ type (
 		// within 10 blocks.
 		FeeEstimation() (minimumRecommended, maximumRecommended types.Currency)
 
+		// FeeEstimationForTarget returns an estimation for how high the
+		// transaction fee needs to be per byte for a transaction to get
+		// confirmed within the target number of blocks. The estimation is based
+		// on the fee rates and confirmation delays of transactions that were
+		// recently confirmed from the pool. If there is not enough history,
+		// the maximum recommended fee of FeeEstimation is returned.
+		FeeEstimationForTarget(target types.BlockHeight) types.Currency
+
 		// PurgeTransactionPool is a temporary function available to the miner. In
 		// the event that a miner mines an unacceptable block, the transaction pool
 		// will be purged to clear out the transaction pool and get rid of the
@@ -76,13 +76,28 @@ func requiredFeesToExtendTpoolAtSize(size int) types.Currency {
 // transaction pool to fit another transaction set. The amount returned has the
 // unit 'currency per byte'.
 func (tp *TransactionPool) requiredFeesToExtendTpool() types.Currency {
+	return tp.requiredFeesToReplaceSets(nil)
+}
+
+// requiredFeesToReplaceSets returns the amount of fees per byte required to
+// replace the provided transaction sets with a superset of them. The replaced
+// sets are removed from the pool when the superset is accepted, so the fee
+// rate is computed at the size of the pool without them. This way a package
+// is only charged for the space it actually adds to the pool, which allows a
+// child with a high fee to pay for a parent set that has become stuck with a
+// fee below the current requirement.
+func (tp *TransactionPool) requiredFeesToReplaceSets(sets map[modules.TransactionSetID]struct{}) types.Currency {
+	size := tp.transactionListSize
+	for setID := range sets {
+		size -= len(encoding.Marshal(tp.transactionSets[setID]))
+	}
+
 	// If the transaction pool is nearly empty, it can be extended even if there
 	// are no fees.
-	if tp.transactionListSize < TransactionPoolSizeForFee {
+	if size < TransactionPoolSizeForFee {
 		return types.ZeroCurrency
 	}
-
-	return requiredFeesToExtendTpoolAtSize(tp.transactionListSize)
+	return requiredFeesToExtendTpoolAtSize(size)
 }
 
 // checkTransactionSetComposition checks if the transaction set is valid given
@@ -182,8 +197,11 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 	}
 
 	// Check that the transaction set has enough fees to justify adding it to
-	// the transaction list.
-	requiredFees := tp.requiredFeesToExtendTpool().Mul64(setSize)
+	// the transaction list. The superset replaces the conflicting sets, so its
+	// fees are evaluated as a package: the combined fees of the parents and
+	// the new children need to cover the fee rate for the combined size.
+	feePerByte := tp.requiredFeesToReplaceSets(supersetMap)
+	requiredFees := feePerByte.Mul64(setSize)
 	var setFees types.Currency
 	for _, txn := range superset {
 		for _, fee := range txn.MinerFees {
@@ -193,7 +211,8 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 	if requiredFees.Cmp(setFees) > 0 {
 		// TODO: check if there is an existing set with lower fees that we can
 		// kick out.
-		return nil, errLowMinerFees
+		packageFeePerByte := setFees.Div64(setSize)
+		return nil, errors.AddContext(errLowMinerFees, fmt.Sprintf("package pays %v per byte but %v per byte is required", packageFeePerByte.HumanString(), feePerByte.HumanString()))
 	}
 
 	// Check that the transaction set is valid.
@@ -826,3 +826,32 @@ func TestPartialConfirmationWeave(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestRequiredFeesToReplaceSets checks that the fee rate required for a
+// package is computed at the size of the pool without the sets it replaces.
+func TestRequiredFeesToReplaceSets(t *testing.T) {
+	tp := &TransactionPool{
+		transactionSets: make(map[modules.TransactionSetID][]types.Transaction),
+	}
+
+	// Add a large set to the pool.
+	setID := modules.TransactionSetID{1}
+	set := []types.Transaction{{ArbitraryData: [][]byte{make([]byte, TransactionPoolSizeForFee)}}}
+	tp.transactionSets[setID] = set
+	tp.transactionListSize = TransactionPoolSizeForFee * 2
+
+	// Extending the pool requires fees.
+	extendFees := tp.requiredFeesToExtendTpool()
+	if extendFees.IsZero() {
+		t.Fatal("expected fees to be required to extend the pool")
+	}
+	if !tp.requiredFeesToReplaceSets(nil).Equals(extendFees) {
+		t.Fatal("replacing no sets should cost the same as extending the pool")
+	}
+
+	// Replacing the large set drops the pool below the size for fees.
+	replaceFees := tp.requiredFeesToReplaceSets(map[modules.TransactionSetID]struct{}{setID: {}})
+	if !replaceFees.IsZero() {
+		t.Fatal("expected no fees to be required to replace the set, got", replaceFees)
+	}
+}
@@ -59,6 +59,17 @@ const (
 	// added to the current tpool size when estimating a good fee rate for new
 	// transactions.
 	feeEstimationProportionalPadding = 1.25
+
+	// confirmationTargetSuccessRate is the fraction of the recorded
+	// transactions paying at least the estimated fee rate that need to have
+	// been confirmed within the target number of blocks for the estimate to be
+	// considered safe.
+	confirmationTargetSuccessRate = 0.85
+
+	// maxConfirmationSamples is the maximum number of confirmation samples
+	// kept by the transaction pool. If there are more samples, the oldest
+	// ones are dropped.
+	maxConfirmationSamples = 5000
 )
 
 // Variables related to the persisting structures of the transaction pool.
@@ -76,6 +87,28 @@ var (
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
 )
 
+// Variables related to historical fee estimation.
+var (
+	// confirmationSampleDepth defines how far backwards in the blockchain the
+	// transaction pool keeps samples of the fee rates and confirmation delays
+	// of the transactions it has seen.
+	confirmationSampleDepth = build.Select(build.Var{
+		Standard: types.BlockHeight(1008),
+		Testnet:  types.BlockHeight(1008),
+		Dev:      types.BlockHeight(144),
+		Testing:  types.BlockHeight(50),
+	}).(types.BlockHeight)
+
+	// minConfirmationSamples is the minimum number of confirmation samples
+	// that are required to estimate the fee for a confirmation target.
+	minConfirmationSamples = build.Select(build.Var{
+		Standard: 100,
+		Testnet:  100,
+		Dev:      20,
+		Testing:  5,
+	}).(int)
+)
+
 // Variables related to propagating transactions through the network.
 var (
 	// relayTransactionSetTimeout establishes the timeout for a relay
@@ -33,6 +33,11 @@ var (
 	// bucketRecentConsensusChange holds the most recent consensus change seen
 	// by the transaction pool.
 	bucketRecentConsensusChange = []byte("RecentConsensusChange")
+
+	// bucketUnconfirmedSets holds a snapshot of the unconfirmed transaction
+	// sets in the pool, keyed by transaction set id, so that they can be
+	// restored after a restart.
+	bucketUnconfirmedSets = []byte("UnconfirmedSets")
 )
 
 // Explicitly named fields in the database.
@@ -74,8 +79,28 @@ type (
 	// medianPersist is the json object that gets stored in the database so that
 	// the transaction pool can persist its block based fee estimations.
 	medianPersist struct {
-		RecentMedians   []types.Currency
-		RecentMedianFee types.Currency
+		RecentMedians       []types.Currency
+		RecentMedianFee     types.Currency
+		ConfirmationSamples []confirmationSample
+	}
+
+	// confirmationSample records the fee rate paid by a transaction that was
+	// seen in the transaction pool, and how many blocks it took for the
+	// transaction to get confirmed. Height is the height of the block that
+	// confirmed the transaction.
+	confirmationSample struct {
+		FeePerByte types.Currency
+		Delay      types.BlockHeight
+		Height     types.BlockHeight
+	}
+
+	// persistedSet is an unconfirmed transaction set as it is stored in the
+	// database. Heights holds the height at which each transaction of the set
+	// was first seen by the pool, and is used to expire the set once all of
+	// its transactions have reached the MaxTransactionAge.
+	persistedSet struct {
+		Transactions []types.Transaction
+		Heights      []types.BlockHeight
 	}
 )
 
@@ -106,6 +131,24 @@ func (tp *TransactionPool) getFeeMedian(tx *bolt.Tx) (medianPersist, error) {
 	return mp, nil
 }
 
+// getUnconfirmedSets returns all of the unconfirmed transaction sets stored in
+// the database.
+func (tp *TransactionPool) getUnconfirmedSets(tx *bolt.Tx) ([]persistedSet, error) {
+	var sets []persistedSet
+	err := tx.Bucket(bucketUnconfirmedSets).ForEach(func(_, v []byte) error {
+		var ps persistedSet
+		if err := encoding.Unmarshal(v, &ps); err != nil {
+			return err
+		}
+		sets = append(sets, ps)
+		return nil
+	})
+	if err != nil {
+		return nil, build.ExtendErr("unable to unmarshal unconfirmed transaction sets:", err)
+	}
+	return sets, nil
+}
+
 // getRecentBlockID will fetch the most recent block id and most recent parent
 // id from the database.
 func (tp *TransactionPool) getRecentBlockID(tx *bolt.Tx) (recentID types.BlockID, err error) {
@@ -158,6 +201,33 @@ func (tp *TransactionPool) putRecentConsensusChange(tx *bolt.Tx, cc modules.Cons
 	return tx.Bucket(bucketRecentConsensusChange).Put(fieldRecentConsensusChange, cc[:])
 }
 
+// putUnconfirmedSets replaces the unconfirmed transaction sets stored in the
+// database with the current contents of the transaction pool.
+func (tp *TransactionPool) putUnconfirmedSets(tx *bolt.Tx) error {
+	err := tx.DeleteBucket(bucketUnconfirmedSets)
+	if err != nil {
+		return err
+	}
+	b, err := tx.CreateBucket(bucketUnconfirmedSets)
+	if err != nil {
+		return err
+	}
+	for setID, set := range tp.transactionSets {
+		ps := persistedSet{
+			Transactions: set,
+			Heights:      make([]types.BlockHeight, len(set)),
+		}
+		for i, txn := range set {
+			ps.Heights[i] = tp.transactionHeights[txn.ID()]
+		}
+		err = b.Put(setID[:], encoding.Marshal(ps))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // putTransaction adds a transaction to the list of confirmed transactions.
 func (tp *TransactionPool) putTransaction(tx *bolt.Tx, id types.TransactionID) error {
 	return tx.Bucket(bucketConfirmedTransactions).Put(id[:], []byte{})
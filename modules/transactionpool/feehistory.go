@@ -0,0 +1,126 @@
+package transactionpool
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// estimateFeeForTarget uses the provided confirmation samples to find the
+// lowest fee rate for which enough of the transactions paying at least that
+// rate were confirmed within target blocks. False is returned if there are
+// not enough samples to make an estimation.
+//
+// The samples are walked from the highest to the lowest fee rate. Once enough
+// samples have been collected, the fee rate is accepted as long as the
+// fraction of the collected samples that got confirmed within the target stays
+// above confirmationTargetSuccessRate. The walk stops at the first fee rate
+// that fails the check, lower fee rates are not considered safe even if the
+// fraction recovers.
+func estimateFeeForTarget(samples []confirmationSample, target types.BlockHeight) (types.Currency, bool) {
+	if target == 0 || len(samples) < minConfirmationSamples {
+		return types.ZeroCurrency, false
+	}
+
+	// Copy the samples so the sorting doesn't change the order of the
+	// original slice.
+	sorted := make([]confirmationSample, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FeePerByte.Cmp(sorted[j].FeePerByte) > 0
+	})
+
+	var fee types.Currency
+	var found bool
+	var total, withinTarget int
+	for i, sample := range sorted {
+		total++
+		if sample.Delay <= target {
+			withinTarget++
+		}
+		// Only evaluate the fee rate once all of the samples paying the same
+		// rate have been collected.
+		if i+1 < len(sorted) && sorted[i+1].FeePerByte.Equals(sample.FeePerByte) {
+			continue
+		}
+		if total < minConfirmationSamples {
+			continue
+		}
+		if float64(withinTarget) < confirmationTargetSuccessRate*float64(total) {
+			break
+		}
+		fee = sample.FeePerByte
+		found = true
+	}
+	return fee, found
+}
+
+// updateConfirmationSamples records a confirmation sample for every
+// transaction of the pool that got confirmed by the applied blocks of cc.
+// Samples of reverted blocks and samples that are too old are dropped. Must be
+// called before the pool is purged for the consensus change.
+func (tp *TransactionPool) updateConfirmationSamples(cc modules.ConsensusChange) {
+	// Drop the samples of the reverted blocks. The heights above the height
+	// the chain was reverted to are not part of the blockchain anymore.
+	if len(cc.RevertedBlocks) > 0 {
+		forkHeight := cc.BlockHeight - types.BlockHeight(len(cc.AppliedBlocks))
+		samples := tp.confirmationSamples[:0]
+		for _, sample := range tp.confirmationSamples {
+			if sample.Height <= forkHeight {
+				samples = append(samples, sample)
+			}
+		}
+		tp.confirmationSamples = samples
+	}
+
+	// Determine the height at which each of the transactions of the applied
+	// blocks got confirmed.
+	confirmedAt := make(map[types.TransactionID]types.BlockHeight)
+	for i, block := range cc.AppliedBlocks {
+		height := cc.BlockHeight - types.BlockHeight(len(cc.AppliedBlocks)-1-i)
+		for _, txn := range block.Transactions {
+			confirmedAt[txn.ID()] = height
+		}
+	}
+
+	// Record a sample for every confirmed transaction that has been seen by
+	// the pool. All transactions of a set use the fee rate of the set, since
+	// that is what they have been competing with for block space.
+	var newSamples []confirmationSample
+	for _, tSet := range tp.transactionSets {
+		var feePerByte types.Currency
+		var feeComputed bool
+		for _, txn := range tSet {
+			height, confirmed := confirmedAt[txn.ID()]
+			seenHeight, seen := tp.transactionHeights[txn.ID()]
+			if !confirmed || !seen || height <= seenHeight {
+				continue
+			}
+			if !feeComputed {
+				feePerByte = modules.CalculateFee(tSet)
+				feeComputed = true
+			}
+			newSamples = append(newSamples, confirmationSample{
+				FeePerByte: feePerByte,
+				Delay:      height - seenHeight,
+				Height:     height,
+			})
+		}
+	}
+	sort.SliceStable(newSamples, func(i, j int) bool {
+		return newSamples[i].Height < newSamples[j].Height
+	})
+	tp.confirmationSamples = append(tp.confirmationSamples, newSamples...)
+
+	// Drop the samples that are older than the sample depth, and then the
+	// oldest samples until there are at most maxConfirmationSamples left.
+	var drop int
+	for drop < len(tp.confirmationSamples) && tp.confirmationSamples[drop].Height+confirmationSampleDepth <= cc.BlockHeight {
+		drop++
+	}
+	if len(tp.confirmationSamples)-drop > maxConfirmationSamples {
+		drop = len(tp.confirmationSamples) - maxConfirmationSamples
+	}
+	tp.confirmationSamples = tp.confirmationSamples[drop:]
+}
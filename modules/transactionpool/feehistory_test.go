@@ -0,0 +1,174 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestEstimateFeeForTarget probes the estimateFeeForTarget function.
+func TestEstimateFeeForTarget(t *testing.T) {
+	// Create samples where transactions paying 10 or more per byte got
+	// confirmed within 1 block and all cheaper transactions took 5 blocks.
+	// There are more cheap transactions than expensive ones.
+	var samples []confirmationSample
+	for fee := uint64(1); fee <= 20; fee++ {
+		delay := types.BlockHeight(1)
+		n := minConfirmationSamples
+		if fee < 10 {
+			delay = 5
+			n *= 3
+		}
+		for i := 0; i < n; i++ {
+			samples = append(samples, confirmationSample{
+				FeePerByte: types.NewCurrency64(fee),
+				Delay:      delay,
+			})
+		}
+	}
+
+	tests := []struct {
+		target types.BlockHeight
+		fee    uint64
+	}{
+		{1, 10},
+		{4, 10},
+		{5, 1},
+		{10, 1},
+	}
+	for _, test := range tests {
+		fee, ok := estimateFeeForTarget(samples, test.target)
+		if !ok {
+			t.Fatal("expected an estimation for target", test.target)
+		}
+		if !fee.Equals64(test.fee) {
+			t.Errorf("target %v: expected fee %v, got %v", test.target, test.fee, fee)
+		}
+	}
+
+	// A target of zero blocks can't be estimated.
+	if _, ok := estimateFeeForTarget(samples, 0); ok {
+		t.Error("expected no estimation for a target of 0")
+	}
+	// Not enough samples.
+	if _, ok := estimateFeeForTarget(samples[:minConfirmationSamples-1], 1); ok {
+		t.Error("expected no estimation with too few samples")
+	}
+	// If none of the samples got confirmed within the target there is no
+	// estimation.
+	if _, ok := estimateFeeForTarget(samples[:27*minConfirmationSamples], 1); ok {
+		t.Error("expected no estimation if no sample got confirmed within the target")
+	}
+}
+
+// TestUpdateConfirmationSamples checks that samples are recorded for the
+// confirmed transactions of the pool and dropped if their blocks get
+// reverted.
+func TestUpdateConfirmationSamples(t *testing.T) {
+	tp := &TransactionPool{
+		transactionHeights: make(map[types.TransactionID]types.BlockHeight),
+		transactionSets:    make(map[modules.TransactionSetID][]types.Transaction),
+	}
+
+	// Add two sets to the pool, one seen at height 10 and one seen at height
+	// 11.
+	txn1 := types.Transaction{MinerFees: []types.Currency{types.NewCurrency64(1000)}, ArbitraryData: [][]byte{{1}}}
+	txn2 := types.Transaction{MinerFees: []types.Currency{types.NewCurrency64(2000)}, ArbitraryData: [][]byte{{2}}}
+	tp.transactionSets[modules.TransactionSetID{1}] = []types.Transaction{txn1}
+	tp.transactionSets[modules.TransactionSetID{2}] = []types.Transaction{txn2}
+	tp.transactionHeights[txn1.ID()] = 10
+	tp.transactionHeights[txn2.ID()] = 11
+
+	// Apply blocks 12 and 13 which confirm the transactions.
+	cc := modules.ConsensusChange{
+		AppliedBlocks: []types.Block{
+			{Transactions: []types.Transaction{txn1}},
+			{Transactions: []types.Transaction{txn2}},
+		},
+		BlockHeight: 13,
+	}
+	tp.updateConfirmationSamples(cc)
+	if len(tp.confirmationSamples) != 2 {
+		t.Fatal("expected 2 samples, got", len(tp.confirmationSamples))
+	}
+	s1, s2 := tp.confirmationSamples[0], tp.confirmationSamples[1]
+	if s1.Height != 12 || s1.Delay != 2 || !s1.FeePerByte.Equals(modules.CalculateFee([]types.Transaction{txn1})) {
+		t.Fatal("wrong first sample", s1)
+	}
+	if s2.Height != 13 || s2.Delay != 2 || !s2.FeePerByte.Equals(modules.CalculateFee([]types.Transaction{txn2})) {
+		t.Fatal("wrong second sample", s2)
+	}
+
+	// Revert block 13 and apply an empty block. The sample of block 13 should
+	// be dropped.
+	tp.transactionSets = make(map[modules.TransactionSetID][]types.Transaction)
+	cc = modules.ConsensusChange{
+		RevertedBlocks: []types.Block{cc.AppliedBlocks[1]},
+		AppliedBlocks:  []types.Block{{}},
+		BlockHeight:    13,
+	}
+	tp.updateConfirmationSamples(cc)
+	if len(tp.confirmationSamples) != 1 || tp.confirmationSamples[0].Height != s1.Height {
+		t.Fatal("expected only the first sample to remain", tp.confirmationSamples)
+	}
+
+	// Apply enough blocks for the remaining sample to become too old.
+	cc = modules.ConsensusChange{
+		AppliedBlocks: make([]types.Block, confirmationSampleDepth),
+		BlockHeight:   13 + confirmationSampleDepth,
+	}
+	tp.updateConfirmationSamples(cc)
+	if len(tp.confirmationSamples) != 0 {
+		t.Fatal("expected old samples to be dropped", tp.confirmationSamples)
+	}
+}
+
+// TestFeeEstimationForTarget checks that the transaction pool records samples
+// for confirmed transactions and uses them to estimate fees.
+func TestFeeEstimationForTarget(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without any history the maximum estimation is used.
+	min, max := tpt.tpool.FeeEstimation()
+	if fee := tpt.tpool.FeeEstimationForTarget(1); !fee.Equals(max) {
+		t.Fatalf("expected %v without history, got %v", max, fee)
+	}
+
+	// Send enough transactions to collect the minimum number of samples and
+	// confirm them.
+	for i := 0; i < minConfirmationSamples; i++ {
+		_, err := tpt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool.mu.Lock()
+	numSamples := len(tpt.tpool.confirmationSamples)
+	tpt.tpool.mu.Unlock()
+	if numSamples < minConfirmationSamples {
+		t.Fatalf("expected at least %v samples, got %v", minConfirmationSamples, numSamples)
+	}
+
+	// The estimation should be within the bounds of FeeEstimation.
+	min, max = tpt.tpool.FeeEstimation()
+	fee := tpt.tpool.FeeEstimationForTarget(1)
+	if fee.Cmp(min) < 0 || fee.Cmp(max) > 0 {
+		t.Fatalf("estimation %v is not within [%v, %v]", fee, min, max)
+	}
+}
@@ -42,8 +42,13 @@ func (tp *TransactionPool) threadedRegularSync() {
 // syncDB commits the current global transaction and immediately begins a new
 // one.
 func (tp *TransactionPool) syncDB() {
+	// Snapshot the unconfirmed transaction sets so they survive a restart.
+	err := tp.putUnconfirmedSets(tp.dbTx)
+	if err != nil {
+		tp.log.Println("ERROR: failed to persist the unconfirmed transaction sets:", err)
+	}
 	// Commit the existing tx.
-	err := tp.dbTx.Commit()
+	err = tp.dbTx.Commit()
 	if err != nil {
 		tp.log.Severe("ERROR: failed to apply database update:", err)
 		tp.dbTx.Rollback()
@@ -115,7 +120,11 @@ func (tp *TransactionPool) initPersist() error {
 	}
 	tp.tg.AfterStop(func() {
 		tp.mu.Lock()
-		err := tp.dbTx.Commit()
+		err := tp.putUnconfirmedSets(tp.dbTx)
+		if err != nil {
+			tp.log.Println("Unable to persist the unconfirmed transaction sets during shutdown:", err)
+		}
+		err = tp.dbTx.Commit()
 		tp.mu.Unlock()
 		if err != nil {
 			tp.log.Println("Unable to close transaction properly during shutdown:", err)
@@ -132,6 +141,7 @@ func (tp *TransactionPool) initPersist() error {
 		bucketRecentConsensusChange,
 		bucketConfirmedTransactions,
 		bucketFeeMedian,
+		bucketUnconfirmedSets,
 	}
 	for _, bucket := range buckets {
 		_, err := tp.dbTx.CreateBucketIfNotExists(bucket)
@@ -176,6 +186,16 @@ func (tp *TransactionPool) initPersist() error {
 	if !errors.Contains(err, errNilFeeMedian) {
 		tp.recentMedians = mp.RecentMedians
 		tp.recentMedianFee = mp.RecentMedianFee
+		tp.confirmationSamples = mp.ConfirmationSamples
+	}
+
+	// Load the unconfirmed transaction sets from the previous run. They are
+	// revalidated once the transaction pool has caught up with the consensus
+	// set.
+	unconfirmedSets, err := tp.getUnconfirmedSets(tp.dbTx)
+	if err != nil {
+		tp.log.Println("Unable to load the unconfirmed transaction sets, they will not be restored:", err)
+		unconfirmedSets = nil
 	}
 
 	// Subscribe to the consensus set using the most recent consensus change.
@@ -206,12 +226,14 @@ func (tp *TransactionPool) initPersist() error {
 			tp.tg.OnStop(func() {
 				tp.consensusSet.Unsubscribe(tp)
 			})
+			tp.managedRestoreUnconfirmedSets(unconfirmedSets)
 			return
 		}
 		if err != nil {
 			tp.log.Critical(err)
 			return
 		}
+		tp.managedRestoreUnconfirmedSets(unconfirmedSets)
 	}()
 	tp.tg.OnStop(func() {
 		tp.consensusSet.Unsubscribe(tp)
@@ -219,6 +241,63 @@ func (tp *TransactionPool) initPersist() error {
 	return nil
 }
 
+// persistedSetExpired returns true if every transaction of the persisted set
+// has reached the MaxTransactionAge at the provided height.
+func persistedSetExpired(ps persistedSet, height types.BlockHeight) bool {
+	if len(ps.Heights) != len(ps.Transactions) {
+		return true
+	}
+	for _, seenHeight := range ps.Heights {
+		if seenHeight > height || height-seenHeight < MaxTransactionAge {
+			return false
+		}
+	}
+	return true
+}
+
+// managedRestoreUnconfirmedSets revalidates the unconfirmed transaction sets
+// that were persisted during the previous run against the current consensus
+// set. Sets that are still valid are added back to the pool with their
+// original heights and relayed to peers. Sets that expired while siad was
+// offline are dropped.
+func (tp *TransactionPool) managedRestoreUnconfirmedSets(sets []persistedSet) {
+	if err := tp.tg.Add(); err != nil {
+		return
+	}
+	defer tp.tg.Done()
+
+	tp.mu.RLock()
+	height := tp.blockHeight
+	tp.mu.RUnlock()
+
+	var restored int
+	for _, ps := range sets {
+		if persistedSetExpired(ps, height) {
+			continue
+		}
+		minSuperSet, err := tp.submitTransactionSet(ps.Transactions)
+		if err != nil {
+			tp.log.Debugln("Unable to restore unconfirmed transaction set:", err)
+			continue
+		}
+		// submitTransactionSet records the current height for every
+		// transaction. Reset the heights so that the set still expires on
+		// schedule.
+		tp.mu.Lock()
+		for i, txn := range ps.Transactions {
+			if _, exists := tp.transactionHeights[txn.ID()]; exists {
+				tp.transactionHeights[txn.ID()] = ps.Heights[i]
+			}
+		}
+		tp.mu.Unlock()
+		tp.Broadcast(minSuperSet)
+		restored++
+	}
+	if len(sets) > 0 {
+		tp.log.Printf("Restored %v of %v unconfirmed transaction sets from the previous run", restored, len(sets))
+	}
+}
+
 // TransactionConfirmed returns true if the transaction has been seen on the
 // blockchain. Note, however, that the block containing the transaction may
 // later be invalidated by a reorg.
@@ -163,3 +163,119 @@ func TestRescan(t *testing.T) {
 		t.Fatal("expecting modules.ErrDuplicateTransactionSet, got:", err)
 	}
 }
+
+// TestPersistUnconfirmedSets checks that unconfirmed transaction sets survive
+// a restart of the transaction pool and keep the height at which they were
+// first seen.
+func TestPersistUnconfirmedSets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create an unconfirmed transaction set using the wallet.
+	txns, err := tpt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnID := txns[len(txns)-1].ID()
+	tpt.tpool.mu.Lock()
+	seenHeight, exists := tpt.tpool.transactionHeights[txnID]
+	tpt.tpool.mu.Unlock()
+	if !exists {
+		t.Fatal("transaction height was not recorded")
+	}
+
+	// Restart the tpool. The transaction should be restored.
+	persistDir := tpt.tpool.persistDir
+	err = tpt.tpool.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		_, _, exists := tpt.tpool.Transaction(txnID)
+		if !exists {
+			return errors.New("transaction was not restored")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool.mu.Lock()
+	restoredHeight := tpt.tpool.transactionHeights[txnID]
+	tpt.tpool.mu.Unlock()
+	if restoredHeight != seenHeight {
+		t.Fatalf("expected restored height %v, got %v", seenHeight, restoredHeight)
+	}
+
+	// The restored set should be a duplicate.
+	err = tpt.tpool.AcceptTransactionSet(txns)
+	if !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		t.Fatal("expecting modules.ErrDuplicateTransactionSet, got:", err)
+	}
+
+	// Mine the transaction. After another restart the pool should be empty.
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tpt.tpool.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := tpt.cs.Height()
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		tpt.tpool.mu.Lock()
+		defer tpt.tpool.mu.Unlock()
+		if tpt.tpool.blockHeight < height {
+			return errors.New("expected tpool height to reach cs height")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("confirmed transaction was restored to the pool")
+	}
+}
+
+// TestPersistedSetExpired is a unit test for persistedSetExpired.
+func TestPersistedSetExpired(t *testing.T) {
+	txns := []types.Transaction{{}, {}}
+	tests := []struct {
+		heights []types.BlockHeight
+		height  types.BlockHeight
+		expired bool
+	}{
+		{[]types.BlockHeight{10, 10}, 10, false},
+		{[]types.BlockHeight{10, 10}, 10 + MaxTransactionAge, true},
+		{[]types.BlockHeight{10, 11}, 10 + MaxTransactionAge, false},
+		{[]types.BlockHeight{20, 20}, 10, false},
+		{[]types.BlockHeight{10}, 10, true},
+	}
+	for i, test := range tests {
+		ps := persistedSet{Transactions: txns, Heights: test.heights}
+		if expired := persistedSetExpired(ps, test.height); expired != test.expired {
+			t.Errorf("%v: expected %v, got %v", i, test.expired, expired)
+		}
+	}
+}
@@ -60,6 +60,11 @@ type (
 		recentMedians   []types.Currency
 		recentMedianFee types.Currency // SC per byte
 
+		// confirmationSamples holds the fee rates and confirmation delays of
+		// the transactions that were confirmed from the pool, ordered by the
+		// height of the block that confirmed them.
+		confirmationSamples []confirmationSample
+
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
 		// transaction pool, all prior consensus changes are sent to the new
@@ -147,7 +152,39 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	defer tp.tg.Done()
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
+	return tp.feeEstimation()
+}
 
+// FeeEstimationForTarget returns the fee per byte that is estimated to get a
+// transaction confirmed within target blocks. The estimation is based on the
+// fee rates and confirmation delays of the transactions that were recently
+// confirmed from the pool, and is kept within the bounds returned by
+// FeeEstimation. If there is not enough history to estimate the fee, the
+// maximum fee returned by FeeEstimation is used.
+func (tp *TransactionPool) FeeEstimationForTarget(target types.BlockHeight) types.Currency {
+	err := tp.tg.Add()
+	if err != nil {
+		return types.ZeroCurrency
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	min, max := tp.feeEstimation()
+	fee, ok := estimateFeeForTarget(tp.confirmationSamples, target)
+	if !ok || fee.Cmp(max) > 0 {
+		return max
+	}
+	if fee.Cmp(min) < 0 {
+		return min
+	}
+	return fee
+}
+
+// feeEstimation returns an estimation for what fee should be applied to
+// transactions. It returns a minimum and maximum estimated fee per transaction
+// byte.
+func (tp *TransactionPool) feeEstimation() (min, max types.Currency) {
 	// Use three methods to determine an acceptable fee. The first method looks
 	// at what fee is required to get into a block on the blockchain based on
 	// the actual fees of transactions confirmed in recent blocks. The second
@@ -302,6 +302,10 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 	})
 	tp.recentMedianFee = safeMedians[len(safeMedians)/2]
 
+	// Record how long the transactions of the pool that got confirmed by this
+	// consensus change had to wait, for the historical fee estimation.
+	tp.updateConfirmationSamples(cc)
+
 	// Update all the on-disk structures.
 	tp.blockHeight = cc.BlockHeight
 	err = tp.putRecentConsensusChange(tp.dbTx, cc.ID)
@@ -317,8 +321,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		tp.log.Println("ERROR: could not update the block height:", err)
 	}
 	err = tp.putFeeMedian(tp.dbTx, medianPersist{
-		RecentMedians:   tp.recentMedians,
-		RecentMedianFee: tp.recentMedianFee,
+		RecentMedians:       tp.recentMedians,
+		RecentMedianFee:     tp.recentMedianFee,
+		ConfirmationSamples: tp.confirmationSamples,
 	})
 	if err != nil {
 		tp.log.Println("ERROR: could not update the transaction pool median fee information:", err)
@@ -0,0 +1,200 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// MaxTags is the maximum number of tags of a file or directory.
+	MaxTags = 64
+
+	// MaxTagLength is the maximum length of a tag in bytes.
+	MaxTagLength = 64
+
+	// MaxUserMetadataEntries is the maximum number of user-defined metadata
+	// entries of a file or directory.
+	MaxUserMetadataEntries = 64
+
+	// MaxUserMetadataKeyLength is the maximum length of the key of a
+	// user-defined metadata entry in bytes.
+	MaxUserMetadataKeyLength = 64
+
+	// MaxUserMetadataValueLength is the maximum length of the value of a
+	// user-defined metadata entry in bytes.
+	MaxUserMetadataValueLength = 1024
+)
+
+var (
+	// ErrInvalidTag is returned if a tag is empty, too long or contains a
+	// comma.
+	ErrInvalidTag = errors.New("invalid tag")
+
+	// ErrInvalidUserMetadata is returned if a key or value of user-defined
+	// metadata is invalid.
+	ErrInvalidUserMetadata = errors.New("invalid user metadata")
+)
+
+// FileQuery describes the files and directories returned by a search. A
+// FileQuery matches a file or directory if all of its criteria match. The zero
+// value of a criterion means that it is not enforced.
+type FileQuery struct {
+	// Tags are the tags a file or directory needs to have.
+	Tags []string
+
+	// UserMetadata are the key/value pairs of user-defined metadata a file or
+	// directory needs to have. An empty value matches any value of the key.
+	UserMetadata map[string]string
+
+	// MinSize and MaxSize limit the size of files and the aggregate size of
+	// directories.
+	MinSize uint64
+	MaxSize uint64
+
+	// MinHealth and MaxHealth limit the max health of files and the
+	// aggregate max health of directories.
+	MinHealth *float64
+	MaxHealth *float64
+
+	// ModifiedAfter and ModifiedBefore limit the time a file or the most
+	// recent file within a directory was modified.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	// CreatedAfter and CreatedBefore limit the time a file was created.
+	// Directories don't have a creation time and never match a query that
+	// sets them.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// MatchFile returns whether a file matches the query.
+func (q FileQuery) MatchFile(fi FileInfo) bool {
+	return q.matchUserMetadata(fi.Tags, fi.UserMetadata) &&
+		q.matchSize(fi.Filesize) &&
+		q.matchHealth(fi.MaxHealth) &&
+		matchTime(fi.ModificationTime, q.ModifiedAfter, q.ModifiedBefore) &&
+		matchTime(fi.CreateTime, q.CreatedAfter, q.CreatedBefore)
+}
+
+// MatchDir returns whether a directory matches the query.
+func (q FileQuery) MatchDir(di DirectoryInfo) bool {
+	if !q.CreatedAfter.IsZero() || !q.CreatedBefore.IsZero() {
+		return false
+	}
+	return q.matchUserMetadata(di.Tags, di.UserMetadata) &&
+		q.matchSize(di.AggregateSize) &&
+		q.matchHealth(di.AggregateMaxHealth) &&
+		matchTime(di.AggregateMostRecentModTime, q.ModifiedAfter, q.ModifiedBefore)
+}
+
+// matchUserMetadata returns whether the tags and user-defined metadata match
+// the query.
+func (q FileQuery) matchUserMetadata(tags []string, md map[string]string) bool {
+	for _, tag := range q.Tags {
+		i := sort.SearchStrings(tags, tag)
+		if i == len(tags) || tags[i] != tag {
+			return false
+		}
+	}
+	for key, value := range q.UserMetadata {
+		v, exists := md[key]
+		if !exists || (value != "" && v != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSize returns whether the size matches the query.
+func (q FileQuery) matchSize(size uint64) bool {
+	return size >= q.MinSize && (q.MaxSize == 0 || size <= q.MaxSize)
+}
+
+// matchHealth returns whether the health matches the query.
+func (q FileQuery) matchHealth(health float64) bool {
+	if q.MinHealth != nil && health < *q.MinHealth {
+		return false
+	}
+	return q.MaxHealth == nil || health <= *q.MaxHealth
+}
+
+// matchTime returns whether t is within the range [after;before]. Zero bounds
+// are ignored.
+func matchTime(t, after, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+	return before.IsZero() || !t.After(before)
+}
+
+// ParseTags parses a comma separated list of tags. Whitespace around the tags
+// and empty tags are ignored.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// NormalizeTags validates the tags of a file or directory and returns them
+// sorted and without duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if tag == "" || len(tag) > MaxTagLength || strings.ContainsAny(tag, ",") || strings.TrimSpace(tag) != tag {
+			return nil, errors.AddContext(ErrInvalidTag, fmt.Sprintf("%q", tag))
+		}
+		if _, exists := seen[tag]; exists {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, errors.AddContext(ErrInvalidTag, fmt.Sprintf("at most %v tags are allowed", MaxTags))
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// ValidateUserMetadata validates the user-defined metadata of a file or
+// directory.
+func ValidateUserMetadata(md map[string]string) error {
+	if len(md) > MaxUserMetadataEntries {
+		return errors.AddContext(ErrInvalidUserMetadata, fmt.Sprintf("at most %v entries are allowed", MaxUserMetadataEntries))
+	}
+	for key, value := range md {
+		if key == "" || len(key) > MaxUserMetadataKeyLength || strings.Contains(key, "=") {
+			return errors.AddContext(ErrInvalidUserMetadata, fmt.Sprintf("invalid key %q", key))
+		}
+		if len(value) > MaxUserMetadataValueLength {
+			return errors.AddContext(ErrInvalidUserMetadata, fmt.Sprintf("value of key %q is longer than %v bytes", key, MaxUserMetadataValueLength))
+		}
+	}
+	return nil
+}
+
+// CopyUserMetadata returns a copy of user-defined metadata. The copy of nil
+// metadata is nil.
+func CopyUserMetadata(md map[string]string) map[string]string {
+	if md == nil {
+		return nil
+	}
+	c := make(map[string]string, len(md))
+	for key, value := range md {
+		c[key] = value
+	}
+	return c
+}
@@ -0,0 +1,144 @@
+package modules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestParseTags tests parsing comma separated lists of tags.
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		s    string
+		tags []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"foo", []string{"foo"}},
+		{"foo, bar ,,baz", []string{"foo", "bar", "baz"}},
+	}
+	for _, test := range tests {
+		if tags := ParseTags(test.s); !reflect.DeepEqual(tags, test.tags) {
+			t.Fatalf("%q: expected %v but got %v", test.s, test.tags, tags)
+		}
+	}
+}
+
+// TestNormalizeTags tests validating, sorting and deduplicating tags.
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{"foo", "bar", "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags, []string{"bar", "foo"}) {
+		t.Fatal("wrong tags", tags)
+	}
+	if tags, err := NormalizeTags([]string{}); err != nil || tags != nil {
+		t.Fatal("empty tags should be normalized to nil", tags, err)
+	}
+
+	invalid := [][]string{
+		{""},
+		{"a,b"},
+		{" foo"},
+		{strings.Repeat("a", MaxTagLength+1)},
+	}
+	var tooMany []string
+	for i := 0; i <= MaxTags; i++ {
+		tooMany = append(tooMany, strings.Repeat("a", i+1))
+	}
+	invalid = append(invalid, tooMany)
+	for _, tags := range invalid {
+		if _, err := NormalizeTags(tags); !errors.Contains(err, ErrInvalidTag) {
+			t.Fatalf("%q: expected ErrInvalidTag but got %v", tags, err)
+		}
+	}
+}
+
+// TestValidateUserMetadata tests validating user-defined metadata.
+func TestValidateUserMetadata(t *testing.T) {
+	valid := []map[string]string{
+		nil,
+		{"owner": "alice", "empty": ""},
+		{strings.Repeat("k", MaxUserMetadataKeyLength): strings.Repeat("v", MaxUserMetadataValueLength)},
+	}
+	for _, md := range valid {
+		if err := ValidateUserMetadata(md); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxUserMetadataEntries; i++ {
+		tooMany[strings.Repeat("k", i+1)] = ""
+	}
+	invalid := []map[string]string{
+		{"": "value"},
+		{"a=b": "value"},
+		{strings.Repeat("k", MaxUserMetadataKeyLength+1): ""},
+		{"key": strings.Repeat("v", MaxUserMetadataValueLength+1)},
+		tooMany,
+	}
+	for _, md := range invalid {
+		if err := ValidateUserMetadata(md); !errors.Contains(err, ErrInvalidUserMetadata) {
+			t.Fatal("expected ErrInvalidUserMetadata but got", err)
+		}
+	}
+}
+
+// TestFileQueryMatch tests matching files and directories against a
+// FileQuery.
+func TestFileQueryMatch(t *testing.T) {
+	now := time.Now()
+	fi := FileInfo{
+		CreateTime:       now.Add(-time.Hour),
+		Filesize:         1000,
+		MaxHealth:        0.5,
+		ModificationTime: now,
+		Tags:             []string{"bar", "foo"},
+		UserMetadata:     map[string]string{"owner": "alice"},
+	}
+	di := DirectoryInfo{
+		AggregateMaxHealth:         0.5,
+		AggregateMostRecentModTime: now,
+		AggregateSize:              1000,
+		Tags:                       []string{"foo"},
+	}
+	low, high := 0.25, 0.75
+
+	tests := []struct {
+		query FileQuery
+		file  bool
+		dir   bool
+	}{
+		{FileQuery{}, true, true},
+		{FileQuery{Tags: []string{"foo"}}, true, true},
+		{FileQuery{Tags: []string{"bar", "foo"}}, true, false},
+		{FileQuery{Tags: []string{"baz"}}, false, false},
+		{FileQuery{UserMetadata: map[string]string{"owner": ""}}, true, false},
+		{FileQuery{UserMetadata: map[string]string{"owner": "alice"}}, true, false},
+		{FileQuery{UserMetadata: map[string]string{"owner": "bob"}}, false, false},
+		{FileQuery{MinSize: 1000, MaxSize: 1000}, true, true},
+		{FileQuery{MinSize: 1001}, false, false},
+		{FileQuery{MaxSize: 999}, false, false},
+		{FileQuery{MinHealth: &low, MaxHealth: &high}, true, true},
+		{FileQuery{MinHealth: &high}, false, false},
+		{FileQuery{MaxHealth: &low}, false, false},
+		{FileQuery{ModifiedAfter: now, ModifiedBefore: now}, true, true},
+		{FileQuery{ModifiedAfter: now.Add(time.Second)}, false, false},
+		{FileQuery{ModifiedBefore: now.Add(-time.Second)}, false, false},
+		{FileQuery{CreatedBefore: now}, true, false},
+		{FileQuery{CreatedAfter: now}, false, false},
+	}
+	for i, test := range tests {
+		if test.query.MatchFile(fi) != test.file {
+			t.Errorf("%v: file should match: %v", i, test.file)
+		}
+		if test.query.MatchDir(di) != test.dir {
+			t.Errorf("%v: dir should match: %v", i, test.dir)
+		}
+	}
+}
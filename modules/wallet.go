@@ -436,6 +436,14 @@ type (
 		// Close permits clean shutdown during testing and serving.
 		Close() error
 
+		// BumpTransaction raises the fee rate of the unconfirmed transaction
+		// set containing the transaction with the provided id by creating a
+		// child transaction that spends one of the wallet's outputs of the
+		// set with a higher fee (child pays for parent). If feePerByte
+		// is zero, the maximum fee estimated by the transaction pool is used.
+		// The transaction set including the child is returned.
+		BumpTransaction(txid types.TransactionID, feePerByte types.Currency) ([]types.Transaction, error)
+
 		// ConfirmedBalance returns the confirmed balance of the wallet, minus
 		// any outgoing transactions. ConfirmedBalance will include unconfirmed
 		// refund transactions.
@@ -507,6 +515,16 @@ type (
 		// SendSiacoinsFeeIncluded sends siacoins with fees included.
 		SendSiacoinsFeeIncluded(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// SendSiacoinsWithFeeTarget sends siacoins paying the fee that is
+		// estimated to get the transaction confirmed within target blocks. If
+		// feeIncluded is true, the fee is subtracted from the amount sent.
+		SendSiacoinsWithFeeTarget(amount types.Currency, dest types.UnlockHash, feeIncluded bool, target types.BlockHeight) ([]types.Transaction, error)
+
+		// SendSiacoinsMultiWithFeeTarget sends coins to multiple addresses
+		// paying the fee that is estimated to get the transaction confirmed
+		// within target blocks.
+		SendSiacoinsMultiWithFeeTarget(outputs []types.SiacoinOutput, target types.BlockHeight) ([]types.Transaction, error)
+
 		SiacoinSenderMulti
 
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
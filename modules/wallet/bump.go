@@ -0,0 +1,197 @@
+package wallet
+
+import (
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errBumpNotNeeded is returned if the transaction set of a transaction
+	// that should be bumped already pays the requested fee rate.
+	errBumpNotNeeded = errors.New("transaction set already pays the requested fee rate")
+
+	// errBumpOutputTooSmall is returned if none of the wallet's outputs of a
+	// transaction set is large enough to pay for bumping its transaction set.
+	errBumpOutputTooSmall = errors.New("wallet output is too small to pay the fee required to bump the transaction set")
+
+	// errNoBumpableOutput is returned if a transaction set has no unspent
+	// siacoin output that belongs to the wallet.
+	errNoBumpableOutput = errors.New("transaction set has no unspent siacoin output that belongs to the wallet")
+
+	// errTransactionNotInPool is returned if the transaction that should be
+	// bumped is not in the transaction pool.
+	errTransactionNotInPool = errors.New("transaction is not in the transaction pool")
+)
+
+// BumpTransaction raises the fee rate of the unconfirmed transaction set that
+// contains the transaction with the provided id (child pays for parent). A
+// child transaction is created that spends one of the wallet's outputs of the
+// set back to the wallet, paying enough fees for the whole set to reach
+// feePerByte. If feePerByte is zero, the maximum fee estimated by the
+// transaction pool is used. The transaction set together with the child is
+// submitted to the transaction pool and returned.
+func (w *Wallet) BumpTransaction(txid types.TransactionID, feePerByte types.Currency) (txns []types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// Check if consensus is synced
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return nil, errors.New("cannot bump transaction until fully synced")
+	}
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+
+	// The fee estimation and the transaction set have to be obtained separate
+	// from the lock.
+	if feePerByte.IsZero() {
+		_, feePerByte = w.tpool.FeeEstimation()
+	}
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, err
+	}
+	txn, _, exists := w.tpool.Transaction(txid)
+	if !exists {
+		return nil, errTransactionNotInPool
+	}
+	var txnSet []types.Transaction
+	if len(txn.SiacoinOutputs) > 0 {
+		txnSet = w.tpool.TransactionSet(crypto.Hash(txn.SiacoinOutputID(0)))
+	} else if len(txn.SiacoinInputs) > 0 {
+		txnSet = w.tpool.TransactionSet(crypto.Hash(txn.SiacoinInputs[0].ParentID))
+	}
+	if len(txnSet) == 0 {
+		return nil, errNoBumpableOutput
+	}
+
+	// Create the child transaction.
+	child, refundAddr, err := w.managedCreateBumpTransaction(txnSet, feePerByte, dustThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.markAddressUnused(refundAddr)
+		for _, sci := range child.SiacoinInputs {
+			dbDeleteSpentOutput(w.dbTx, types.OutputID(sci.ParentID))
+		}
+	}()
+
+	// Submit the set together with the child to the transaction pool.
+	txns = append(txnSet, child)
+	err = w.tpool.AcceptTransactionSet(txns)
+	if err != nil {
+		w.log.Println("Attempt to bump transaction has failed - transaction pool rejected transaction:", err)
+		return nil, errors.AddContext(err, "unable to get bump transaction accepted")
+	}
+	w.log.Printf("Bumped transaction %v with a child paying %v, package fee rate is now %v per byte, child ID: %v", txid, child.MinerFees[0].HumanString(), modules.CalculateFee(txns).HumanString(), child.ID())
+	return txns, nil
+}
+
+// managedCreateBumpTransaction creates a transaction that spends the largest
+// of the wallet's unspent outputs of txnSet back to the wallet, paying the
+// fees required for txnSet and the new transaction to reach feePerByte.
+func (w *Wallet) managedCreateBumpTransaction(txnSet []types.Transaction, feePerByte, dustThreshold types.Currency) (_ types.Transaction, _ types.UnlockConditions, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.Transaction{}, types.UnlockConditions{}, err
+	}
+
+	// Collect the outputs of the set that are already spent by the set itself.
+	spentInSet := make(map[types.SiacoinOutputID]struct{})
+	for _, t := range txnSet {
+		for _, sci := range t.SiacoinInputs {
+			spentInSet[sci.ParentID] = struct{}{}
+		}
+	}
+
+	// Find the largest unspent output of the set that can be spent by the
+	// wallet.
+	var scoid types.SiacoinOutputID
+	var sco types.SiacoinOutput
+	for _, txn := range txnSet {
+		for i, output := range txn.SiacoinOutputs {
+			id := txn.SiacoinOutputID(uint64(i))
+			if _, exists := w.keys[output.UnlockHash]; !exists {
+				continue
+			}
+			if _, spent := spentInSet[id]; spent {
+				continue
+			}
+			if _, err := dbGetSpentOutput(w.dbTx, types.OutputID(id)); err == nil {
+				continue
+			}
+			if output.Value.Cmp(sco.Value) > 0 {
+				scoid, sco = id, output
+			}
+		}
+	}
+	if sco.Value.IsZero() {
+		return types.Transaction{}, types.UnlockConditions{}, errNoBumpableOutput
+	}
+
+	// Compute the fee of the child. The child has to pay for the size of the
+	// whole set including itself, minus the fees that the set already pays.
+	var setFees types.Currency
+	for _, t := range txnSet {
+		for _, fee := range t.MinerFees {
+			setFees = setFees.Add(fee)
+		}
+	}
+	setSize := uint64(len(encoding.Marshal(txnSet)))
+	requiredFees := feePerByte.Mul64(setSize + estimatedTransactionSize)
+	if setFees.Cmp(requiredFees) >= 0 {
+		return types.Transaction{}, types.UnlockConditions{}, errBumpNotNeeded
+	}
+	fee := requiredFees.Sub(setFees)
+	if sco.Value.Cmp(fee.Add(dustThreshold)) <= 0 {
+		return types.Transaction{}, types.UnlockConditions{}, errBumpOutputTooSmall
+	}
+
+	// Create the child.
+	refundAddr, err := w.nextPrimarySeedAddress(w.dbTx)
+	if err != nil {
+		return types.Transaction{}, types.UnlockConditions{}, err
+	}
+	defer func() {
+		if err != nil {
+			w.markAddressUnused(refundAddr)
+		}
+	}()
+	uc := w.keys[sco.UnlockHash].UnlockConditions
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         scoid,
+			UnlockConditions: uc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      sco.Value.Sub(fee),
+			UnlockHash: refundAddr.UnlockHash(),
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	addSignatures(&child, types.FullCoveredFields, uc, crypto.Hash(scoid), w.keys[sco.UnlockHash], consensusHeight)
+
+	// Mark the output as spent.
+	if err = dbPutSpentOutput(w.dbTx, types.OutputID(scoid), consensusHeight); err != nil {
+		return types.Transaction{}, types.UnlockConditions{}, err
+	}
+	return child, refundAddr, nil
+}
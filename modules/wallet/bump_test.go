@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBumpTransaction probes the BumpTransaction method of the wallet.
+func TestBumpTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Bumping a transaction that is not in the pool should fail.
+	_, err = wt.wallet.BumpTransaction(types.TransactionID{}, types.ZeroCurrency)
+	if !errors.Contains(err, errTransactionNotInPool) {
+		t.Fatal("expected errTransactionNotInPool, got", err)
+	}
+
+	// Send some coins. The refund output of the transaction belongs to the
+	// wallet.
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(3), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	feeBefore := modules.CalculateFee(txns)
+
+	// Bumping to the fee rate the set already pays is not necessary.
+	_, err = wt.wallet.BumpTransaction(txid, feeBefore.Div64(2))
+	if !errors.Contains(err, errBumpNotNeeded) {
+		t.Fatal("expected errBumpNotNeeded, got", err)
+	}
+
+	// Bump the set to a higher fee rate.
+	target := feeBefore.Mul64(4)
+	bumped, err := wt.wallet.BumpTransaction(txid, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := bumped[len(bumped)-1]
+	if modules.CalculateFee(bumped).Cmp(target) < 0 {
+		t.Fatalf("package fee rate %v is below the target %v", modules.CalculateFee(bumped), target)
+	}
+	_, _, exists := wt.tpool.Transaction(child.ID())
+	if !exists {
+		t.Fatal("child was not added to the transaction pool")
+	}
+
+	// The child and the parent should be part of the same set in the pool.
+	set := wt.tpool.TransactionSet(crypto.Hash(child.SiacoinInputs[0].ParentID))
+	var foundParent, foundChild bool
+	for _, txn := range set {
+		foundParent = foundParent || txn.ID() == txid
+		foundChild = foundChild || txn.ID() == child.ID()
+	}
+	if !foundParent || !foundChild {
+		t.Fatal("parent and child were not merged into the same set")
+	}
+
+	// Bump the set again. This time the output of the child is spent.
+	target = target.Mul64(2)
+	bumped, err = wt.wallet.BumpTransaction(txid, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modules.CalculateFee(bumped).Cmp(target) < 0 {
+		t.Fatalf("package fee rate %v is below the target %v", modules.CalculateFee(bumped), target)
+	}
+	grandchild := bumped[len(bumped)-1]
+	if grandchild.SiacoinInputs[0].ParentID != child.SiacoinOutputID(0) {
+		t.Fatal("second bump should spend the output of the first child")
+	}
+
+	// Mine the set. All transactions should be confirmed.
+	_, err = wt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, txn := range []types.Transaction{txns[len(txns)-1], child, grandchild} {
+		confirmed, err := wt.tpool.TransactionConfirmed(txn.ID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !confirmed {
+			t.Fatal("transaction was not confirmed", txn.ID())
+		}
+	}
+}
@@ -135,6 +135,32 @@ func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.Unloc
 
 	_, fee := w.tpool.FeeEstimation()
 	fee = fee.Mul64(estimatedTransactionSize)
+	return w.managedSendSiacoinsFeeIncluded(amount, fee, dest)
+}
+
+// SendSiacoinsWithFeeTarget creates a transaction sending 'amount' to 'dest'
+// that pays the fee which is estimated to get the transaction confirmed within
+// 'target' blocks. If feeIncluded is true, the fee is subtracted from the
+// amount sent, otherwise it is added to it. The transaction is submitted to the
+// transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsWithFeeTarget(amount types.Currency, dest types.UnlockHash, feeIncluded bool, target types.BlockHeight) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		err = modules.ErrWalletShutdown
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	fee := w.tpool.FeeEstimationForTarget(target)
+	fee = fee.Mul64(estimatedTransactionSize)
+	if feeIncluded {
+		return w.managedSendSiacoinsFeeIncluded(amount, fee, dest)
+	}
+	return w.managedSendSiacoins(amount, fee, dest)
+}
+
+// managedSendSiacoinsFeeIncluded creates a transaction sending 'amount' minus
+// 'fee' to 'dest'.
+func (w *Wallet) managedSendSiacoinsFeeIncluded(amount, fee types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
 	// Don't allow sending an amount equal to the fee, as zero spending is not
 	// allowed and would error out later.
 	if amount.Cmp(fee) <= 0 {
@@ -210,6 +236,29 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 		return nil, err
 	}
 	defer w.tg.Done()
+
+	_, tpoolFee := w.tpool.FeeEstimation()
+	return w.managedSendSiacoinsMulti(outputs, tpoolFee)
+}
+
+// SendSiacoinsMultiWithFeeTarget creates a transaction that includes the
+// specified outputs and pays the fee which is estimated to get the transaction
+// confirmed within 'target' blocks. The transaction is submitted to the
+// transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsMultiWithFeeTarget(outputs []types.SiacoinOutput, target types.BlockHeight) (txns []types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		err = modules.ErrWalletShutdown
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	tpoolFee := w.tpool.FeeEstimationForTarget(target)
+	return w.managedSendSiacoinsMulti(outputs, tpoolFee)
+}
+
+// managedSendSiacoinsMulti creates a transaction that includes the specified
+// outputs, paying feePerByte for the estimated size of the transaction.
+func (w *Wallet) managedSendSiacoinsMulti(outputs []types.SiacoinOutput, feePerByte types.Currency) (txns []types.Transaction, err error) {
 	w.log.Println("Beginning call to SendSiacoinsMulti")
 
 	// Check if consensus is synced
@@ -236,8 +285,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	}()
 
 	// Add estimated transaction fee.
-	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
+	tpoolFee := feePerByte.Mul64(2)                           // We don't want send-to-many transactions to fail.
 	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
 	txnBuilder.AddMinerFee(tpoolFee)
 
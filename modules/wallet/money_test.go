@@ -204,6 +204,54 @@ func TestSendSiacoinsFeeIncluded(t *testing.T) {
 	}
 }
 
+// TestSendSiacoinsWithFeeTarget probes the SendSiacoinsWithFeeTarget method
+// of the wallet.
+func TestSendSiacoinsWithFeeTarget(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Send siacoins with and without the fee included. The transaction should
+	// pay the fee estimated for the target.
+	sendValue := types.SiacoinPrecision.Mul64(3)
+	for _, feeIncluded := range []bool{false, true} {
+		fee := wt.wallet.tpool.FeeEstimationForTarget(2).Mul64(estimatedTransactionSize)
+		txns, err := wt.wallet.SendSiacoinsWithFeeTarget(sendValue, types.UnlockHash{}, feeIncluded, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paid types.Currency
+		for _, txn := range txns {
+			for _, mf := range txn.MinerFees {
+				paid = paid.Add(mf)
+			}
+		}
+		if !paid.Equals(fee) {
+			t.Fatalf("expected fee %v, got %v", fee, paid)
+		}
+		expectedValue := sendValue
+		if feeIncluded {
+			expectedValue = sendValue.Sub(fee)
+		}
+		var sent bool
+		for _, sco := range txns[len(txns)-1].SiacoinOutputs {
+			sent = sent || (sco.UnlockHash == types.UnlockHash{} && sco.Value.Equals(expectedValue))
+		}
+		if !sent {
+			t.Fatal("transaction doesn't send the expected value", expectedValue)
+		}
+	}
+}
+
 // TestIntegrationSendOverUnder sends too many siacoins, resulting in an error,
 // followed by sending few enough siacoins that the send should complete.
 //
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// ArchiveFormatTar is the format of uncompressed tar archives.
+	ArchiveFormatTar = "tar"
+
+	// ArchiveFormatTarGz is the format of gzip compressed tar archives.
+	ArchiveFormatTarGz = "targz"
+
+	// ArchiveFormatZip is the format of zip archives.
+	ArchiveFormatZip = "zip"
+)
+
+// archivePrefetch is the number of files after the file that is currently
+// being written to an archive for which streamers are already opened. Opening
+// a streamer starts filling its cache, so the next files are fetched while the
+// current one is written.
+const archivePrefetch = 4
+
+// errUnknownArchiveFormat is returned for unsupported archive formats.
+var errUnknownArchiveFormat = errors.New("unknown archive format")
+
+type (
+	// archiveFile is a file that is written to an archive.
+	archiveFile struct {
+		name string
+		info modules.FileInfo
+	}
+
+	// archiveStream is the streamer of a file that is written to an archive.
+	archiveStream struct {
+		stream modules.Streamer
+		err    error
+	}
+
+	// archiveWriter writes files to an archive of a specific format.
+	archiveWriter interface {
+		// WriteFile writes the header of a file to the archive and returns
+		// a writer for its data.
+		WriteFile(f archiveFile) (io.Writer, error)
+
+		// Close finishes the archive.
+		Close() error
+	}
+
+	// tarArchiveWriter is an archiveWriter for tar archives.
+	tarArchiveWriter struct {
+		tw *tar.Writer
+		gw *gzip.Writer
+	}
+
+	// zipArchiveWriter is an archiveWriter for zip archives.
+	zipArchiveWriter struct {
+		zw *zip.Writer
+	}
+)
+
+// archiveContentType returns the content type and file extension of an
+// archive format.
+func archiveContentType(format string) (contentType, ext string, err error) {
+	switch format {
+	case ArchiveFormatTar:
+		return "application/x-tar", ".tar", nil
+	case ArchiveFormatTarGz:
+		return "application/gzip", ".tar.gz", nil
+	case ArchiveFormatZip:
+		return "application/zip", ".zip", nil
+	}
+	return "", "", errors.AddContext(errUnknownArchiveFormat, format)
+}
+
+// newArchiveWriter creates an archiveWriter for the format which writes to w.
+func newArchiveWriter(w io.Writer, format string) (archiveWriter, error) {
+	switch format {
+	case ArchiveFormatTar:
+		return &tarArchiveWriter{tw: tar.NewWriter(w)}, nil
+	case ArchiveFormatTarGz:
+		gw := gzip.NewWriter(w)
+		return &tarArchiveWriter{tw: tar.NewWriter(gw), gw: gw}, nil
+	case ArchiveFormatZip:
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}, nil
+	}
+	return nil, errors.AddContext(errUnknownArchiveFormat, format)
+}
+
+// WriteFile implements archiveWriter.
+func (aw *tarArchiveWriter) WriteFile(f archiveFile) (io.Writer, error) {
+	err := aw.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     f.name,
+		Size:     int64(f.info.Filesize),
+		Mode:     int64(f.info.Mode().Perm()),
+		ModTime:  f.info.ModificationTime,
+	})
+	return aw.tw, err
+}
+
+// Close implements archiveWriter.
+func (aw *tarArchiveWriter) Close() error {
+	err := aw.tw.Close()
+	if aw.gw != nil {
+		err = errors.Compose(err, aw.gw.Close())
+	}
+	return err
+}
+
+// WriteFile implements archiveWriter.
+func (aw *zipArchiveWriter) WriteFile(f archiveFile) (io.Writer, error) {
+	header := &zip.FileHeader{
+		Name:     f.name,
+		Method:   zip.Deflate,
+		Modified: f.info.ModificationTime,
+	}
+	header.SetMode(f.info.Mode().Perm())
+	return aw.zw.CreateHeader(header)
+}
+
+// Close implements archiveWriter.
+func (aw *zipArchiveWriter) Close() error {
+	return aw.zw.Close()
+}
+
+// writeArchive writes the files to an archive of the given format. The files
+// are read from the streamers returned by open, which is called for up to
+// archivePrefetch files in advance.
+func writeArchive(w io.Writer, format string, files []archiveFile, open func(modules.SiaPath) (modules.Streamer, error)) (err error) {
+	aw, err := newArchiveWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	// Open the streamers in the background. The channel's buffer limits the
+	// number of streamers that are open at the same time.
+	streams := make(chan archiveStream, archivePrefetch)
+	stop := make(chan struct{})
+	go func() {
+		defer close(streams)
+		for _, f := range files {
+			var as archiveStream
+			if f.info.Filesize > 0 {
+				as.stream, as.err = open(f.info.SiaPath)
+			}
+			select {
+			case streams <- as:
+			case <-stop:
+				if as.stream != nil {
+					_ = as.stream.Close()
+				}
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(stop)
+		for as := range streams {
+			if as.stream != nil {
+				_ = as.stream.Close()
+			}
+		}
+	}()
+
+	for _, f := range files {
+		as := <-streams
+		if as.err != nil {
+			return errors.AddContext(as.err, fmt.Sprintf("unable to open %v", f.info.SiaPath))
+		}
+		fw, err := aw.WriteFile(f)
+		if err == nil && as.stream != nil {
+			_, err = io.CopyN(fw, as.stream, int64(f.info.Filesize))
+		}
+		if as.stream != nil {
+			err = errors.Compose(err, as.stream.Close())
+		}
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("unable to write %v to archive", f.info.SiaPath))
+		}
+	}
+	return aw.Close()
+}
+
+// archiveFiles returns the files within a directory sorted by their names in
+// the archive. Names are prefixed with the name of the directory.
+func archiveFiles(dir modules.SiaPath, infos []modules.FileInfo) ([]archiveFile, error) {
+	prefix := dir.Name()
+	if prefix == "" {
+		prefix = "sia"
+	}
+	files := make([]archiveFile, 0, len(infos))
+	for _, fi := range infos {
+		rel, err := fi.SiaPath.Rebase(dir, modules.RootSiaPath())
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{
+			name: path.Join(prefix, rel.String()),
+			info: fi,
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	return files, nil
+}
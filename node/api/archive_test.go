@@ -0,0 +1,178 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestWriteArchive tests writing the files of a directory to archives of all
+// supported formats.
+func TestWriteArchive(t *testing.T) {
+	dir, err := modules.NewSiaPath("home/user/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Unix(1600000000, 0).UTC()
+
+	// Create files with different sizes and modes, including an empty one.
+	data := make(map[modules.SiaPath][]byte)
+	var infos []modules.FileInfo
+	for i, name := range []string{"b", "a", "sub/c", "sub/d", "empty", "f", "g"} {
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := 0
+		if name != "empty" {
+			size = fastrand.Intn(1000) + 1
+		}
+		data[siaPath] = fastrand.Bytes(size)
+		infos = append(infos, modules.FileInfo{
+			SiaPath:          siaPath,
+			Filesize:         uint64(size),
+			FileMode:         os.FileMode(0600 + i),
+			ModificationTime: modTime.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	files, err := archiveFiles(dir, infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].name != "docs/a" || files[len(files)-1].name != "docs/sub/d" {
+		t.Fatal("files aren't sorted by name", files[0].name, files[len(files)-1].name)
+	}
+
+	// open returns a streamer for the data of a file. It keeps track of the
+	// number of open streamers.
+	var open int64
+	openFn := func(siaPath modules.SiaPath) (modules.Streamer, error) {
+		d, ok := data[siaPath]
+		if !ok {
+			return nil, errors.New("file not found")
+		}
+		if len(d) == 0 {
+			return nil, errors.New("streamer opened for empty file")
+		}
+		atomic.AddInt64(&open, 1)
+		return &countingStreamer{Streamer: streamerFromSlice(d), open: &open}, nil
+	}
+
+	// check verifies that a file read from an archive matches the original.
+	check := func(f archiveFile, name string, mode os.FileMode, modified time.Time, r io.Reader) {
+		if name != f.name {
+			t.Fatalf("expected %v but got %v", f.name, name)
+		}
+		if mode.Perm() != f.info.Mode().Perm() {
+			t.Fatalf("%v: expected mode %v but got %v", name, f.info.Mode(), mode)
+		}
+		if !modified.Equal(f.info.ModificationTime) {
+			t.Fatalf("%v: expected modtime %v but got %v", name, f.info.ModificationTime, modified)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, data[f.info.SiaPath]) {
+			t.Fatalf("%v: data doesn't match", name)
+		}
+	}
+
+	for _, format := range []string{ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatZip} {
+		var buf bytes.Buffer
+		if err := writeArchive(&buf, format, files, openFn); err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt64(&open); n != 0 {
+			t.Fatalf("%v: %v streamers weren't closed", format, n)
+		}
+
+		switch format {
+		case ArchiveFormatTar, ArchiveFormatTarGz:
+			var r io.Reader = &buf
+			if format == ArchiveFormatTarGz {
+				gr, err := gzip.NewReader(&buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = gr
+			}
+			tr := tar.NewReader(r)
+			for _, f := range files {
+				hdr, err := tr.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				check(f, hdr.Name, hdr.FileInfo().Mode(), hdr.ModTime, tr)
+			}
+			if _, err := tr.Next(); err != io.EOF {
+				t.Fatal("expected end of archive but got", err)
+			}
+		case ArchiveFormatZip:
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(zr.File) != len(files) {
+				t.Fatalf("expected %v files but got %v", len(files), len(zr.File))
+			}
+			for i, zf := range zr.File {
+				rc, err := zf.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				check(files[i], zf.Name, zf.Mode(), zf.Modified, rc)
+				if err := rc.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	// Unknown formats are rejected.
+	if err := writeArchive(ioutil.Discard, "rar", files, openFn); !errors.Contains(err, errUnknownArchiveFormat) {
+		t.Fatal("expected errUnknownArchiveFormat but got", err)
+	}
+
+	// If a streamer can't be opened, all other streamers are closed.
+	missing, err := dir.Join("missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	brokenFiles := append(files[:2:2], archiveFile{
+		name: "docs/missing",
+		info: modules.FileInfo{SiaPath: missing, Filesize: 1},
+	})
+	brokenFiles = append(brokenFiles, files[2:]...)
+	if err := writeArchive(ioutil.Discard, ArchiveFormatTar, brokenFiles, openFn); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	if n := atomic.LoadInt64(&open); n != 0 {
+		t.Fatalf("%v streamers weren't closed", n)
+	}
+}
+
+// countingStreamer is a streamer which decrements a counter of open streamers
+// when it is closed.
+type countingStreamer struct {
+	modules.Streamer
+	open *int64
+}
+
+// Close implements io.Closer.
+func (cs *countingStreamer) Close() error {
+	atomic.AddInt64(cs.open, -1)
+	return cs.Streamer.Close()
+}
@@ -32,6 +32,25 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	return
 }
 
+// ConsensusCheckpointGet requests the /consensus/checkpoint api resource and
+// writes the checkpoint to w.
+func (c *Client) ConsensusCheckpointGet(w io.Writer) error {
+	req, err := c.NewRequest("GET", "/consensus/checkpoint", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return readAPIError(resp.Body)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
 // ConsensusSubscribeSingle streams consensus changes from the
 // /consensus/subscribe endpoint to the provided subscriber. Multiple calls may
 // be required before the subscriber is fully caught up. It returns the latest
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+// ExplorerAddressBalanceGet requests the /explorer/addresses/:address/balance
+// api resource for the current balance of an address.
+func (c *Client) ExplorerAddressBalanceGet(addr types.UnlockHash) (eabg api.ExplorerAddressBalanceGET, err error) {
+	err = c.get("/explorer/addresses/"+addr.String()+"/balance", &eabg)
+	return
+}
+
+// ExplorerAddressBalanceAtHeightGet requests the
+// /explorer/addresses/:address/balance api resource for the balance of an
+// address at the given height.
+func (c *Client) ExplorerAddressBalanceAtHeightGet(addr types.UnlockHash, height types.BlockHeight) (eabg api.ExplorerAddressBalanceGET, err error) {
+	err = c.get("/explorer/addresses/"+addr.String()+"/balance?height="+fmt.Sprint(height), &eabg)
+	return
+}
+
+// ExplorerAddressOutputsGet requests the /explorer/addresses/:address/outputs
+// api resource. A zero 'after' starts at the first output.
+func (c *Client) ExplorerAddressOutputsGet(addr types.UnlockHash, after types.OutputID, limit int) (eaog api.ExplorerAddressOutputsGET, err error) {
+	values := url.Values{}
+	if after != (types.OutputID{}) {
+		values.Set("after", after.String())
+	}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.get("/explorer/addresses/"+addr.String()+"/outputs?"+values.Encode(), &eaog)
+	return
+}
+
+// ExplorerHostGet requests the /explorer/hosts/:pubkey api resource
+func (c *Client) ExplorerHostGet(spk types.SiaPublicKey) (ehg api.ExplorerHostGET, err error) {
+	err = c.get("/explorer/hosts/"+spk.String(), &ehg)
+	return
+}
+
+// ExplorerHostStatsGet requests the /explorer/hosts/:pubkey/stats api
+// resource for the stats between the given heights (inclusive).
+func (c *Client) ExplorerHostStatsGet(spk types.SiaPublicKey, from, to types.BlockHeight) (ehsg api.ExplorerHostStatsGET, err error) {
+	values := url.Values{}
+	values.Set("from", fmt.Sprint(from))
+	values.Set("to", fmt.Sprint(to))
+	err = c.get("/explorer/hosts/"+spk.String()+"/stats?"+values.Encode(), &ehsg)
+	return
+}
+
+// ExplorerBlocksGet requests the /explorer/blocks api resource for the block
+// facts between the given heights (inclusive).
+func (c *Client) ExplorerBlocksGet(from, to types.BlockHeight) (ebg api.ExplorerBlocksGET, err error) {
+	values := url.Values{}
+	values.Set("from", fmt.Sprint(from))
+	values.Set("to", fmt.Sprint(to))
+	err = c.get("/explorer/blocks?"+values.Encode(), &ebg)
+	return
+}
+
+// ExplorerHashGet requests the /explorer/hashes/:hash api resource. For
+// hashes associated with sets of transactions, the page of transactions
+// between the given heights (inclusive) that starts after the transaction
+// with ID 'after' is returned. A zero 'after' starts at the first page.
+func (c *Client) ExplorerHashGet(hash string, from, to types.BlockHeight, after types.TransactionID, limit int) (ehg api.ExplorerHashGET, err error) {
+	values := url.Values{}
+	values.Set("from", fmt.Sprint(from))
+	values.Set("to", fmt.Sprint(to))
+	if after != (types.TransactionID{}) {
+		values.Set("after", after.String())
+	}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.get("/explorer/hashes/"+hash+"?"+values.Encode(), &ehg)
+	return
+}
@@ -1,6 +1,11 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -41,6 +46,36 @@ func (c *Client) MinerStartGet() (err error) {
 	return
 }
 
+// MinerTemplateGet requests the /miner/template endpoint's resources. If
+// longPollID is the ID of the current template, the call blocks until the
+// template changes or the timeout expires.
+func (c *Client) MinerTemplateGet(longPollID string, timeout time.Duration) (mtg api.MinerTemplateGET, err error) {
+	values := url.Values{}
+	if longPollID != "" {
+		values.Set("longpollid", longPollID)
+		values.Set("timeout", fmt.Sprint(int(timeout.Seconds())))
+	}
+	err = c.get("/miner/template?"+values.Encode(), &mtg)
+	return
+}
+
+// MinerTemplatePost uses the /miner/template endpoint to submit a solved block
+// that was assembled from a block template.
+func (c *Client) MinerTemplatePost(b types.Block) (mtp api.MinerTemplatePOST, err error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return api.MinerTemplatePOST{}, err
+	}
+	err = c.post("/miner/template", string(data), &mtp)
+	return
+}
+
+// MinerStratumGet requests the /miner/stratum endpoint's resources.
+func (c *Client) MinerStratumGet() (msg api.MinerStratumGET, err error) {
+	err = c.get("/miner/stratum", &msg)
+	return
+}
+
 // MinerStopGet uses the /miner/stop endpoint to stop the cpu miner.
 func (c *Client) MinerStopGet() (err error) {
 	err = c.get("/miner/stop", nil)
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -296,6 +297,26 @@ func (c *Client) RenterDownloadInfoGet(uid modules.DownloadID) (di api.DownloadI
 	return
 }
 
+// RenterBandwidthScheduleGet uses the /renter/bandwidthschedule endpoint to
+// get the renter's bandwidth schedule.
+func (c *Client) RenterBandwidthScheduleGet() (rbsg api.RenterBandwidthScheduleGET, err error) {
+	err = c.get("/renter/bandwidthschedule", &rbsg)
+	return
+}
+
+// RenterBandwidthSchedulePost uses the /renter/bandwidthschedule endpoint to
+// replace the renter's bandwidth schedule.
+func (c *Client) RenterBandwidthSchedulePost(windows []modules.BandwidthWindow) (err error) {
+	data, err := json.Marshal(api.RenterBandwidthSchedulePOST{
+		Windows: windows,
+	})
+	if err != nil {
+		return err
+	}
+	err = c.post("/renter/bandwidthschedule", string(data), nil)
+	return
+}
+
 // RenterBackups lists the backups the renter has uploaded to hosts.
 func (c *Client) RenterBackups() (ubs api.RenterBackupsGET, err error) {
 	err = c.get("/renter/backups", &ubs)
@@ -352,12 +373,22 @@ func (c *Client) RenterRecoverLocalBackupPost(src string) (err error) {
 // RenterDownloadFullGet uses the /renter/download endpoint to download a full
 // file.
 func (c *Client) RenterDownloadFullGet(siaPath modules.SiaPath, destination string, async, root bool) (modules.DownloadID, error) {
+	return c.RenterDownloadFullPriorityGet(siaPath, destination, "", async, root)
+}
+
+// RenterDownloadFullPriorityGet uses the /renter/download endpoint to download
+// a full file with the given priority class. An empty priority uses the
+// default class.
+func (c *Client) RenterDownloadFullPriorityGet(siaPath modules.SiaPath, destination string, priority modules.DownloadPriority, async, root bool) (modules.DownloadID, error) {
 	sp := escapeSiaPath(siaPath)
 	values := url.Values{}
 	values.Set("destination", destination)
 	values.Set("httpresp", fmt.Sprint(false))
 	values.Set("async", fmt.Sprint(async))
 	values.Set("root", fmt.Sprint(root))
+	if priority != "" {
+		values.Set("priority", string(priority))
+	}
 	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
 	if err != nil {
 		return "", err
@@ -536,6 +567,19 @@ func (c *Client) RenterStreamGet(siaPath modules.SiaPath, disableLocalFetch, roo
 	return
 }
 
+// RenterArchiveGet uses the /renter/archive endpoint to download a directory
+// as an archive of the given format. The caller has to close the returned
+// reader.
+func (c *Client) RenterArchiveGet(siaPath modules.SiaPath, format string, disableLocalFetch, root bool) (io.ReadCloser, error) {
+	values := url.Values{}
+	values.Set("format", format)
+	values.Set("disablelocalfetch", fmt.Sprint(disableLocalFetch))
+	values.Set("root", fmt.Sprint(root))
+	sp := escapeSiaPath(siaPath)
+	_, body, err := c.getReaderResponse(fmt.Sprintf("/renter/archive/%s?%s", sp, values.Encode()))
+	return body, err
+}
+
 // RenterStreamPartialGet uses the /renter/stream endpoint to download a part
 // of data as a stream.
 func (c *Client) RenterStreamPartialGet(siaPath modules.SiaPath, start, end uint64, disableLocalFetch, root bool) (resp []byte, err error) {
@@ -568,6 +612,29 @@ func (c *Client) RenterSetFileStuckPost(siaPath modules.SiaPath, root, stuck boo
 	return
 }
 
+// RenterSyncPost uses the /renter/sync endpoint to synchronize a local
+// directory with a siadir.
+func (c *Client) RenterSyncPost(params modules.SyncParams) (report modules.SyncReport, err error) {
+	sp := escapeSiaPath(params.SiaPath)
+	values := url.Values{}
+	values.Set("localpath", params.LocalPath)
+	values.Set("direction", string(params.Direction))
+	values.Set("delete", fmt.Sprint(params.Delete))
+	values.Set("dryrun", fmt.Sprint(params.DryRun))
+	if len(params.Include) > 0 {
+		values.Set("include", strings.Join(params.Include, ","))
+	}
+	if len(params.Exclude) > 0 {
+		values.Set("exclude", strings.Join(params.Exclude, ","))
+	}
+	if params.ErasureCode != nil {
+		values.Set("datapieces", strconv.Itoa(params.ErasureCode.MinPieces()))
+		values.Set("paritypieces", strconv.Itoa(params.ErasureCode.NumPieces()-params.ErasureCode.MinPieces()))
+	}
+	err = c.post(fmt.Sprintf("/renter/sync/%s", sp), values.Encode(), &report)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
 	return c.RenterUploadForcePost(path, siaPath, dataPieces, parityPieces, false)
@@ -586,6 +653,35 @@ func (c *Client) RenterUploadForcePost(path string, siaPath modules.SiaPath, dat
 	return
 }
 
+// RenterUploadChecksumPost uses the /renter/upload endpoint to upload a file
+// and compute its checksum with the given algorithm.
+func (c *Client) RenterUploadChecksumPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, checksum string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("checksum", checksum)
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUploadOptionsPost uses the /renter/upload endpoint to upload a file
+// with the given checksum algorithm and compression codec and with or without
+// deduplication. Empty values select the defaults.
+func (c *Client) RenterUploadOptionsPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, checksum, compression string, dedup bool) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("checksum", checksum)
+	values.Set("compression", compression)
+	values.Set("dedup", strconv.FormatBool(dedup))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.SiaPath) (err error) {
@@ -608,6 +704,63 @@ func (c *Client) RenterUploadStreamPost(r io.Reader, siaPath modules.SiaPath, da
 	return err
 }
 
+// RenterUploadStreamChecksumPost uploads data using a stream and computes its
+// checksum with the given algorithm.
+func (c *Client) RenterUploadStreamChecksumPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, checksum string) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("checksum", checksum)
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamCompressedPost uploads data using a stream and compresses
+// it with the given codec before it is encrypted.
+func (c *Client) RenterUploadStreamCompressedPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, compression string) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("compression", compression)
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamMetadataPost uploads a file using the /renter/uploadstream
+// endpoint with the given tags and user-defined metadata.
+func (c *Client) RenterUploadStreamMetadataPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64, tags []string, md map[string]string) error {
+	sp := escapeSiaPath(siaPath)
+	b, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("tags", strings.Join(tags, ","))
+	values.Set("metadata", string(b))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err = c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamDedupPost uploads a file using the /renter/uploadstream
+// endpoint with deduplication enabled.
+func (c *Client) RenterUploadStreamDedupPost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("dedup", strconv.FormatBool(true))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
 // RenterUploadStreamRepairPost a siafile using a stream. If the data provided
 // by r is not the same as the previously uploaded data, the data will be
 // corrupted.
@@ -676,6 +829,49 @@ func (c *Client) RenterDirGet(siaPath modules.SiaPath) (rd api.RenterDirectory,
 	return
 }
 
+// RenterTrashGet uses the /renter/trash endpoint to list the files and
+// directories in the trash.
+func (c *Client) RenterTrashGet() (rtg api.RenterTrashGET, err error) {
+	err = c.get("/renter/trash", &rtg)
+	return
+}
+
+// RenterTrashRootGet uses the /renter/trash endpoint to list all the files and
+// directories in the trash with siapaths relative to the root.
+func (c *Client) RenterTrashRootGet() (rtg api.RenterTrashGET, err error) {
+	err = c.get("/renter/trash?root=true", &rtg)
+	return
+}
+
+// RenterTrashRetentionPost uses the /renter/trash endpoint to set the number
+// of days after which files and directories are purged from the trash.
+func (c *Client) RenterTrashRetentionPost(days uint64) (err error) {
+	values := url.Values{}
+	values.Set("retentiondays", fmt.Sprint(days))
+	err = c.post("/renter/trash", values.Encode(), nil)
+	return
+}
+
+// RenterTrashPurgePost uses the /renter/trash/purge endpoint to permanently
+// delete a file or directory in the trash. An empty id purges the whole trash.
+func (c *Client) RenterTrashPurgePost(id string) (err error) {
+	values := url.Values{}
+	if id != "" {
+		values.Set("id", id)
+	}
+	err = c.post("/renter/trash/purge", values.Encode(), nil)
+	return
+}
+
+// RenterTrashRestorePost uses the /renter/trash/restore endpoint to restore a
+// file or directory from the trash.
+func (c *Client) RenterTrashRestorePost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/renter/trash/restore", values.Encode(), nil)
+	return
+}
+
 // RenterValidateSiaPathPost uses the /renter/validatesiapath endpoint to
 // validate a potential siapath
 //
@@ -686,6 +882,131 @@ func (c *Client) RenterValidateSiaPathPost(siaPathStr string) (err error) {
 	return
 }
 
+// RenterVersioningGet uses the /renter/versioning endpoint to list the
+// directories that have versioning enabled.
+func (c *Client) RenterVersioningGet() (rvg api.RenterVersioningGET, err error) {
+	err = c.get("/renter/versioning", &rvg)
+	return
+}
+
+// RenterVersioningPost uses the /renter/versioning endpoint to enable
+// versioning for a directory.
+func (c *Client) RenterVersioningPost(siaPath modules.SiaPath, keepVersions, keepDays uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("keepversions", fmt.Sprint(keepVersions))
+	values.Set("keepdays", fmt.Sprint(keepDays))
+	err = c.post(fmt.Sprintf("/renter/versioning/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterVersioningDisablePost uses the /renter/versioning endpoint to disable
+// versioning for a directory.
+func (c *Client) RenterVersioningDisablePost(siaPath modules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("disable", "true")
+	err = c.post(fmt.Sprintf("/renter/versioning/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterVersionsGet uses the /renter/versions endpoint to list the prior
+// versions of a file.
+func (c *Client) RenterVersionsGet(siaPath modules.SiaPath) (rvg api.RenterFileVersionsGET, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get(fmt.Sprintf("/renter/versions/%s", sp), &rvg)
+	return
+}
+
+// RenterVersionRestorePost uses the /renter/versions endpoint to restore a
+// prior version of a file.
+func (c *Client) RenterVersionRestorePost(siaPath modules.SiaPath, version string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("version", version)
+	err = c.post(fmt.Sprintf("/renter/versions/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDownloadVersionGet uses the /renter/download endpoint to download a
+// prior version of a file to destination.
+func (c *Client) RenterDownloadVersionGet(siaPath modules.SiaPath, version, destination string) (modules.DownloadID, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("version", version)
+	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return modules.DownloadID(h.Get("ID")), nil
+}
+
+// RenterTagsPost uses the /renter/metadata endpoint to replace the tags of a
+// file or directory.
+func (c *Client) RenterTagsPost(siaPath modules.SiaPath, tags []string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("tags", strings.Join(tags, ","))
+	err = c.post(fmt.Sprintf("/renter/metadata/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUserMetadataPost uses the /renter/metadata endpoint to replace the
+// user-defined metadata of a file or directory.
+func (c *Client) RenterUserMetadataPost(siaPath modules.SiaPath, md map[string]string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	b, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("metadata", string(b))
+	err = c.post(fmt.Sprintf("/renter/metadata/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterFindGet uses the /renter/find endpoint to search the files and
+// directories within a directory.
+func (c *Client) RenterFindGet(siaPath modules.SiaPath, query modules.FileQuery) (rfg api.RenterFindGET, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	if len(query.Tags) > 0 {
+		values.Set("tags", strings.Join(query.Tags, ","))
+	}
+	if len(query.UserMetadata) > 0 {
+		b, err := json.Marshal(query.UserMetadata)
+		if err != nil {
+			return api.RenterFindGET{}, err
+		}
+		values.Set("metadata", string(b))
+	}
+	if query.MinSize > 0 {
+		values.Set("minsize", fmt.Sprint(query.MinSize))
+	}
+	if query.MaxSize > 0 {
+		values.Set("maxsize", fmt.Sprint(query.MaxSize))
+	}
+	if query.MinHealth != nil {
+		values.Set("minhealth", fmt.Sprint(*query.MinHealth))
+	}
+	if query.MaxHealth != nil {
+		values.Set("maxhealth", fmt.Sprint(*query.MaxHealth))
+	}
+	for name, t := range map[string]time.Time{
+		"modifiedafter":  query.ModifiedAfter,
+		"modifiedbefore": query.ModifiedBefore,
+		"createdafter":   query.CreatedAfter,
+		"createdbefore":  query.CreatedBefore,
+	} {
+		if !t.IsZero() {
+			values.Set(name, fmt.Sprint(t.Unix()))
+		}
+	}
+	err = c.get(fmt.Sprintf("/renter/find/%s?%s", sp, values.Encode()), &rfg)
+	return
+}
+
 // RenterUploadReadyGet uses the /renter/uploadready endpoint to determine if
 // the renter is ready for upload.
 func (c *Client) RenterUploadReadyGet(dataPieces, parityPieces uint64) (rur api.RenterUploadReadyGet, err error) {
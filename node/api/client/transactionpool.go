@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/url"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -15,6 +16,14 @@ func (c *Client) TransactionPoolFeeGet() (tfg api.TpoolFeeGET, err error) {
 	return
 }
 
+// TransactionPoolFeeTargetGet uses the /tpool/fee endpoint to get a fee
+// estimation that includes the fee to get a transaction confirmed within
+// target blocks.
+func (c *Client) TransactionPoolFeeTargetGet(target types.BlockHeight) (tfg api.TpoolFeeGET, err error) {
+	err = c.get(fmt.Sprintf("/tpool/fee?target=%v", target), &tfg)
+	return
+}
+
 // TransactionPoolRawPost uses the /tpool/raw endpoint to send a raw
 // transaction to the transaction pool.
 func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents []types.Transaction) (err error) {
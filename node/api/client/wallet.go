@@ -27,6 +27,19 @@ func (c *Client) WalletAddressesGet() (wag api.WalletAddressesGET, err error) {
 	return
 }
 
+// WalletBumpPost uses the /wallet/bump endpoint to raise the fee rate of the
+// unconfirmed transaction set containing txid with a child transaction. A zero
+// feePerByte uses the fee estimated by the transaction pool.
+func (c *Client) WalletBumpPost(txid types.TransactionID, feePerByte types.Currency) (wbp api.WalletBumpPOST, err error) {
+	values := url.Values{}
+	values.Set("txid", txid.String())
+	if !feePerByte.IsZero() {
+		values.Set("feeperbyte", feePerByte.String())
+	}
+	err = c.post("/wallet/bump", values.Encode(), &wbp)
+	return
+}
+
 // WalletChangePasswordPost uses the /wallet/changepassword endpoint to change
 // the wallet's password.
 func (c *Client) WalletChangePasswordPost(currentPassword, newPassword string) (err error) {
@@ -152,6 +165,19 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiacoinsFeeTargetPost uses the /wallet/siacoins api endpoint to send
+// money to a single address, paying the fee that is estimated to get the
+// transaction confirmed within feeTarget blocks.
+func (c *Client) WalletSiacoinsFeeTargetPost(amount types.Currency, destination types.UnlockHash, feeIncluded bool, feeTarget types.BlockHeight) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	values.Set("feeIncluded", strconv.FormatBool(feeIncluded))
+	values.Set("feetarget", fmt.Sprint(feeTarget))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -123,6 +123,9 @@ func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet)
 	router.GET("/consensus/blocks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusBlocksHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/checkpoint", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusCheckpointHandler(cs, w, req, ps)
+	})
 	router.GET("/consensus/subscribe/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusSubscribeHandler(cs, w, req, ps)
 	})
@@ -323,6 +326,20 @@ func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.Resp
 	WriteSuccess(w)
 }
 
+// consensusCheckpointHandler handles the API call to export a checkpoint of
+// the consensus set.
+func consensusCheckpointHandler(cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if cs.Height() == 0 {
+		WriteError(w, Error{"cannot create a checkpoint of the genesis block"}, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	// The checkpoint is streamed, so errors can't be reported to the client
+	// once the export has started. A failed export results in a truncated
+	// checkpoint, which is rejected when it is imported.
+	_, _, _, _ = cs.ExportCheckpoint(w)
+}
+
 // consensusSubscribeHandler handles the API calls to the /consensus/subscribe
 // endpoint.
 func consensusSubscribeHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"io"
 	"testing"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -168,3 +170,46 @@ func TestIntegrationConsensusSubscribe(t *testing.T) {
 		}
 	}
 }
+
+// TestIntegrationConsensusCheckpoint probes the /consensus/checkpoint
+// endpoint.
+func TestIntegrationConsensusCheckpoint(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/consensus/checkpoint")
+	if err != nil {
+		t.Fatal("unable to make an http request", err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if non2xx(resp.StatusCode) {
+		t.Fatal(decodeError(resp))
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkpoint should match a checkpoint exported directly from the
+	// consensus set.
+	var expected bytes.Buffer
+	_, _, hash, err := st.cs.ExportCheckpoint(&expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected.Bytes()) || crypto.HashBytes(buf.Bytes()) != hash {
+		t.Fatal("checkpoint returned by the API does not match the consensus set")
+	}
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -12,6 +15,20 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// defaultExplorerLimit is the number of results returned by paginated
+	// explorer endpoints if no limit is specified.
+	defaultExplorerLimit = 100
+
+	// maxExplorerLimit is the maximum number of results returned by a single
+	// call to a paginated explorer endpoint.
+	maxExplorerLimit = 1000
+
+	// explorerBlocksFlushInterval is the number of block facts that are
+	// written to a /explorer/blocks response between flushes.
+	explorerBlocksFlushInterval = 100
+)
+
 type (
 	// ExplorerBlock is a block with some extra information such as the id and
 	// height. This information is provided for programs that may not be
@@ -53,6 +70,41 @@ type (
 		modules.BlockFacts
 	}
 
+	// ExplorerAddressBalanceGET is the object returned by a GET request to
+	// /explorer/addresses/:address/balance.
+	ExplorerAddressBalanceGET struct {
+		modules.AddressBalance
+	}
+
+	// ExplorerAddressOutputsGET is the object returned by a GET request to
+	// /explorer/addresses/:address/outputs. If More is true, the next page
+	// starts after the last output.
+	ExplorerAddressOutputsGET struct {
+		Outputs []modules.UnspentOutput `json:"outputs"`
+		More    bool                    `json:"more"`
+	}
+
+	// ExplorerBlocksGET is the object returned by a GET request to
+	// /explorer/blocks. The response is streamed.
+	ExplorerBlocksGET struct {
+		Facts []modules.BlockFacts `json:"facts"`
+	}
+
+	// ExplorerHostGET is the object returned by a GET request to
+	// /explorer/hosts/:pubkey. ProofSuccessRate is the fraction of the
+	// host's resolved contracts for which a storage proof was submitted.
+	ExplorerHostGET struct {
+		Announcements    []modules.ExplorerHostAnnouncement `json:"announcements"`
+		ContractStats    modules.HostContractStats          `json:"contractstats"`
+		ProofSuccessRate float64                            `json:"proofsuccessrate"`
+	}
+
+	// ExplorerHostStatsGET is the object returned by a GET request to
+	// /explorer/hosts/:pubkey/stats.
+	ExplorerHostStatsGET struct {
+		History []modules.HostContractStats `json:"history"`
+	}
+
 	// ExplorerBlockGET is the object returned by a GET request to
 	// /explorer/block.
 	ExplorerBlockGET struct {
@@ -73,6 +125,12 @@ type (
 		Blocks       []ExplorerBlock       `json:"blocks"`
 		Transaction  ExplorerTransaction   `json:"transaction"`
 		Transactions []ExplorerTransaction `json:"transactions"`
+
+		// TransactionIDs lists the ids of the blocks and transactions of a
+		// page in height order. More indicates whether there are more pages,
+		// the next of which starts after the last id of this page.
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+		More           bool                  `json:"more"`
 	}
 )
 
@@ -81,9 +139,24 @@ func RegisterRoutesExplorer(router *httprouter.Router, e modules.Explorer, cs mo
 	router.GET("/explorer", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerHandler(e, w, req, ps)
 	})
+	router.GET("/explorer/addresses/:address/balance", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerAddressBalanceHandler(e, w, req, ps)
+	})
+	router.GET("/explorer/addresses/:address/outputs", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerAddressOutputsHandler(e, w, req, ps)
+	})
+	router.GET("/explorer/blocks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerBlocksRangeHandler(e, w, req, ps)
+	})
 	router.GET("/explorer/blocks/:height", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerBlocksHandler(e, cs, w, req, ps)
 	})
+	router.GET("/explorer/hosts/:pubkey", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerHostHandler(e, w, req, ps)
+	})
+	router.GET("/explorer/hosts/:pubkey/stats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerHostStatsHandler(e, w, req, ps)
+	})
 	router.GET("/explorer/hashes/:hash", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerHashHandler(e, w, req, ps)
 	})
@@ -210,6 +283,165 @@ func buildExplorerBlock(explorer modules.Explorer, height types.BlockHeight, blo
 	}
 }
 
+// explorerAddressBalanceHandler handles GET requests to
+// /explorer/addresses/:address/balance.
+func explorerAddressBalanceHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("address"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Default to the current height.
+	currentHeight := explorer.LatestBlockFacts().Height
+	height := currentHeight
+	if req.FormValue("height") != "" {
+		_, err := fmt.Sscan(req.FormValue("height"), &height)
+		if err != nil {
+			WriteError(w, Error{"unable to parse height: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if height > currentHeight {
+			WriteError(w, Error{"height is above the current block height"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	balance, exists := explorer.AddressBalance(addr, height)
+	if !exists {
+		WriteError(w, Error{"address does not appear in the blockchain"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ExplorerAddressBalanceGET{
+		AddressBalance: balance,
+	})
+}
+
+// explorerAddressOutputsHandler handles GET requests to
+// /explorer/addresses/:address/outputs.
+func explorerAddressOutputsHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("address"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var after types.OutputID
+	if req.FormValue("after") != "" {
+		h, err := scanHash(req.FormValue("after"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse after: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		after = types.OutputID(h)
+	}
+	limit, err := scanExplorerLimit(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra output to determine whether there are more outputs.
+	outputs := explorer.AddressOutputs(addr, after, limit+1)
+	more := len(outputs) > limit
+	if more {
+		outputs = outputs[:limit]
+	}
+	if outputs == nil {
+		outputs = []modules.UnspentOutput{}
+	}
+	WriteJSON(w, ExplorerAddressOutputsGET{
+		Outputs: outputs,
+		More:    more,
+	})
+}
+
+// explorerBlocksRangeHandler handles GET requests to /explorer/blocks. The
+// block facts are streamed so that large ranges don't need to be buffered.
+func explorerBlocksRangeHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	from, to, err := scanExplorerHeightRange(req, explorer.LatestBlockFacts().Height)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := io.WriteString(w, `{"facts":[`); err != nil {
+		return
+	}
+	enc := json.NewEncoder(w)
+	flusher, canFlush := w.(http.Flusher)
+	for height := from; height <= to; height++ {
+		// The facts may not exist if the blocks were reverted after the
+		// range was checked.
+		facts, exists := explorer.BlockFacts(height)
+		if !exists {
+			break
+		}
+		if height > from {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return
+			}
+		}
+		if err := enc.Encode(facts); err != nil {
+			return
+		}
+		if canFlush && (height-from)%explorerBlocksFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+	_, _ = io.WriteString(w, "]}\n")
+}
+
+// explorerHostHandler handles GET requests to /explorer/hosts/:pubkey.
+func explorerHostHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var spk types.SiaPublicKey
+	if err := spk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	anns := explorer.HostAnnouncements(spk)
+	stats, exists := explorer.HostContractStats(spk)
+	if len(anns) == 0 && !exists {
+		WriteError(w, Error{"host does not appear in the blockchain"}, http.StatusBadRequest)
+		return
+	}
+	if anns == nil {
+		anns = []modules.ExplorerHostAnnouncement{}
+	}
+
+	var successRate float64
+	if resolved := stats.StorageProofCount + stats.MissedProofCount; resolved > 0 {
+		successRate = float64(stats.StorageProofCount) / float64(resolved)
+	}
+	WriteJSON(w, ExplorerHostGET{
+		Announcements:    anns,
+		ContractStats:    stats,
+		ProofSuccessRate: successRate,
+	})
+}
+
+// explorerHostStatsHandler handles GET requests to
+// /explorer/hosts/:pubkey/stats.
+func explorerHostStatsHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var spk types.SiaPublicKey
+	if err := spk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	from, to, err := scanExplorerHeightRange(req, explorer.LatestBlockFacts().Height)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	history := explorer.HostContractStatsHistory(spk, from, to)
+	if history == nil {
+		history = []modules.HostContractStats{}
+	}
+	WriteJSON(w, ExplorerHostStatsGET{
+		History: history,
+	})
+}
+
 // explorerHandler handles API calls to /explorer/blocks/:height.
 func explorerBlocksHandler(e modules.Explorer, cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Parse the height that's being requested.
@@ -259,7 +491,7 @@ func buildTransactionSet(explorer modules.Explorer, txids []types.TransactionID)
 }
 
 // explorerHashHandler handles GET requests to /explorer/hash/:hash.
-func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Scan the hash as a hash. If that fails, try scanning the hash as an
 	// address.
 	hash, err := scanHash(ps.ByName("hash"))
@@ -272,13 +504,6 @@ func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, _ *ht
 		hash = crypto.Hash(addr)
 	}
 
-	// TODO: lookups on the zero hash are too expensive to allow. Need a
-	// better way to handle this case.
-	if hash == (crypto.Hash{}) {
-		WriteError(w, Error{"can't lookup the empty unlock hash"}, http.StatusBadRequest)
-		return
-	}
-
 	// Try the hash as a block id.
 	block, height, exists := explorer.Block(types.BlockID(hash))
 	if exists {
@@ -305,57 +530,56 @@ func explorerHashHandler(explorer modules.Explorer, w http.ResponseWriter, _ *ht
 		return
 	}
 
-	// Try the hash as a siacoin output id.
-	txids := explorer.SiacoinOutputID(types.SiacoinOutputID(hash))
-	if len(txids) != 0 {
-		txns, blocks := buildTransactionSet(explorer, txids)
-		WriteJSON(w, ExplorerHashGET{
-			HashType:     "siacoinoutputid",
-			Blocks:       blocks,
-			Transactions: txns,
-		})
-		return
-	}
-
-	// Try the hash as a file contract id.
-	txids = explorer.FileContractID(types.FileContractID(hash))
-	if len(txids) != 0 {
-		txns, blocks := buildTransactionSet(explorer, txids)
-		WriteJSON(w, ExplorerHashGET{
-			HashType:     "filecontractid",
-			Blocks:       blocks,
-			Transactions: txns,
-		})
+	// The remaining hash types are associated with sets of transactions,
+	// which are paginated.
+	q, err := scanExplorerTransactionQuery(req, explorer.LatestBlockFacts().Height)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
-
-	// Try the hash as a siafund output id.
-	txids = explorer.SiafundOutputID(types.SiafundOutputID(hash))
-	if len(txids) != 0 {
-		txns, blocks := buildTransactionSet(explorer, txids)
-		WriteJSON(w, ExplorerHashGET{
-			HashType:     "siafundoutputid",
-			Blocks:       blocks,
-			Transactions: txns,
-		})
-		return
+	lookups := []struct {
+		hashType string
+		lookup   func() ([]types.TransactionID, bool, error)
+	}{
+		{"siacoinoutputid", func() ([]types.TransactionID, bool, error) {
+			return explorer.SiacoinOutputTransactions(types.SiacoinOutputID(hash), q)
+		}},
+		{"filecontractid", func() ([]types.TransactionID, bool, error) {
+			return explorer.FileContractTransactions(types.FileContractID(hash), q)
+		}},
+		{"siafundoutputid", func() ([]types.TransactionID, bool, error) {
+			return explorer.SiafundOutputTransactions(types.SiafundOutputID(hash), q)
+		}},
+		// Unlock hash is checked last because unlock hashes do not have
+		// collision-free guarantees. Someone can create an unlock hash that
+		// collides with another object id. They will not be able to use the
+		// unlock hash, but they can disrupt the explorer. This is handled by
+		// checking the unlock hash last. Anyone intentionally creating a
+		// colliding unlock hash (such a collision can only happen if done
+		// intentionally) will be unable to find their unlock hash in the
+		// blockchain through the explorer hash lookup.
+		{"unlockhash", func() ([]types.TransactionID, bool, error) {
+			return explorer.UnlockHashTransactions(types.UnlockHash(hash), q)
+		}},
 	}
-
-	// Try the hash as an unlock hash. Unlock hash is checked last because
-	// unlock hashes do not have collision-free guarantees. Someone can create
-	// an unlock hash that collides with another object id. They will not be
-	// able to use the unlock hash, but they can disrupt the explorer. This is
-	// handled by checking the unlock hash last. Anyone intentionally creating
-	// a colliding unlock hash (such a collision can only happen if done
-	// intentionally) will be unable to find their unlock hash in the
-	// blockchain through the explorer hash lookup.
-	txids = explorer.UnlockHash(types.UnlockHash(hash))
-	if len(txids) != 0 {
+	for _, l := range lookups {
+		txids, more, err := l.lookup()
+		if errors.Contains(err, modules.ErrExplorerObjectNotFound) {
+			continue
+		} else if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
 		txns, blocks := buildTransactionSet(explorer, txids)
+		if txids == nil {
+			txids = []types.TransactionID{}
+		}
 		WriteJSON(w, ExplorerHashGET{
-			HashType:     "unlockhash",
-			Blocks:       blocks,
-			Transactions: txns,
+			HashType:       l.hashType,
+			Blocks:         blocks,
+			Transactions:   txns,
+			TransactionIDs: txids,
+			More:           more,
 		})
 		return
 	}
@@ -371,3 +595,61 @@ func explorerHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.R
 		BlockFacts: facts,
 	})
 }
+
+// scanExplorerLimit scans the limit of a paginated explorer request.
+func scanExplorerLimit(req *http.Request) (int, error) {
+	limit := defaultExplorerLimit
+	if req.FormValue("limit") == "" {
+		return limit, nil
+	}
+	if _, err := fmt.Sscan(req.FormValue("limit"), &limit); err != nil {
+		return 0, errors.AddContext(err, "unable to parse limit")
+	}
+	if limit <= 0 || limit > maxExplorerLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %v", maxExplorerLimit)
+	}
+	return limit, nil
+}
+
+// scanExplorerHeightRange scans the 'from' and 'to' heights of an explorer
+// request. The range defaults to all heights up to the current height.
+func scanExplorerHeightRange(req *http.Request, currentHeight types.BlockHeight) (from, to types.BlockHeight, err error) {
+	to = currentHeight
+	if req.FormValue("from") != "" {
+		if _, err := fmt.Sscan(req.FormValue("from"), &from); err != nil {
+			return 0, 0, errors.AddContext(err, "unable to parse from")
+		}
+	}
+	if req.FormValue("to") != "" {
+		if _, err := fmt.Sscan(req.FormValue("to"), &to); err != nil {
+			return 0, 0, errors.AddContext(err, "unable to parse to")
+		}
+	}
+	if from > to {
+		return 0, 0, errors.New("from must not be greater than to")
+	} else if to > currentHeight {
+		return 0, 0, errors.New("to is above the current block height")
+	}
+	return from, to, nil
+}
+
+// scanExplorerTransactionQuery scans the height range and pagination
+// parameters of an explorer request for a set of transactions.
+func scanExplorerTransactionQuery(req *http.Request, currentHeight types.BlockHeight) (q modules.ExplorerTransactionQuery, err error) {
+	q.MinHeight, q.MaxHeight, err = scanExplorerHeightRange(req, currentHeight)
+	if err != nil {
+		return modules.ExplorerTransactionQuery{}, err
+	}
+	q.Limit, err = scanExplorerLimit(req)
+	if err != nil {
+		return modules.ExplorerTransactionQuery{}, err
+	}
+	if req.FormValue("after") != "" {
+		h, err := scanHash(req.FormValue("after"))
+		if err != nil {
+			return modules.ExplorerTransactionQuery{}, errors.AddContext(err, "unable to parse after")
+		}
+		q.After = types.TransactionID(h)
+	}
+	return q, nil
+}
@@ -81,3 +81,48 @@ func TestIntegrationExplorerHashGet(t *testing.T) {
 		t.Error("wrong block type returned")
 	}
 }
+
+// TestExplorerRangeAndPagination probes the /explorer/blocks range endpoint
+// and the pagination of /explorer/hashes/:hash.
+func TestExplorerRangeAndPagination(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createExplorerServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.panicClose()
+
+	var ebg ExplorerBlocksGET
+	err = st.getAPI("/explorer/blocks", &ebg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ebg.Facts) != 1 || ebg.Facts[0].BlockID != types.GenesisID {
+		t.Fatal("expected the genesis block facts", ebg.Facts)
+	}
+	if err := st.getAPI("/explorer/blocks?from=1", &ebg); err == nil {
+		t.Fatal("expected an error for a range above the current height")
+	}
+
+	uh := types.GenesisBlock.Transactions[0].SiafundOutputs[0].UnlockHash
+	var ehg ExplorerHashGET
+	err = st.getAPI("/explorer/hashes/"+uh.String()+"?limit=1", &ehg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ehg.HashType != "unlockhash" || len(ehg.TransactionIDs) != 1 || ehg.More {
+		t.Fatal("wrong page", ehg.HashType, ehg.TransactionIDs, ehg.More)
+	}
+	err = st.getAPI("/explorer/hashes/"+uh.String()+"?after="+ehg.TransactionIDs[0].String(), &ehg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ehg.TransactionIDs) != 0 || ehg.More {
+		t.Fatal("expected an empty last page", ehg.TransactionIDs)
+	}
+	if err := st.getAPI("/explorer/hashes/"+uh.String()+"?limit=0", &ehg); err == nil {
+		t.Fatal("expected an error for an invalid limit")
+	}
+}
@@ -14,9 +14,11 @@ import (
 type (
 	// GatewayGET contains the fields returned by a GET call to "/gateway".
 	GatewayGET struct {
-		NetAddress modules.NetAddress `json:"netaddress"`
-		Peers      []modules.Peer     `json:"peers"`
-		Online     bool               `json:"online"`
+		NetAddress  modules.NetAddress `json:"netaddress"`
+		Peers       []modules.Peer     `json:"peers"`
+		PeerHistory []modules.Peer     `json:"peerhistory"`
+		Bans        []modules.PeerBan  `json:"bans"`
+		Online      bool               `json:"online"`
 
 		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
 		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
@@ -86,7 +88,12 @@ func gatewayHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.R
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{gateway.Address(), peers, gateway.Online(), mds, mus})
+	bans, err := gateway.Bans()
+	if err != nil {
+		WriteError(w, Error{"failed to get the gateway bans: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, GatewayGET{gateway.Address(), peers, gateway.PeerHistory(), bans, gateway.Online(), mds, mus})
 }
 
 // gatewayHandlerPOST handles the API call changing gateway specific settings.
@@ -2,7 +2,9 @@ package api
 
 import (
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules/gateway"
 )
@@ -116,4 +118,21 @@ func TestGatewayPeerDisconnect(t *testing.T) {
 	if len(info.Peers) != 0 {
 		t.Fatal("/gateway/disconnect did not disconnect from peer", peer.Address())
 	}
+
+	// The peer should show up in the history once its connection is closed.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if err := st.getAPI("/gateway", &info); err != nil {
+			return err
+		}
+		if len(info.PeerHistory) != 1 || info.PeerHistory[0].NetAddress != peer.Address() {
+			return errors.New("peer missing from history")
+		}
+		if info.PeerHistory[0].Stats.BytesSent == 0 || info.PeerHistory[0].Stats.DisconnectedAt.IsZero() {
+			return errors.New("history is missing stats")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 }
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -10,6 +14,16 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// defaultTemplateTimeout is the time a long-polling request to
+	// /miner/template waits for a new template by default.
+	defaultTemplateTimeout = 60 * time.Second
+
+	// maxTemplateTimeout is the maximum time a long-polling request to
+	// /miner/template can wait for a new template.
+	maxTemplateTimeout = 10 * time.Minute
+)
+
 type (
 	// MinerGET contains the information that is returned after a GET request
 	// to /miner.
@@ -19,6 +33,24 @@ type (
 		CPUMining        bool `json:"cpumining"`
 		StaleBlocksMined int  `json:"staleblocksmined"`
 	}
+
+	// MinerTemplateGET contains the information that is returned after a GET
+	// request to /miner/template.
+	MinerTemplateGET struct {
+		modules.BlockTemplate
+	}
+
+	// MinerTemplatePOST contains the information that is returned after a
+	// POST request to /miner/template.
+	MinerTemplatePOST struct {
+		ID types.BlockID `json:"id"`
+	}
+
+	// MinerStratumGET contains the information that is returned after a GET
+	// request to /miner/stratum.
+	MinerStratumGET struct {
+		modules.StratumStats
+	}
 )
 
 // RegisterRoutesMiner is a helper function to register all miner routes.
@@ -41,6 +73,15 @@ func RegisterRoutesMiner(router *httprouter.Router, m modules.Miner, requiredPas
 	router.GET("/miner/stop", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		minerStopHandler(m, w, req, ps)
 	}, requiredPassword))
+	router.GET("/miner/template", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		minerTemplateHandlerGET(m, w, req, ps)
+	}, requiredPassword))
+	router.POST("/miner/template", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		minerTemplateHandlerPOST(m, w, req, ps)
+	}, requiredPassword))
+	router.GET("/miner/stratum", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		minerStratumHandlerGET(m, w, req, ps)
+	})
 }
 
 // minerHandler handles the API call that queries the miner's status.
@@ -55,6 +96,12 @@ func minerHandler(miner modules.Miner, w http.ResponseWriter, _ *http.Request, _
 	WriteJSON(w, mg)
 }
 
+// minerStratumHandlerGET handles the API call that queries the status of the
+// stratum server.
+func minerStratumHandlerGET(miner modules.Miner, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, MinerStratumGET{miner.StratumStats()})
+}
+
 // minerStartHandler handles the API call that starts the miner.
 func minerStartHandler(miner modules.Miner, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	miner.StartCPUMining()
@@ -111,3 +158,45 @@ func minerBlockHandlerPOST(miner modules.Miner, w http.ResponseWriter, req *http
 	}
 	WriteSuccess(w)
 }
+
+// minerTemplateHandlerGET handles the API call that retrieves the current
+// block template, optionally waiting for it to change.
+func minerTemplateHandlerGET(miner modules.Miner, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	timeout := defaultTemplateTimeout
+	if t := req.FormValue("timeout"); t != "" {
+		seconds, err := strconv.ParseUint(t, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse timeout: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+		if timeout > maxTemplateTimeout {
+			timeout = maxTemplateTimeout
+		}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+	bt, err := miner.BlockTemplate(req.FormValue("longpollid"), ctx.Done())
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, MinerTemplateGET{bt})
+}
+
+// minerTemplateHandlerPOST handles the API call to submit a solved block that
+// was assembled from a block template.
+func minerTemplateHandlerPOST(miner modules.Miner, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var b types.Block
+	err := json.NewDecoder(req.Body).Decode(&b)
+	if err != nil {
+		WriteError(w, Error{"could not decode block: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = miner.SubmitBlockTemplate(b)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, MinerTemplatePOST{b.ID()})
+}
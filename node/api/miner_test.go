@@ -1,12 +1,18 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
 	"testing"
 	"time"
 	"unsafe"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules/miner"
 	"go.sia.tech/siad/types"
 )
 
@@ -45,6 +51,117 @@ func TestMinerGET(t *testing.T) {
 	}
 }
 
+// TestMinerStratumGET checks the GET call to the /miner/stratum endpoint.
+func TestMinerStratumGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// The stratum server is not running by default.
+	var msg MinerStratumGET
+	if err := st.getAPI("/miner/stratum", &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Address != "" {
+		t.Fatal("stratum server should not be running", msg.Address)
+	}
+
+	// Start the server and check that the address is reported.
+	m, ok := st.miner.(*miner.Miner)
+	if !ok {
+		t.Fatal("unexpected miner type")
+	}
+	if err := m.StartStratum("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/miner/stratum", &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Address == "" || msg.AcceptedShares != 0 || len(msg.Workers) != 0 {
+		t.Fatal("unexpected stratum status", msg)
+	}
+}
+
+// TestMinerTemplate checks that blocks can be mined with the template GET and
+// POST calls.
+func TestMinerTemplate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	startingHeight := st.cs.Height()
+
+	var mtg MinerTemplateGET
+	if err := st.getAPI("/miner/template", &mtg); err != nil {
+		t.Fatal(err)
+	}
+	if mtg.ParentID != st.cs.CurrentBlock().ID() {
+		t.Fatal("template doesn't build on the current block")
+	}
+
+	// Long-polling times out if the template doesn't change.
+	var same MinerTemplateGET
+	if err := st.getAPI("/miner/template?timeout=1&longpollid="+mtg.ID, &same); err != nil {
+		t.Fatal(err)
+	}
+	if same.ID != mtg.ID {
+		t.Fatal("template changed unexpectedly")
+	}
+
+	// Assemble a block without transactions, solve and submit it.
+	b := types.Block{
+		ParentID:     mtg.ParentID,
+		Timestamp:    mtg.Timestamp,
+		MinerPayouts: []types.SiacoinOutput{{Value: mtg.Subsidy}},
+	}
+	for {
+		id := b.ID()
+		if bytes.Compare(mtg.Target[:], id[:]) >= 0 {
+			break
+		}
+		*(*uint64)(unsafe.Pointer(&b.Nonce)) += types.ASICHardforkFactor
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := HttpPOST("http://"+st.server.listener.Addr().String()+"/miner/template", string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mtp MinerTemplatePOST
+	err = json.NewDecoder(resp.Body).Decode(&mtp)
+	if err := errors.Compose(err, resp.Body.Close()); err != nil {
+		t.Fatal(err)
+	}
+	if mtp.ID != b.ID() || st.cs.Height() != startingHeight+1 {
+		t.Fatal("block wasn't accepted")
+	}
+
+	// Submitting the block again fails, it no longer extends the template.
+	resp, err = HttpPOST("http://"+st.server.listener.Addr().String()+"/miner/template", string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected a stale block to be rejected", resp.StatusCode)
+	}
+}
+
 // TestMinerStartStop checks that the miner start and miner stop api endpoints
 // toggle the cpu miner.
 func TestMinerStartStop(t *testing.T) {
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -242,6 +244,48 @@ type (
 		ParityPieces int `json:"paritypieces"`
 	}
 
+	// RenterVersioningPolicy is the versioning policy of a directory.
+	RenterVersioningPolicy struct {
+		SiaPath modules.SiaPath `json:"siapath"`
+		modules.VersioningPolicy
+	}
+
+	// RenterVersioningGET lists the directories that have versioning enabled.
+	RenterVersioningGET struct {
+		Policies []RenterVersioningPolicy `json:"policies"`
+	}
+
+	// RenterFileVersionsGET lists the prior versions of a file.
+	RenterFileVersionsGET struct {
+		Versions []modules.FileVersion `json:"versions"`
+	}
+
+	// RenterBandwidthScheduleGET contains the windows of the renter's
+	// bandwidth schedule and the window that is currently active.
+	RenterBandwidthScheduleGET struct {
+		Windows []modules.BandwidthWindow `json:"windows"`
+		Active  *modules.BandwidthWindow  `json:"active"`
+	}
+
+	// RenterBandwidthSchedulePOST contains the windows of a new bandwidth
+	// schedule.
+	RenterBandwidthSchedulePOST struct {
+		Windows []modules.BandwidthWindow `json:"windows"`
+	}
+
+	// RenterTrashGET lists the files and directories in the trash and the
+	// number of days after which they are purged.
+	RenterTrashGET struct {
+		Entries       []modules.TrashEntry `json:"entries"`
+		RetentionDays uint64               `json:"retentiondays"`
+	}
+
+	// RenterFindGET contains the files and directories matching a search.
+	RenterFindGET struct {
+		Files       []modules.FileInfo      `json:"files"`
+		Directories []modules.DirectoryInfo `json:"directories"`
+	}
+
 	// DownloadInfo contains all client-facing information of a file.
 	DownloadInfo struct {
 		Destination     string          `json:"destination"`     // The destination of the download.
@@ -251,6 +295,8 @@ type (
 		Offset          uint64          `json:"offset"`          // The offset within the siafile requested for the download.
 		SiaPath         modules.SiaPath `json:"siapath"`         // The siapath of the file used for the download.
 
+		Priority modules.DownloadPriority `json:"priority"` // The priority class of the download.
+
 		Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 		EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
 		Error                string    `json:"error"`                // Will be the empty string unless there was an error.
@@ -592,6 +638,16 @@ func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// parseChecksumAlgorithm parses the checksum algorithm of an upload. An empty
+// string selects the default algorithm.
+func parseChecksumAlgorithm(algorithm string) (string, error) {
+	if algorithm == "" || algorithm == modules.ChecksumNone {
+		return algorithm, nil
+	}
+	_, err := modules.NewChecksumHasher(algorithm)
+	return algorithm, err
+}
+
 // parseErasureCodingParameters parses the supplied string values and creates
 // an erasure coder. If values haven't been supplied it will fill in sane
 // defaults.
@@ -1294,6 +1350,8 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request,
 			Offset:          di.Offset,
 			SiaPath:         di.SiaPath,
 
+			Priority: di.Priority,
+
 			Completed:            di.Completed,
 			EndTime:              di.EndTime,
 			Error:                di.Error,
@@ -1330,6 +1388,8 @@ func (api *API) renterDownloadByUIDHandlerGET(w http.ResponseWriter, _ *http.Req
 		Offset:          di.Offset,
 		SiaPath:         di.SiaPath,
 
+		Priority: di.Priority,
+
 		Completed:            di.Completed,
 		EndTime:              di.EndTime,
 		Error:                di.Error,
@@ -1867,13 +1927,21 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	// Parse the priority class of the download.
+	priority, err := modules.ParseDownloadPriority(req.FormValue("priority"))
+	if err != nil {
+		return modules.RenterDownloadParameters{}, errors.AddContext(err, "error parsing the priority")
+	}
+
 	dp := modules.RenterDownloadParameters{
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
 		Async:            async,
 		Length:           length,
 		Offset:           offset,
+		Priority:         priority,
 		SiaPath:          siaPath,
+		Version:          req.FormValue("version"),
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -1924,6 +1992,81 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 	http.ServeContent(w, req, fileName, time.Time{}, streamer)
 }
 
+// renterArchiveHandlerGET handles the API call to download a directory as an
+// archive.
+func (api *API) renterArchiveHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		err = errors.AddContext(err, "error parsing the root flag")
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var disableLocalFetch bool
+	if dlf := req.FormValue("disablelocalfetch"); dlf != "" {
+		disableLocalFetch, err = scanBool(dlf)
+		if err != nil {
+			err = errors.AddContext(err, "error parsing the disablelocalfetch flag")
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	format := req.FormValue("format")
+	if format == "" {
+		format = ArchiveFormatTar
+	}
+	contentType, ext, err := archiveContentType(format)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Collect the files of the directory.
+	var infos []modules.FileInfo
+	var mu sync.Mutex
+	err = api.renter.FileList(siaPath, true, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		infos = append(infos, fi)
+		mu.Unlock()
+	})
+	if err != nil {
+		WriteError(w, Error{"failed to get file infos: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	files, err := archiveFiles(siaPath, infos)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// Once the headers are written, errors can't be reported to the client
+	// anymore. The archive is left incomplete and the error is logged instead.
+	name := siaPath.Name()
+	if name == "" {
+		name = "sia"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+ext))
+	err = writeArchive(w, format, files, func(sp modules.SiaPath) (modules.Streamer, error) {
+		_, streamer, err := api.renter.Streamer(sp, disableLocalFetch)
+		return streamer, err
+	})
+	if err != nil {
+		log.Printf("WARN: unable to write the archive of %v: %v", siaPath, err)
+	}
+}
+
 // renterUploadHandler handles the API call to upload a file.
 func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Get the source path.
@@ -1949,6 +2092,34 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	// Parse the checksum algorithm.
+	checksum, err := parseChecksumAlgorithm(req.FormValue("checksum"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'checksum' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the compression codec.
+	compression := req.FormValue("compression")
+	if err := modules.ValidateCompressionCodec(compression); err != nil {
+		WriteError(w, Error{"unable to parse 'compression' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Check whether the chunks of the file should be deduplicated.
+	dedup := false
+	if d := req.FormValue("dedup"); d != "" {
+		dedup, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the tags and user-defined metadata.
+	tags := modules.ParseTags(req.FormValue("tags"))
+	userMetadata, err := parseUserMetadata(req.FormValue("metadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'metadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -1967,6 +2138,11 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode:         ec,
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
+		ChecksumAlgorithm:   checksum,
+		Compression:         compression,
+		Dedup:               dedup,
+		Tags:                tags,
+		UserMetadata:        userMetadata,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2080,6 +2256,34 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	// Parse the checksum algorithm.
+	checksum, err := parseChecksumAlgorithm(queryForm.Get("checksum"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'checksum' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the compression codec.
+	compression := queryForm.Get("compression")
+	if err := modules.ValidateCompressionCodec(compression); err != nil {
+		WriteError(w, Error{"unable to parse 'compression' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Check whether the chunks of the file should be deduplicated.
+	dedup := false
+	if d := queryForm.Get("dedup"); d != "" {
+		dedup, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the tags and user-defined metadata.
+	tags := modules.ParseTags(queryForm.Get("tags"))
+	userMetadata, err := parseUserMetadata(queryForm.Get("metadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'metadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -2098,6 +2302,12 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		Force:       force,
 		Repair:      repair,
 
+		ChecksumAlgorithm: checksum,
+		Compression:       compression,
+		Dedup:             dedup,
+		Tags:              tags,
+		UserMetadata:      userMetadata,
+
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
 	}
@@ -2109,6 +2319,458 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	WriteSuccess(w)
 }
 
+// renterSyncHandlerPOST handles the API call to synchronize a local directory
+// with a siadir.
+func (api *API) renterSyncHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Get the local path.
+	localPath := req.FormValue("localpath")
+	if !filepath.IsAbs(localPath) {
+		WriteError(w, Error{"localpath must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the optional params.
+	var err error
+	var del, dryRun bool
+	if d := req.FormValue("delete"); d != "" {
+		del, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'delete' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if d := req.FormValue("dryrun"); d != "" {
+		dryRun, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dryrun' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var include, exclude []string
+	if i := req.FormValue("include"); i != "" {
+		include = strings.Split(i, ",")
+	}
+	if e := req.FormValue("exclude"); e != "" {
+		exclude = strings.Split(e, ",")
+	}
+	// Parse the erasure coder used for uploads.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the siapath.
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	report, err := api.renter.Sync(modules.SyncParams{
+		LocalPath:   localPath,
+		SiaPath:     siaPath,
+		Direction:   modules.SyncDirection(req.FormValue("direction")),
+		Delete:      del,
+		Include:     include,
+		Exclude:     exclude,
+		DryRun:      dryRun,
+		ErasureCode: ec,
+	})
+	if err != nil {
+		WriteError(w, Error{"sync failed: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
+
+// parseVersioningSiaPath parses the siapath of the versioning endpoints and
+// rebases it to the user folder unless the root flag is set.
+func parseVersioningSiaPath(req *http.Request, ps httprouter.Params) (modules.SiaPath, error) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	str := ps.ByName("siapath")
+	if str == "" || str == "/" {
+		if root {
+			return modules.RootSiaPath(), nil
+		}
+		return modules.UserFolder, nil
+	}
+	siaPath, err := modules.NewSiaPath(str)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+	}
+	return siaPath, err
+}
+
+// parseUserMetadata parses user-defined metadata from a JSON object. An empty
+// string results in empty metadata.
+func parseUserMetadata(str string) (map[string]string, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var md map[string]string
+	if err := json.Unmarshal([]byte(str), &md); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// parseUnixTime parses a unix timestamp in seconds. An empty string results
+// in the zero time.
+func parseUnixTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	secs, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// renterMetadataHandlerPOST handles the API call to set the tags and
+// user-defined metadata of a file or directory. Parameters which aren't
+// provided are left unchanged.
+func (api *API) renterMetadataHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseVersioningSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	_, setTags := req.Form["tags"]
+	_, setMetadata := req.Form["metadata"]
+	if !setTags && !setMetadata {
+		WriteError(w, Error{"either 'tags' or 'metadata' needs to be provided"}, http.StatusBadRequest)
+		return
+	}
+	userMetadata, err := parseUserMetadata(req.FormValue("metadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'metadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if setTags {
+		if err := api.renter.SetTags(siaPath, modules.ParseTags(req.FormValue("tags"))); err != nil {
+			WriteError(w, Error{"unable to set tags: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if setMetadata {
+		if err := api.renter.SetUserMetadata(siaPath, userMetadata); err != nil {
+			WriteError(w, Error{"unable to set metadata: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteSuccess(w)
+}
+
+// renterFindHandlerGET handles the API call to search the files and
+// directories within a directory.
+func (api *API) renterFindHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := parseVersioningSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	query := modules.FileQuery{
+		Tags: modules.ParseTags(req.FormValue("tags")),
+	}
+	query.UserMetadata, err = parseUserMetadata(req.FormValue("metadata"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'metadata' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	for name, size := range map[string]*uint64{
+		"minsize": &query.MinSize,
+		"maxsize": &query.MaxSize,
+	} {
+		if str := req.FormValue(name); str != "" {
+			if *size, err = strconv.ParseUint(str, 10, 64); err != nil {
+				WriteError(w, Error{fmt.Sprintf("unable to parse '%v' parameter: %v", name, err)}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	for name, health := range map[string]**float64{
+		"minhealth": &query.MinHealth,
+		"maxhealth": &query.MaxHealth,
+	} {
+		if str := req.FormValue(name); str != "" {
+			h, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				WriteError(w, Error{fmt.Sprintf("unable to parse '%v' parameter: %v", name, err)}, http.StatusBadRequest)
+				return
+			}
+			*health = &h
+		}
+	}
+	for name, t := range map[string]*time.Time{
+		"modifiedafter":  &query.ModifiedAfter,
+		"modifiedbefore": &query.ModifiedBefore,
+		"createdafter":   &query.CreatedAfter,
+		"createdbefore":  &query.CreatedBefore,
+	} {
+		if *t, err = parseUnixTime(req.FormValue(name)); err != nil {
+			WriteError(w, Error{fmt.Sprintf("unable to parse '%v' parameter: %v", name, err)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	files, dirs, err := api.renter.Find(siaPath, query)
+	if err != nil {
+		WriteError(w, Error{"unable to search files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		if files, err = trimSiaDirFolderOnFiles(files...); err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if dirs, err = trimSiaDirFolder(dirs...); err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	WriteJSON(w, RenterFindGET{
+		Files:       files,
+		Directories: dirs,
+	})
+}
+
+// renterVersioningHandlerGET handles the API call to list the versioning
+// policies of directories.
+func (api *API) renterVersioningHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	policies := []RenterVersioningPolicy{}
+	for siaPath, policy := range api.renter.VersioningPolicies() {
+		// Unless the root flag is set, only the policies within the user
+		// folder are returned relative to the user folder.
+		if !root {
+			if !siaPath.Equals(modules.UserFolder) && !strings.HasPrefix(siaPath.String(), modules.UserFolder.String()+"/") {
+				continue
+			}
+			siaPath, err = siaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+				return
+			}
+		}
+		policies = append(policies, RenterVersioningPolicy{
+			SiaPath:          siaPath,
+			VersioningPolicy: policy,
+		})
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].SiaPath.String() < policies[j].SiaPath.String()
+	})
+	WriteJSON(w, RenterVersioningGET{
+		Policies: policies,
+	})
+}
+
+// renterVersioningHandlerPOST handles the API call to enable or disable
+// versioning for a directory.
+func (api *API) renterVersioningHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseVersioningSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	disable := false
+	if d := req.FormValue("disable"); d != "" {
+		disable, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'disable' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var policy *modules.VersioningPolicy
+	if !disable {
+		policy = new(modules.VersioningPolicy)
+		if k := req.FormValue("keepversions"); k != "" {
+			if _, err := fmt.Sscan(k, &policy.KeepVersions); err != nil {
+				WriteError(w, Error{"unable to parse 'keepversions' parameter: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if k := req.FormValue("keepdays"); k != "" {
+			if _, err := fmt.Sscan(k, &policy.KeepDays); err != nil {
+				WriteError(w, Error{"unable to parse 'keepdays' parameter: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if err := api.renter.SetVersioningPolicy(siaPath, policy); err != nil {
+		WriteError(w, Error{"unable to set versioning policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterVersionsHandlerGET handles the API call to list the prior versions of
+// a file.
+func (api *API) renterVersionsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseVersioningSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	versions, err := api.renter.FileVersions(siaPath)
+	if err != nil {
+		WriteError(w, Error{"unable to list file versions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterFileVersionsGET{
+		Versions: versions,
+	})
+}
+
+// renterVersionsHandlerPOST handles the API call to restore a prior version of
+// a file.
+func (api *API) renterVersionsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseVersioningSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	version := req.FormValue("version")
+	if version == "" {
+		WriteError(w, Error{"'version' parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.RestoreFileVersion(siaPath, version); err != nil {
+		WriteError(w, Error{"unable to restore file version: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterBandwidthScheduleHandlerGET handles the API call to get the renter's
+// bandwidth schedule.
+func (api *API) renterBandwidthScheduleHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	rbsg := RenterBandwidthScheduleGET{
+		Windows: api.renter.BandwidthSchedule(),
+	}
+	if active, ok := api.renter.ActiveBandwidthWindow(); ok {
+		rbsg.Active = &active
+	}
+	WriteJSON(w, rbsg)
+}
+
+// renterBandwidthScheduleHandlerPOST handles the API call to replace the
+// renter's bandwidth schedule.
+func (api *API) renterBandwidthScheduleHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params RenterBandwidthSchedulePOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.SetBandwidthSchedule(params.Windows); err != nil {
+		WriteError(w, Error{"unable to set bandwidth schedule: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterTrashHandlerGET handles the API call to list the files and directories
+// in the trash.
+func (api *API) renterTrashHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	entries := []modules.TrashEntry{}
+	for _, entry := range api.renter.TrashEntries() {
+		// Unless the root flag is set, only the entries deleted from within
+		// the user folder are returned relative to the user folder.
+		if !root {
+			if !strings.HasPrefix(entry.SiaPath.String(), modules.UserFolder.String()+"/") {
+				continue
+			}
+			entry.SiaPath, err = entry.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+				return
+			}
+		}
+		entries = append(entries, entry)
+	}
+	WriteJSON(w, RenterTrashGET{
+		Entries:       entries,
+		RetentionDays: api.renter.TrashRetention(),
+	})
+}
+
+// renterTrashHandlerPOST handles the API call to set the number of days after
+// which files and directories are purged from the trash.
+func (api *API) renterTrashHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var days uint64
+	d := req.FormValue("retentiondays")
+	if d == "" {
+		WriteError(w, Error{"'retentiondays' parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	if _, err := fmt.Sscan(d, &days); err != nil {
+		WriteError(w, Error{"unable to parse 'retentiondays' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.SetTrashRetention(days); err != nil {
+		WriteError(w, Error{"unable to set trash retention: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterTrashPurgeHandlerPOST handles the API call to permanently delete a
+// file or directory in the trash, or the whole trash if no id is given.
+func (api *API) renterTrashPurgeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var err error
+	if id := req.FormValue("id"); id != "" {
+		err = api.renter.PurgeTrashEntry(id)
+	} else {
+		err = api.renter.PurgeTrash()
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to purge the trash: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterTrashRestoreHandlerPOST handles the API call to restore a file or
+// directory from the trash.
+func (api *API) renterTrashRestoreHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{"'id' parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.RestoreTrashEntry(id); err != nil {
+		WriteError(w, Error{"unable to restore from the trash: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterValidateSiaPathHandler handles the API call that validates a siapath
 func (api *API) renterValidateSiaPathHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Try and create a new siapath, this will validate the potential siapath
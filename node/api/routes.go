@@ -82,6 +82,8 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
 		router.POST("/renter/allowance/cancel", RequirePassword(api.renterAllowanceCancelHandlerPOST, requiredPassword))
 		router.POST("/renter/bubble", api.renterBubbleHandlerPOST)
+		router.GET("/renter/bandwidthschedule", api.renterBandwidthScheduleHandlerGET)
+		router.POST("/renter/bandwidthschedule", RequirePassword(api.renterBandwidthScheduleHandlerPOST, requiredPassword))
 		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
 		router.POST("/renter/backups/create", RequirePassword(api.renterBackupsCreateHandlerPOST, requiredPassword))
 		router.POST("/renter/backups/restore", RequirePassword(api.renterBackupsRestoreHandlerGET, requiredPassword))
@@ -93,8 +95,10 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
+		router.GET("/renter/find/*siapath", api.renterFindHandlerGET)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
 		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
+		router.POST("/renter/metadata/*siapath", RequirePassword(api.renterMetadataHandlerPOST, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
@@ -108,12 +112,22 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
+		router.GET("/renter/archive/*siapath", api.renterArchiveHandlerGET)
+		router.POST("/renter/sync/*siapath", RequirePassword(api.renterSyncHandlerPOST, requiredPassword))
+		router.GET("/renter/trash", api.renterTrashHandlerGET)
+		router.POST("/renter/trash", RequirePassword(api.renterTrashHandlerPOST, requiredPassword))
+		router.POST("/renter/trash/purge", RequirePassword(api.renterTrashPurgeHandlerPOST, requiredPassword))
+		router.POST("/renter/trash/restore", RequirePassword(api.renterTrashRestoreHandlerPOST, requiredPassword))
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
 		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
 		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateSiaPathHandler, requiredPassword))
+		router.GET("/renter/versioning", api.renterVersioningHandlerGET)
+		router.POST("/renter/versioning/*siapath", RequirePassword(api.renterVersioningHandlerPOST, requiredPassword))
+		router.GET("/renter/versions/*siapath", api.renterVersionsHandlerGET)
+		router.POST("/renter/versions/*siapath", RequirePassword(api.renterVersionsHandlerPOST, requiredPassword))
 		router.GET("/renter/workers", api.renterWorkersHandler)
 		router.GET("/renter/hosts/*siapath", api.renterFileHostsHandler)
 
@@ -200,5 +214,5 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 
 // isUnrestricted checks if a request may bypass the useragent check.
 func isUnrestricted(req *http.Request) bool {
-	return strings.HasPrefix(req.URL.Path, "/renter/stream/")
+	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || strings.HasPrefix(req.URL.Path, "/renter/archive/")
 }
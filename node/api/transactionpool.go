@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -15,10 +16,12 @@ import (
 )
 
 type (
-	// TpoolFeeGET contains the current estimated fee
+	// TpoolFeeGET contains the current estimated fee. Target is only set if
+	// a confirmation target was requested.
 	TpoolFeeGET struct {
 		Minimum types.Currency `json:"minimum"`
 		Maximum types.Currency `json:"maximum"`
+		Target  types.Currency `json:"target"`
 	}
 
 	// TpoolRawGET contains the requested transaction encoded to the raw
@@ -72,13 +75,26 @@ func decodeTransactionID(txidStr string) (types.TransactionID, error) {
 }
 
 // tpoolFeeHandlerGET returns the current estimated fee. Transactions with
-// fees are lower than the estimated fee may take longer to confirm.
-func tpoolFeeHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+// fees are lower than the estimated fee may take longer to confirm. If a
+// confirmation target is provided, the fee estimated to get a transaction
+// confirmed within that many blocks is returned as well.
+func tpoolFeeHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var target types.BlockHeight
+	if t := req.FormValue("target"); t != "" {
+		if _, err := fmt.Sscan(t, &target); err != nil || target == 0 {
+			WriteError(w, Error{"unable to parse target, must be a positive number of blocks"}, http.StatusBadRequest)
+			return
+		}
+	}
 	min, max := tpool.FeeEstimation()
-	WriteJSON(w, TpoolFeeGET{
+	tfg := TpoolFeeGET{
 		Minimum: min,
 		Maximum: max,
-	})
+	}
+	if target > 0 {
+		tfg.Target = tpool.FeeEstimationForTarget(target)
+	}
+	WriteJSON(w, tfg)
 }
 
 // tpoolRawHandlerGET will provide the raw byte representation of a
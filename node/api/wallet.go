@@ -48,6 +48,14 @@ type (
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
 
+	// WalletBumpPOST contains the transaction set submitted in the POST call
+	// to /wallet/bump. The last transaction is the child that pays for the
+	// rest of the set.
+	WalletBumpPOST struct {
+		Transactions   []types.Transaction   `json:"transactions"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
 	// WalletInitPOST contains the primary seed that gets generated during a
 	// POST call to /wallet/init.
 	WalletInitPOST struct {
@@ -180,6 +188,9 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletBackupHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/bump", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletBumpHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/init", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -406,6 +417,38 @@ func walletBackupHandler(wallet modules.Wallet, w http.ResponseWriter, req *http
 	WriteSuccess(w)
 }
 
+// walletBumpHandler handles API calls to /wallet/bump.
+func walletBumpHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txid, err := decodeTransactionID(req.FormValue("txid"))
+	if err != nil {
+		WriteError(w, Error{"could not read txid from POST call to /wallet/bump: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var feePerByte types.Currency
+	if fee := req.FormValue("feeperbyte"); fee != "" {
+		var ok bool
+		feePerByte, ok = scanAmount(fee)
+		if !ok {
+			WriteError(w, Error{"could not read feeperbyte from POST call to /wallet/bump"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	txns, err := wallet.BumpTransaction(txid, feePerByte)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/bump: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletBumpPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.CipherKey
@@ -581,6 +624,14 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var feeTarget types.BlockHeight
+	if t := req.FormValue("feetarget"); t != "" {
+		if _, err := fmt.Sscan(t, &feeTarget); err != nil || feeTarget == 0 {
+			WriteError(w, Error{"could not read feetarget from POST call to /wallet/siacoins, must be a positive number of blocks"}, http.StatusBadRequest)
+			return
+		}
+	}
+
 	var txns []types.Transaction
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
@@ -595,7 +646,11 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = wallet.SendSiacoinsMulti(outputs)
+		if feeTarget > 0 {
+			txns, err = wallet.SendSiacoinsMultiWithFeeTarget(outputs, feeTarget)
+		} else {
+			txns, err = wallet.SendSiacoinsMulti(outputs)
+		}
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -618,7 +673,9 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			return
 		}
 
-		if feeIncluded {
+		if feeTarget > 0 {
+			txns, err = wallet.SendSiacoinsWithFeeTarget(amount, dest, feeIncluded, feeTarget)
+		} else if feeIncluded {
 			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
 		} else {
 			txns, err = wallet.SendSiacoins(amount, dest)
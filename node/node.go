@@ -33,6 +33,7 @@ import (
 	"go.sia.tech/siad/modules/transactionpool"
 	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
 )
 
 // NodeParams contains a bunch of parameters for creating a new test node. As
@@ -112,6 +113,22 @@ type NodeParams struct {
 	RPCAddress     string
 	WalletPassword string
 
+	// ConsensusCheckpoint is the path of a checkpoint the consensus set is
+	// bootstrapped from if no consensus database exists yet. The checkpoint
+	// has to match ConsensusCheckpointHash, or a hash trusted by the build if
+	// ConsensusCheckpointHash is empty.
+	ConsensusCheckpoint     string
+	ConsensusCheckpointHash crypto.Hash
+
+	// ConsensusPruneDepth enables pruning of the consensus database if it is
+	// not zero. Only the most recent ConsensusPruneDepth blocks keep their
+	// transactions and diffs.
+	ConsensusPruneDepth types.BlockHeight
+
+	// StratumAddress is the address the miner's stratum server listens on.
+	// The server is not started if it is empty.
+	StratumAddress string
+
 	// Initialize node from existing seed.
 	PrimarySeed string
 
@@ -264,6 +281,28 @@ func tryUnlockWallet(w modules.Wallet, password string) error {
 	return modules.ErrBadEncryptionKey
 }
 
+// loadConsensusCheckpoint bootstraps the consensus database in dir from the
+// checkpoint at path. Nothing happens if the consensus database already
+// exists.
+func loadConsensusCheckpoint(dir, path string, hash crypto.Hash) error {
+	if _, err := os.Stat(filepath.Join(dir, consensus.DatabaseFilename)); err == nil {
+		printlnRelease("Consensus database exists, ignoring checkpoint")
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	printlnRelease("Loading consensus checkpoint...")
+	id, height, err := consensus.ImportCheckpoint(dir, f, hash)
+	if err != nil {
+		return err
+	}
+	printfRelease("Loaded consensus checkpoint at height %v (%v)\n", height, id)
+	return nil
+}
+
 // New will create a new node. The inputs to the function are the respective
 // 'New' calls for each module. We need to use this awkward method of
 // initialization because the siatest package cannot import any of the modules
@@ -336,7 +375,30 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if consensusSetDeps == nil {
 			consensusSetDeps = modules.ProdDependencies
 		}
-		return consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+		if params.ConsensusPruneDepth > 0 && params.CreateExplorer {
+			c <- errors.New("cannot prune the consensus set of a node with an explorer")
+			return nil, c
+		}
+		if params.ConsensusCheckpoint != "" {
+			if params.CreateExplorer {
+				c <- errors.New("cannot bootstrap the consensus set of a node with an explorer from a checkpoint")
+				return nil, c
+			}
+			err := loadConsensusCheckpoint(filepath.Join(dir, modules.ConsensusDir), params.ConsensusCheckpoint, params.ConsensusCheckpointHash)
+			if err != nil {
+				c <- errors.AddContext(err, "unable to load consensus checkpoint")
+				return nil, c
+			}
+		}
+		cs, errChanCS := consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+		if err := modules.PeekErr(errChanCS); err != nil || params.ConsensusPruneDepth == 0 {
+			return cs, errChanCS
+		}
+		if err := cs.SetPruneDepth(params.ConsensusPruneDepth); err != nil {
+			c <- errors.Compose(errors.AddContext(err, "unable to enable consensus pruning"), cs.Close())
+			return nil, c
+		}
+		return cs, errChanCS
 	}()
 	if err := modules.PeekErr(errChanCS); err != nil {
 		errChan <- errors.Extend(err, errors.New("unable to create consensus set"))
@@ -446,6 +508,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if err != nil {
 			return nil, err
 		}
+		if params.StratumAddress != "" {
+			if err := m.StartStratum(params.StratumAddress); err != nil {
+				return nil, errors.Compose(err, m.Close())
+			}
+		}
 		return m, nil
 	}()
 	if err != nil {
@@ -15,3 +15,15 @@ type DependencyDisableAutoOnline struct {
 func (d *DependencyDisableAutoOnline) Disrupt(s string) bool {
 	return s == "DisableGatewayAutoOnline"
 }
+
+// DependencyScoreLocalPeers makes the gateway score and ban local peers, which
+// it never does otherwise. This allows for testing misbehaviour scores with
+// gateways that run on the same machine.
+type DependencyScoreLocalPeers struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt returns true if the correct string is provided.
+func (d *DependencyScoreLocalPeers) Disrupt(s string) bool {
+	return s == "ScoreLocalPeers"
+}
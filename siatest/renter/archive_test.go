@@ -0,0 +1,114 @@
+package renter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/siatest"
+)
+
+// testDirectoryArchive tests downloading a directory as tar and zip archives.
+func testDirectoryArchive(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Upload a file into a directory and into one of its subdirectories.
+	dir := modules.RandomSiaPath()
+	data := make(map[string][]byte)
+	for _, name := range []string{"a.dat", "sub/b.dat"} {
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[dir.Name()+"/"+name] = fastrand.Bytes(100 + siatest.Fuzz())
+		err = r.RenterUploadStreamPost(bytes.NewReader(data[dir.Name()+"/"+name]), siaPath, dataPieces, parityPieces, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// download fetches the archive of the directory in the given format.
+	download := func(format string) []byte {
+		body, err := r.RenterArchiveGet(dir, format, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := body.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		archive, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return archive
+	}
+	// check compares a file of an archive to the uploaded data.
+	check := func(name string, r io.Reader) {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, data[name]) {
+			t.Fatalf("%v doesn't match the uploaded data", name)
+		}
+	}
+
+	// Check the gzipped tar archive.
+	gr, err := gzip.NewReader(bytes.NewReader(download(api.ArchiveFormatTarGz)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var numFiles int
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(hdr.Name, tr)
+		numFiles++
+	}
+	if numFiles != len(data) {
+		t.Fatalf("expected %v files in tar archive but got %v", len(data), numFiles)
+	}
+
+	// Check the zip archive.
+	archive := download(api.ArchiveFormatZip)
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != len(data) {
+		t.Fatalf("expected %v files in zip archive but got %v", len(data), len(zr.File))
+	}
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(zf.Name, rc)
+		if err := rc.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Unknown formats are rejected.
+	if _, err := r.RenterArchiveGet(dir, "rar", false, false); err == nil {
+		t.Fatal("expected error for unknown archive format")
+	}
+}
@@ -0,0 +1,46 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testBandwidthSchedule tests setting and clearing the renter's bandwidth
+// schedule through the API.
+func testBandwidthSchedule(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	if time.Now().Format(modules.BandwidthWindowTimeFormat) == "23:59" {
+		t.Skip("windows ending at 23:59 aren't active during the last minute of the day")
+	}
+
+	// Invalid windows are rejected.
+	if err := r.RenterBandwidthSchedulePost([]modules.BandwidthWindow{{Start: "25:00", End: "09:00"}}); err == nil {
+		t.Fatal("invalid window should be rejected")
+	}
+
+	// Set a schedule with a window that is always active.
+	windows := []modules.BandwidthWindow{
+		{Name: "always", Start: "00:00", End: "23:59", MaxDownloadSpeed: 1 << 30, MaxUploadSpeed: 1 << 30, MaxRepairSpeed: 1 << 30},
+	}
+	if err := r.RenterBandwidthSchedulePost(windows); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.RenterBandwidthSchedulePost(nil); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rbsg, err := r.RenterBandwidthScheduleGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbsg.Windows) != 1 || rbsg.Windows[0].MaxRepairSpeed != 1<<30 {
+		t.Fatal("unexpected schedule", rbsg.Windows)
+	}
+	if rbsg.Active == nil || rbsg.Active.Name != "always" {
+		t.Fatal("expected the window to be active", rbsg.Active)
+	}
+}
@@ -0,0 +1,142 @@
+package renter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/crypto/blake2b"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testFileChecksums tests that the checksum of the plaintext of a file is
+// computed on upload and that full downloads of the file are verified.
+func testFileChecksums(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// waitForChecksum waits for the checksum of a file to be computed.
+	waitForChecksum := func(siaPath modules.SiaPath) (checksum modules.FileChecksum, err error) {
+		err = build.Retry(100, 100*time.Millisecond, func() error {
+			rf, err := r.RenterFileGet(siaPath)
+			if err != nil {
+				return err
+			}
+			checksum = rf.File.Checksum
+			if !checksum.IsSet() {
+				return fmt.Errorf("checksum of %v not set", siaPath)
+			}
+			return nil
+		})
+		return
+	}
+
+	// Upload a file with the default algorithm.
+	lf, rf, err := r.UploadNewFileBlocking(int(modules.SectorSize)+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := waitForChecksum(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum.Algorithm != modules.DefaultChecksumAlgorithm || checksum.Hash != sha256.Sum256(data) {
+		t.Fatal("wrong checksum", checksum)
+	}
+	// Full downloads to disk should pass verification. Http streams aren't
+	// verified but should still succeed.
+	if _, _, err := r.DownloadToDisk(rf, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.DownloadByStream(rf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload a file with BLAKE2b.
+	lf, err = r.FilesDir().NewFile(100 + siatest.Fuzz())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := modules.NewSiaPath(lf.FileName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterUploadChecksumPost(lf.Path(), siaPath, dataPieces, parityPieces, modules.ChecksumBLAKE2b); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err = waitForChecksum(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum.Algorithm != modules.ChecksumBLAKE2b || checksum.Hash != blake2b.Sum256(data) {
+		t.Fatal("wrong checksum", checksum)
+	}
+
+	// Upload a file without a checksum.
+	lf, err = r.FilesDir().NewFile(100 + siatest.Fuzz())
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err = modules.NewSiaPath(lf.FileName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterUploadChecksumPost(lf.Path(), siaPath, dataPieces, parityPieces, modules.ChecksumNone); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	file, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.File.Checksum.IsSet() {
+		t.Fatal("checksum shouldn't be set", file.File.Checksum)
+	}
+
+	// Unknown algorithms should be rejected.
+	err = r.RenterUploadChecksumPost(lf.Path(), siaPath, dataPieces, parityPieces, "md5")
+	if err == nil {
+		t.Fatal("upload with unknown checksum algorithm should fail")
+	}
+
+	// Streamed uploads have their checksum set once they return.
+	data = fastrand.Bytes(int(modules.SectorSize) + siatest.Fuzz())
+	siaPath = modules.RandomSiaPath()
+	if err := r.RenterUploadStreamChecksumPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, modules.ChecksumBLAKE2b); err != nil {
+		t.Fatal(err)
+	}
+	file, err = r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.File.Checksum.Algorithm != modules.ChecksumBLAKE2b || file.File.Checksum.Hash != blake2b.Sum256(data) {
+		t.Fatal("wrong checksum", file.File.Checksum)
+	}
+	dst := filepath.Join(r.FilesDir().Path(), "stream.dat")
+	if _, err := r.RenterDownloadFullGet(siaPath, dst, false, false); err != nil {
+		t.Fatal(err)
+	}
+	_, downloaded, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("downloaded data doesn't match")
+	}
+}
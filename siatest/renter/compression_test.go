@@ -0,0 +1,126 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testCompressedUpload tests uploading compressed files and that downloads
+// and streams of them are decompressed transparently.
+func testCompressedUpload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Create data spanning multiple chunks which compresses well.
+	data := make([]byte, 10*int(modules.SectorSize)+siatest.Fuzz())
+	for i := 0; i < len(data); i += 1000 {
+		fastrand.Read(data[i : i+1+fastrand.Intn(100)])
+	}
+
+	// checkFile checks the file's info and downloads it.
+	checkFile := func(siaPath modules.SiaPath) {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rf.File.Compression != modules.CompressionGzip {
+			t.Fatal("wrong compression", rf.File.Compression)
+		}
+		if rf.File.Filesize != uint64(len(data)) {
+			t.Fatalf("expected filesize %v but got %v", len(data), rf.File.Filesize)
+		}
+		if rf.File.CompressedSize == 0 || rf.File.CompressedSize >= rf.File.Filesize {
+			t.Fatalf("file wasn't compressed: %v of %v bytes stored", rf.File.CompressedSize, rf.File.Filesize)
+		}
+
+		// Download the whole file over http and to disk.
+		_, downloaded, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Fatal("downloaded data doesn't match")
+		}
+		dst := filepath.Join(r.FilesDir().Path(), siaPath.Name()+".dat")
+		if _, err := r.RenterDownloadFullGet(siaPath, dst, false, false); err != nil {
+			t.Fatal(err)
+		}
+		downloaded, err = ioutil.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Fatal("data downloaded to disk doesn't match")
+		}
+
+		// Download and stream random ranges.
+		for i := 0; i < 3; i++ {
+			offset := uint64(fastrand.Intn(len(data)))
+			length := uint64(fastrand.Intn(len(data)-int(offset))) + 1
+			_, downloaded, err := r.RenterDownloadHTTPResponseGet(siaPath, offset, length, true, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(downloaded, data[offset:offset+length]) {
+				t.Fatalf("downloaded range [%v;%v) doesn't match", offset, offset+length)
+			}
+			streamed, err := r.RenterStreamPartialGet(siaPath, offset, offset+length, true, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(streamed, data[offset:offset+length]) {
+				t.Fatalf("streamed range [%v;%v) doesn't match", offset, offset+length)
+			}
+		}
+	}
+
+	// Upload the data as a compressed stream.
+	siaPath := modules.RandomSiaPath()
+	err := r.RenterUploadStreamCompressedPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, modules.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(siaPath)
+
+	// Upload the data from disk with compression.
+	path := filepath.Join(r.FilesDir().Path(), "compressed.dat")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	siaPath = modules.RandomSiaPath()
+	err = r.RenterUploadOptionsPost(path, siaPath, dataPieces, parityPieces, "", modules.CompressionGzip, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if !rf.File.Available {
+			return fmt.Errorf("%v isn't available yet", siaPath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(siaPath)
+
+	// Unknown codecs are rejected.
+	err = r.RenterUploadStreamCompressedPost(bytes.NewReader(data), modules.RandomSiaPath(), dataPieces, parityPieces, "zip")
+	if err == nil {
+		t.Fatal("upload with unknown compression codec should fail")
+	}
+}
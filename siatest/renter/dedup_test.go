@@ -0,0 +1,89 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testFileDedup tests that identical chunks of deduplicated files are only
+// uploaded once and that deleting one of the files doesn't affect the others.
+func testFileDedup(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	data := fastrand.Bytes(3 * int(modules.SectorSize))
+
+	// contractSize returns the total size of the renter's active contracts.
+	contractSize := func() uint64 {
+		rc, err := r.RenterContractsGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var size uint64
+		for _, c := range rc.ActiveContracts {
+			size += c.Size
+		}
+		return size
+	}
+	// checkFile checks the file's info and downloads it.
+	checkFile := func(siaPath modules.SiaPath) {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rf.File.Dedup || rf.File.DedupChunks != 3 {
+			t.Fatalf("file isn't deduplicated: %v %v", rf.File.Dedup, rf.File.DedupChunks)
+		}
+		_, downloaded, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Fatal("downloaded data doesn't match")
+		}
+	}
+
+	// Upload the data twice. The second upload shouldn't store any new
+	// sectors.
+	siaPath1 := modules.RandomSiaPath()
+	if err := r.RenterUploadStreamDedupPost(bytes.NewReader(data), siaPath1, dataPieces, parityPieces); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(siaPath1)
+	err := build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(siaPath1)
+		if err != nil {
+			return err
+		}
+		if rf.File.UploadProgress < 100 {
+			return fmt.Errorf("%v isn't fully uploaded yet: %v%%", siaPath1, rf.File.UploadProgress)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizeBefore := contractSize()
+	siaPath2 := modules.RandomSiaPath()
+	if err := r.RenterUploadStreamDedupPost(bytes.NewReader(data), siaPath2, dataPieces, parityPieces); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(siaPath2)
+	if size := contractSize(); size != sizeBefore {
+		t.Fatalf("duplicate chunks were uploaded again: contract size grew from %v to %v", sizeBefore, size)
+	}
+
+	// Deleting the first file doesn't affect the second one.
+	if err := r.RenterFileDeletePost(siaPath1); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(siaPath2)
+}
@@ -0,0 +1,68 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testDownloadPriority tests downloading a file with a priority class and
+// that the workers report the downloads by class.
+func testDownloadPriority(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Upload a file.
+	siaPath := modules.RandomSiaPath()
+	data := fastrand.Bytes(100 + siatest.Fuzz())
+	if err := r.RenterUploadStreamPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown classes are rejected.
+	dst := filepath.Join(r.FilesDir().Path(), "batch.dat")
+	if _, err := r.RenterDownloadFullPriorityGet(siaPath, dst, "urgent", false, false); err == nil {
+		t.Fatal("unknown priority class should be rejected")
+	}
+
+	// Download the file as a batch download.
+	id, err := r.RenterDownloadFullPriorityGet(siaPath, dst, modules.DownloadPriorityBatch, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("downloaded data doesn't match the uploaded data")
+	}
+	di, err := r.RenterDownloadInfoGet(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if di.Priority != modules.DownloadPriorityBatch {
+		t.Fatal("expected a batch download but got", di.Priority)
+	}
+
+	// The workers report the data downloaded for the batch class.
+	rwg, err := r.RenterWorkersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rwg.DownloadClasses) != len(modules.DownloadPriorities) {
+		t.Fatal("expected a status for every class", rwg.DownloadClasses)
+	}
+	for _, c := range rwg.DownloadClasses {
+		if c.Priority == modules.DownloadPriorityBatch && (c.BytesDownloaded == 0 || c.BandwidthShare == 0) {
+			t.Fatal("expected data downloaded for the batch class", c)
+		}
+	}
+}
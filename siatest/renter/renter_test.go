@@ -92,6 +92,16 @@ func TestRenterTwo(t *testing.T) {
 		{Name: "TestSiaFileTimestamps", Test: testSiafileTimestamps},
 		{Name: "TestZeroByteFile", Test: testZeroByteFile},
 		{Name: "TestUploadWithAndWithoutForceParameter", Test: testUploadWithAndWithoutForceParameter},
+		{Name: "TestFileChecksums", Test: testFileChecksums},
+		{Name: "TestDirectorySync", Test: testDirectorySync},
+		{Name: "TestFileVersioning", Test: testFileVersioning},
+		{Name: "TestDirectoryArchive", Test: testDirectoryArchive},
+		{Name: "TestCompressedUpload", Test: testCompressedUpload},
+		{Name: "TestFileDedup", Test: testFileDedup},
+		{Name: "TestUserMetadata", Test: testUserMetadata},
+		{Name: "TestTrash", Test: testTrash},
+		{Name: "TestBandwidthSchedule", Test: testBandwidthSchedule},
+		{Name: "TestDownloadPriority", Test: testDownloadPriority},
 	}
 
 	// Run tests
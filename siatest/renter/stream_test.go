@@ -377,4 +377,22 @@ func testUploadStreamingWithBadDeps(t *testing.T, tg *siatest.TestGroup) {
 	if err == nil {
 		t.Fatal("dependency injection should have caused the upload to fail")
 	}
+
+	// The compression of a failed compressed upload is stored for the chunks
+	// that were uploaded, so that they can still be decompressed.
+	siaPath, err = modules.NewSiaPath("/compressed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = renter.RenterUploadStreamCompressedPost(bytes.NewReader(data), siaPath, 1, uint64(len(tg.Hosts())-1), modules.CompressionGzip)
+	if err == nil {
+		t.Fatal("dependency injection should have caused the upload to fail")
+	}
+	rf, err := renter.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Compression != modules.CompressionGzip || rf.File.Filesize != uint64(len(data)) {
+		t.Fatalf("expected %v bytes compressed with %v, got %v bytes compressed with %q", len(data), modules.CompressionGzip, rf.File.Filesize, rf.File.Compression)
+	}
 }
@@ -0,0 +1,167 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testDirectorySync tests syncing local directories with a siadir in both
+// directions.
+func testDirectorySync(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	ec, err := modules.NewRSSubCode(1, len(tg.Hosts())-1, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := modules.RandomSiaPath()
+
+	// Create a local directory with a subdirectory and a file that is
+	// excluded from the sync.
+	src, err := r.FilesDir().CreateDir("syncsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := src.CreateDir("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := src.NewFileWithName("a.dat", 100+siatest.Fuzz())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sub.NewFileWithName("b.dat", 100+siatest.Fuzz())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.NewFileWithName("c.tmp", 100); err != nil {
+		t.Fatal(err)
+	}
+	srcParams := modules.SyncParams{
+		LocalPath:   src.Path(),
+		SiaPath:     siaPath,
+		Delete:      true,
+		Exclude:     []string{"*.tmp"},
+		ErasureCode: ec,
+	}
+
+	// A dry run shouldn't upload anything.
+	dryRun := srcParams
+	dryRun.DryRun = true
+	report, err := r.RenterSyncPost(dryRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a.dat", "sub/b.dat"}
+	if !reflect.DeepEqual(report.Uploaded, expected) {
+		t.Fatal("unexpected dry run report", report)
+	}
+	if _, err := r.RenterDirGet(siaPath); err == nil {
+		t.Fatal("dry run shouldn't upload files")
+	}
+
+	// Upload the directory.
+	report, err = r.RenterSyncPost(srcParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Uploaded, expected) || len(report.Errors) > 0 {
+		t.Fatal("unexpected report", report)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		for _, p := range expected {
+			sp, err := siaPath.Join(p)
+			if err != nil {
+				return err
+			}
+			rf, err := r.RenterFileGet(sp)
+			if err != nil {
+				return err
+			}
+			if !rf.File.Available || !rf.File.Checksum.IsSet() {
+				return fmt.Errorf("%v not available yet", sp)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Syncing again shouldn't do anything.
+	report, err = r.RenterSyncPost(srcParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Unchanged != 2 || len(report.Uploaded) > 0 || len(report.Downloaded) > 0 {
+		t.Fatal("unexpected report", report)
+	}
+
+	// Download the siadir into another directory.
+	dst, err := r.FilesDir().CreateDir("syncdst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstParams := modules.SyncParams{
+		LocalPath: dst.Path(),
+		SiaPath:   siaPath,
+		Direction: modules.SyncDownload,
+		Delete:    true,
+	}
+	report, err = r.RenterSyncPost(dstParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Downloaded, expected) || len(report.Errors) > 0 {
+		t.Fatal("unexpected report", report)
+	}
+	for i, lf := range []*siatest.LocalFile{a, b} {
+		data, err := lf.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		downloaded, err := ioutil.ReadFile(filepath.Join(dst.Path(), filepath.FromSlash(expected[i])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, downloaded) {
+			t.Fatalf("%v doesn't match", expected[i])
+		}
+	}
+
+	// Deleting a file locally removes it from the siadir and from the other
+	// directory.
+	if err := a.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	report, err = r.RenterSyncPost(srcParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.DeletedRemote, []string{"a.dat"}) || report.Unchanged != 1 {
+		t.Fatal("unexpected report", report)
+	}
+	report, err = r.RenterSyncPost(dstParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.DeletedLocal, []string{"a.dat"}) || report.Unchanged != 1 {
+		t.Fatal("unexpected report", report)
+	}
+	files, err := dst.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Fatal("a.dat should have been deleted from the other directory")
+	}
+}
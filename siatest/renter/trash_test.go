@@ -0,0 +1,102 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testTrash tests that deleted files and directories are moved to the trash
+// from which they can be restored and purged.
+func testTrash(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Upload a file and delete it.
+	dir := modules.RandomSiaPath()
+	siaPath, err := dir.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(100 + siatest.Fuzz())
+	if err := r.RenterUploadStreamPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterFileDeletePost(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileGet(siaPath); err == nil {
+		t.Fatal("file should have been deleted")
+	}
+
+	// The file is in the trash.
+	rtg, err := r.RenterTrashGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id string
+	for _, e := range rtg.Entries {
+		if e.SiaPath.Equals(siaPath) {
+			id = e.ID
+		}
+	}
+	if id == "" {
+		t.Fatal("file not found in the trash", rtg.Entries)
+	}
+
+	// Restore and download the file.
+	if err := r.RenterTrashRestorePost(id); err != nil {
+		t.Fatal(err)
+	}
+	_, downloaded, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("restored file doesn't match the original file")
+	}
+
+	// Delete the directory and purge it from the trash.
+	if err := r.RenterDirDeletePost(dir); err != nil {
+		t.Fatal(err)
+	}
+	rtg, err = r.RenterTrashGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id = ""
+	for _, e := range rtg.Entries {
+		if e.SiaPath.Equals(dir) && e.IsDir && e.NumFiles == 1 {
+			id = e.ID
+		}
+	}
+	if id == "" {
+		t.Fatal("directory not found in the trash", rtg.Entries)
+	}
+	if err := r.RenterTrashPurgePost(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterTrashRestorePost(id); err == nil {
+		t.Fatal("purged directory shouldn't be restorable")
+	}
+
+	// Set the retention.
+	if err := r.RenterTrashRetentionPost(7); err != nil {
+		t.Fatal(err)
+	}
+	rtg, err = r.RenterTrashGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtg.RetentionDays != 7 {
+		t.Fatal("expected a retention of 7 days but got", rtg.RetentionDays)
+	}
+	if err := r.RenterTrashRetentionPost(0); err == nil {
+		t.Fatal("a retention of 0 days should be rejected")
+	}
+}
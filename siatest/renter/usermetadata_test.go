@@ -0,0 +1,123 @@
+package renter
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/siatest"
+)
+
+// testUserMetadata tests setting the tags and user-defined metadata of files
+// and folders and searching for them.
+func testUserMetadata(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Upload two files with tags and metadata and create a subfolder.
+	dir := modules.RandomSiaPath()
+	file1, err := dir.Join("file1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file2, err := dir.Join("file2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subDir, err := dir.Join("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]string{"owner": "alice"}
+	err = r.RenterUploadStreamMetadataPost(bytes.NewReader(fastrand.Bytes(100)), file1, dataPieces, parityPieces, []string{"photos", "2020", "photos"}, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadStreamMetadataPost(bytes.NewReader(fastrand.Bytes(200)), file2, dataPieces, parityPieces, []string{"photos"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterDirCreatePost(subDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterTagsPost(subDir, []string{"photos"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterTagsPost(subDir, []string{strings.Repeat("a", modules.MaxTagLength+1)}); err == nil {
+		t.Fatal("setting an invalid tag should fail")
+	}
+
+	// The tags are normalized.
+	rf, err := r.RenterFileGet(file1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rf.File.Tags, []string{"2020", "photos"}) || !reflect.DeepEqual(rf.File.UserMetadata, md) {
+		t.Fatal("wrong tags or metadata", rf.File.Tags, rf.File.UserMetadata)
+	}
+
+	// find searches dir and checks the siapaths of the results.
+	find := func(query modules.FileQuery, files []modules.SiaPath, dirs []modules.SiaPath) {
+		t.Helper()
+		rfg, err := r.RenterFindGet(dir, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSiaPaths(t, rfg, files, dirs)
+	}
+	find(modules.FileQuery{Tags: []string{"photos"}}, []modules.SiaPath{file1, file2}, []modules.SiaPath{subDir})
+	find(modules.FileQuery{Tags: []string{"photos", "2020"}}, []modules.SiaPath{file1}, nil)
+	find(modules.FileQuery{UserMetadata: map[string]string{"owner": ""}}, []modules.SiaPath{file1}, nil)
+	find(modules.FileQuery{UserMetadata: map[string]string{"owner": "bob"}}, nil, nil)
+	find(modules.FileQuery{Tags: []string{"photos"}, MinSize: 150}, []modules.SiaPath{file2}, nil)
+	find(modules.FileQuery{Tags: []string{"photos"}, CreatedAfter: time.Now().Add(-time.Hour)}, []modules.SiaPath{file1, file2}, nil)
+	find(modules.FileQuery{CreatedBefore: time.Now().Add(-time.Hour)}, nil, nil)
+
+	// Replace the metadata and remove the tags of the first file.
+	if err := r.RenterUserMetadataPost(file1, map[string]string{"owner": "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterTagsPost(file1, nil); err != nil {
+		t.Fatal(err)
+	}
+	find(modules.FileQuery{UserMetadata: map[string]string{"owner": "bob"}}, []modules.SiaPath{file1}, nil)
+	find(modules.FileQuery{Tags: []string{"photos"}}, []modules.SiaPath{file2}, []modules.SiaPath{subDir})
+
+	// The tags of the subfolder survive a bubble.
+	if err := r.RenterBubblePost(subDir, false, false); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := r.RenterDirGet(subDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd.Directories[0].Tags, []string{"photos"}) {
+		t.Fatal("wrong tags of folder", rd.Directories[0].Tags)
+	}
+}
+
+// checkSiaPaths checks that the results of a search match the expected
+// siapaths.
+func checkSiaPaths(t *testing.T, rfg api.RenterFindGET, files, dirs []modules.SiaPath) {
+	t.Helper()
+	if len(rfg.Files) != len(files) || len(rfg.Directories) != len(dirs) {
+		t.Fatalf("expected %v files and %v folders but got %v and %v", len(files), len(dirs), len(rfg.Files), len(rfg.Directories))
+	}
+	for i, file := range rfg.Files {
+		if !file.SiaPath.Equals(files[i]) {
+			t.Fatalf("expected file %v but got %v", files[i], file.SiaPath)
+		}
+	}
+	for i, dir := range rfg.Directories {
+		if !dir.SiaPath.Equals(dirs[i]) {
+			t.Fatalf("expected folder %v but got %v", dirs[i], dir.SiaPath)
+		}
+	}
+}
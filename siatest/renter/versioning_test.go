@@ -0,0 +1,118 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest"
+)
+
+// testFileVersioning tests that overwritten files are kept as versions in
+// directories with versioning enabled and that versions can be downloaded and
+// restored.
+func testFileVersioning(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Enable versioning for a directory.
+	dir := modules.RandomSiaPath()
+	if err := r.RenterVersioningPost(dir, 5, 0); err != nil {
+		t.Fatal(err)
+	}
+	rvg, err := r.RenterVersioningGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, p := range rvg.Policies {
+		found = found || (p.SiaPath.Equals(dir) && p.KeepVersions == 5)
+	}
+	if !found {
+		t.Fatal("versioning policy not found", rvg.Policies)
+	}
+
+	// Upload a file and overwrite it.
+	siaPath, err := dir.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := fastrand.Bytes(100 + siatest.Fuzz())
+	overwritten := fastrand.Bytes(100 + siatest.Fuzz())
+	for _, data := range [][]byte{original, overwritten} {
+		if err := r.RenterUploadStreamPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	versions, err := r.RenterVersionsGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 1 || versions.Versions[0].Filesize != uint64(len(original)) {
+		t.Fatal("expected the original file as the only version", versions.Versions)
+	}
+	id := versions.Versions[0].ID
+
+	// Download the version.
+	dst := filepath.Join(r.FilesDir().Path(), "version.dat")
+	if _, err := r.RenterDownloadVersionGet(siaPath, id, dst); err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, original) {
+		t.Fatal("downloaded version doesn't match the original file")
+	}
+
+	// Restore the version.
+	if err := r.RenterVersionRestorePost(siaPath, id); err != nil {
+		t.Fatal(err)
+	}
+	_, current, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(original)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(current, original) {
+		t.Fatal("restored file doesn't match the original file")
+	}
+	versions, err = r.RenterVersionsGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 1 || versions.Versions[0].Filesize != uint64(len(overwritten)) {
+		t.Fatal("expected the overwritten file as the only version", versions.Versions)
+	}
+
+	// Deleted files are kept as versions too.
+	if err := r.RenterFileDeletePost(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.RenterVersionsGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 2 {
+		t.Fatal("expected 2 versions but got", len(versions.Versions))
+	}
+
+	// Disable versioning.
+	if err := r.RenterVersioningDisablePost(dir); err != nil {
+		t.Fatal(err)
+	}
+	rvg, err = r.RenterVersioningGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range rvg.Policies {
+		if p.SiaPath.Equals(dir) {
+			t.Fatal("versioning should be disabled")
+		}
+	}
+}